@@ -7,17 +7,22 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/lib/pq"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 // ActiveViewMode represents the current mode of the Active view
 type ActiveViewMode int
 
 const (
-	ActiveModeList             ActiveViewMode = iota
+	ActiveModeList ActiveViewMode = iota
 	ActiveModeDetail
 	ActiveModeConfirmTerminate
+	ActiveModeKillPattern // typing a query pattern to terminate every match
+	ActiveModeConfirmKill // confirming the list of backends a pattern matched
 )
 
 // ActiveProcess holds structured data for a single pg_stat_activity row
@@ -29,24 +34,44 @@ type ActiveProcess struct {
 	State         string
 	QueryStart    string
 	Duration      string
+	DurationSecs  float64 // Duration as seconds, for filtering and sorting; 0 without a query_start
+	XactStart     string  // when the open transaction began; "" outside a transaction
+	XactAge       string
+	XactAgeSecs   float64 // XactAge as seconds; 0 outside a transaction
 	WaitEvent     string
 	WaitEventType string
 	Query         string
 	BackendType   string
 }
 
+// oldTransactionSecs is the transaction age at which a backend is highlighted, whatever its
+// current query is doing. Long-open transactions hold back vacuum and keep their locks.
+const oldTransactionSecs = 5 * 60
+
+// OldTransaction reports whether p's transaction has been open for oldTransactionSecs or more
+func (p ActiveProcess) OldTransaction() bool {
+	return p.XactStart != "" && p.XactAgeSecs >= oldTransactionSecs
+}
+
 // ActiveView holds the state for the interactive Active tab
 type ActiveView struct {
-	Processes     []ActiveProcess
-	SelectedIndex int
-	SelectedPID   int    // preserved across refreshes
-	Mode          ActiveViewMode
-	TerminateType string // "terminate" or "cancel"
-	LastError     string
-	ScrollOffset  int
-	DetailProcess   *ActiveProcess // snapshot of the process when entering detail/confirm mode
-	DetailCompleted bool           // true when the detail PID is no longer in pg_stat_activity
-	CopyStatus      string         // brief feedback after clipboard copy ("Copied!" or error)
+	Processes       []ActiveProcess
+	SelectedIndex   int
+	SelectedPID     int // preserved across refreshes
+	Mode            ActiveViewMode
+	TerminateType   string // "terminate" or "cancel"
+	LastError       string
+	ScrollOffset    int
+	DetailProcess   *ActiveProcess  // snapshot of the process when entering detail/confirm mode
+	DetailCompleted bool            // true when the detail PID is no longer in pg_stat_activity
+	Filter          ActiveFilter    // filter Processes were fetched with, for the title
+	CopyStatus      string          // brief feedback after clipboard copy ("Copied!" or error)
+	ConfirmInput    string          // typed at a terminate prompt, which only goes ahead on "yes"
+	KillInput       string          // query pattern typed at the kill prompt
+	KillMatches     []ActiveProcess // backends the kill pattern matched, awaiting confirmation
+	CancelUser      bool            // the kill prompt takes a user name and cancels that user's queries instead
+	KillSent        bool            // the listed backends were sent off; further confirms are ignored until the result
+	FetchedAt       time.Time       // when Processes were read; the detail duration counts up from here
 }
 
 // ActiveQuery returns the hardcoded Active query (used for tab display; actual data fetched structurally)
@@ -80,28 +105,85 @@ func scrubNewlines(s string) string {
 	return strings.TrimSpace(s)
 }
 
-// FetchActiveProcesses queries pg_stat_activity for non-idle, non-self processes
-func FetchActiveProcesses(db *sql.DB) ([]ActiveProcess, error) {
-	query := `
-		SELECT
-			pid,
-			COALESCE(usename, '') AS usename,
-			COALESCE(datname, '') AS datname,
-			COALESCE(client_addr::text, '') AS client_addr,
-			COALESCE(state, '') AS state,
-			COALESCE(query_start::text, '') AS query_start,
-			COALESCE(LEFT((NOW() - query_start)::text, 15), '') AS duration,
-			COALESCE(wait_event, '') AS wait_event,
-			COALESCE(wait_event_type, '') AS wait_event_type,
-			COALESCE(query, '') AS query,
-			COALESCE(backend_type, '') AS backend_type
-		FROM pg_stat_activity
-		WHERE pid != pg_backend_pid()
-		  AND state IS NOT NULL
-		  AND state != 'idle'
-		ORDER BY query_start ASC NULLS LAST`
-
-	rows, err := db.Query(query)
+// ActiveFilter chooses which backends FetchActiveProcesses lists
+type ActiveFilter struct {
+	ShowIdle       bool   // include idle sessions, e.g. to see a connection pool
+	HideBackground bool   // list client backends only, leaving out autovacuum and other workers
+	ShowSelf       bool   // include psq's own connections
+	Database       string // only backends connected to this database; "" covers the whole instance
+}
+
+// where returns the WHERE clause for f. Backends without a state (the checkpointer, WAL
+// writer and the like) never have a query to show, so they're always left out.
+func (f ActiveFilter) where() string {
+	conditions := []string{"state IS NOT NULL"}
+	if !f.ShowSelf {
+		conditions = append(conditions, "pid != pg_backend_pid()",
+			"application_name IS DISTINCT FROM "+pq.QuoteLiteral(psqApplicationName))
+	}
+	if !f.ShowIdle {
+		conditions = append(conditions, "state != 'idle'")
+	}
+	if f.HideBackground {
+		conditions = append(conditions, "backend_type = 'client backend'")
+	}
+	if f.Database != "" {
+		conditions = append(conditions, "datname = "+pq.QuoteLiteral(f.Database))
+	}
+	return "WHERE " + strings.Join(conditions, "\n  AND ")
+}
+
+// scope names what the Active list covers: one database or the whole instance
+func (f ActiveFilter) scope() string {
+	if f.Database == "" {
+		return "whole instance"
+	}
+	return "database " + f.Database
+}
+
+// describe summarizes how f differs from the default, e.g. "idle shown, client backends only"
+func (f ActiveFilter) describe() string {
+	var parts []string
+	if f.ShowIdle {
+		parts = append(parts, "idle shown")
+	}
+	if f.HideBackground {
+		parts = append(parts, "client backends only")
+	}
+	if f.ShowSelf {
+		parts = append(parts, "psq's own connections shown")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// activeProcessesSQL is the pg_stat_activity query behind the Active tab, laid out to be
+// readable when copied with Y
+func activeProcessesSQL(filter ActiveFilter) string {
+	return `SELECT
+	pid,
+	COALESCE(usename, '') AS usename,
+	COALESCE(datname, '') AS datname,
+	COALESCE(client_addr::text, '') AS client_addr,
+	COALESCE(state, '') AS state,
+	COALESCE(query_start::text, '') AS query_start,
+	COALESCE(LEFT((NOW() - query_start)::text, 15), '') AS duration,
+	COALESCE(EXTRACT(EPOCH FROM (NOW() - query_start))::float8, 0) AS duration_secs,
+	COALESCE(xact_start::text, '') AS xact_start,
+	COALESCE(LEFT((NOW() - xact_start)::text, 15), '') AS xact_age,
+	COALESCE(EXTRACT(EPOCH FROM (NOW() - xact_start))::float8, 0) AS xact_age_secs,
+	COALESCE(wait_event, '') AS wait_event,
+	COALESCE(wait_event_type, '') AS wait_event_type,
+	COALESCE(query, '') AS query,
+	COALESCE(backend_type, '') AS backend_type
+FROM pg_stat_activity
+` + filter.where() + `
+ORDER BY query_start ASC NULLS LAST`
+}
+
+// FetchActiveProcesses queries pg_stat_activity for the backends filter selects. The zero
+// filter lists non-idle processes other than psq's own, background workers included.
+func FetchActiveProcesses(db queryer, filter ActiveFilter) ([]ActiveProcess, error) {
+	rows, err := db.Query(activeProcessesSQL(filter))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pg_stat_activity: %w", err)
 	}
@@ -112,7 +194,8 @@ func FetchActiveProcesses(db *sql.DB) ([]ActiveProcess, error) {
 		var p ActiveProcess
 		if err := rows.Scan(
 			&p.PID, &p.Username, &p.Database, &p.ClientAddr,
-			&p.State, &p.QueryStart, &p.Duration,
+			&p.State, &p.QueryStart, &p.Duration, &p.DurationSecs,
+			&p.XactStart, &p.XactAge, &p.XactAgeSecs,
 			&p.WaitEvent, &p.WaitEventType, &p.Query, &p.BackendType,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -151,6 +234,7 @@ func CancelBackend(db *sql.DB, pid int) error {
 // UpdateSelection preserves selected PID across data refreshes
 func (av *ActiveView) UpdateSelection(processes []ActiveProcess) {
 	av.Processes = processes
+	av.FetchedAt = time.Now()
 
 	// If we're in detail/confirm mode, keep DetailProcess live while the PID exists
 	if av.DetailProcess != nil && !av.DetailCompleted {
@@ -192,6 +276,150 @@ func (av *ActiveView) UpdateSelection(processes []ActiveProcess) {
 	av.ensureVisible()
 }
 
+// MoveSelection moves the selected row by delta, clamped to the list; returns false if nothing changed
+func (av *ActiveView) MoveSelection(delta int) bool {
+	if len(av.Processes) == 0 {
+		return false
+	}
+	next := av.SelectedIndex + delta
+	if next < 0 {
+		next = 0
+	}
+	if next > len(av.Processes)-1 {
+		next = len(av.Processes) - 1
+	}
+	if next == av.SelectedIndex {
+		return false
+	}
+	av.SelectedIndex = next
+	av.SelectedPID = av.Processes[next].PID
+	av.ensureVisible()
+	return true
+}
+
+// Select moves the selection to row i; returns false if i is out of range or already selected
+func (av *ActiveView) Select(i int) bool {
+	if i < 0 || i >= len(av.Processes) || i == av.SelectedIndex {
+		return false
+	}
+	av.SelectedIndex = i
+	av.SelectedPID = av.Processes[i].PID
+	av.ensureVisible()
+	return true
+}
+
+// OpenDetail switches to the detail view for the selected process; returns false if there's none
+func (av *ActiveView) OpenDetail() bool {
+	p := av.SelectedProcess()
+	if p == nil {
+		return false
+	}
+	snap := *p
+	av.DetailProcess = &snap
+	av.Mode = ActiveModeDetail
+	av.LastError = ""
+	av.CopyStatus = ""
+	return true
+}
+
+// ConfirmAction asks to terminate or cancel ("terminate"/"cancel") the selected process;
+// returns false if there's none
+func (av *ActiveView) ConfirmAction(action string) bool {
+	p := av.SelectedProcess()
+	if p == nil {
+		return false
+	}
+	snap := *p
+	av.DetailProcess = &snap
+	av.confirm(action)
+	return true
+}
+
+// terminateConfirmWord has to be typed to terminate a backend, or every backend a kill
+// pattern matched
+const terminateConfirmWord = "yes"
+
+// confirm asks before terminating or cancelling DetailProcess
+func (av *ActiveView) confirm(action string) {
+	av.TerminateType = action
+	av.Mode = ActiveModeConfirmTerminate
+	av.ConfirmInput = ""
+	av.LastError = ""
+}
+
+// declineConfirm goes back to the list without terminating or cancelling anything
+func (av *ActiveView) declineConfirm() {
+	av.Mode = ActiveModeList
+	av.DetailProcess = nil
+	av.DetailCompleted = false
+	av.ConfirmInput = ""
+	av.LastError = ""
+}
+
+// typedConfirm reports whether the pending action has to be confirmed by typing
+// terminateConfirmWord. Terminating drops the whole connection, so unlike cancelling a
+// query it isn't a single keystroke.
+func (av *ActiveView) typedConfirm() bool {
+	return av.TerminateType != "cancel"
+}
+
+// activeRowZone is the bubblezone ID of the list row showing process i
+func activeRowZone(i int) string {
+	return fmt.Sprintf("active_row_%d", i)
+}
+
+// activeKillZone is the bubblezone ID of the [x] that asks to terminate process i
+func activeKillZone(i int) string {
+	return fmt.Sprintf("active_kill_%d", i)
+}
+
+// activeCancelZone is the bubblezone ID of the [c] that asks to cancel process i's query
+func activeCancelZone(i int) string {
+	return fmt.Sprintf("active_cancel_%d", i)
+}
+
+// activeKillLabel is the per-row terminate affordance at the end of each list row
+const activeKillLabel = "[x]"
+
+// activeCancelLabel is the per-row cancel affordance, just before activeKillLabel
+const activeCancelLabel = "[c]"
+
+// activeActionsWidth is what the row affordances and the spaces before them take
+const activeActionsWidth = 1 + len(activeCancelLabel) + 1 + len(activeKillLabel)
+
+// activeMinQueryW is the narrowest the Active list's query column gets
+const activeMinQueryW = 20
+
+// activeColumns holds the Active list's column widths
+type activeColumns struct {
+	pid, user, state, duration, xact, wait, query int
+}
+
+// activeColumnWidths fits the Active list's columns to width. The query column gets whatever
+// is left; on a narrow terminal the other columns shrink, down to their header widths, before
+// it drops below activeMinQueryW.
+func activeColumnWidths(width int) activeColumns {
+	c := activeColumns{pid: 8, user: 12, state: 12, duration: 12, xact: 12, wait: 16}
+	fixed := func() int { return c.pid + c.user + c.state + c.duration + c.xact + c.wait }
+	// Border, row affordances and separators
+	avail := width - 4 - activeActionsWidth - 8
+
+	over := fixed() + activeMinQueryW - avail
+	for _, col := range []struct {
+		w   *int
+		min int
+	}{{&c.wait, 10}, {&c.user, 8}, {&c.xact, 8}, {&c.duration, 8}, {&c.state, 6}} {
+		if over <= 0 {
+			break
+		}
+		cut := min(over, *col.w-col.min)
+		*col.w -= cut
+		over -= cut
+	}
+	c.query = max(avail-fixed(), activeMinQueryW)
+	return c
+}
+
 // SelectedProcess returns the currently selected process, or nil
 func (av *ActiveView) SelectedProcess() *ActiveProcess {
 	if len(av.Processes) == 0 || av.SelectedIndex >= len(av.Processes) {
@@ -222,12 +450,24 @@ func (av *ActiveView) ensureVisible() {
 func RenderActiveList(av *ActiveView, width, height int) string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86"))
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	filterNote := lipgloss.NewStyle().Foreground(theme.Accent).Render("  " + av.Filter.scope())
+	if d := av.Filter.describe(); d != "" {
+		filterNote += dimStyle.Render("  (" + d + ")")
+	}
 
 	if len(av.Processes) == 0 {
-		return titleStyle.Render("Active Connections") + "\n\n" +
-			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No active (non-idle) connections") + "\n\n" +
-			lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("esc: quit")
+		empty := "No active (non-idle) connections"
+		if av.Filter.ShowIdle {
+			empty = "No connections"
+		}
+		return titleStyle.Render("Active Connections") + filterNote + "\n\n" +
+			lipgloss.NewStyle().Foreground(theme.Muted).Render(empty) + "\n\n" +
+			dimStyle.Render("i: show idle  B: show background workers  o: this database / whole instance  esc: quit")
 	}
 
 	pageSize := av.pageSize(height)
@@ -243,45 +483,43 @@ func RenderActiveList(av *ActiveView, width, height int) string {
 		av.ScrollOffset = 0
 	}
 
-	// Column widths
-	pidW := 8
-	userW := 12
-	stateW := 12
-	durationW := 12
-	waitW := 16
-
-	// Query column gets the remaining width
-	fixedW := pidW + userW + stateW + durationW + waitW + 7 // 7 for separators
-	queryW := width - fixedW - 4
-	if queryW < 20 {
-		queryW = 20
-	}
+	cols := activeColumnWidths(width)
+	pidW, userW, stateW, durationW, xactW, waitW, queryW := cols.pid, cols.user, cols.state, cols.duration, cols.xact, cols.wait, cols.query
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(lipgloss.Color("#7C3AED"))
+		Foreground(theme.HeaderFg).
+		Background(theme.HeaderBg)
 
 	selectedStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
-		Background(lipgloss.Color("235"))
+		Foreground(theme.Primary).
+		Background(theme.SelectedBg)
 
 	rowStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("252"))
+		Foreground(theme.Text)
 
-	dimStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240"))
+	oldXactStyle := lipgloss.NewStyle().
+		Foreground(theme.Caution)
+
+	killStyle := lipgloss.NewStyle().
+		Foreground(theme.Error)
+
+	cancelStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
 
 	var b strings.Builder
-	b.WriteString(titleStyle.Render(fmt.Sprintf("Active Connections (%d)", len(av.Processes))))
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Active Connections (%d)", len(av.Processes))) + filterNote)
 	b.WriteString("\n\n")
 
 	// Header
-	header := fmt.Sprintf("%-*s %-*s %-*s %-*s %-*s %-*s",
+	header := fmt.Sprintf("%-*s %-*s %-*s %-*s %-*s %-*s %-*s",
 		pidW, "PID", userW, "User",
-		stateW, "State", durationW, "Duration", waitW, "Wait Event",
+		stateW, "State", durationW, "Duration", xactW, "Xact Age", waitW, "Wait Event",
 		queryW, "Query")
+	if monochrome {
+		header = "  " + header
+	}
 	b.WriteString(headerStyle.Render(truncate(header, width-2)))
 	b.WriteString("\n")
 
@@ -298,19 +536,35 @@ func RenderActiveList(av *ActiveView, width, height int) string {
 			queryTrunc = queryTrunc[:queryW-1] + "~"
 		}
 
-		line := fmt.Sprintf("%-*d %-*s %-*s %-*s %-*s %-*s",
+		line := fmt.Sprintf("%-*d %-*s %-*s %-*s %-*s %-*s %-*s",
 			pidW, p.PID,
 			userW, truncate(p.Username, userW),
 			stateW, truncate(p.State, stateW),
 			durationW, truncate(p.Duration, durationW),
+			xactW, truncate(p.XactAge, xactW),
 			waitW, truncate(p.WaitEvent, waitW),
 			queryW, queryTrunc)
 
+		if monochrome {
+			// The selection highlight is invisible without color, so mark the row instead
+			if i == av.SelectedIndex {
+				line = "> " + line
+			} else {
+				line = "  " + line
+			}
+		}
+
+		// Rows are marked by process index, not screen position, so clicks map through ScrollOffset
+		lineW := width - 2 - activeActionsWidth
 		if i == av.SelectedIndex {
-			b.WriteString(selectedStyle.Render(truncate(line, width-2)))
+			b.WriteString(zone.Mark(activeRowZone(i), selectedStyle.Render(truncate(line, lineW))))
+		} else if p.OldTransaction() {
+			b.WriteString(zone.Mark(activeRowZone(i), oldXactStyle.Render(truncate(line, lineW))))
 		} else {
-			b.WriteString(rowStyle.Render(truncate(line, width-2)))
+			b.WriteString(zone.Mark(activeRowZone(i), rowStyle.Render(truncate(line, lineW))))
 		}
+		b.WriteString(" " + zone.Mark(activeCancelZone(i), cancelStyle.Render(activeCancelLabel)))
+		b.WriteString(" " + zone.Mark(activeKillZone(i), killStyle.Render(activeKillLabel)))
 		b.WriteString("\n")
 	}
 
@@ -320,12 +574,8 @@ func RenderActiveList(av *ActiveView, width, height int) string {
 		b.WriteString("\n")
 	}
 
-	// Footer hints
-	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  up/down: select  enter: details  t: terminate  c: cancel query  esc: quit"))
-
 	if av.LastError != "" {
-		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
 		b.WriteString("\n")
 		b.WriteString(errStyle.Render("  Error: " + av.LastError))
 	}
@@ -342,18 +592,18 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86"))
+		Foreground(theme.Primary)
 
 	labelStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("244")).
+		Foreground(theme.Subtle).
 		Width(18)
 
 	valueStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("252"))
+		Foreground(theme.Text)
 
 	dimStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240"))
+		Foreground(theme.Dim)
 
 	var b strings.Builder
 	b.WriteString(titleStyle.Render(fmt.Sprintf("Process Detail - PID %d", proc.PID)))
@@ -361,7 +611,7 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 	if av.DetailCompleted {
 		completedStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("8"))
+			Foreground(theme.Muted)
 		b.WriteString("  " + completedStyle.Render("(process completed)"))
 	}
 
@@ -375,13 +625,23 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 		{"State", proc.State},
 		{"Backend Type", proc.BackendType},
 		{"Query Start", proc.QueryStart},
-		{"Duration", proc.Duration},
+		{"Duration", av.detailDuration(time.Now())},
+		{"Transaction Start", proc.XactStart},
+		{"Transaction Age", proc.XactAge},
 		{"Wait Event", proc.WaitEvent},
 		{"Wait Event Type", proc.WaitEventType},
 	}
 
+	oldXactStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Caution)
+
 	for _, f := range fields {
-		b.WriteString(labelStyle.Render(f.label+":") + " " + valueStyle.Render(f.value) + "\n")
+		style := valueStyle
+		if f.label == "Transaction Age" && proc.OldTransaction() {
+			style = oldXactStyle
+		}
+		b.WriteString(labelStyle.Render(f.label+":") + " " + style.Render(f.value) + "\n")
 	}
 
 	b.WriteString("\n")
@@ -389,10 +649,10 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 	b.WriteString("\n")
 
 	queryStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("251")).
+		Foreground(theme.Text).
 		Width(width - 4)
 
-	b.WriteString(queryStyle.Render(scrubNewlines(proc.Query)))
+	b.WriteString(queryStyle.Render(highlightSQL(strings.TrimSpace(proc.Query))))
 	b.WriteString("\n\n")
 
 	if av.DetailCompleted {
@@ -402,12 +662,12 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 	}
 
 	if av.CopyStatus != "" {
-		copyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+		copyStyle := lipgloss.NewStyle().Foreground(theme.Success)
 		b.WriteString("  " + copyStyle.Render(av.CopyStatus))
 	}
 
 	if av.LastError != "" {
-		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
 		b.WriteString("\n")
 		b.WriteString(errStyle.Render("  Error: " + av.LastError))
 	}
@@ -415,6 +675,29 @@ func RenderActiveDetail(av *ActiveView, width int) string {
 	return b.String()
 }
 
+// detailDuration is how long the detail process's query has run as of now, e.g. "running
+// for 00:05:12". It counts up between refreshes and freezes once the process is gone.
+func (av *ActiveView) detailDuration(now time.Time) string {
+	proc := av.DetailProcess
+	if proc == nil || proc.QueryStart == "" {
+		return ""
+	}
+	if av.DetailCompleted {
+		return formatClock(proc.DurationSecs) + " (completed)"
+	}
+	secs := proc.DurationSecs
+	if !av.FetchedAt.IsZero() && now.After(av.FetchedAt) {
+		secs += now.Sub(av.FetchedAt).Seconds()
+	}
+	return "running for " + formatClock(secs)
+}
+
+// formatClock formats seconds as HH:MM:SS
+func formatClock(secs float64) string {
+	s := int(max(secs, 0))
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
 // RenderTerminateConfirm renders the confirmation prompt
 func RenderTerminateConfirm(av *ActiveView) string {
 	proc := av.DetailProcess
@@ -422,24 +705,37 @@ func RenderTerminateConfirm(av *ActiveView) string {
 		return "No process selected"
 	}
 
-	warnStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("9"))
-
 	dimStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240"))
-
-	action := "Terminate"
-	if av.TerminateType == "cancel" {
-		action = "Cancel query on"
-	}
+		Foreground(theme.Dim)
 
 	var b strings.Builder
-	b.WriteString(warnStyle.Render(fmt.Sprintf("%s PID %d? (y/n)", action, proc.PID)))
+	if av.typedConfirm() {
+		dangerStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.HeaderFg).
+			Background(theme.Error)
+		warnStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Error)
+		b.WriteString(dangerStyle.Render(fmt.Sprintf(" Terminate PID %d? ", proc.PID)))
+		b.WriteString(warnStyle.Render(" This closes the connection and rolls back any open transaction."))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("  Type %s and press enter to terminate: %s█", terminateConfirmWord, av.ConfirmInput))
+	} else {
+		warnStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Warning)
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Cancel query on PID %d? (y/n)", proc.PID)))
+		b.WriteString(dimStyle.Render("  The connection stays open."))
+	}
 	b.WriteString("\n\n")
 	b.WriteString(dimStyle.Render(fmt.Sprintf("  User: %s  Database: %s", proc.Username, proc.Database)))
 	b.WriteString("\n")
 	b.WriteString(dimStyle.Render(fmt.Sprintf("  Query: %s", truncate(scrubNewlines(proc.Query), 60))))
+	if av.LastError != "" {
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  " + av.LastError))
+	}
 	return b.String()
 }
 
@@ -459,7 +755,8 @@ func truncate(s string, max int) string {
 
 // clipboardResultMsg is sent after a clipboard copy attempt
 type clipboardResultMsg struct {
-	err error
+	err  error
+	note string // shown above the results on success; empty for Active view copies
 }
 
 // copyToClipboard copies text to the system clipboard
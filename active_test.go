@@ -1,7 +1,13 @@
 package main
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 func TestScrubNewlines(t *testing.T) {
@@ -175,3 +181,329 @@ func TestTruncate(t *testing.T) {
 		}
 	}
 }
+
+func TestMoveSelection(t *testing.T) {
+	processes := []ActiveProcess{{PID: 10}, {PID: 20}, {PID: 30}}
+
+	tests := []struct {
+		name        string
+		start       int
+		delta       int
+		wantIndex   int
+		wantPID     int
+		wantChanged bool
+	}{
+		{name: "move down", start: 0, delta: 1, wantIndex: 1, wantPID: 20, wantChanged: true},
+		{name: "move up", start: 2, delta: -1, wantIndex: 1, wantPID: 20, wantChanged: true},
+		{name: "clamp at top", start: 0, delta: -1, wantIndex: 0, wantPID: 10, wantChanged: false},
+		{name: "clamp at bottom", start: 2, delta: 5, wantIndex: 2, wantPID: 30, wantChanged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			av := &ActiveView{Processes: processes, SelectedIndex: tt.start, SelectedPID: processes[tt.start].PID}
+			changed := av.MoveSelection(tt.delta)
+			if changed != tt.wantChanged {
+				t.Errorf("MoveSelection() = %v, want %v", changed, tt.wantChanged)
+			}
+			if av.SelectedIndex != tt.wantIndex {
+				t.Errorf("SelectedIndex = %d, want %d", av.SelectedIndex, tt.wantIndex)
+			}
+			if av.SelectedPID != tt.wantPID {
+				t.Errorf("SelectedPID = %d, want %d", av.SelectedPID, tt.wantPID)
+			}
+		})
+	}
+}
+
+// mouseAt draws m, waits for the zone scan to record id, then returns a mouse event inside it
+func mouseAt(t *testing.T, m *Model, id string, action tea.MouseAction, button tea.MouseButton) tea.MouseMsg {
+	t.Helper()
+	m.View()
+	deadline := time.Now().Add(time.Second)
+	z := zone.Get(id)
+	for z.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		z = zone.Get(id)
+	}
+	if z.IsZero() {
+		t.Fatalf("%s was never marked", id)
+	}
+	return tea.MouseMsg{X: z.StartX + 1, Y: z.StartY, Action: action, Button: button}
+}
+
+func TestActiveRowClick(t *testing.T) {
+	zone.NewGlobal()
+
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{{PID: 101, State: "active"}, {PID: 102, State: "active"}, {PID: 103, State: "active"}})
+	m := &Model{
+		queries:    []Query{ActiveQuery()},
+		activeView: av,
+		ready:      true,
+		width:      120,
+		height:     40,
+		viewport:   viewport.New(120, 40),
+	}
+	m.updateContent()
+
+	click := func(i int) {
+		m.handleMouseMsg(mouseAt(t, m, activeRowZone(i), tea.MouseActionRelease, tea.MouseButtonLeft))
+	}
+
+	click(2)
+	if av.SelectedIndex != 2 || av.SelectedPID != 103 || av.Mode != ActiveModeList {
+		t.Fatalf("after clicking row 2: index %d, pid %d, mode %d; want 103 selected in the list", av.SelectedIndex, av.SelectedPID, av.Mode)
+	}
+
+	click(2)
+	if av.Mode != ActiveModeDetail || av.DetailProcess == nil || av.DetailProcess.PID != 103 {
+		t.Errorf("clicking the selected row again should open its details, mode = %d", av.Mode)
+	}
+}
+
+func TestActiveRowTerminateClick(t *testing.T) {
+	zone.NewGlobal()
+
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{{PID: 101, State: "active"}, {PID: 102, State: "active"}})
+	m := &Model{
+		queries:    []Query{ActiveQuery()},
+		activeView: av,
+		ready:      true,
+		width:      120,
+		height:     40,
+		viewport:   viewport.New(120, 40),
+	}
+	m.updateContent()
+
+	m.handleMouseMsg(mouseAt(t, m, activeKillZone(1), tea.MouseActionRelease, tea.MouseButtonLeft))
+	if av.Mode != ActiveModeConfirmTerminate || av.TerminateType != "terminate" || av.DetailProcess.PID != 102 {
+		t.Fatalf("clicking [x] on row 1: mode %d, action %q; want to confirm terminating 102", av.Mode, av.TerminateType)
+	}
+
+	m.handleActiveViewKeys(tea.KeyMsg{Type: tea.KeyEscape})
+	m.updateContent()
+	m.handleMouseMsg(mouseAt(t, m, activeRowZone(0), tea.MouseActionPress, tea.MouseButtonRight))
+	if av.Mode != ActiveModeConfirmTerminate || av.DetailProcess.PID != 101 {
+		t.Errorf("right-clicking row 0: mode %d; want to confirm terminating 101", av.Mode)
+	}
+
+	m.handleActiveViewKeys(tea.KeyMsg{Type: tea.KeyEscape})
+	m.updateContent()
+	m.handleMouseMsg(mouseAt(t, m, activeCancelZone(1), tea.MouseActionRelease, tea.MouseButtonLeft))
+	if av.Mode != ActiveModeConfirmTerminate || av.TerminateType != "cancel" || av.DetailProcess.PID != 102 {
+		t.Errorf("clicking [c] on row 1: mode %d, action %q; want to confirm cancelling 102's query", av.Mode, av.TerminateType)
+	}
+}
+
+func TestActiveFilterWhere(t *testing.T) {
+	tests := []struct {
+		filter  ActiveFilter
+		want    []string
+		notWant []string
+	}{
+		{ActiveFilter{}, []string{"state IS NOT NULL", "pid != pg_backend_pid()", "state != 'idle'"}, []string{"backend_type"}},
+		{ActiveFilter{ShowIdle: true}, []string{"pid != pg_backend_pid()"}, []string{"state != 'idle'"}},
+		{ActiveFilter{HideBackground: true}, []string{"backend_type = 'client backend'"}, nil},
+		{ActiveFilter{}, []string{"application_name IS DISTINCT FROM '" + psqApplicationName + "'"}, nil},
+		{ActiveFilter{ShowSelf: true}, []string{"state != 'idle'"}, []string{"pg_backend_pid", "application_name"}},
+		{ActiveFilter{Database: "o'brien"}, []string{"datname = 'o''brien'"}, nil},
+		{ActiveFilter{}, nil, []string{"datname"}},
+	}
+	for _, tt := range tests {
+		where := tt.filter.where()
+		for _, s := range tt.want {
+			if !strings.Contains(where, s) {
+				t.Errorf("%+v: where = %q, missing %q", tt.filter, where, s)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(where, s) {
+				t.Errorf("%+v: where = %q, shouldn't contain %q", tt.filter, where, s)
+			}
+		}
+	}
+}
+
+func TestActiveFilterKeys(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{
+		queries:    []Query{ActiveQuery()},
+		activeView: NewActiveView(),
+		ready:      true,
+		width:      120,
+		height:     40,
+		viewport:   viewport.New(120, 40),
+	}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	if !m.activeFilter.ShowIdle || !m.loading {
+		t.Fatalf("i should show idle connections and refresh, filter %+v, loading %v", m.activeFilter, m.loading)
+	}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	if !m.activeFilter.HideBackground {
+		t.Errorf("B should hide background workers, filter %+v", m.activeFilter)
+	}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	if m.activeFilter.ShowIdle {
+		t.Errorf("a second i should hide idle connections again")
+	}
+
+	m.activeView.Filter = m.activeFilter
+	if out := RenderActiveList(m.activeView, 120, 40); !strings.Contains(out, "client backends only") || !strings.Contains(out, "whole instance") {
+		t.Errorf("the Active view should say only client backends are listed, across the instance:\n%s", out)
+	}
+
+	// o narrows the list to the database psq is connected to, and back
+	m.timeouts = &SessionTimeouts{Database: "app"}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if m.activeFilter.Database != "app" {
+		t.Fatalf("o should scope the list to the current database, filter %+v", m.activeFilter)
+	}
+	m.activeView.Filter = m.activeFilter
+	if out := RenderActiveList(m.activeView, 120, 40); !strings.Contains(out, "database app") {
+		t.Errorf("the Active view should name the database it's scoped to:\n%s", out)
+	}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if m.activeFilter.Database != "" {
+		t.Errorf("a second o should cover the whole instance again, filter %+v", m.activeFilter)
+	}
+}
+
+func TestDetailDuration(t *testing.T) {
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{{PID: 101, State: "active", QueryStart: "2026-01-01 12:00:00", DurationSecs: 3725}})
+	if !av.OpenDetail() {
+		t.Fatal("OpenDetail failed")
+	}
+	fetched := av.FetchedAt
+
+	if got := av.detailDuration(fetched); got != "running for 01:02:05" {
+		t.Errorf("at fetch time: %q", got)
+	}
+	if got := av.detailDuration(fetched.Add(10 * time.Second)); got != "running for 01:02:15" {
+		t.Errorf("10s after the fetch the duration should count up, got %q", got)
+	}
+
+	// The process finishes: the duration freezes at its last reading
+	av.UpdateSelection(nil)
+	if got := av.detailDuration(time.Now().Add(time.Minute)); got != "01:02:05 (completed)" {
+		t.Errorf("completed: %q", got)
+	}
+
+	av.DetailProcess = &ActiveProcess{PID: 102}
+	if got := av.detailDuration(time.Now()); got != "" {
+		t.Errorf("no query_start should show no duration, got %q", got)
+	}
+}
+
+func TestTerminateNeedsTypedConfirm(t *testing.T) {
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{{PID: 101, State: "active"}})
+	m := &Model{queries: []Query{ActiveQuery()}, activeView: av}
+	key := func(s string) tea.Cmd {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+		switch s {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		}
+		_, cmd := m.handleActiveViewKeys(msg)
+		return cmd
+	}
+
+	av.ConfirmAction("terminate")
+	if cmd := key("y"); cmd != nil || av.Mode != ActiveModeConfirmTerminate {
+		t.Fatalf("y alone at the terminate prompt: mode %d, cmd %v; want to keep asking", av.Mode, cmd != nil)
+	}
+	if cmd := key("enter"); cmd != nil || av.LastError == "" {
+		t.Fatalf("enter after %q: cmd %v, error %q; want a hint and no terminate", av.ConfirmInput, cmd != nil, av.LastError)
+	}
+	key("backspace")
+	for _, r := range []string{"Y", "e", "s"} {
+		key(r)
+	}
+	if cmd := key("enter"); cmd == nil {
+		t.Fatalf("enter after %q: want terminate to run", av.ConfirmInput)
+	}
+
+	av.Mode = ActiveModeList
+	av.ConfirmAction("cancel")
+	if cmd := key("y"); cmd == nil {
+		t.Fatal("y at the cancel prompt: want cancel to run right away")
+	}
+	if out := RenderTerminateConfirm(av); !strings.Contains(out, "Cancel query on PID 101? (y/n)") {
+		t.Errorf("cancel prompt = %q", out)
+	}
+}
+
+func TestOldTransaction(t *testing.T) {
+	tests := []struct {
+		p    ActiveProcess
+		want bool
+	}{
+		{ActiveProcess{}, false},
+		{ActiveProcess{XactStart: "2026-01-01 00:00:00", XactAgeSecs: 10}, false},
+		// Idle in transaction with a short query age but an old transaction
+		{ActiveProcess{State: "idle in transaction", DurationSecs: 1, XactStart: "2026-01-01 00:00:00", XactAgeSecs: 3600}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.p.OldTransaction(); got != tt.want {
+			t.Errorf("%+v.OldTransaction() = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	av := NewActiveView()
+	av.DetailProcess = &ActiveProcess{PID: 7, XactStart: "2026-01-01 00:00:00", XactAge: "01:00:00", XactAgeSecs: 3600}
+	if out := RenderActiveDetail(av, 80); !strings.Contains(out, "Transaction Age:") || !strings.Contains(out, "01:00:00") {
+		t.Errorf("RenderActiveDetail() doesn't show the transaction age:\n%s", out)
+	}
+	av.UpdateSelection([]ActiveProcess{*av.DetailProcess})
+	if out := RenderActiveList(av, 160, 20); !strings.Contains(out, "Xact Age") {
+		t.Errorf("RenderActiveList() has no Xact Age column:\n%s", out)
+	}
+}
+
+func TestActiveColumnWidths(t *testing.T) {
+	wide := activeColumnWidths(200)
+	if wide != (activeColumns{pid: 8, user: 12, state: 12, duration: 12, xact: 12, wait: 16, query: 108}) {
+		t.Errorf("wide terminal columns = %+v", wide)
+	}
+
+	// The fixed columns give up space before the query column drops below its minimum
+	mid := activeColumnWidths(100)
+	if mid.query != activeMinQueryW {
+		t.Errorf("query width at 100 = %d, want %d", mid.query, activeMinQueryW)
+	}
+	if w := mid.pid + mid.user + mid.state + mid.duration + mid.xact + mid.wait + mid.query + 8 + 4 + activeActionsWidth; w != 100 {
+		t.Errorf("columns at 100 add up to %d, want 100", w)
+	}
+
+	narrow := activeColumnWidths(40)
+	if narrow.wait != 10 || narrow.user != 8 || narrow.state != 6 || narrow.query != activeMinQueryW {
+		t.Errorf("narrow terminal columns = %+v, want each at its minimum", narrow)
+	}
+}
+
+func TestSelectedSQL(t *testing.T) {
+	m := &Model{queries: []Query{HomeQuery(), ActiveQuery(), {Name: "Locks", SQL: "SELECT * FROM pg_locks"}}}
+
+	m.selected = 2
+	if got := m.selectedSQL(); got != "SELECT * FROM pg_locks" {
+		t.Errorf("saved query SQL = %q", got)
+	}
+	m.selected = 0
+	if got := m.selectedSQL(); got != HomeQuery().SQL {
+		t.Errorf("Home SQL = %q, want the built-in query", got)
+	}
+
+	// The Active tab copies the query it actually runs, filter included
+	m.selected = 1
+	m.activeFilter = ActiveFilter{HideBackground: true}
+	got := m.selectedSQL()
+	if !strings.HasPrefix(got, "SELECT") || !strings.Contains(got, "FROM pg_stat_activity") || !strings.Contains(got, "backend_type = 'client backend'") {
+		t.Errorf("Active SQL = %q, want the pg_stat_activity query with the filter", got)
+	}
+}
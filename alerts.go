@@ -0,0 +1,214 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Alert metrics a rule in config.json can watch
+const (
+	AlertConnectionsPercent = "connections_percent"   // backends as a percentage of max_connections
+	AlertLongestQuery       = "longest_query_seconds" // how long the oldest running query has run
+	AlertBlockedBackends    = "blocked_backends"      // backends waiting on a lock
+)
+
+// alertMetrics lists the metrics rules can watch, in the order they're checked
+var alertMetrics = []string{AlertConnectionsPercent, AlertLongestQuery, AlertBlockedBackends}
+
+// alertFlashes is how many times the header flips when an alert starts firing
+const alertFlashes = 6
+
+// alertFlashInterval is how long each flip of the header lasts
+const alertFlashInterval = 300 * time.Millisecond
+
+// bellOut is where the terminal bell is written
+var bellOut io.Writer = os.Stdout
+
+// AlertRule fires when a metric goes above a threshold, e.g.
+// {"metric": "longest_query_seconds", "above": 300, "bell": true}
+type AlertRule struct {
+	Metric string  `json:"metric"`
+	Above  float64 `json:"above"`
+	Bell   bool    `json:"bell,omitempty"` // ring the terminal bell when the rule starts firing
+}
+
+// alertFlashMsg flips the header highlight while an alert flashes
+type alertFlashMsg struct{}
+
+// bellMsg asks Update to ring the terminal bell
+type bellMsg struct{}
+
+// isAlertMetric reports whether name is a metric rules can watch
+func isAlertMetric(name string) bool {
+	for _, m := range alertMetrics {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validAlertRules drops rules for unknown metrics, reporting the first one dropped
+func validAlertRules(rules []AlertRule) ([]AlertRule, error) {
+	var valid []AlertRule
+	var err error
+	for _, r := range rules {
+		if !isAlertMetric(r.Metric) {
+			if err == nil {
+				err = fmt.Errorf("unknown alert metric %q (want one of %s)", r.Metric, strings.Join(alertMetrics, ", "))
+			}
+			continue
+		}
+		valid = append(valid, r)
+	}
+	return valid, err
+}
+
+// describe says why r is firing at value, e.g. "longest query 6m 12s (over 5m)"
+func (r AlertRule) describe(value float64) string {
+	switch r.Metric {
+	case AlertConnectionsPercent:
+		return fmt.Sprintf("connections at %.0f%% (over %.0f%%)", value, r.Above)
+	case AlertLongestQuery:
+		return fmt.Sprintf("longest query %s (over %s)", formatDuration(int(value)), formatDuration(int(r.Above)))
+	case AlertBlockedBackends:
+		return fmt.Sprintf("%.0f blocked backends (over %.0f)", value, r.Above)
+	}
+	return fmt.Sprintf("%s %g (over %g)", r.Metric, value, r.Above)
+}
+
+// alertRules returns the rules from config.json
+func (m *Model) alertRules() []AlertRule {
+	if m.config == nil {
+		return nil
+	}
+	return m.config.Alerts
+}
+
+// watchesMetric reports whether any alert rule needs metric read
+func (m *Model) watchesMetric(metric string) bool {
+	for _, r := range m.alertRules() {
+		if r.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// readAlertMetrics reads the metrics the connection count doesn't already cover, only for
+// the ones some rule watches. Metrics that fail to read are left out of the result.
+func readAlertMetrics(db *sql.DB, longest, blocked bool) map[string]float64 {
+	metrics := map[string]float64{}
+	if longest {
+		var secs float64
+		err := db.QueryRow(`
+			SELECT COALESCE(MAX(EXTRACT(EPOCH FROM (NOW() - query_start))), 0)::float8
+			FROM pg_stat_activity
+			WHERE state = 'active' AND pid != pg_backend_pid() AND backend_type = 'client backend'`).Scan(&secs)
+		if err == nil {
+			metrics[AlertLongestQuery] = secs
+		}
+	}
+	if blocked {
+		if info, err := GetBlockingLockInfo(db); err == nil {
+			metrics[AlertBlockedBackends] = float64(info.BlockedCount)
+		}
+	}
+	return metrics
+}
+
+// checkAlerts updates which rules are firing from metrics. Rules whose metric wasn't read
+// keep their previous state. When a rule starts firing the header flashes, and the bell
+// rings if that rule asks for it.
+func (m *Model) checkAlerts(metrics map[string]float64) tea.Cmd {
+	rules := m.alertRules()
+	if len(rules) == 0 {
+		return nil
+	}
+	if m.alerts == nil {
+		m.alerts = make(map[int]string)
+	}
+
+	started, bell := false, false
+	for i, r := range rules {
+		value, ok := metrics[r.Metric]
+		if !ok {
+			continue
+		}
+		if value <= r.Above {
+			delete(m.alerts, i)
+			continue
+		}
+		if _, firing := m.alerts[i]; !firing {
+			started = true
+			bell = bell || r.Bell
+		}
+		m.alerts[i] = r.describe(value)
+	}
+
+	var cmds []tea.Cmd
+	if started {
+		flashing := m.alertFlash > 0
+		m.alertFlash = alertFlashes
+		if !flashing {
+			cmds = append(cmds, scheduleAlertFlash())
+		}
+	}
+	if bell {
+		cmds = append(cmds, func() tea.Msg { return bellMsg{} })
+	}
+	return tea.Batch(cmds...)
+}
+
+// scheduleAlertFlash waits for the next flip of the header highlight
+func scheduleAlertFlash() tea.Cmd {
+	return tea.Tick(alertFlashInterval, func(time.Time) tea.Msg {
+		return alertFlashMsg{}
+	})
+}
+
+// handleAlertFlash flips the header highlight until the flashes run out
+func (m *Model) handleAlertFlash() (tea.Model, tea.Cmd) {
+	if m.alertFlash > 0 {
+		m.alertFlash--
+	}
+	m.updateContent()
+	if m.alertFlash == 0 {
+		return m, nil
+	}
+	return m, scheduleAlertFlash()
+}
+
+// ringBell writes the terminal bell. It runs in Update, on the program's own goroutine,
+// rather than from a Cmd racing the renderer.
+func ringBell() {
+	fmt.Fprint(bellOut, "\a")
+}
+
+// renderAlerts renders the firing alerts for the header, highlighted on alternate flashes,
+// or "" when none are firing
+func (m *Model) renderAlerts() string {
+	if len(m.alerts) == 0 {
+		return ""
+	}
+	var firing []string
+	for i := range m.alertRules() {
+		if text, ok := m.alerts[i]; ok {
+			firing = append(firing, text)
+		}
+	}
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+	if m.alertFlash%2 == 1 {
+		style = style.Reverse(true)
+	}
+	return "  " + style.Render("🔔 "+strings.Join(firing, "; "))
+}
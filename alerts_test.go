@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidAlertRules(t *testing.T) {
+	rules, err := validAlertRules([]AlertRule{
+		{Metric: AlertLongestQuery, Above: 300},
+		{Metric: "bogus", Above: 1},
+		{Metric: AlertConnectionsPercent, Above: 90},
+	})
+	if err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("error = %v, want one naming the unknown metric", err)
+	}
+	if len(rules) != 2 || rules[0].Metric != AlertLongestQuery || rules[1].Metric != AlertConnectionsPercent {
+		t.Errorf("rules = %+v, want the two known ones in order", rules)
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	var bell bytes.Buffer
+	bellOut = &bell
+	t.Cleanup(func() { bellOut = os.Stdout })
+
+	m := &Model{config: &Config{Alerts: []AlertRule{
+		{Metric: AlertLongestQuery, Above: 300, Bell: true},
+		{Metric: AlertConnectionsPercent, Above: 90},
+	}}}
+
+	if cmd := m.checkAlerts(map[string]float64{AlertLongestQuery: 120, AlertConnectionsPercent: 50}); cmd != nil || len(m.alerts) != 0 {
+		t.Fatalf("nothing over its threshold should fire, alerts %v", m.alerts)
+	}
+
+	cmd := m.checkAlerts(map[string]float64{AlertLongestQuery: 372, AlertConnectionsPercent: 50})
+	if got := m.alerts[0]; got != "longest query 6m 12s (over 5m)" {
+		t.Errorf("alert = %q", got)
+	}
+	if m.alertFlash != alertFlashes {
+		t.Errorf("alertFlash = %d, want %d so the header flashes", m.alertFlash, alertFlashes)
+	}
+	if cmd == nil {
+		t.Fatal("a new alert should schedule the flash and ring the bell")
+	}
+	if bell.Len() != 0 {
+		t.Error("the bell should ring from Update, not while checking alerts")
+	}
+	m.Update(bellMsg{})
+	if bell.String() != "\a" {
+		t.Errorf("bell wrote %q", bell.String())
+	}
+
+	// Still firing: no new flash or bell
+	m.alertFlash = 0
+	if cmd := m.checkAlerts(map[string]float64{AlertLongestQuery: 400}); cmd != nil || m.alertFlash != 0 {
+		t.Error("an alert that keeps firing shouldn't flash again")
+	}
+	if !strings.Contains(m.renderAlerts(), "longest query 6m 40s") {
+		t.Errorf("header should show the firing alert: %q", m.renderAlerts())
+	}
+
+	// A metric that wasn't read leaves its rule alone
+	m.checkAlerts(map[string]float64{AlertConnectionsPercent: 95})
+	if len(m.alerts) != 2 {
+		t.Errorf("alerts = %v, want both firing", m.alerts)
+	}
+
+	m.checkAlerts(map[string]float64{AlertLongestQuery: 10, AlertConnectionsPercent: 20})
+	if len(m.alerts) != 0 || m.renderAlerts() != "" {
+		t.Errorf("alerts under their thresholds should clear, got %v", m.alerts)
+	}
+}
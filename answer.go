@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// explainSystemPrompt asks for a read-only explanation of a query instead of SQL
+const explainSystemPrompt = "You are a PostgreSQL expert helping a DBA understand a monitoring query someone else wrote. " +
+	"Explain in plain English what the query does and what its result shows, then list anything to watch for: " +
+	"cost on large databases, locks it takes, extensions or privileges it needs, and version differences. " +
+	"Be concise and answer in plain text, not markdown."
+
+// AIAnswer holds a read-only LLM answer shown in place of the results
+type AIAnswer struct {
+	Title  string
+	Text   string // answer so far; complete once Done
+	Err    string
+	Status string // pending retry while waiting, e.g. "rate limited, retrying in 2s…"
+	Done   bool
+}
+
+// aiAnswerMsg is sent when an askLLM request completes
+type aiAnswerMsg struct {
+	Text string
+	Err  error
+}
+
+// aiAnswerPartialMsg carries the answer streamed so far, or a status such as a pending retry;
+// next waits for the following update
+type aiAnswerPartialMsg struct {
+	Text   string
+	Status string
+	next   tea.Cmd
+}
+
+// explainMessages asks the LLM what query does and what to watch out for
+func explainMessages(query Query) []chatMessage {
+	var prompt strings.Builder
+	prompt.WriteString("Explain this query, named " + query.Name)
+	if query.Description != "" {
+		prompt.WriteString(" (" + query.Description + ")")
+	}
+	prompt.WriteString(":\n\n" + query.SQL)
+	return []chatMessage{
+		{Role: "system", Content: explainSystemPrompt},
+		{Role: "user", Content: prompt.String()},
+	}
+}
+
+// askLLM sends messages to the configured LLM provider for a free-text answer.
+// Streamed output arrives as aiAnswerPartialMsg updates before the final aiAnswerMsg;
+// cancelling ctx abandons the request.
+func askLLM(ctx context.Context, messages []chatMessage) tea.Cmd {
+	updates := make(chan tea.Msg)
+	go func() {
+		defer close(updates)
+		send := func(msg tea.Msg) bool {
+			select {
+			case updates <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		provider, err := newLLMProvider()
+		if err != nil {
+			send(aiAnswerMsg{Err: err})
+			return
+		}
+
+		ctx := withRetryNotice(ctx, func(status string) {
+			send(aiAnswerPartialMsg{Status: status, next: waitForChatGPT(updates)})
+		})
+		var partial strings.Builder
+		content, err := provider.Generate(ctx, messages, func(delta string) {
+			partial.WriteString(delta)
+			send(aiAnswerPartialMsg{Text: partial.String(), next: waitForChatGPT(updates)})
+		})
+		if err != nil {
+			send(aiAnswerMsg{Err: err})
+			return
+		}
+		send(aiAnswerMsg{Text: strings.TrimSpace(content)})
+	}()
+	return waitForChatGPT(updates)
+}
+
+// startAIAnswer opens the answer view and sends messages to the LLM
+func (m *Model) startAIAnswer(title string, messages []chatMessage) tea.Cmd {
+	m.cancelAI()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.aiCancel = cancel
+	m.aiAnswer = &AIAnswer{Title: title}
+	m.viewport.GotoTop()
+	m.updateContent()
+	return askLLM(ctx, messages)
+}
+
+// handleExplainQuery asks the LLM to explain the selected query's SQL
+func (m *Model) handleExplainQuery() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	query := m.queries[m.selected]
+	// Home and Active are built in; there's no user SQL to explain
+	if IsHomeTab(query.Name) || IsActiveTab(query.Name) || strings.TrimSpace(query.SQL) == "" {
+		return m, nil
+	}
+	return m, m.startAIAnswer("Explain "+query.Name, explainMessages(query))
+}
+
+// handleAIAnswerKeys scrolls the answer; esc, q or enter closes it, stopping a response still streaming
+func (m *Model) handleAIAnswerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.viewport.ScrollUp(1)
+	case key.Matches(msg, keys.Down):
+		m.viewport.ScrollDown(1)
+	case key.Matches(msg, keys.PageUp):
+		m.viewport.PageUp()
+	case key.Matches(msg, keys.PageDown):
+		m.viewport.PageDown()
+	case msg.Type == tea.KeyEscape || msg.Type == tea.KeyEnter || msg.String() == "q" || msg.String() == "ctrl+[":
+		m.cancelAI()
+		m.aiAnswer = nil
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// handleAIAnswerPartial shows the answer streamed so far and waits for more
+func (m *Model) handleAIAnswerPartial(msg aiAnswerPartialMsg) (tea.Model, tea.Cmd) {
+	if m.aiAnswer == nil || m.aiAnswer.Done {
+		return m, nil
+	}
+	m.aiAnswer.Text = msg.Text
+	m.aiAnswer.Status = msg.Status
+	m.updateContent()
+	return m, msg.next
+}
+
+// handleAIAnswer shows the finished answer, or why it failed
+func (m *Model) handleAIAnswer(msg aiAnswerMsg) (tea.Model, tea.Cmd) {
+	// The view was closed while the request ran
+	if m.aiAnswer == nil || m.aiAnswer.Done {
+		return m, nil
+	}
+	m.aiCancel = nil
+	m.aiAnswer.Done = true
+	if msg.Err != nil {
+		m.aiAnswer.Err = msg.Err.Error()
+	} else {
+		m.aiAnswer.Text = msg.Text
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// RenderAIAnswer renders the answer wrapped to width, with a spinner while it streams
+func RenderAIAnswer(a *AIAnswer, width int, spinner string) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(a.Title + " (" + llmProviderLabel() + ")"))
+	b.WriteString("\n\n")
+	if a.Text != "" {
+		b.WriteString(lipgloss.NewStyle().Width(max(width-2, 20)).Render(a.Text))
+		b.WriteString("\n\n")
+	}
+	if a.Err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  Error: " + a.Err))
+		b.WriteString("\n\n")
+	}
+	if !a.Done {
+		status := "Thinking..."
+		if a.Status != "" {
+			status = a.Status
+		}
+		b.WriteString("  " + spinner + dimStyle.Render(" "+status))
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestExplainQuery(t *testing.T) {
+	zone.NewGlobal()
+
+	var got []chatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = req.Messages
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Lists tables by dead tuples."}}]}`))
+	}))
+	defer server.Close()
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_BASE_URL": server.URL,
+	})
+
+	query := Query{Name: "Bloat", Description: "dead tuples", SQL: "SELECT relname FROM pg_stat_user_tables"}
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, viewport: viewport.New(80, 40), queries: []Query{query}}
+
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	if m.aiAnswer == nil || cmd == nil {
+		t.Fatalf("E did not start an explanation")
+	}
+	m.Update(cmd())
+
+	if len(got) != 2 || got[0].Content != explainSystemPrompt || !strings.Contains(got[1].Content, query.SQL) {
+		t.Fatalf("request messages = %+v, want the explain prompt and the query's SQL", got)
+	}
+	if !m.aiAnswer.Done || m.aiAnswer.Text != "Lists tables by dead tuples." {
+		t.Fatalf("aiAnswer = %+v, want the finished explanation", m.aiAnswer)
+	}
+	if view := m.viewport.View(); !strings.Contains(view, "Lists tables by dead tuples.") {
+		t.Errorf("view does not show the explanation:\n%s", view)
+	}
+	if query.SQL != m.queries[0].SQL {
+		t.Errorf("explaining changed the query's SQL")
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.aiAnswer != nil {
+		t.Errorf("esc did not close the explanation")
+	}
+
+	// Without an API key the view says why instead of calling out
+	setEnv(t, map[string]string{"OPENAI_API_KEY": ""})
+	_, cmd = m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	m.Update(cmd())
+	if m.aiAnswer == nil || !strings.Contains(m.aiAnswer.Err, "OPENAI_API_KEY") {
+		t.Errorf("aiAnswer = %+v, want an OPENAI_API_KEY error", m.aiAnswer)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ArchiveView holds the state for browsing archived queries
+type ArchiveView struct {
+	Queries       []Query // archived queries, most recently archived first
+	SelectedIndex int
+	ConfirmPurge  bool   // p pressed; awaiting y/n before deleting for good
+	Message       string // result of the last restore or delete
+	LastError     string
+}
+
+// NewArchiveView creates an ArchiveView listing the archived queries
+func NewArchiveView() *ArchiveView {
+	av := &ArchiveView{}
+	av.load()
+	return av
+}
+
+// load re-reads the archived queries, keeping the selection in range
+func (av *ArchiveView) load() {
+	if globalQueryDB == nil {
+		return
+	}
+	queries, err := globalQueryDB.LoadArchivedQueries()
+	if err != nil {
+		av.LastError = fmt.Sprintf("Failed to load archived queries: %v", err)
+		return
+	}
+	av.Queries = queries
+	if av.SelectedIndex >= len(av.Queries) {
+		av.SelectedIndex = max(len(av.Queries)-1, 0)
+	}
+}
+
+// RenderArchiveView renders the archived query list
+func RenderArchiveView(av *ArchiveView) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary).
+		Background(theme.SelectedBg)
+
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Archived Queries"))
+	b.WriteString("\n\n")
+	if len(av.Queries) == 0 {
+		b.WriteString(dimStyle.Render("  Nothing archived. Ctrl+D in the editor archives a query."))
+		b.WriteString("\n")
+	}
+	now := time.Now()
+	for i, q := range av.Queries {
+		line := q.Name
+		if q.Description != "" {
+			line += " - " + q.Description
+		}
+		if i == av.SelectedIndex {
+			b.WriteString(selectedStyle.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		if !q.UpdatedAt.IsZero() {
+			b.WriteString(" " + dimStyle.Render("archived "+timeAgo(q.UpdatedAt, now)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if av.ConfirmPurge && av.SelectedIndex < len(av.Queries) {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Delete %q for good? This can't be undone. y/n", av.Queries[av.SelectedIndex].Name)))
+	} else {
+		b.WriteString(dimStyle.Render("  up/down: select  enter/r: restore  p: delete for good  esc: close"))
+	}
+
+	if av.Message != "" {
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Success).Render("  " + av.Message))
+	}
+	if av.LastError != "" {
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
+		b.WriteString("\n\n")
+		b.WriteString(errStyle.Render("  Error: " + av.LastError))
+	}
+
+	return b.String()
+}
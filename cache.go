@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCacheTTL reads a query's cache TTL as typed in the editor: seconds ("90"), a Go
+// duration ("10m", "1h30m") or the way the editor shows one ("1h 30m"). Empty means 0,
+// no caching.
+func parseCacheTTL(s string) (int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid cache TTL %q: use seconds or a duration like 10m", s)
+	}
+	return int(d.Seconds()), nil
+}
+
+// formatCacheTTL shows a cache TTL in the editor, "" for none
+func formatCacheTTL(seconds int) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return formatDuration(seconds)
+}
+
+// cacheFresh reports whether query's last result is recent enough to reuse instead of
+// running it again. Only ticks reuse results; selecting a tab or pressing r always runs it.
+func (m *Model) cacheFresh(query Query, now time.Time) bool {
+	if query.CacheTTL <= 0 {
+		return false
+	}
+	cached, ok := m.resultCache[query.Name]
+	return ok && now.Sub(cached.at) < time.Duration(query.CacheTTL)*time.Second
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCacheTTL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"90", 90, false},
+		{"10m", 600, false},
+		{"1h 30m", 5400, false},
+		{formatCacheTTL(3725), 3720, false}, // the editor shows whole minutes past an hour
+		{"soon", 0, true},
+		{"-5m", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseCacheTTL(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseCacheTTL(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCachedTickSkipsQuery(t *testing.T) {
+	query := Query{Name: "Sizes", SQL: "SELECT 1", CacheTTL: 60}
+	now := time.Now()
+	m := &Model{
+		queries:       []Query{query},
+		lastQuery:     query,
+		lastRefreshAt: now.Add(-10 * time.Second),
+		resultCache:   map[string]cachedResult{"Sizes": {queryResultMsg{Output: "cached"}, now.Add(-10 * time.Second)}},
+	}
+	if !m.cacheFresh(query, now) {
+		t.Fatal("cacheFresh() = false for a 10s old result with a 60s TTL")
+	}
+	if m.cacheFresh(query, now.Add(time.Minute)) {
+		t.Error("cacheFresh() = true past the TTL")
+	}
+	if m.cacheFresh(Query{Name: "Sizes"}, now) {
+		t.Error("cacheFresh() = true for a query without a TTL")
+	}
+
+	if _, cmd := m.handleTickMsg(); cmd == nil || m.loading {
+		t.Errorf("tick within the TTL: loading %v; want the cached result kept and the next tick scheduled", m.loading)
+	}
+	if m.refreshOverdue() {
+		t.Error("refreshOverdue() = true for a result within its cache TTL")
+	}
+}
+
+func TestCacheTTLPersists(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	if err := qdb.SaveQuery(Query{Name: "Sizes", SQL: "SELECT 1", CacheTTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := qdb.GetQuery("Sizes")
+	if err != nil || got.CacheTTL != 300 {
+		t.Errorf("GetQuery() CacheTTL = %d, %v; want 300", got.CacheTTL, err)
+	}
+}
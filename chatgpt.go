@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultOpenAIModel   = "gpt-4o-mini"
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+)
+
+const (
+	// openAIMaxRetries is how many times a rate-limited or failed request is retried
+	openAIMaxRetries = 2
+	// openAIMaxRetryWait is the longest Retry-After psq waits out; longer waits fail right away
+	openAIMaxRetryWait = 30 * time.Second
+)
+
+// openAIRetryBackoff is the first wait before retrying when the server doesn't send
+// Retry-After; it doubles on each attempt
+var openAIRetryBackoff = time.Second
+
+// openAIClient waits up to 30s for a response to start but sets no limit on reading it:
+// a streamed completion can take longer than that, and esc cancels the request's context
+var openAIClient = &http.Client{Transport: func() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}()}
+
+// chatgptSystemPrompt keeps responses to a single runnable statement
+const chatgptSystemPrompt = "You are a PostgreSQL expert helping a DBA write monitoring queries. " +
+	"Respond with a single PostgreSQL query only: no explanation, no markdown code fences."
+
+// AIState represents the current step of AI query generation in the editor
+type AIState int
+
+const (
+	AIStateNone AIState = iota
+	AIStatePrompt
+	AIStateWaiting
+	AIStateReview
+	AIStateFollowUp
+)
+
+// chatgptResponseMsg is sent when a ChatGPT request completes
+type chatgptResponseMsg struct {
+	SQL string
+	Err error
+}
+
+// chatgptPartialMsg carries the SQL generated so far while a response streams in, or a
+// status such as a pending retry; next waits for the following update
+type chatgptPartialMsg struct {
+	SQL    string
+	Status string
+	next   tea.Cmd
+}
+
+// OpenAISettings holds the model and endpoint used for ChatGPT requests
+type OpenAISettings struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+// openAISettingsFromEnv reads $OPENAI_API_KEY, $PSQ_OPENAI_MODEL and $PSQ_OPENAI_BASE_URL,
+// defaulting to gpt-4o-mini on the OpenAI API
+func openAISettingsFromEnv() (OpenAISettings, error) {
+	settings := OpenAISettings{
+		APIKey:  os.Getenv("OPENAI_API_KEY"),
+		Model:   strings.TrimSpace(os.Getenv("PSQ_OPENAI_MODEL")),
+		BaseURL: strings.TrimSpace(os.Getenv("PSQ_OPENAI_BASE_URL")),
+	}
+	if settings.Model == "" {
+		settings.Model = defaultOpenAIModel
+	}
+	if settings.BaseURL == "" {
+		settings.BaseURL = defaultOpenAIBaseURL
+	}
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+
+	if err := validateBaseURL("PSQ_OPENAI_BASE_URL", settings.BaseURL, defaultOpenAIBaseURL); err != nil {
+		return settings, err
+	}
+	if settings.APIKey == "" {
+		return settings, fmt.Errorf("OPENAI_API_KEY is not set")
+	}
+	return settings, nil
+}
+
+// validateBaseURL checks that the base URL set in envVar is an absolute http(s) URL
+func validateBaseURL(envVar, baseURL, example string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", envVar, baseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s %q: must be an http(s) URL like %s", envVar, baseURL, example)
+	}
+	return nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// chatCompletionChunk is one server-sent event of a streamed completion
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+}
+
+// openAIProvider sends chat completions to OpenAI or an OpenAI-compatible endpoint
+type openAIProvider struct {
+	settings OpenAISettings
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, messages []chatMessage, onDelta func(string)) (string, error) {
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:    p.settings.Model,
+		Messages: messages,
+		Stream:   onDelta != nil,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	// Rate limits and overloaded servers usually clear up within seconds, so retry those a
+	// couple of times before giving up
+	for attempt := 0; ; attempt++ {
+		content, err := p.post(ctx, body, onDelta)
+		var retry *retryableError
+		if !errors.As(err, &retry) || attempt == openAIMaxRetries {
+			return content, err
+		}
+		wait := retry.retryAfter
+		if wait <= 0 {
+			wait = openAIRetryBackoff << attempt
+		}
+		if wait > openAIMaxRetryWait {
+			return "", err
+		}
+		notifyRetry(ctx, retry.status, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// post sends one chat completion request. 429 and 5xx responses come back as a *retryableError.
+func (p *openAIProvider) post(ctx context.Context, body []byte, onDelta func(string)) (string, error) {
+	settings := p.settings
+
+	endpoint := settings.BaseURL + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", settings.BaseURL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+settings.APIKey)
+
+	resp, err := openAIClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to %s failed: %w", settings.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	// Compatible endpoints that ignore stream:true answer with a single JSON body instead
+	if resp.StatusCode == http.StatusOK && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readCompletionStream(resp.Body, settings.BaseURL, onDelta)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from %s: %w", settings.BaseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, code := openAIErrorMessage(respBody)
+		err := fmt.Errorf("%s returned %s: %s", settings.BaseURL, resp.Status, message)
+		// An exhausted quota won't recover by waiting
+		if (resp.StatusCode == http.StatusTooManyRequests && code != "insufficient_quota") || resp.StatusCode >= 500 {
+			return "", &retryableError{err: err, status: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+		}
+		return "", err
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return "", fmt.Errorf("failed to parse response from %s: %w", settings.BaseURL, err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no choices", settings.BaseURL)
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+// openAIErrorMessage pulls the message and code out of an OpenAI error body, falling back
+// to the raw body for endpoints that answer some other way
+func openAIErrorMessage(body []byte) (message, code string) {
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		return parsed.Error.Message, parsed.Error.Code
+	}
+	return strings.TrimSpace(string(body)), ""
+}
+
+// retryableError is a transient failure (429 or 5xx) worth sending the request again for
+type retryableError struct {
+	err        error
+	status     int
+	retryAfter time.Duration // from the Retry-After header; 0 when absent
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryNoticeKey is the context key for withRetryNotice's callback
+type retryNoticeKey struct{}
+
+// withRetryNotice returns a context whose LLM requests call notify with a short status,
+// e.g. "rate limited, retrying in 2s…", before waiting to retry
+func withRetryNotice(ctx context.Context, notify func(string)) context.Context {
+	return context.WithValue(ctx, retryNoticeKey{}, notify)
+}
+
+// notifyRetry reports an upcoming retry to the callback set by withRetryNotice, if any
+func notifyRetry(ctx context.Context, status int, wait time.Duration) {
+	notify, ok := ctx.Value(retryNoticeKey{}).(func(string))
+	if !ok {
+		return
+	}
+	reason := "rate limited"
+	if status != http.StatusTooManyRequests {
+		reason = fmt.Sprintf("server error (%d)", status)
+	}
+	notify(fmt.Sprintf("%s, retrying in %s…", reason, formatDuration(int((wait+time.Second-1)/time.Second))))
+}
+
+// readCompletionStream collects the content deltas of a streamed chat completion,
+// passing each one to onDelta as it arrives
+func readCompletionStream(r io.Reader, baseURL string, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to parse stream from %s: %w", baseURL, err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if onDelta != nil {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream from %s: %w", baseURL, err)
+	}
+	return content.String(), nil
+}
+
+// buildSQLMessages prefixes a conversation of user requests and generated SQL with the
+// instructions for a single SQL query, grounding it in the database schema when one is available
+func buildSQLMessages(conversation []chatMessage, schema string) []chatMessage {
+	system := chatgptSystemPrompt
+	if schema != "" {
+		system += "\n\nOnly reference tables and columns that exist. The public schema is:\n" + schema
+	}
+	return append([]chatMessage{{Role: "system", Content: system}}, conversation...)
+}
+
+// callChatGPT asks the configured LLM provider for SQL answering the latest request in the conversation.
+// Streamed output arrives as chatgptPartialMsg updates before the final chatgptResponseMsg;
+// cancelling ctx abandons the request.
+func callChatGPT(ctx context.Context, db *sql.DB, service string, conversation []chatMessage) tea.Cmd {
+	updates := make(chan tea.Msg)
+	go func() {
+		defer close(updates)
+		send := func(msg tea.Msg) bool {
+			select {
+			case updates <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		provider, err := newLLMProvider()
+		if err != nil {
+			send(chatgptResponseMsg{Err: err})
+			return
+		}
+
+		// The schema only improves accuracy, so generate without it if it can't be read
+		var schema string
+		if db != nil && aiSchemaEnabled() {
+			schema, _ = schemaSummaryForService(db, service)
+		}
+
+		ctx := withRetryNotice(ctx, func(status string) {
+			send(chatgptPartialMsg{Status: status, next: waitForChatGPT(updates)})
+		})
+		var partial strings.Builder
+		content, err := provider.Generate(ctx, buildSQLMessages(conversation, schema), func(delta string) {
+			partial.WriteString(delta)
+			send(chatgptPartialMsg{SQL: stripCodeFences(partial.String()), next: waitForChatGPT(updates)})
+		})
+		if err != nil {
+			send(chatgptResponseMsg{Err: err})
+			return
+		}
+		send(chatgptResponseMsg{SQL: stripCodeFences(content)})
+	}()
+	return waitForChatGPT(updates)
+}
+
+// waitForChatGPT delivers the next update from a running callChatGPT
+func waitForChatGPT(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// stripCodeFences removes markdown ``` fences models sometimes add despite instructions
+func stripCodeFences(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	// Drop the language tag on the opening fence (```sql)
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+const aiPromptPlaceholder = "Describe the query you want, e.g. tables with the most dead tuples"
+
+// startChatGPTPrompt opens the natural-language prompt input in the editor
+func (m *Model) startChatGPTPrompt() {
+	m.aiInput = textinput.New()
+	m.aiInput.Placeholder = aiPromptPlaceholder
+	m.aiInput.CharLimit = 500
+	m.aiInput.Width = 80
+	m.aiInput.Focus()
+	m.aiState = AIStatePrompt
+	m.aiResult = ""
+	m.aiErr = ""
+	m.aiMessages = nil
+}
+
+// handleChatGPTKeys handles keys while the editor's AI panel is open
+func (m *Model) handleChatGPTKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyEscape || msg.String() == "esc" || msg.String() == "ctrl+[" {
+		if m.aiState == AIStateFollowUp {
+			// Back to the SQL being refined rather than throwing it away
+			m.aiState = AIStateReview
+			m.updateContent()
+			return m, nil
+		}
+		// Stops a streaming response early if it's heading the wrong way
+		m.cancelAI()
+		m.aiState = AIStateNone
+		m.updateContent()
+		return m, nil
+	}
+
+	switch m.aiState {
+	case AIStatePrompt, AIStateFollowUp:
+		if msg.String() == "enter" {
+			prompt := strings.TrimSpace(m.aiInput.Value())
+			if prompt == "" {
+				return m, nil
+			}
+			if m.aiState == AIStatePrompt {
+				// A new prompt starts a new conversation
+				m.aiMessages = nil
+			}
+			m.aiMessages = append(m.aiMessages, chatMessage{Role: "user", Content: prompt})
+			return m, m.requestAISQL()
+		}
+		var cmd tea.Cmd
+		m.aiInput, cmd = m.aiInput.Update(msg)
+		m.updateContent()
+		return m, cmd
+
+	case AIStateReview:
+		switch msg.String() {
+		case "c":
+			m.aiSQLUsed = true
+			m.aiUndoSQL = m.sqlTextarea.Value()
+			m.aiUndoArmed = m.aiUndoSQL != m.aiResult
+			m.setSQL(m.aiResult)
+			m.aiState = AIStateNone
+			m.updateContent()
+		case "r":
+			// Edit the original prompt and start over
+			m.aiState = AIStatePrompt
+			if len(m.aiMessages) > 0 {
+				m.aiInput.SetValue(m.aiMessages[0].Content)
+			}
+			m.aiInput.Placeholder = aiPromptPlaceholder
+			m.aiInput.Focus()
+			m.updateContent()
+		case "f":
+			// Refine the generated SQL with a follow-up instruction
+			m.aiState = AIStateFollowUp
+			m.aiInput.SetValue("")
+			m.aiInput.Placeholder = "Refine it, e.g. also include the database name"
+			m.aiInput.Focus()
+			m.updateContent()
+		}
+	}
+
+	return m, nil
+}
+
+// requestAISQL sends the conversation so far to the LLM provider
+func (m *Model) requestAISQL() tea.Cmd {
+	m.aiState = AIStateWaiting
+	m.aiErr = ""
+	m.aiResult = ""
+	m.aiStatus = ""
+	m.updateContent()
+	m.cancelAI()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.aiCancel = cancel
+	return callChatGPT(ctx, m.db, m.service, m.aiMessages)
+}
+
+// cancelAI abandons the in-flight AI request, if any
+func (m *Model) cancelAI() {
+	if m.aiCancel != nil {
+		m.aiCancel()
+		m.aiCancel = nil
+	}
+}
+
+// handleChatGPTPartial shows the SQL streamed so far and waits for more
+func (m *Model) handleChatGPTPartial(msg chatgptPartialMsg) (tea.Model, tea.Cmd) {
+	if !m.editMode || m.aiState != AIStateWaiting {
+		m.cancelAI()
+		return m, nil
+	}
+	m.aiResult = msg.SQL
+	m.aiStatus = msg.Status
+	m.updateContent()
+	return m, msg.next
+}
+
+// handleChatGPTResponse shows generated SQL for review, or the error back at the prompt
+func (m *Model) handleChatGPTResponse(msg chatgptResponseMsg) (tea.Model, tea.Cmd) {
+	// Ignore late responses after the user cancelled or left the editor
+	if !m.editMode || m.aiState != AIStateWaiting {
+		return m, nil
+	}
+	m.aiCancel = nil
+	if msg.Err != nil {
+		m.aiErr = msg.Err.Error()
+		m.aiState = AIStatePrompt
+		if n := len(m.aiMessages); n > 1 {
+			// A failed follow-up keeps the SQL it was refining; the instruction stays in the input to retry
+			m.aiMessages = m.aiMessages[:n-1]
+			m.aiResult = m.aiMessages[n-2].Content
+			m.aiState = AIStateFollowUp
+		}
+	} else {
+		m.aiResult = msg.SQL
+		m.aiMessages = append(m.aiMessages, chatMessage{Role: "assistant", Content: msg.SQL})
+		m.aiState = AIStateReview
+		m.aiPlan, m.aiPlanErr = aiPlanUnchecked, ""
+		if m.db != nil && !m.reconnecting && aiExplainEnabled() {
+			m.aiPlan = aiPlanChecking
+			m.updateContent()
+			return m, checkAIPlan(m.db, m.service, msg.SQL)
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handleAIPlan shows whether Postgres could plan the SQL under review
+func (m *Model) handleAIPlan(msg aiPlanMsg) (tea.Model, tea.Cmd) {
+	// A follow-up may have replaced the SQL while the check ran
+	if !m.editMode || m.aiState != AIStateReview || m.aiResult != msg.SQL {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.aiPlan, m.aiPlanErr = aiPlanInvalid, msg.Err.Error()
+	} else {
+		m.aiPlan, m.aiPlanErr = aiPlanValid, ""
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// renderChatGPTPanel renders the AI prompt, progress, or generated SQL below the editor
+func (m *Model) renderChatGPTPanel() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	sqlStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Padding(0, 1)
+
+	var b strings.Builder
+	b.WriteString("\n" + titleStyle.Render("Generate with "+llmProviderLabel()) + "\n")
+
+	switch m.aiState {
+	case AIStatePrompt:
+		b.WriteString(m.aiInput.View() + "\n")
+		b.WriteString(dimStyle.Render("  enter: generate  esc: cancel"))
+	case AIStateWaiting:
+		if m.aiResult != "" {
+			b.WriteString(sqlStyle.Render(highlightSQL(m.aiResult)) + "\n")
+		}
+		status := "Generating..."
+		if m.aiStatus != "" {
+			status = m.aiStatus
+		}
+		b.WriteString("  " + m.spinner.View() + dimStyle.Render(" "+status+"  esc: stop"))
+	case AIStateReview:
+		b.WriteString(sqlStyle.Render(highlightSQL(m.aiResult)) + "\n")
+		switch m.aiPlan {
+		case aiPlanChecking:
+			b.WriteString("  " + m.spinner.View() + dimStyle.Render(" checking plan...") + "\n")
+		case aiPlanValid:
+			b.WriteString(lipgloss.NewStyle().Foreground(theme.Success).Render("  ✓ valid plan") + "\n")
+		case aiPlanInvalid:
+			b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  ✗ "+m.aiPlanErr) + "\n")
+		}
+		b.WriteString(dimStyle.Render("  c: use this SQL (ctrl+z reverts)  f: follow up  r: change prompt  esc: discard"))
+	case AIStateFollowUp:
+		b.WriteString(sqlStyle.Render(highlightSQL(m.aiResult)) + "\n")
+		b.WriteString(m.aiInput.View() + "\n")
+		b.WriteString(dimStyle.Render("  enter: refine  esc: back to review"))
+	}
+
+	if m.aiErr != "" {
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
+		b.WriteString("\n" + errStyle.Render("  Error: "+m.aiErr))
+	}
+
+	return b.String()
+}
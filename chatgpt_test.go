@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+// setEnv sets environment variables for the duration of a test
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for k, v := range vars {
+		original, had := os.LookupEnv(k)
+		os.Setenv(k, v)
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(k, original)
+			} else {
+				os.Unsetenv(k)
+			}
+		})
+	}
+}
+
+func TestOpenAISettingsFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantModel   string
+		wantBaseURL string
+		wantErr     string
+	}{
+		{
+			name:        "defaults",
+			env:         map[string]string{"OPENAI_API_KEY": "sk-test", "PSQ_OPENAI_MODEL": "", "PSQ_OPENAI_BASE_URL": ""},
+			wantModel:   defaultOpenAIModel,
+			wantBaseURL: defaultOpenAIBaseURL,
+		},
+		{
+			name:        "custom model and endpoint",
+			env:         map[string]string{"OPENAI_API_KEY": "sk-test", "PSQ_OPENAI_MODEL": "gpt-4o", "PSQ_OPENAI_BASE_URL": "http://localhost:8080/v1/"},
+			wantModel:   "gpt-4o",
+			wantBaseURL: "http://localhost:8080/v1",
+		},
+		{
+			name:    "malformed base URL",
+			env:     map[string]string{"OPENAI_API_KEY": "sk-test", "PSQ_OPENAI_MODEL": "", "PSQ_OPENAI_BASE_URL": "localhost:8080"},
+			wantErr: "invalid PSQ_OPENAI_BASE_URL",
+		},
+		{
+			name:    "missing API key",
+			env:     map[string]string{"OPENAI_API_KEY": "", "PSQ_OPENAI_MODEL": "", "PSQ_OPENAI_BASE_URL": ""},
+			wantErr: "OPENAI_API_KEY is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			settings, err := openAISettingsFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("openAISettingsFromEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openAISettingsFromEnv() error = %v", err)
+			}
+			if settings.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", settings.Model, tt.wantModel)
+			}
+			if settings.BaseURL != tt.wantBaseURL {
+				t.Errorf("BaseURL = %q, want %q", settings.BaseURL, tt.wantBaseURL)
+			}
+		})
+	}
+}
+
+func TestCallChatGPT(t *testing.T) {
+	var gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var req chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		gotModel = req.Model
+		w.Write([]byte("{\"choices\":[{\"message\":{\"role\":\"assistant\",\"content\":\"```sql\\nSELECT 1;\\n```\"}}]}"))
+	}))
+	defer server.Close()
+
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_MODEL":    "test-model",
+		"PSQ_OPENAI_BASE_URL": server.URL + "/v1",
+	})
+
+	msg := callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "anything"}})().(chatgptResponseMsg)
+	if msg.Err != nil {
+		t.Fatalf("callChatGPT() error = %v", msg.Err)
+	}
+	if msg.SQL != "SELECT 1;" {
+		t.Errorf("SQL = %q, want %q", msg.SQL, "SELECT 1;")
+	}
+	if gotModel != "test-model" {
+		t.Errorf("request model = %q, want test-model", gotModel)
+	}
+
+	// Failures name the endpoint so misconfigured URLs are obvious
+	setEnv(t, map[string]string{"PSQ_OPENAI_BASE_URL": server.URL + "/wrong"})
+	msg = callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "anything"}})().(chatgptResponseMsg)
+	if msg.Err == nil || !strings.Contains(msg.Err.Error(), server.URL+"/wrong") {
+		t.Errorf("callChatGPT() error = %v, want it to mention the base URL", msg.Err)
+	}
+}
+
+func TestCallChatGPTStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("request did not ask for a streamed response")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"```sql\n", "SELECT ", "1;", "\n```"} {
+			chunk, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]string{"content": delta}}}})
+			w.Write([]byte("data: " + string(chunk) + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_BASE_URL": server.URL,
+	})
+
+	var partials []string
+	msg := callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "anything"}})()
+	for {
+		partial, ok := msg.(chatgptPartialMsg)
+		if !ok {
+			break
+		}
+		partials = append(partials, partial.SQL)
+		msg = partial.next()
+	}
+
+	final := msg.(chatgptResponseMsg)
+	if final.Err != nil || final.SQL != "SELECT 1;" {
+		t.Fatalf("final = %+v, want SELECT 1;", final)
+	}
+	if len(partials) != 4 || partials[1] != "SELECT" || partials[2] != "SELECT 1;" {
+		t.Errorf("partials = %q, want the SQL to build up chunk by chunk", partials)
+	}
+}
+
+func TestOpenAIClientLetsStreamsRun(t *testing.T) {
+	// A whole-request timeout would cut a long streamed completion off partway through
+	if openAIClient.Timeout != 0 {
+		t.Errorf("openAIClient.Timeout = %v, want none", openAIClient.Timeout)
+	}
+	transport, ok := openAIClient.Transport.(*http.Transport)
+	if !ok || transport.ResponseHeaderTimeout <= 0 {
+		t.Errorf("openAIClient should still give up on a server that never starts responding")
+	}
+}
+
+func TestOpenAIRetry(t *testing.T) {
+	defer func(backoff time.Duration) { openAIRetryBackoff = backoff }(openAIRetryBackoff)
+	openAIRetryBackoff = time.Millisecond
+
+	var requests int
+	var responses []func(w http.ResponseWriter)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses[requests](w)
+		requests++
+	}))
+	defer server.Close()
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_BASE_URL": server.URL,
+		"PSQ_AI_SCHEMA":       "off",
+	})
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached for gpt-4o-mini","type":"requests","code":"rate_limit_exceeded"}}`))
+	}
+	unavailable := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"message":"The server is overloaded"}}`))
+	}
+	ok := func(w http.ResponseWriter) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"SELECT 1"}}]}`))
+	}
+
+	// A rate limit is retried, telling the UI what's going on
+	requests, responses = 0, []func(http.ResponseWriter){rateLimited, ok}
+	msg := callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "anything"}})()
+	var statuses []string
+	for {
+		partial, isPartial := msg.(chatgptPartialMsg)
+		if !isPartial {
+			break
+		}
+		statuses = append(statuses, partial.Status)
+		msg = partial.next()
+	}
+	if final := msg.(chatgptResponseMsg); final.Err != nil || final.SQL != "SELECT 1" {
+		t.Fatalf("final = %+v, want SELECT 1 after a retry", final)
+	}
+	if len(statuses) != 1 || !strings.HasPrefix(statuses[0], "rate limited, retrying") {
+		t.Errorf("statuses = %q, want one rate limit notice", statuses)
+	}
+
+	// Server errors give up after the last retry with the API's message, not the raw body
+	requests, responses = 0, []func(http.ResponseWriter){unavailable, unavailable, unavailable, ok}
+	provider := &openAIProvider{settings: OpenAISettings{APIKey: "sk-test", Model: "m", BaseURL: server.URL}}
+	_, err := provider.Generate(context.Background(), nil, nil)
+	if requests != openAIMaxRetries+1 {
+		t.Errorf("sent %d requests, want %d", requests, openAIMaxRetries+1)
+	}
+	if err == nil || !strings.HasSuffix(err.Error(), ": The server is overloaded") {
+		t.Errorf("Generate() error = %v, want the API's error message", err)
+	}
+
+	// An exhausted quota isn't retried
+	requests, responses = 0, []func(http.ResponseWriter){func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"You exceeded your current quota","code":"insufficient_quota"}}`))
+	}, ok}
+	if _, err := provider.Generate(context.Background(), nil, nil); err == nil || requests != 1 {
+		t.Errorf("Generate() = %v after %d requests, want a quota error without retrying", err, requests)
+	}
+}
+
+func TestAIWriteConfirm(t *testing.T) {
+	zone.NewGlobal()
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true, service: "prod"}
+	m.initEditor(Query{Name: "Cleanup", SQL: "SELECT 1"})
+	m.startChatGPTPrompt()
+	m.aiState, m.aiResult = AIStateReview, "DELETE FROM jobs WHERE done"
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+
+	// Saving AI SQL that writes asks first, showing the statement
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.confirmAIWrite != "DELETE" || !m.editMode {
+		t.Fatalf("confirmAIWrite = %q, editMode = %v; want a DELETE confirmation in the editor", m.confirmAIWrite, m.editMode)
+	}
+	if _, err := qdb.GetQuery("Cleanup"); err == nil {
+		t.Fatalf("query was saved before confirming")
+	}
+	if view := m.renderEditMode(); !strings.Contains(view, "contains DELETE") || !strings.Contains(view, "Run it? y/n") {
+		t.Errorf("editor does not show the confirmation:\n%s", view)
+	}
+
+	// n goes back to the editor without saving
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.confirmAIWrite != "" || !m.editMode {
+		t.Fatalf("n did not return to the editor")
+	}
+
+	// y saves and runs it
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlS})
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.editMode || cmd == nil {
+		t.Fatalf("y did not save and run the query")
+	}
+	if q, err := qdb.GetQuery("Cleanup"); err != nil || q.SQL != "DELETE FROM jobs WHERE done" {
+		t.Errorf("saved query = %+v (%v), want the confirmed DELETE", q, err)
+	}
+
+	// Reads from the AI, and writes typed by hand, save straight away
+	m.editMode = true
+	m.initEditor(Query{Name: "Cleanup", SQL: "DELETE FROM jobs WHERE done"})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.confirmAIWrite != "" || m.editMode {
+		t.Errorf("hand-written SQL asked for confirmation")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"soon":                          0,
+		"Tue, 02 Jan 2024 15:04:15 GMT": 10 * time.Second,
+		"Tue, 02 Jan 2024 15:00:00 GMT": 0,
+	}
+	for header, want := range tests {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestRestoreAIReplacedSQL(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true}
+	m.initEditor(Query{Name: "Q", SQL: "SELECT careful_work()"})
+
+	use := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}
+	restore := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")}
+
+	m.aiState, m.aiResult = AIStateReview, "SELECT 1"
+	m.handleKeyMsg(use)
+	if got := m.sqlTextarea.Value(); got != "SELECT 1" || !m.aiUndoArmed {
+		t.Fatalf("SQL = %q, armed = %v; want the generated SQL with the undo hint", got, m.aiUndoArmed)
+	}
+	m.handleKeyMsg(restore)
+	if got := m.sqlTextarea.Value(); got != "SELECT careful_work()" || m.aiUndoArmed {
+		t.Fatalf("SQL = %q after u, want the original restored", got)
+	}
+
+	// Any other key dismisses the hint, after which u is just a character again
+	m.aiState, m.aiResult = AIStateReview, "SELECT 2"
+	m.handleKeyMsg(use)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	m.handleKeyMsg(restore)
+	if got := m.sqlTextarea.Value(); got != "SELECT 2" {
+		t.Errorf("u after the hint was dismissed changed the SQL to %q", got)
+	}
+}
+
+func TestAIFollowUp(t *testing.T) {
+	zone.NewGlobal()
+
+	var got []chatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = req.Messages
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"SELECT datname, pid FROM pg_stat_activity"}}]}`))
+	}))
+	defer server.Close()
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_BASE_URL": server.URL,
+		"PSQ_AI_SCHEMA":       "off",
+	})
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true}
+	m.initEditor(Query{Name: "Q"})
+	m.startChatGPTPrompt()
+	m.aiMessages = []chatMessage{{Role: "user", Content: "list backends"}, {Role: "assistant", Content: "SELECT pid FROM pg_stat_activity"}}
+	m.aiState, m.aiResult = AIStateReview, "SELECT pid FROM pg_stat_activity"
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if m.aiState != AIStateFollowUp {
+		t.Fatalf("aiState = %v after f, want follow-up", m.aiState)
+	}
+	m.aiInput.SetValue("also include the database name")
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+
+	// system + first request + first answer + follow-up
+	if len(got) != 4 || got[2].Role != "assistant" || got[3].Content != "also include the database name" {
+		t.Fatalf("request messages = %+v, want the whole conversation", got)
+	}
+	if m.aiState != AIStateReview || m.aiResult != "SELECT datname, pid FROM pg_stat_activity" {
+		t.Fatalf("aiState = %v, aiResult = %q; want the refined SQL up for review", m.aiState, m.aiResult)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m.sqlTextarea.Value() != "SELECT datname, pid FROM pg_stat_activity" {
+		t.Errorf("c after a follow-up did not use the refined SQL")
+	}
+}
+
+func TestAIPlanCheck(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true}
+	m.initEditor(Query{Name: "Q"})
+	m.startChatGPTPrompt()
+	m.aiState = AIStateWaiting
+
+	// Without a connection there is nothing to EXPLAIN against
+	if _, cmd := m.handleChatGPTResponse(chatgptResponseMsg{SQL: "SELECT 1"}); cmd != nil || m.aiPlan != aiPlanUnchecked {
+		t.Fatalf("plan check started without a database (aiPlan = %v)", m.aiPlan)
+	}
+
+	m.aiPlan = aiPlanChecking
+	m.handleAIPlan(aiPlanMsg{SQL: "SELECT 1", Err: errors.New(`column "nope" does not exist`)})
+	if panel := m.renderChatGPTPanel(); !strings.Contains(panel, `✗ column "nope" does not exist`) {
+		t.Errorf("panel does not show the plan error:\n%s", panel)
+	}
+
+	// Results for SQL that has since been replaced are ignored
+	m.handleAIPlan(aiPlanMsg{SQL: "SELECT 2"})
+	if m.aiPlan != aiPlanInvalid {
+		t.Errorf("stale plan result changed aiPlan to %v", m.aiPlan)
+	}
+
+	m.handleAIPlan(aiPlanMsg{SQL: "SELECT 1"})
+	if panel := m.renderChatGPTPanel(); !strings.Contains(panel, "✓ valid plan") {
+		t.Errorf("panel does not show the valid plan:\n%s", panel)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ColumnPicker holds the state for choosing which result columns a query shows
+type ColumnPicker struct {
+	Query         string   // name of the query whose columns are being chosen
+	Columns       []string // every column of the result, in query order
+	Hidden        map[string]bool
+	DiffKey       string // column that matches rows between refreshes in diff mode; "" for the first
+	SelectedIndex int
+}
+
+// NewColumnPicker creates a ColumnPicker for query's result columns
+func NewColumnPicker(query Query, columns []string) *ColumnPicker {
+	cp := &ColumnPicker{Query: query.Name, Columns: columns, Hidden: map[string]bool{}}
+	for _, name := range query.HiddenColumns {
+		cp.Hidden[name] = true
+	}
+	return cp
+}
+
+// Toggle shows or hides the selected column. The last visible column can't be hidden.
+func (cp *ColumnPicker) Toggle() {
+	if cp.SelectedIndex >= len(cp.Columns) {
+		return
+	}
+	name := cp.Columns[cp.SelectedIndex]
+	if cp.Hidden[name] {
+		delete(cp.Hidden, name)
+		return
+	}
+	if cp.visible() > 1 {
+		cp.Hidden[name] = true
+	}
+}
+
+// HiddenColumns lists the hidden columns in query order
+func (cp *ColumnPicker) HiddenColumns() []string {
+	var hidden []string
+	for _, name := range cp.Columns {
+		if cp.Hidden[name] {
+			hidden = append(hidden, name)
+		}
+	}
+	return hidden
+}
+
+// diffKey returns the column diff mode matches rows by: the chosen one while it's shown,
+// otherwise the first visible column
+func (cp *ColumnPicker) diffKey() string {
+	first := ""
+	for _, name := range cp.Columns {
+		if cp.Hidden[name] {
+			continue
+		}
+		if name == cp.DiffKey {
+			return name
+		}
+		if first == "" {
+			first = name
+		}
+	}
+	return first
+}
+
+func (cp *ColumnPicker) visible() int {
+	n := 0
+	for _, name := range cp.Columns {
+		if !cp.Hidden[name] {
+			n++
+		}
+	}
+	return n
+}
+
+// RenderColumnPicker renders the column checklist
+func RenderColumnPicker(cp *ColumnPicker) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary).
+		Background(theme.SelectedBg)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Columns - %s (%d of %d shown)", cp.Query, cp.visible(), len(cp.Columns))))
+	b.WriteString("\n\n")
+	key := cp.diffKey()
+	for i, name := range cp.Columns {
+		box := "[x] "
+		if cp.Hidden[name] {
+			box = "[ ] "
+		}
+		if i == cp.SelectedIndex {
+			b.WriteString(selectedStyle.Render("▶ " + box + name))
+		} else if cp.Hidden[name] {
+			b.WriteString(dimStyle.Render("  " + box + name))
+		} else {
+			b.WriteString("  " + box + name)
+		}
+		if name == key {
+			b.WriteString(dimStyle.Render("  (diff key)"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd creates the `psq export` subcommand
+func newExportCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "export <path>",
+		Short: "Export saved queries to a JSON file or a directory of .sql files",
+		Long: `Export every saved query (including hidden ones) in a diff-friendly format.
+
+  --format json   writes a single JSON array of queries to <path>
+  --format sql    writes one .sql file per query into the directory <path>
+
+Both formats keep each query's tab order and can be read back with 'psq import'.`,
+		Example: `  psq export queries.json
+  psq export --format sql ~/dotfiles/psq-queries`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := initQueryDB(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			queries, err := globalQueryDB.LoadAllQueries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to load queries: %v\n", err)
+				os.Exit(1)
+			}
+
+			switch strings.ToLower(format) {
+			case "json":
+				err = ExportQueriesJSON(queries, args[0])
+			case "sql":
+				err = ExportQueriesSQLDir(queries, args[0])
+			default:
+				err = fmt.Errorf("unknown format %q (use json or sql)", format)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Exported %d queries to %s\n", len(queries), args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Export format: json or sql")
+	return cmd
+}
+
+// newImportCmd creates the `psq import` subcommand
+func newImportCmd() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "import <path>",
+		Short: "Import queries from a JSON export, a directory of .sql files, or a dump file",
+		Long: `Merge queries into ~/.psq/queries.db.
+
+New queries are added. Queries whose name already exists with different content
+are skipped unless --overwrite is given.`,
+		Example: `  psq import queries.json
+  psq import ~/dotfiles/psq-queries --overwrite`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := initQueryDB(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			incoming, err := readQueriesFromPath(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			existing, err := globalQueryDB.LoadAllQueries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to load queries: %v\n", err)
+				os.Exit(1)
+			}
+			archived, err := globalQueryDB.LoadArchivedQueries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to load archived queries: %v\n", err)
+				os.Exit(1)
+			}
+
+			toSave, conflicts, held, unchanged := planImport(existing, archived, incoming)
+			skipped := len(conflicts)
+			if overwrite {
+				toSave = append(toSave, conflicts...)
+				skipped = 0
+			}
+			skipped += len(held)
+			if err := globalQueryDB.saveAll(toSave); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to import: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("%d imported, %d skipped, %d already up to date\n", len(toSave), skipped, unchanged)
+			if len(held) > 0 {
+				fmt.Printf("Skipped archived queries (restore or delete them from the archive first): %s\n", strings.Join(held, ", "))
+			}
+			if skipped > len(held) {
+				fmt.Println("Use --overwrite to replace existing queries with the same name")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace existing queries that have the same name")
+	return cmd
+}
+
+// serviceInfo describes a pg_service.conf entry for `psq services` output; passwords are never included
+type serviceInfo struct {
+	Name     string `json:"name"`
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Database string `json:"dbname,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+// newServicesCmd creates the `psq services` subcommand
+func newServicesCmd() *cobra.Command {
+	var long, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "services",
+		Short: "List the services in ~/.pg_service.conf",
+		Example: `  psq services
+  psq services --long
+  psq services --json | jq -r '.[].name'`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			names, err := listServices()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := writeServices(os.Stdout, names, long, asJSON); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&long, "long", "l", false, "Include user, host, port and database")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print services as a JSON array")
+	return cmd
+}
+
+// writeServices prints service names one per line, tab-separated details with long, or JSON
+func writeServices(out io.Writer, names []string, long, asJSON bool) error {
+	services := make([]serviceInfo, 0, len(names))
+	for _, name := range names {
+		info := serviceInfo{Name: name}
+		// Services without a host can't be resolved; list them by name only
+		if config, err := getDBConfig(name); err == nil {
+			info.Host = config.Host
+			info.Port = config.Port
+			info.Database = config.Database
+			info.User = config.User
+		}
+		services = append(services, info)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(services)
+	}
+
+	for _, s := range services {
+		var err error
+		if long {
+			_, err = fmt.Fprintf(out, "%s\t%s@%s:%s/%s\n", s.Name, s.User, s.Host, s.Port, s.Database)
+		} else {
+			_, err = fmt.Fprintln(out, s.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeServices suggests service names from ~/.pg_service.conf for `psq <tab>` and --service
+func completeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	services, err := listServices()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var matches []string
+	for _, s := range services {
+		if strings.HasPrefix(s, toComplete) {
+			matches = append(matches, s)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeQueryNames suggests saved query names for --query. It reads an existing query
+// database only; pressing tab before psq has ever run shouldn't create and seed one.
+func completeQueryNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if _, err := os.Stat(filepath.Join(configDir(), "queries.db")); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if err := initQueryDB(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	queries, err := globalQueryDB.LoadAllQueries()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var matches []string
+	for _, q := range queries {
+		if strings.HasPrefix(q.Name, toComplete) {
+			matches = append(matches, q.Name)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// newActiveCmd creates the `psq active` subcommand
+func newActiveCmd() *cobra.Command {
+	var service string
+	var states []string
+	var minDuration time.Duration
+	var asJSON bool
+	var filter ActiveFilter
+
+	cmd := &cobra.Command{
+		Use:   "active [service]",
+		Short: "Print the non-idle backends, as the Active tab shows them, and exit",
+		Example: `  psq -s prod active --json
+  psq active prod --state "idle in transaction" --min-duration 5m
+  psq active prod --json --min-duration 30s | jq '.[].PID'
+  psq active prod --idle --client-only`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				service = args[0]
+			}
+			if service == "" {
+				service = "default"
+			}
+			if err := runActive(service, filter, states, minDuration, asJSON, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&service, "service", "s", "", "Database service name from ~/.pg_service.conf (default: 'default')")
+	cmd.Flags().StringSliceVar(&states, "state", nil, "Only backends in these states, e.g. active or \"idle in transaction\" (repeatable)")
+	cmd.Flags().DurationVar(&minDuration, "min-duration", 0, "Only backends whose current query has run at least this long, e.g. 30s or 5m")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print backends as a JSON array")
+	cmd.Flags().BoolVar(&filter.ShowIdle, "idle", false, "Include idle connections")
+	cmd.Flags().BoolVar(&filter.HideBackground, "client-only", false, "Leave out autovacuum, replication and other background workers")
+	cmd.Flags().BoolVar(&filter.ShowSelf, "self", false, "Include psq's own connections")
+	cmd.Flags().StringVar(&filter.Database, "database", "", "Only backends connected to this database (default: the whole instance)")
+	cmd.RegisterFlagCompletionFunc("service", completeServices)
+	return cmd
+}
+
+// runActive fetches the backends filter selects from service and writes the ones matching states and minDuration
+func runActive(service string, filter ActiveFilter, states []string, minDuration time.Duration, asJSON bool, out io.Writer) error {
+	defer closeTunnel(service)
+	db, err := connectDB(service)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	processes, err := FetchActiveProcesses(db, withStates(filter, states))
+	if err != nil {
+		return err
+	}
+	return writeActive(out, filterProcesses(processes, states, minDuration), asJSON)
+}
+
+// withStates returns filter with idle sessions included when states asks for them; they'd
+// otherwise be left out by the query before --state ever saw them
+func withStates(filter ActiveFilter, states []string) ActiveFilter {
+	if slices.ContainsFunc(states, func(s string) bool { return strings.EqualFold(strings.TrimSpace(s), "idle") }) {
+		filter.ShowIdle = true
+	}
+	return filter
+}
+
+// filterProcesses keeps backends in one of states (any state when empty) whose query has run
+// for at least minDuration
+func filterProcesses(processes []ActiveProcess, states []string, minDuration time.Duration) []ActiveProcess {
+	filtered := []ActiveProcess{}
+	for _, p := range processes {
+		if len(states) > 0 && !slices.ContainsFunc(states, func(s string) bool { return strings.EqualFold(strings.TrimSpace(s), p.State) }) {
+			continue
+		}
+		if p.DurationSecs < minDuration.Seconds() {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// writeActive prints backends as a JSON array, or as the table the Active tab shows
+func writeActive(out io.Writer, processes []ActiveProcess, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(processes)
+	}
+
+	columns := []string{"pid", "user", "database", "state", "duration", "xact_age", "wait_event", "query"}
+	rows := make([][]string, len(processes))
+	for i, p := range processes {
+		rows[i] = []string{fmt.Sprint(p.PID), p.Username, p.Database, p.State, p.Duration, p.XactAge, p.WaitEvent, p.Query}
+	}
+	return writeResult(out, "table", columns, rows)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteServices(t *testing.T) {
+	tmpDir := t.TempDir()
+	conf := `[prod]
+host=db.example.com
+dbname=app
+user=admin
+password=secret
+
+[broken]
+dbname=nohost
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".pg_service.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	clearPGEnv(t)
+	names := []string{"prod", "broken"}
+
+	var plain bytes.Buffer
+	if err := writeServices(&plain, names, false, false); err != nil {
+		t.Fatalf("writeServices() error = %v", err)
+	}
+	if plain.String() != "prod\nbroken\n" {
+		t.Errorf("writeServices() = %q, want names one per line", plain.String())
+	}
+
+	var long bytes.Buffer
+	if err := writeServices(&long, names, true, false); err != nil {
+		t.Fatalf("writeServices(long) error = %v", err)
+	}
+	if !strings.HasPrefix(long.String(), "prod\tadmin@db.example.com:5432/app\n") {
+		t.Errorf("writeServices(long) = %q, want connection details", long.String())
+	}
+
+	var out bytes.Buffer
+	if err := writeServices(&out, names, false, true); err != nil {
+		t.Fatalf("writeServices(json) error = %v", err)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("writeServices(json) leaked the password: %s", out.String())
+	}
+	var services []serviceInfo
+	if err := json.Unmarshal(out.Bytes(), &services); err != nil {
+		t.Fatalf("writeServices(json) produced invalid JSON: %v", err)
+	}
+	if len(services) != 2 || services[0].Host != "db.example.com" || services[1].Host != "" {
+		t.Errorf("writeServices(json) = %+v, want prod details and a name-only broken entry", services)
+	}
+}
+
+func TestCompleteServices(t *testing.T) {
+	tmpDir := t.TempDir()
+	conf := "[prod]\nhost=a\n\n[prod-replica]\nhost=b\n\n[staging]\nhost=c\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".pg_service.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	got, _ := completeServices(nil, nil, "pro")
+	if strings.Join(got, ",") != "prod,prod-replica" {
+		t.Errorf("completeServices(pro) = %v, want [prod prod-replica]", got)
+	}
+
+	// Only the first positional argument is a service
+	if got, _ := completeServices(nil, []string{"prod"}, ""); len(got) != 0 {
+		t.Errorf("completeServices() after a service = %v, want none", got)
+	}
+}
+
+func TestFilterProcesses(t *testing.T) {
+	processes := []ActiveProcess{
+		{PID: 1, State: "active", DurationSecs: 2},
+		{PID: 2, State: "idle in transaction", DurationSecs: 600},
+		{PID: 3, State: "active", DurationSecs: 120},
+	}
+
+	tests := []struct {
+		name        string
+		states      []string
+		minDuration time.Duration
+		want        []int
+	}{
+		{"no filters", nil, 0, []int{1, 2, 3}},
+		{"state", []string{"active"}, 0, []int{1, 3}},
+		{"state is case-insensitive", []string{"Idle In Transaction"}, 0, []int{2}},
+		{"several states", []string{"active", "idle in transaction"}, 0, []int{1, 2, 3}},
+		{"min duration", nil, time.Minute, []int{2, 3}},
+		{"both", []string{"active"}, time.Minute, []int{3}},
+		{"nothing matches", []string{"disabled"}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, p := range filterProcesses(processes, tt.states, tt.minDuration) {
+				got = append(got, p.PID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterProcesses() PIDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithStatesIncludesIdle(t *testing.T) {
+	tests := []struct {
+		states []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"active"}, false},
+		{[]string{"idle in transaction"}, false},
+		{[]string{"active", " Idle "}, true},
+	}
+	for _, tt := range tests {
+		filter := withStates(ActiveFilter{}, tt.states)
+		if filter.ShowIdle != tt.want {
+			t.Errorf("withStates(%q).ShowIdle = %v, want %v", tt.states, filter.ShowIdle, tt.want)
+		}
+		if idleExcluded := strings.Contains(filter.where(), "state != 'idle'"); idleExcluded == tt.want {
+			t.Errorf("withStates(%q) where = %q; --state idle needs idle sessions fetched", tt.states, filter.where())
+		}
+	}
+	if !withStates(ActiveFilter{ShowIdle: true}, []string{"active"}).ShowIdle {
+		t.Error("withStates() turned off --idle")
+	}
+}
+
+func TestWriteActive(t *testing.T) {
+	processes := []ActiveProcess{{PID: 42, Username: "app", State: "active", Duration: "00:00:05", DurationSecs: 5, Query: "SELECT\n1"}}
+
+	var out bytes.Buffer
+	if err := writeActive(&out, processes, true); err != nil {
+		t.Fatalf("writeActive(json) error = %v", err)
+	}
+	var decoded []ActiveProcess
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeActive(json) produced invalid JSON: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].PID != 42 || decoded[0].Query != "SELECT\n1" {
+		t.Errorf("writeActive(json) = %+v", decoded)
+	}
+
+	// No matches is an empty array, not null, so scripts can iterate it
+	out.Reset()
+	if err := writeActive(&out, filterProcesses(processes, []string{"idle"}, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("writeActive(json) with no backends = %q, want []", out.String())
+	}
+
+	out.Reset()
+	if err := writeActive(&out, processes, false); err != nil {
+		t.Fatalf("writeActive(table) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "42") || !strings.Contains(out.String(), "SELECT 1") {
+		t.Errorf("writeActive(table) = %q", out.String())
+	}
+}
+
+func TestCompleteQueryNamesLeavesNoDatabase(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profile")
+	setEnv(t, map[string]string{"HOME": t.TempDir(), "PSQ_CONFIG_DIR": dir})
+	originalQueryDB := globalQueryDB
+	globalQueryDB = nil
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	names, _ := completeQueryNames(nil, nil, "")
+	if len(names) != 0 {
+		t.Errorf("completeQueryNames() = %v without a query database, want none", names)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("completion created %s (stat error %v)", dir, err)
+	}
+	if globalQueryDB != nil {
+		t.Error("completion opened a query database")
+	}
+}
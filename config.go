@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds user preferences stored in ~/.psq/config.json
+type Config struct {
+	HomeWidgets []string `json:"home_widgets,omitempty"` // Home dashboard widgets, in display order
+	ReadOnly    bool     `json:"read_only,omitempty"`    // disable actions that change server state
+	HumanBytes  bool     `json:"human_bytes,omitempty"`  // start with byte-count columns shown as KB/MB/GB
+	Theme       string   `json:"theme,omitempty"`        // built-in palette: dark, light or high-contrast
+	Density     string   `json:"density,omitempty"`      // result tables: comfortable (default) or compact
+
+	ConnectionWarnPercent int         `json:"connection_warn_percent,omitempty"` // share of max_connections that raises the warning banner
+	Alerts                []AlertRule `json:"alerts,omitempty"`                  // rules that flash the header (and optionally ring the bell)
+	MaxRows               int         `json:"max_rows,omitempty"`                // rows a tab shows before cutting the result off
+
+	ThemeColors map[string]string `json:"theme_colors,omitempty"` // per-role overrides, e.g. {"primary": "33"}
+}
+
+// Table densities; z switches between them
+const (
+	densityComfortable = "comfortable"
+	densityCompact     = "compact"
+)
+
+// defaultMaxRows caps result tables so an accidental SELECT * on a huge table can't hang psq
+const defaultMaxRows = 5000
+
+// DefaultConfig returns the preferences used when no config file exists
+func DefaultConfig() *Config {
+	return &Config{
+		HomeWidgets: append([]string{}, defaultHomeWidgets...),
+		MaxRows:     defaultMaxRows,
+	}
+}
+
+// configDirFlag is set by --config-dir and takes precedence over $PSQ_CONFIG_DIR
+var configDirFlag string
+
+// configDir returns the directory holding the query database, dumps and config.json:
+// --config-dir, then $PSQ_CONFIG_DIR, then ~/.psq
+func configDir() string {
+	if configDirFlag != "" {
+		return configDirFlag
+	}
+	if dir := os.Getenv("PSQ_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return defaultConfigDir()
+}
+
+// defaultConfigDir is ~/.psq, used when no other directory is configured
+func defaultConfigDir() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".psq")
+}
+
+// legacyConfigDir is where early versions kept the query database
+func legacyConfigDir() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".psqi")
+}
+
+func configFilePath() string {
+	return filepath.Join(configDir(), "config.json")
+}
+
+// loadConfig reads ~/.psq/config.json, falling back to defaults for anything unset.
+// The returned config is always usable, even when an error is reported: an invalid
+// setting keeps its default, and every invalid setting is reported, not just the first.
+func loadConfig() (*Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(configFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var fileConfig Config
+	if err := json.Unmarshal(data, &fileConfig); err != nil {
+		return config, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var errs []error
+	config.ReadOnly = fileConfig.ReadOnly
+	config.HumanBytes = fileConfig.HumanBytes
+	switch fileConfig.Density {
+	case "", densityComfortable, densityCompact:
+		config.Density = fileConfig.Density
+	default:
+		errs = append(errs, fmt.Errorf("invalid density %q in config: must be %s or %s", fileConfig.Density, densityComfortable, densityCompact))
+	}
+	if fileConfig.ConnectionWarnPercent < 0 || fileConfig.ConnectionWarnPercent > 100 {
+		errs = append(errs, fmt.Errorf("invalid connection_warn_percent %d in config: must be between 1 and 100, or 0 for the default", fileConfig.ConnectionWarnPercent))
+	} else {
+		config.ConnectionWarnPercent = fileConfig.ConnectionWarnPercent
+	}
+	if fileConfig.MaxRows < 0 {
+		errs = append(errs, fmt.Errorf("invalid max_rows %d in config: must be at least 1, or 0 for the default", fileConfig.MaxRows))
+	} else if fileConfig.MaxRows > 0 {
+		config.MaxRows = fileConfig.MaxRows
+	}
+
+	alerts, err := validAlertRules(fileConfig.Alerts)
+	config.Alerts = alerts
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid alert in config: %w", err))
+	}
+
+	// Check the theme here so a typo falls back to the default instead of blocking startup
+	if _, err := buildTheme(fileConfig.Theme, fileConfig.ThemeColors); err != nil {
+		errs = append(errs, fmt.Errorf("invalid theme in config: %w", err))
+	} else {
+		config.Theme = fileConfig.Theme
+		config.ThemeColors = fileConfig.ThemeColors
+	}
+
+	if widgets := validHomeWidgets(fileConfig.HomeWidgets); len(widgets) > 0 {
+		config.HomeWidgets = widgets
+	}
+
+	return config, errors.Join(errs...)
+}
+
+// saveConfigSetting writes one setting to config.json, leaving the rest of the file as it is
+func saveConfigSetting(name string, value any) error {
+	settings := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(configFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	settings[name] = encoded
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(configFilePath(), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// validHomeWidgets drops unknown and duplicate widget names, preserving order
+func validHomeWidgets(names []string) []string {
+	var widgets []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if !isHomeWidget(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		widgets = append(widgets, name)
+	}
+	return widgets
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string // empty means no config file
+		wantWidgets []string
+		wantErr     bool
+	}{
+		{
+			name:        "missing file uses defaults",
+			content:     "",
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     false,
+		},
+		{
+			name:        "custom widget selection",
+			content:     `{"home_widgets": ["tps", "state_counts"]}`,
+			wantWidgets: []string{"tps", "state_counts"},
+			wantErr:     false,
+		},
+		{
+			name:        "unknown and duplicate widgets dropped",
+			content:     `{"home_widgets": ["tps", "bogus", "tps", "cache_hit_ratio"]}`,
+			wantWidgets: []string{"tps", "cache_hit_ratio"},
+			wantErr:     false,
+		},
+		{
+			name:        "empty selection uses defaults",
+			content:     `{"home_widgets": []}`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     false,
+		},
+		{
+			name:        "connection warning threshold out of range",
+			content:     `{"connection_warn_percent": 150}`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     true,
+		},
+		{
+			name:        "negative row cap",
+			content:     `{"max_rows": -1}`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     true,
+		},
+		{
+			name:        "unknown density",
+			content:     `{"density": "cozy"}`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     true,
+		},
+		{
+			name:        "unknown alert metric",
+			content:     `{"alerts": [{"metric": "bogus", "above": 1}]}`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     true,
+		},
+		{
+			name:        "settings after an invalid one still apply",
+			content:     `{"density": "cozy", "home_widgets": ["tps"]}`,
+			wantWidgets: []string{"tps"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid json falls back to defaults",
+			content:     `{not json`,
+			wantWidgets: defaultHomeWidgets,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if tt.content != "" {
+				if err := os.MkdirAll(filepath.Join(tmpDir, ".psq"), 0755); err != nil {
+					t.Fatalf("Failed to create config dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(tmpDir, ".psq", "config.json"), []byte(tt.content), 0644); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+			}
+
+			// Temporarily override HOME to use test config
+			originalHome := os.Getenv("HOME")
+			os.Setenv("HOME", tmpDir)
+			defer os.Setenv("HOME", originalHome)
+
+			config, err := loadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if config == nil {
+				t.Fatalf("loadConfig() returned nil config")
+			}
+			if !reflect.DeepEqual(config.HomeWidgets, tt.wantWidgets) {
+				t.Errorf("HomeWidgets = %v, want %v", config.HomeWidgets, tt.wantWidgets)
+			}
+		})
+	}
+}
+
+func TestLoadConfigReportsEveryInvalidSetting(t *testing.T) {
+	tmpDir := t.TempDir()
+	setEnv(t, map[string]string{"HOME": tmpDir, "PSQ_CONFIG_DIR": ""})
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".psq"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"density": "cozy", "connection_warn_percent": 150, "max_rows": -1, "alerts": [{"metric": "bogus", "above": 1}], "theme": "neon"}`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".psq", "config.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig()
+	if err == nil {
+		t.Fatal("loadConfig() error = nil, want every invalid setting reported")
+	}
+	for _, want := range []string{"density", "connection_warn_percent", "max_rows", "alert", "theme", "or 0 for the default"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("loadConfig() error = %q, missing %q", err, want)
+		}
+	}
+	if config.MaxRows != defaultMaxRows || config.ConnectionWarnPercent != 0 || config.Theme != "" || config.Density != "" {
+		t.Errorf("config = %+v, want defaults for the invalid settings", config)
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	tests := []struct {
+		name string
+		flag string
+		env  string
+		want string
+	}{
+		{"default", "", "", filepath.Join("/home/test", ".psq")},
+		{"env", "", "/srv/psq", "/srv/psq"},
+		{"flag wins over env", "/tmp/profile", "/srv/psq", "/tmp/profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{"HOME": "/home/test", "PSQ_CONFIG_DIR": tt.env})
+			original := configDirFlag
+			configDirFlag = tt.flag
+			defer func() { configDirFlag = original }()
+
+			if got := configDir(); got != tt.want {
+				t.Errorf("configDir() = %q, want %q", got, tt.want)
+			}
+			if got, want := configFilePath(), filepath.Join(tt.want, "config.json"); got != want {
+				t.Errorf("configFilePath() = %q, want %q", got, want)
+			}
+			if got, want := defaultDumpPath(), filepath.Join(tt.want, "default_queries.db"); got != want {
+				t.Errorf("defaultDumpPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestNewQueryDBUsesDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profile")
+	qdb, err := NewQueryDB(dir)
+	if err != nil {
+		t.Fatalf("NewQueryDB() error = %v", err)
+	}
+	defer qdb.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "queries.db")); err != nil {
+		t.Errorf("queries.db not created in %s: %v", dir, err)
+	}
+}
+
+func TestSaveConfigSetting(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, map[string]string{"PSQ_CONFIG_DIR": dir})
+
+	// A missing config file is created
+	if err := saveConfigSetting("density", densityCompact); err != nil {
+		t.Fatalf("saveConfigSetting() error = %v", err)
+	}
+	if config, err := loadConfig(); err != nil || config.Density != densityCompact {
+		t.Fatalf("loadConfig() = %+v, %v; want compact density", config, err)
+	}
+
+	// Other settings, including ones psq doesn't know, are kept
+	content := `{"read_only": true, "density": "compact", "future_setting": [1, 2]}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveConfigSetting("density", densityComfortable); err != nil {
+		t.Fatalf("saveConfigSetting() error = %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil || config.Density != densityComfortable || !config.ReadOnly {
+		t.Errorf("loadConfig() = %+v, %v; want comfortable density and read_only kept", config, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if !strings.Contains(string(data), "future_setting") {
+		t.Errorf("config.json = %s, want future_setting kept", data)
+	}
+}
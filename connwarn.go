@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultConnectionWarnPercent is the share of max_connections in use that raises the
+// connection warning banner when config.json doesn't set one
+const defaultConnectionWarnPercent = 90
+
+// connUsageTickMsg asks for the next connection count sample
+type connUsageTickMsg time.Time
+
+// connUsageMsg carries a connection count read for the warning banner, along with any
+// other metrics alert rules watch
+type connUsageMsg struct {
+	Usage   ConnectionUsage
+	Err     error
+	Skipped bool // the banner was dismissed and no rule watches connections, so Usage wasn't read
+	Metrics map[string]float64
+}
+
+// scheduleConnUsageSample waits a refresh interval before the next connection count. Like
+// the transactions/sec sample it runs on its own tick, so the banner and alerts work on every tab.
+func scheduleConnUsageSample() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return connUsageTickMsg(t)
+	})
+}
+
+// handleConnUsageTick reads the connection count in the background, plus whatever alert
+// rules need; each is only read while the banner or a rule uses it. Once the banner is
+// dismissed and no rules are set there's nothing left to sample for.
+func (m *Model) handleConnUsageTick() (tea.Model, tea.Cmd) {
+	if m.connWarnHidden && len(m.alertRules()) == 0 {
+		return m, nil
+	}
+	db := m.db
+	if db == nil || m.reconnecting {
+		return m, scheduleConnUsageSample()
+	}
+	count := !m.connWarnHidden || m.watchesMetric(AlertConnectionsPercent)
+	longest, blocked := m.watchesMetric(AlertLongestQuery), m.watchesMetric(AlertBlockedBackends)
+	return m, func() tea.Msg {
+		msg := connUsageMsg{Skipped: !count, Metrics: readAlertMetrics(db, longest, blocked)}
+		if count {
+			msg.Usage, msg.Err = GetConnectionUsage(db)
+		}
+		return msg
+	}
+}
+
+// handleConnUsage keeps the latest count for the banner, checks alert rules and schedules
+// the next sample. A failed read keeps the previous count rather than hiding a warning.
+func (m *Model) handleConnUsage(msg connUsageMsg) (tea.Model, tea.Cmd) {
+	shown, alerts := m.connectionWarning(), m.renderAlerts()
+	metrics := msg.Metrics
+	if msg.Err == nil && !msg.Skipped {
+		m.connUsage = &msg.Usage
+		if metrics == nil {
+			metrics = map[string]float64{}
+		}
+		metrics[AlertConnectionsPercent] = msg.Usage.Percent()
+	}
+	alertCmd := m.checkAlerts(metrics)
+	if shown != m.connectionWarning() || alerts != m.renderAlerts() {
+		m.updateContent()
+	}
+	return m, tea.Batch(alertCmd, scheduleConnUsageSample())
+}
+
+// handleDismissConnWarning hides the connection warning for the rest of the session
+func (m *Model) handleDismissConnWarning() (tea.Model, tea.Cmd) {
+	if m.connectionWarning() == "" {
+		return m, nil
+	}
+	m.connWarnHidden = true
+	m.updateContent()
+	return m, nil
+}
+
+// connectionWarnPercent is the configured warning threshold, or the default
+func (m *Model) connectionWarnPercent() int {
+	if m.config != nil && m.config.ConnectionWarnPercent > 0 {
+		return m.config.ConnectionWarnPercent
+	}
+	return defaultConnectionWarnPercent
+}
+
+// connectionWarning returns the banner text, e.g. "⚠ 95/100 connections", or "" while
+// usage is under the threshold or the banner was dismissed
+func (m *Model) connectionWarning() string {
+	if m.connWarnHidden || m.connUsage == nil || m.connUsage.MaxConnections <= 0 {
+		return ""
+	}
+	if m.connUsage.Percent() < float64(m.connectionWarnPercent()) {
+		return ""
+	}
+	return fmt.Sprintf("⚠ %d/%d connections", m.connUsage.Current, m.connUsage.MaxConnections)
+}
+
+// renderConnectionWarning renders the banner line shown above the header on every tab,
+// or "" when there's nothing to warn about
+func (m *Model) renderConnectionWarning() string {
+	warning := m.connectionWarning()
+	if warning == "" {
+		return ""
+	}
+	bannerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+	return " " + bannerStyle.Render(warning) +
+		dimStyle.Render(fmt.Sprintf("  (%.0f%% of max_connections)  %s: dismiss", m.connUsage.Percent(), keys.Dismiss.Help().Key)) + "\n"
+}
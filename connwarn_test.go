@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConnectionWarning(t *testing.T) {
+	m := &Model{}
+	if got := m.connectionWarning(); got != "" {
+		t.Errorf("no warning before the first sample, got %q", got)
+	}
+
+	m.handleConnUsage(connUsageMsg{Usage: ConnectionUsage{Current: 80, MaxConnections: 100}})
+	if got := m.connectionWarning(); got != "" {
+		t.Errorf("80%% is under the default threshold, got %q", got)
+	}
+
+	m.handleConnUsage(connUsageMsg{Usage: ConnectionUsage{Current: 95, MaxConnections: 100}})
+	if got := m.connectionWarning(); got != "⚠ 95/100 connections" {
+		t.Errorf("warning = %q, want %q", got, "⚠ 95/100 connections")
+	}
+	if !strings.Contains(m.renderConnectionWarning(), "95% of max_connections") {
+		t.Errorf("banner should show the percentage: %q", m.renderConnectionWarning())
+	}
+
+	m.handleConnUsage(connUsageMsg{Err: errors.New("connection refused")})
+	if m.connectionWarning() == "" {
+		t.Error("a failed read should keep the last warning")
+	}
+
+	m.handleDismissConnWarning()
+	if got := m.connectionWarning(); got != "" {
+		t.Errorf("dismissed warning still shown: %q", got)
+	}
+	if _, cmd := m.handleConnUsageTick(); cmd != nil {
+		t.Error("sampling should stop once the warning is dismissed")
+	}
+
+	// With the banner dismissed, a sample that only read other alert metrics keeps the count
+	m.handleConnUsage(connUsageMsg{Skipped: true, Metrics: map[string]float64{AlertLongestQuery: 1}})
+	if m.connUsage == nil || m.connUsage.Current != 95 {
+		t.Errorf("connUsage = %+v, want the last count kept", m.connUsage)
+	}
+
+	m = &Model{config: &Config{ConnectionWarnPercent: 75}}
+	m.handleConnUsage(connUsageMsg{Usage: ConnectionUsage{Current: 80, MaxConnections: 100}})
+	if m.connectionWarning() == "" {
+		t.Error("80% should warn with a 75% threshold")
+	}
+}
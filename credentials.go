@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// redactedPassword replaces passwords that would otherwise appear in error messages
+const redactedPassword = "********"
+
+// redactedError hides a password in an error's message while keeping the original error for errors.Is/As
+type redactedError struct {
+	err      error
+	password string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.password, redactedPassword)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactPassword wraps err so its message never contains password
+func redactPassword(err error, password string) error {
+	if err == nil || password == "" {
+		return err
+	}
+	return &redactedError{err: err, password: password}
+}
+
+// dsnQuote quotes a value for a key=value connection string so spaces and quotes survive parsing
+func dsnQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// pgpassEscape escapes the separators in a .pgpass field
+func pgpassEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, ":", `\:`)
+}
+
+// writeTempPgpass writes config's credentials to a private temporary .pgpass file for psql,
+// so the password isn't visible in the child's environment. The caller removes the file.
+func writeTempPgpass(config *DBConfig) (string, error) {
+	f, err := os.CreateTemp("", "psq-pgpass-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary pgpass file: %w", err)
+	}
+	defer f.Close()
+
+	if err := f.Chmod(0600); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to secure temporary pgpass file: %w", err)
+	}
+
+	line := strings.Join([]string{
+		pgpassEscape(config.Host),
+		pgpassEscape(config.Port),
+		pgpassEscape(config.Database),
+		pgpassEscape(config.User),
+		pgpassEscape(config.Password),
+	}, ":") + "\n"
+	if _, err := f.WriteString(line); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temporary pgpass file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// pgpassPath returns $PGPASSFILE, or ~/.pgpass like libpq
+func pgpassPath() string {
+	if path := os.Getenv("PGPASSFILE"); path != "" {
+		return path
+	}
+	return filepath.Join(os.ExpandEnv("$HOME"), ".pgpass")
+}
+
+// lookupPgpass finds the password for config in the pgpass file. Like libpq, the file is
+// ignored unless it is private (no group or other permissions), and the first matching line wins.
+func lookupPgpass(config *DBConfig) (string, bool) {
+	path := pgpassPath()
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0077 != 0 {
+		return "", false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	// libpq defaults the database name to the user name
+	database := config.Database
+	if database == "" {
+		database = config.User
+	}
+	want := []string{config.Host, config.Port, database, config.User}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		if pgpassFieldsMatch(fields[:4], want) {
+			return fields[4], true
+		}
+	}
+	return "", false
+}
+
+// pgpassFieldsMatch compares host, port, database and user, where * matches anything
+func pgpassFieldsMatch(fields, want []string) bool {
+	for i, field := range fields {
+		if field != "*" && field != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPgpassLine splits a .pgpass line on unescaped colons, unescaping \: and \\
+func splitPgpassLine(line string) []string {
+	var fields []string
+	var current strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case c == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(fields, current.String())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactPassword(t *testing.T) {
+	base := errors.New("bad option in host=db password=s3cret! sslmode=require")
+	err := redactPassword(fmt.Errorf("wrapped: %w", base), "s3cret!")
+
+	if strings.Contains(err.Error(), "s3cret!") {
+		t.Errorf("redactPassword() = %q, want password removed", err.Error())
+	}
+	if !strings.Contains(err.Error(), redactedPassword) {
+		t.Errorf("redactPassword() = %q, want %s placeholder", err.Error(), redactedPassword)
+	}
+	if !errors.Is(err, base) {
+		t.Error("redactPassword() should keep the original error for errors.Is")
+	}
+	if redactPassword(nil, "x") != nil {
+		t.Error("redactPassword(nil) should be nil")
+	}
+}
+
+func TestConnectDBErrorHidesPassword(t *testing.T) {
+	// Grab a free port and close it so the connection is refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	const password = "hunter2 with 'quotes'"
+	tmpDir := t.TempDir()
+	conf := fmt.Sprintf("[down]\nhost=127.0.0.1\nport=%s\ndbname=app\nuser=admin\npassword=%s\n", port, password)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".pg_service.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	_, err = connectDB("down")
+	if err == nil {
+		t.Fatal("connectDB() error = nil, want connection refused")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("connectDB() error %q contains the password", err.Error())
+	}
+}
+
+func TestWriteTempPgpass(t *testing.T) {
+	path, err := writeTempPgpass(&DBConfig{Host: "db", Port: "5432", Database: "app", User: "admin", Password: `pa:ss\word`})
+	if err != nil {
+		t.Fatalf("writeTempPgpass() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("pgpass mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if want := "db:5432:app:admin:pa\\:ss\\\\word\n"; string(data) != want {
+		t.Errorf("pgpass = %q, want %q", data, want)
+	}
+}
+
+func TestLookupPgpass(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "pgpass")
+	content := `# comment
+db.example.com:5432:app:admin:exact
+*:*:*:readonly:wild\:card
+other:5432:app:admin:wrong-host
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PGPASSFILE", path)
+
+	tests := []struct {
+		name   string
+		config DBConfig
+		want   string
+		wantOK bool
+	}{
+		{name: "exact match", config: DBConfig{Host: "db.example.com", Port: "5432", Database: "app", User: "admin"}, want: "exact", wantOK: true},
+		{name: "wildcards and escaped colon", config: DBConfig{Host: "any", Port: "6432", Database: "x", User: "readonly"}, want: "wild:card", wantOK: true},
+		{name: "no match", config: DBConfig{Host: "nowhere", Port: "5432", Database: "app", User: "admin"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupPgpass(&tt.config)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookupPgpass() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// libpq ignores a pgpass file others can read
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lookupPgpass(&tests[0].config); ok {
+		t.Error("lookupPgpass() used a world-readable file")
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type DBConfig struct {
@@ -16,56 +20,36 @@ type DBConfig struct {
 	Database string
 	User     string
 	Password string
+	SSH      *sshConfig // bastion to tunnel through; nil connects directly
 }
 
 func getDBConfig(serviceName string) (*DBConfig, error) {
-	configPath := os.ExpandEnv("$HOME/.pg_service.conf")
-	data, err := os.ReadFile(configPath)
+	stanzas, err := loadServiceStanzas()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read ~/.pg_service.conf: %w", err)
+		return nil, err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var currentService string
-	config := &DBConfig{}
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			currentService = strings.Trim(line, "[]")
-			if currentService == serviceName {
-				config = &DBConfig{}
-			}
-			continue
-		}
-
-		if currentService == serviceName {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				switch key {
-				case "host":
-					config.Host = value
-				case "port":
-					config.Port = value
-				case "dbname":
-					config.Database = value
-				case "user":
-					config.User = value
-				case "password":
-					config.Password = value
-				}
-			}
-		}
+	settings, found := stanzas.Settings[serviceName]
+	if !found {
+		return nil, fmt.Errorf("service '%s' not found in %s", serviceName, serviceFilesLabel())
+	}
+	config := &DBConfig{
+		Host:     settings["host"],
+		Port:     settings["port"],
+		Database: settings["dbname"],
+		User:     settings["user"],
+		Password: settings["password"],
 	}
+	if config.SSH, err = sshConfigFromSettings(settings); err != nil {
+		return nil, fmt.Errorf("service '%s': %w", serviceName, err)
+	}
+
+	// Like libpq, the service file wins and PG* environment variables fill in what it leaves out
+	applyEnvDefaults(config)
 
 	if config.Host == "" {
-		return nil, fmt.Errorf("service '%s' not found in ~/.pg_service.conf", serviceName)
+		return nil, fmt.Errorf("service '%s' has no host: set host= in the service file or $PGHOST "+
+			"(precedence: service file, then PGHOST/PGPORT/PGDATABASE/PGUSER/PGPASSWORD, then ~/.pgpass for the password)", serviceName)
 	}
 
 	// Set defaults
@@ -73,65 +57,233 @@ func getDBConfig(serviceName string) (*DBConfig, error) {
 		config.Port = "5432"
 	}
 
+	// Keep secrets out of the service file by falling back to ~/.pgpass
+	if config.Password == "" {
+		if password, ok := lookupPgpass(config); ok {
+			config.Password = password
+		}
+	}
+
 	return config, nil
 }
 
-func listServices() ([]string, error) {
-	configPath := os.ExpandEnv("$HOME/.pg_service.conf")
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read ~/.pg_service.conf: %w", err)
+// applyEnvDefaults fills fields missing from the service stanza from the standard PG* environment variables
+func applyEnvDefaults(config *DBConfig) {
+	fields := []struct {
+		value  *string
+		envVar string
+	}{
+		{&config.Host, "PGHOST"},
+		{&config.Port, "PGPORT"},
+		{&config.Database, "PGDATABASE"},
+		{&config.User, "PGUSER"},
+		{&config.Password, "PGPASSWORD"},
 	}
-
-	lines := strings.Split(string(data), "\n")
-	var services []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			service := strings.Trim(line, "[]")
-			services = append(services, service)
+	for _, f := range fields {
+		if *f.value == "" {
+			*f.value = os.Getenv(f.envVar)
 		}
 	}
+}
 
-	return services, nil
+// listServices returns the service names across all service files, each once, in the
+// order they first appear
+func listServices() ([]string, error) {
+	stanzas, err := loadServiceStanzas()
+	if err != nil {
+		return nil, err
+	}
+	return stanzas.Names, nil
 }
 
 func connectDB(serviceName string) (*sql.DB, error) {
-	config, err := getDBConfig(serviceName)
+	config, err := getTunneledDBConfig(serviceName)
 	if err != nil {
 		return nil, err
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=require",
-		config.Host, config.Port, config.Database, config.User, config.Password)
+	dsn := dsnFor(config)
+	debugLog.Info("connecting", "service", serviceName, "host", config.Host, "port", config.Port,
+		"database", config.Database, "user", config.User)
 
+	// Driver errors can echo parts of the DSN; never let the password reach the UI
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		err = fmt.Errorf("failed to connect to database: %w", redactPassword(err, config.Password))
+		debugLog.Warn("connect failed", "service", serviceName, "error", err)
+		return nil, err
 	}
 
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		db.Close()
+		err = fmt.Errorf("failed to ping database: %w", redactPassword(err, config.Password))
+		debugLog.Warn("connect failed", "service", serviceName, "error", err)
+		return nil, err
 	}
 
+	debugLog.Info("connected", "service", serviceName)
 	return db, nil
 }
 
-func executeQuery(db *sql.DB, query string) (string, error) {
-	rows, err := db.Query(query)
+// psqApplicationName tags every connection this psq process opens, so the Active list can
+// leave all of them out and not just the backend that ran the list query
+var psqApplicationName = fmt.Sprintf("psq-%d", os.Getpid())
+
+// dsnFor returns the libpq connection string for config, password included. Unlike
+// connInfo, it names psq's own connections; a psql session opened from psq isn't one.
+func dsnFor(config *DBConfig) string {
+	return connInfo(config) + " application_name=" + psqApplicationName + " password=" + dsnQuote(config.Password)
+}
+
+// executeQuery runs query and renders it as a table, also returning every column
+// the query produced, including any hidden by opts
+func executeQuery(ctx context.Context, db *sql.DB, query string, opts TableOptions) (queryResultMsg, error) {
+	allColumns, allRows, truncated, err := fetchRows(ctx, db, query, opts.MaxRows)
+	if err != nil {
+		return queryResultMsg{}, err
+	}
+	columns, allRows := hideColumns(allColumns, allRows, opts.HiddenColumns)
+
+	// Diff the values as fetched so display formatting never counts as a change
+	snapshot := &resultSnapshot{Columns: columns, Rows: make([][]string, len(allRows))}
+	for i, row := range allRows {
+		snapshot.Rows[i] = slices.Clone(row)
+	}
+	if opts.Diff {
+		opts.changes = diffResults(opts.Previous, *snapshot, opts.DiffKey)
+	}
+
+	formatRows(columns, allRows, opts)
+	if opts.changes != nil {
+		formatRows(columns, opts.changes.Gone, opts)
+	}
+
+	output := renderTable(columns, allRows, opts)
+	if truncated {
+		output = lipgloss.NewStyle().Foreground(theme.Warning).Render(rowCapNote(opts.MaxRows)) + "\n\n" + output
+	}
+	return queryResultMsg{Output: output, Columns: allColumns, Snapshot: snapshot}, nil
+}
+
+// rowCapNote explains a result cut off at maxRows
+func rowCapNote(maxRows int) string {
+	return fmt.Sprintf("Showing the first %d rows; the query returned more (raise max_rows in config.json, or add a LIMIT or WHERE)", maxRows)
+}
+
+// formatRows applies display-only formatting in place; one-shot CSV output keeps the raw values
+func formatRows(columns []string, rows [][]string, opts TableOptions) {
+	if opts.HumanBytes {
+		humanizeBytesColumns(columns, rows)
+	}
+
+	// Keep each row on one line in the table
+	for _, row := range rows {
+		for i := range row {
+			row[i] = scrubNewlines(row[i])
+		}
+	}
+}
+
+// fetchRows runs query and returns its column names and its rows as strings, with NULL for
+// nulls. With maxRows above 0 a query that only reads is run through a cursor that fetches
+// one row past the cap, and the result is reported truncated when that row came back; 0, or
+// any other statement, reads every row.
+func fetchRows(ctx context.Context, db *sql.DB, query string, maxRows int) ([]string, [][]string, bool, error) {
+	if maxRows > 0 && cursorable(query) {
+		columns, allRows, truncated, err := fetchCursor(ctx, db, query, maxRows)
+		// A SELECT calling a function that writes, e.g. one defined in the database, can't
+		// run read-only; it ran fine before rows were capped, so run it as it is
+		if !isReadOnlyViolation(err) {
+			return columns, allRows, truncated, err
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, allRows, err := scanRows(rows)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return columns, allRows, false, nil
+}
+
+// fetchCursor reads at most maxRows of query through a server-side cursor in a read-only
+// transaction. Stopping there ends the query cleanly: cancelling it instead would make lib/pq
+// throw the connection away, and every refresh of a big tab would reconnect.
+func fetchCursor(ctx context.Context, db *sql.DB, query string, maxRows int) ([]string, [][]string, bool, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	// Rolling back closes the cursor; nothing in the transaction wrote
+	defer tx.Rollback()
+
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if _, err := tx.ExecContext(ctx, "DECLARE psq_rows NO SCROLL CURSOR FOR "+query); err != nil {
+		return nil, nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM psq_rows", maxRows+1))
 	if err != nil {
-		return "", fmt.Errorf("failed to execute query: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
+	columns, allRows, err := scanRows(rows)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(allRows) > maxRows {
+		return columns, allRows[:maxRows], true, nil
+	}
+	return columns, allRows, false, nil
+}
+
+// isReadOnlyViolation reports whether err is Postgres refusing a write in a read-only
+// transaction (read_only_sql_transaction)
+func isReadOnlyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "25006"
+}
+
+// cursorable reports whether query is a single statement that only reads and can be
+// declared as a cursor in a read-only transaction. Anything else, e.g. an INSERT ... RETURNING
+// or a SELECT ... FOR UPDATE, must run to completion, so it is never capped.
+func cursorable(query string) bool {
+	statements := splitStatements(query)
+	if len(statements) != 1 || mutatingKeyword(query) != "" {
+		return false
+	}
+	var words []string
+	for _, tok := range statements[0] {
+		if tok.Kind == sqlKeyword || tok.Kind == sqlIdent {
+			words = append(words, strings.ToUpper(tok.Text))
+		}
+	}
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+	default:
+		return false
+	}
+	for i := 1; i < len(words); i++ {
+		if words[i-1] == "FOR" && (words[i] == "UPDATE" || words[i] == "SHARE" || words[i] == "NO" || words[i] == "KEY") {
+			return false
+		}
+	}
+	return true
+}
+
+// scanRows reads every row left in rows as strings, with NULL for nulls
+func scanRows(rows *sql.Rows) ([]string, [][]string, error) {
 	columns, err := rows.Columns()
 	if err != nil {
-		return "", fmt.Errorf("failed to get columns: %w", err)
+		return nil, nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Collect all data
@@ -144,7 +296,7 @@ func executeQuery(db *sql.DB, query string) (string, error) {
 
 	for rows.Next() {
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return "", fmt.Errorf("failed to scan row: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		row := make([]string, len(columns))
@@ -152,82 +304,179 @@ func executeQuery(db *sql.DB, query string) (string, error) {
 			if val == nil {
 				row[i] = "NULL"
 			} else if bytes, ok := val.([]byte); ok {
-				row[i] = scrubNewlines(string(bytes))
+				row[i] = string(bytes)
 			} else {
-				row[i] = scrubNewlines(fmt.Sprintf("%v", val))
+				row[i] = fmt.Sprintf("%v", val)
 			}
 		}
 		allRows = append(allRows, row)
 	}
 
 	if err := rows.Err(); err != nil {
-		return "", fmt.Errorf("error iterating rows: %w", err)
+		return nil, nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return renderTable(columns, allRows), nil
+	return columns, allRows, nil
+}
+
+// pgStatStatementsHint is shown instead of the raw error when pg_stat_statements isn't usable
+const pgStatStatementsHint = "pg_stat_statements is not enabled — run CREATE EXTENSION pg_stat_statements and add it to shared_preload_libraries"
+
+// isPgStatStatementsMissing checks if a query failed because pg_stat_statements
+// isn't installed (undefined table) or isn't preloaded by the server
+func isPgStatStatementsMissing(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "42P01", "42883": // undefined_table, undefined_function
+		return strings.Contains(pqErr.Message, "pg_stat_statements")
+	case "55000": // object_not_in_prerequisite_state
+		return strings.Contains(pqErr.Message, "pg_stat_statements must be loaded")
+	}
+	return false
 }
 
 // renderTable renders columns and rows in the same styled plain-text table as the Active tab
-func renderTable(columns []string, allRows [][]string) string {
+func renderTable(columns []string, allRows [][]string, opts TableOptions) string {
 	if len(columns) == 0 {
 		return "No columns returned"
 	}
 
-	// Calculate optimal column widths
+	// Rows gone since the previous result are shown once more, struck through, after the rest
+	var gone [][]string
+	if opts.changes != nil {
+		gone = opts.changes.Gone
+	}
+	shownRows := append(slices.Clip(allRows), gone...)
+
+	// Calculate optimal column widths; comfortable tables pad each column by a space
+	pad := 1
+	if opts.Compact {
+		pad = 0
+	}
 	colWidths := make([]int, len(columns))
 	for i, col := range columns {
-		colWidths[i] = len(col) + 1
+		colWidths[i] = len(col) + pad
 	}
-	for _, row := range allRows {
+	for _, row := range shownRows {
 		for i, cell := range row {
-			if len(cell)+1 > colWidths[i] {
-				colWidths[i] = len(cell) + 1
+			if len(cell)+pad > colWidths[i] {
+				colWidths[i] = len(cell) + pad
 			}
 		}
 	}
 
+	// Numeric columns are right-aligned so magnitudes line up
+	numeric := numericColumns(len(columns), shownRows)
+
 	// Cap column widths
+	minWidth, maxWidth := opts.columnWidthBounds()
 	for i := range colWidths {
-		if colWidths[i] > 50 {
-			colWidths[i] = 50
+		if colWidths[i] > maxWidth {
+			colWidths[i] = maxWidth
 		}
-		if colWidths[i] < 6 {
-			colWidths[i] = 6
+		if colWidths[i] < minWidth {
+			colWidths[i] = minWidth
 		}
 	}
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(lipgloss.Color("#7C3AED"))
+		Foreground(theme.HeaderFg).
+		Background(theme.HeaderBg)
 
 	rowStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("252"))
+		Foreground(theme.Text)
 
 	dimStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240"))
+		Foreground(theme.Dim)
+
+	newStyle := lipgloss.NewStyle().
+		Foreground(theme.Success)
+
+	changedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Caution)
+
+	goneStyle := dimStyle.
+		Strikethrough(true)
+
+	warnStyle := lipgloss.NewStyle().
+		Foreground(theme.Caution)
+
+	criticalStyle := lipgloss.NewStyle().
+		Foreground(theme.Error)
 
 	var b strings.Builder
 
 	// Header
 	var headerParts []string
 	for i, col := range columns {
-		headerParts = append(headerParts, fmt.Sprintf("%-*s", colWidths[i], truncate(col, colWidths[i])))
+		headerParts = append(headerParts, padCell(truncate(col, colWidths[i]), colWidths[i], numeric[i]))
 	}
-	b.WriteString(headerStyle.Render(strings.Join(headerParts, " ")))
+	header := strings.Join(headerParts, " ")
+	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
+	if monochrome {
+		// Without the header background, a rule keeps column names apart from the data
+		b.WriteString(strings.Repeat("-", len(header)))
+		b.WriteString("\n")
+	}
 
 	// Rows
-	if len(allRows) == 0 {
+	if len(shownRows) == 0 {
 		b.WriteString(dimStyle.Render("  (no rows)"))
 		b.WriteString("\n")
 	} else {
-		for _, row := range allRows {
+		for r, row := range shownRows {
+			style := rowStyle
+			switch rowHealth(columns, row) {
+			case "warn", "warning":
+				style = warnStyle
+			case "critical":
+				style = criticalStyle
+			}
+			var changed []bool
+			switch {
+			case r >= len(allRows):
+				style = goneStyle
+			case opts.changes != nil && opts.changes.New[r]:
+				style = newStyle
+			case opts.changes != nil:
+				changed = opts.changes.Changed[r]
+			}
+
+			if opts.Wrap {
+				// Wrapped cells span several lines, so a changed row is highlighted as a whole
+				if changed != nil {
+					style = changedStyle
+				}
+				for _, line := range wrapRow(row, colWidths, numeric) {
+					b.WriteString(style.Render(line))
+					b.WriteString("\n")
+				}
+				continue
+			}
+			if changed == nil {
+				var parts []string
+				for i, cell := range row {
+					parts = append(parts, padCell(truncate(cell, colWidths[i]), colWidths[i], numeric[i]))
+				}
+				b.WriteString(style.Render(strings.Join(parts, " ")))
+				b.WriteString("\n")
+				continue
+			}
 			var parts []string
 			for i, cell := range row {
-				parts = append(parts, fmt.Sprintf("%-*s", colWidths[i], truncate(cell, colWidths[i])))
+				cellStyle := style
+				if changed[i] {
+					cellStyle = changedStyle
+				}
+				parts = append(parts, cellStyle.Render(padCell(truncate(cell, colWidths[i]), colWidths[i], numeric[i])))
 			}
-			b.WriteString(rowStyle.Render(strings.Join(parts, " ")))
+			b.WriteString(strings.Join(parts, style.Render(" ")))
 			b.WriteString("\n")
 		}
 	}
@@ -235,72 +484,112 @@ func renderTable(columns []string, allRows [][]string) string {
 	return b.String()
 }
 
-func renderConnectionBarChart(db *sql.DB, query string, queryName string, model *Model) (string, error) {
+// queryer is satisfied by both *sql.DB and contextDB
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// contextDB runs db's queries under ctx, so cancelling a refresh also stops the Home
+// widgets' and the Active tab's queries
+type contextDB struct {
+	ctx context.Context
+	db  *sql.DB
+}
+
+func (c contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func renderConnectionBarChart(ctx context.Context, db *sql.DB, query Query, opts TableOptions, sparkline *SparklineData, model *Model) (queryResultMsg, error) {
 	// Render interactive Active view
-	if IsActiveTab(queryName) {
-		return renderActiveView(db, model)
+	if IsActiveTab(query.Name) {
+		result, err := renderActiveView(contextDB{ctx, db}, model)
+		return queryResultMsg{Output: result}, err
 	}
 
 	// Only render charts for the Home query
-	if IsHomeTab(queryName) {
-		// Calculate chart width for responsive rendering
-		chartWidth := GetChartWidth(model.width)
-
-		// Get the bar chart with responsive width
-		barChart, err := RenderHomeChart(db, query, chartWidth)
-		if err != nil {
-			return "", err
-		}
+	if IsHomeTab(query.Name) {
+		return queryResultMsg{Output: renderHomeView(contextDB{ctx, db}, query.SQL, sparkline, model)}, nil
+	}
 
-		// Update sparkline data with transaction commits
-		currentCommits, dbNow, err := GetTransactionCommits(db)
-		if err != nil {
-			// If we can't get commits, just show the bar chart
-			return barChart, nil
-		}
+	opts.HiddenColumns = query.HiddenColumns
+	start := time.Now()
+	result, err := executeQuery(ctx, db, query.SQL, opts)
+	logStatement(model.service, query.SQL, start, err)
+	if err != nil {
+		return queryResultMsg{}, err
+	}
+	if usesPgStatStatements(query.SQL) {
+		result.Output += RenderStatStatementsFooter(contextDB{ctx, db}, model.readOnly())
+	}
+	return result, nil
+}
 
-		// Calculate commits per second using DB timestamps for accurate elapsed time
-		var commitsPerSec float64
-		if model.lastCommits > 0 && !model.lastCommitTime.IsZero() {
-			elapsed := dbNow.Sub(model.lastCommitTime).Seconds()
-			if elapsed > 0 {
-				commitsPerSec = (currentCommits - model.lastCommits) / elapsed
+// renderHomeView renders the Home dashboard widgets selected in config.json. sparkline is a
+// copy of the TPS samples taken on the main goroutine, which keeps adding to the original.
+func renderHomeView(db queryer, query string, sparkline *SparklineData, model *Model) string {
+	// Calculate chart width for responsive rendering
+	chartWidth := GetChartWidth(model.width)
+	errorStyle := lipgloss.NewStyle().Foreground(theme.Error)
+
+	var widgets []HomeWidget
+	for _, name := range model.homeWidgets() {
+		switch name {
+		case WidgetBlockingLocks:
+			widgets = append(widgets, HomeWidget{Content: RenderBlockingLocks(db), FullWidth: true})
+		case WidgetConnections:
+			widgets = append(widgets, HomeWidget{Content: RenderConnectionUsage(db, model.width), FullWidth: true})
+		case WidgetStateCounts:
+			// Get the bar chart with responsive width
+			barChart, err := RenderHomeChart(db, query, chartWidth, model.height/2)
+			if err != nil {
+				barChart = errorStyle.Render(fmt.Sprintf("Error: %v", err))
 			}
+			widgets = append(widgets, HomeWidget{Content: barChart})
+		case WidgetClients:
+			clientChart, err := RenderClientChart(db, chartWidth, model.height/2)
+			if err != nil {
+				clientChart = errorStyle.Render(fmt.Sprintf("Error: %v", err))
+			}
+			widgets = append(widgets, HomeWidget{Content: clientChart})
+		case WidgetTPS:
+			widgets = append(widgets, HomeWidget{Content: RenderSparklineChart(sparkline, chartWidth)})
+		case WidgetDatabaseSize:
+			sizeChart, err := RenderDatabaseSize(db, model.width, 5)
+			if err != nil {
+				sizeChart = errorStyle.Render(fmt.Sprintf("Error: %v", err))
+			}
+			widgets = append(widgets, HomeWidget{Content: sizeChart, FullWidth: true})
+		case WidgetCacheHitRatio:
+			widgets = append(widgets, HomeWidget{Content: RenderCacheHitRatio(db), Compact: true})
+		case WidgetReplicationLag:
+			widgets = append(widgets, HomeWidget{Content: RenderReplicationLag(db), Compact: true})
 		}
-		model.lastCommits = currentCommits
-		model.lastCommitTime = dbNow
-
-		// Add data point to sparkline
-		model.sparklineData.AddPoint(commitsPerSec, dbNow)
-
-		// Render sparkline chart with responsive width
-		sparklineChart := RenderSparklineChart(model.sparklineData, chartWidth)
-
-		// Render bottom row widgets
-		cacheHitRatio := RenderCacheHitRatio(db)
-		replicationLag := RenderReplicationLag(db)
-
-		// Render blocking locks widget (full width)
-		blockingLocks := RenderBlockingLocks(db)
-
-		return RenderHomeDashboard(barChart, sparklineChart, cacheHitRatio, replicationLag, blockingLocks, model.width), nil
 	}
-	return executeQuery(db, query)
+
+	return RenderHomeDashboard(widgets, model.width)
 }
 
 // renderActiveView fetches active processes and renders the interactive Active view
-func renderActiveView(db *sql.DB, model *Model) (string, error) {
+func renderActiveView(db queryer, model *Model) (string, error) {
 	if model.activeView == nil {
 		model.activeView = NewActiveView()
 	}
 	// Capture local ref — tab switches in the main goroutine may nil out model.activeView
 	av := model.activeView
 
-	processes, err := FetchActiveProcesses(db)
+	filter := model.activeFilter
+	processes, err := FetchActiveProcesses(db, filter)
 	if err != nil {
 		return "", err
 	}
 
+	av.Filter = filter
 	av.UpdateSelection(processes)
 
 	switch av.Mode {
@@ -308,6 +597,10 @@ func renderActiveView(db *sql.DB, model *Model) (string, error) {
 		return RenderActiveDetail(av, model.width), nil
 	case ActiveModeConfirmTerminate:
 		return RenderTerminateConfirm(av), nil
+	case ActiveModeKillPattern:
+		return RenderKillPattern(av), nil
+	case ActiveModeConfirmKill:
+		return RenderConfirmKill(av, model.width), nil
 	default:
 		return RenderActiveList(av, model.width, model.height), nil
 	}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/lib/pq"
 )
 
 func TestGetDBConfig(t *testing.T) {
@@ -39,6 +48,7 @@ user=minimaluser
 	originalHome := os.Getenv("HOME")
 	os.Setenv("HOME", tmpDir)
 	defer os.Setenv("HOME", originalHome)
+	clearPGEnv(t)
 
 	tests := []struct {
 		name        string
@@ -110,6 +120,50 @@ user=minimaluser
 	}
 }
 
+// clearPGEnv unsets the PG* variables getDBConfig falls back to, so the developer's environment can't leak in
+func clearPGEnv(t *testing.T) {
+	for _, v := range []string{"PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD", "PGPASSFILE"} {
+		t.Setenv(v, "")
+	}
+}
+
+func TestGetDBConfigEnvFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `[partial]
+dbname=fromfile
+
+[hostless]
+user=nobody
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".pg_service.conf"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", originalHome)
+	clearPGEnv(t)
+
+	t.Setenv("PGHOST", "envhost")
+	t.Setenv("PGPORT", "6432")
+	t.Setenv("PGDATABASE", "envdb")
+	t.Setenv("PGUSER", "envuser")
+	t.Setenv("PGPASSWORD", "envpass")
+
+	got, err := getDBConfig("partial")
+	if err != nil {
+		t.Fatalf("getDBConfig() error = %v", err)
+	}
+	want := DBConfig{Host: "envhost", Port: "6432", Database: "fromfile", User: "envuser", Password: "envpass"}
+	if *got != want {
+		t.Errorf("getDBConfig() = %+v, want %+v (service file wins over env)", *got, want)
+	}
+
+	t.Setenv("PGHOST", "")
+	if _, err := getDBConfig("hostless"); err == nil || !strings.Contains(err.Error(), "PGHOST") {
+		t.Errorf("getDBConfig() error = %v, want it to explain where the host comes from", err)
+	}
+}
+
 func TestListServices(t *testing.T) {
 	// Create a temporary pg_service.conf for testing
 	tmpDir := t.TempDir()
@@ -151,3 +205,167 @@ host=host3.example.com
 		}
 	}
 }
+
+func TestIsPgStatStatementsMissing(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "extension not installed",
+			err:  fmt.Errorf("failed to execute query: %w", &pq.Error{Code: "42P01", Message: `relation "pg_stat_statements" does not exist`}),
+			want: true,
+		},
+		{
+			name: "extension not preloaded",
+			err:  &pq.Error{Code: "55000", Message: `pg_stat_statements must be loaded via "shared_preload_libraries"`},
+			want: true,
+		},
+		{
+			name: "other undefined table",
+			err:  &pq.Error{Code: "42P01", Message: `relation "missing_table" does not exist`},
+			want: false,
+		},
+		{
+			name: "non-postgres error",
+			err:  errors.New("pg_stat_statements exploded"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPgStatStatementsMissing(tt.err); got != tt.want {
+				t.Errorf("isPgStatStatementsMissing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRowsUncapped(t *testing.T) {
+	// SQLite stands in for Postgres, which the capped cursor path needs; statements that
+	// can't be declared as a cursor run to completion and are never cut off
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	const query = "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 10) SELECT i FROM n"
+
+	columns, rows, truncated, err := fetchRows(context.Background(), qdb.db, query, 0)
+	if err != nil || len(columns) != 1 || len(rows) != 10 || truncated {
+		t.Errorf("fetchRows(max 0) = %d rows, truncated %v, %v; want 10 rows", len(rows), truncated, err)
+	}
+
+	if _, err := qdb.db.Exec("CREATE TABLE t (i INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	columns, rows, truncated, err = fetchRows(context.Background(), qdb.db, "INSERT INTO t (i) VALUES (1), (2), (3), (4), (5) RETURNING i", 3)
+	if err != nil || len(columns) != 1 || len(rows) != 5 || truncated {
+		t.Errorf("fetchRows(INSERT ... RETURNING, max 3) = %d rows, truncated %v, %v; want all 5 rows", len(rows), truncated, err)
+	}
+	var count int
+	if err := qdb.db.QueryRow("SELECT COUNT(*) FROM t").Scan(&count); err != nil || count != 5 {
+		t.Errorf("rows inserted = %d, %v; want 5", count, err)
+	}
+}
+
+// writingSelectConn stands in for Postgres running a SELECT that calls a function defined in
+// the database that writes: declaring it as a cursor in a read-only transaction fails with
+// read_only_sql_transaction, while running it outright returns its rows
+type writingSelectConn struct {
+	declared *bool
+}
+
+func (c writingSelectConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c writingSelectConn) Driver() driver.Driver                        { return c }
+func (c writingSelectConn) Open(string) (driver.Conn, error)             { return c, nil }
+func (c writingSelectConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c writingSelectConn) Close() error              { return nil }
+func (c writingSelectConn) Begin() (driver.Tx, error) { return c, nil }
+func (c writingSelectConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return c, nil
+}
+func (c writingSelectConn) Commit() error   { return nil }
+func (c writingSelectConn) Rollback() error { return nil }
+
+func (c writingSelectConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.HasPrefix(query, "DECLARE") {
+		*c.declared = true
+		return nil, &pq.Error{Code: "25006", Message: "cannot execute INSERT in a read-only transaction"}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c writingSelectConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &sequenceRows{n: 5}, nil
+}
+
+type sequenceRows struct {
+	i, n int64
+}
+
+func (r *sequenceRows) Columns() []string { return []string{"archive_order"} }
+func (r *sequenceRows) Close() error      { return nil }
+func (r *sequenceRows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		return io.EOF
+	}
+	r.i++
+	dest[0] = r.i
+	return nil
+}
+
+func TestFetchRowsWritingSelect(t *testing.T) {
+	var declared bool
+	db := sql.OpenDB(writingSelectConn{declared: &declared})
+	defer db.Close()
+
+	columns, rows, truncated, err := fetchRows(context.Background(), db, "SELECT archive_order(id) FROM orders", 3)
+	if !declared {
+		t.Error("fetchRows(max 3) didn't try a cursor first")
+	}
+	if err != nil || len(columns) != 1 || len(rows) != 5 || truncated {
+		t.Errorf("fetchRows(max 3) = %d rows, truncated %v, %v; want all 5 rows without the cursor", len(rows), truncated, err)
+	}
+}
+
+func TestCursorable(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM pg_stat_activity", true},
+		{"  select 1;", true},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", true},
+		{"VALUES (1), (2)", true},
+		{"TABLE pg_settings", true},
+		{"-- comment\nSELECT 1", true},
+		{"SELECT substring('abc' FROM 1 FOR 2)", true},
+		{"SELECT * FROM t FOR UPDATE", false},
+		{"SELECT * FROM t FOR NO KEY UPDATE", false},
+		{"SELECT * FROM t FOR SHARE", false},
+		{"INSERT INTO t VALUES (1) RETURNING *", false},
+		{"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", false},
+		{"SELECT nextval('s')", false},
+		{"SELECT 1; SELECT 2", false},
+		{"SHOW ALL", false},
+		{"EXPLAIN SELECT 1", false},
+	}
+	for _, tt := range tests {
+		if got := cursorable(tt.query); got != tt.want {
+			t.Errorf("cursorable(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDSNNamesPsqConnections(t *testing.T) {
+	config := &DBConfig{Host: "db", Port: "5432", Database: "app", User: "me", Password: "secret"}
+	if dsn := dsnFor(config); !strings.Contains(dsn, "application_name="+psqApplicationName) {
+		t.Errorf("dsnFor() = %q, want psq's application_name so the Active list can leave it out", dsn)
+	}
+	// A psql session opened from psq is the user's, not psq's
+	if info := connInfo(config); strings.Contains(info, "application_name") {
+		t.Errorf("connInfo() = %q, shouldn't set application_name", info)
+	}
+}
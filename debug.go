@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// debugFlag is set by --debug
+var debugFlag bool
+
+// debugLog receives psq's own troubleshooting log. It discards everything unless --debug
+// is given, since the terminal belongs to the TUI.
+var debugLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// debugLogPath is where --debug writes its log
+func debugLogPath() string {
+	return filepath.Join(configDir(), "psq.log")
+}
+
+// initDebugLog starts writing the debug log to ~/.psq/psq.log when --debug is given
+func initDebugLog() error {
+	if !debugFlag {
+		return nil
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.OpenFile(debugLogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log: %w", err)
+	}
+	debugLog = newDebugLogger(f)
+	debugLog.Info("psq started", "version", version, "args", os.Args[1:])
+	return nil
+}
+
+// newDebugLogger logs at debug level to w. Attributes whose key mentions a password or
+// a DSN are replaced, in case one is ever passed by mistake.
+func newDebugLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			key := strings.ToLower(a.Key)
+			if strings.Contains(key, "password") || strings.Contains(key, "dsn") {
+				return slog.String(a.Key, "[redacted]")
+			}
+			return a
+		},
+	}))
+}
+
+// logMsg records the tea messages worth seeing when troubleshooting: resizes, query
+// failures and the connection lifecycle. Key presses and ticks are left out.
+func logMsg(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		debugLog.Debug("window resized", "width", msg.Width, "height", msg.Height)
+	case queryErrorMsg:
+		debugLog.Warn("query failed", "error", string(msg))
+	case connectionLostMsg:
+		debugLog.Warn("connection lost", "error", msg.err)
+	case reconnectResultMsg:
+		if msg.err != nil {
+			debugLog.Warn("reconnect failed", "error", msg.err)
+		} else {
+			debugLog.Info("reconnected")
+		}
+	case startupConnectResultMsg:
+		if msg.err != nil {
+			debugLog.Warn("startup connect failed", "error", msg.err)
+		}
+	case terminateResultMsg:
+		debugLog.Info("backend action", "action", msg.Action, "pid", msg.PID, "success", msg.Success, "error", msg.Error)
+	case bulkBackendMsg:
+		debugLog.Info("bulk backend action", "action", msg.Action, "done", msg.Done, "errors", msg.Errors)
+	case statsResetMsg:
+		debugLog.Info("pg_stat_statements reset", "error", msg.err)
+	}
+}
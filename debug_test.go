@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDebugLogger(t *testing.T) {
+	var buf bytes.Buffer
+	original := debugLog
+	debugLog = newDebugLogger(&buf)
+	defer func() { debugLog = original }()
+
+	debugLog.Info("connecting", "service", "prod", "password", "hunter2", "dsn", "host=db password=hunter2")
+	logMsg(tea.WindowSizeMsg{Width: 120, Height: 40})
+	logMsg(connectionLostMsg{err: errors.New("connection refused")})
+	logMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("debug log leaked a password:\n%s", out)
+	}
+	for _, want := range []string{"service=prod", "window resized", "width=120", "connection lost", "connection refused"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("debug log missing %q:\n%s", want, out)
+		}
+	}
+	if lines := strings.Count(out, "\n"); lines != 3 {
+		t.Errorf("key presses shouldn't be logged; got %d lines:\n%s", lines, out)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// diagnoseSystemPrompt asks for on-call triage of a database activity summary
+const diagnoseSystemPrompt = "You are a PostgreSQL expert on call. You are given a summary of a database's current activity. " +
+	"Say whether anything looks wrong, the most likely cause, and the next steps to confirm or fix it, most urgent first. " +
+	"If everything looks normal, say so briefly. Be concise and answer in plain text, not markdown."
+
+// diagnoseLongest is how many of the longest-running backends the summary includes
+const diagnoseLongest = 5
+
+// diagnoseQueryChars caps each query's text in the summary to keep the prompt small
+const diagnoseQueryChars = 200
+
+// healthSnapshot is the structured activity data a diagnosis is built from
+type healthSnapshot struct {
+	Usage     *ConnectionUsage // nil when it couldn't be read
+	Locks     BlockingLockInfo
+	Processes []ActiveProcess // non-idle backends, longest running first
+}
+
+// diagnoseSummaryMsg carries the activity summary to send for diagnosis
+type diagnoseSummaryMsg struct {
+	Summary string
+	Err     error
+}
+
+// fetchHealthSnapshot reads the data a diagnosis needs. Only the backend list is required;
+// connection usage and lock waits are left out if they can't be read.
+func fetchHealthSnapshot(db *sql.DB) (healthSnapshot, error) {
+	var s healthSnapshot
+	processes, err := FetchActiveProcesses(db, ActiveFilter{})
+	if err != nil {
+		return s, err
+	}
+	s.Processes = processes
+	if usage, err := GetConnectionUsage(db); err == nil {
+		s.Usage = &usage
+	}
+	s.Locks, _ = GetBlockingLockInfo(db)
+	return s, nil
+}
+
+// summarizeHealth renders a snapshot as the compact text sent to the LLM
+func summarizeHealth(s healthSnapshot) string {
+	var b strings.Builder
+	if s.Usage != nil {
+		fmt.Fprintf(&b, "Connections: %d of max_connections %d (%.0f%%)\n", s.Usage.Current, s.Usage.MaxConnections, s.Usage.Percent())
+	}
+	fmt.Fprintf(&b, "Non-idle backends: %d\n", len(s.Processes))
+	if len(s.Processes) > 0 {
+		b.WriteString("By state: " + countBy(s.Processes, func(p ActiveProcess) string { return p.State }) + "\n")
+		if waits := countBy(s.Processes, func(p ActiveProcess) string { return p.WaitEventType }); waits != "" {
+			b.WriteString("Waiting on: " + waits + "\n")
+		}
+	}
+	if s.Locks.Valid {
+		fmt.Fprintf(&b, "Backends waiting on locks: %d, longest wait %s\n", s.Locks.BlockedCount, formatDuration(s.Locks.LongestWaitSec))
+	} else {
+		b.WriteString("Backends waiting on locks: 0\n")
+	}
+
+	if len(s.Processes) > 0 {
+		b.WriteString("Longest running:\n")
+		for _, p := range s.Processes[:min(len(s.Processes), diagnoseLongest)] {
+			fmt.Fprintf(&b, "- pid %d %s@%s %s for %s", p.PID, p.Username, p.Database, p.State, p.Duration)
+			if p.WaitEventType != "" {
+				fmt.Fprintf(&b, ", waiting on %s/%s", p.WaitEventType, p.WaitEvent)
+			}
+			if p.BackendType != "" && p.BackendType != "client backend" {
+				b.WriteString(" (" + p.BackendType + ")")
+			}
+			if q := scrubNewlines(p.Query); q != "" {
+				b.WriteString(": " + truncate(q, diagnoseQueryChars))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// countBy tallies processes by key, most common first, e.g. "active 5, idle in transaction 2".
+// Processes with an empty key aren't counted.
+func countBy(processes []ActiveProcess, keyOf func(ActiveProcess) string) string {
+	counts := map[string]int{}
+	var keys []string
+	for _, p := range processes {
+		k := keyOf(p)
+		if k == "" {
+			continue
+		}
+		if counts[k] == 0 {
+			keys = append(keys, k)
+		}
+		counts[k]++
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return counts[keys[i]] > counts[keys[j]] })
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s %d", k, counts[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diagnoseMessages asks the LLM to triage a service's activity summary
+func diagnoseMessages(service, summary string) []chatMessage {
+	return []chatMessage{
+		{Role: "system", Content: diagnoseSystemPrompt},
+		{Role: "user", Content: "Current activity on " + service + ":\n\n" + summary},
+	}
+}
+
+// handleDiagnose gathers the current activity on the Home or Active tab and asks the LLM to triage it
+func (m *Model) handleDiagnose() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	if name := m.queries[m.selected].Name; !IsHomeTab(name) && !IsActiveTab(name) {
+		return m, nil
+	}
+	m.cancelAI()
+	m.aiAnswer = &AIAnswer{Title: "Diagnose " + m.service}
+	m.viewport.GotoTop()
+	m.updateContent()
+
+	db := m.db
+	if db == nil || m.reconnecting {
+		return m, func() tea.Msg {
+			return diagnoseSummaryMsg{Err: fmt.Errorf("not connected")}
+		}
+	}
+	return m, func() tea.Msg {
+		s, err := fetchHealthSnapshot(db)
+		if err != nil {
+			return diagnoseSummaryMsg{Err: err}
+		}
+		return diagnoseSummaryMsg{Summary: summarizeHealth(s)}
+	}
+}
+
+// handleDiagnoseSummary sends the gathered summary to the LLM, unless the view was closed meanwhile
+func (m *Model) handleDiagnoseSummary(msg diagnoseSummaryMsg) (tea.Model, tea.Cmd) {
+	if m.aiAnswer == nil || m.aiAnswer.Done || m.aiCancel != nil {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.aiAnswer.Done = true
+		m.aiAnswer.Err = "Failed to read activity: " + msg.Err.Error()
+		m.updateContent()
+		return m, nil
+	}
+	return m, m.startAIAnswer(m.aiAnswer.Title, diagnoseMessages(m.service, msg.Summary))
+}
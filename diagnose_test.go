@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestSummarizeHealth(t *testing.T) {
+	s := healthSnapshot{
+		Usage: &ConnectionUsage{Current: 90, MaxConnections: 100},
+		Locks: BlockingLockInfo{Valid: true, BlockedCount: 2, LongestWaitSec: 65},
+		Processes: []ActiveProcess{
+			{PID: 1, Username: "app", Database: "prod", State: "idle in transaction", Duration: "01:02:03", Query: "UPDATE accounts\n  SET balance = 0"},
+			{PID: 2, Username: "app", Database: "prod", State: "active", Duration: "00:01:00", WaitEventType: "Lock", WaitEvent: "transactionid", Query: "UPDATE accounts SET balance = 1"},
+			{PID: 3, Username: "app", Database: "prod", State: "active", Duration: "00:00:01", WaitEventType: "Lock", WaitEvent: "transactionid", Query: strings.Repeat("x", 500)},
+			{PID: 4, Username: "", Database: "", State: "active", Duration: "00:00:01", BackendType: "autovacuum worker"},
+		},
+	}
+
+	got := summarizeHealth(s)
+	for _, want := range []string{
+		"Connections: 90 of max_connections 100 (90%)",
+		"Non-idle backends: 4",
+		"By state: active 3, idle in transaction 1",
+		"Waiting on: Lock 2",
+		"Backends waiting on locks: 2, longest wait 1m 5s",
+		"- pid 1 app@prod idle in transaction for 01:02:03: UPDATE accounts SET balance = 0",
+		"waiting on Lock/transactionid",
+		"(autovacuum worker)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, strings.Repeat("x", diagnoseQueryChars)) {
+		t.Errorf("summary didn't truncate long queries:\n%s", got)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	zone.NewGlobal()
+
+	var got []chatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = req.Messages
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"pid 1 is holding locks; end that transaction."}}]}`))
+	}))
+	defer server.Close()
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER":    "",
+		"OPENAI_API_KEY":      "sk-test",
+		"PSQ_OPENAI_BASE_URL": server.URL,
+	})
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, viewport: viewport.New(80, 40), service: "prod",
+		queries: []Query{HomeQuery(), {Name: "Locks", SQL: "SELECT 1"}}}
+
+	// Only the Home and Active tabs have activity to diagnose
+	m.selected = 1
+	if _, cmd := m.handleDiagnose(); cmd != nil || m.aiAnswer != nil {
+		t.Fatalf("diagnose started on a query tab")
+	}
+
+	m.selected = 0
+	if _, cmd := m.handleDiagnose(); cmd == nil || m.aiAnswer == nil {
+		t.Fatalf("diagnose did not start on the Home tab")
+	}
+	_, cmd := m.Update(diagnoseSummaryMsg{Summary: "Non-idle backends: 1\n"})
+	m.Update(cmd())
+
+	if len(got) != 2 || got[0].Content != diagnoseSystemPrompt || !strings.Contains(got[1].Content, "prod") || !strings.Contains(got[1].Content, "Non-idle backends: 1") {
+		t.Fatalf("request messages = %+v, want the diagnose prompt and the summary", got)
+	}
+	if !m.aiAnswer.Done || !strings.Contains(m.viewport.View(), "end that transaction") {
+		t.Errorf("view does not show the diagnosis:\n%s", m.viewport.View())
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// resultSnapshot is a table result's rows as fetched, before display formatting. The next
+// refresh of the same tab is diffed against it.
+type resultSnapshot struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// rowDiff describes how a result differs from the previous refresh of the same query
+type rowDiff struct {
+	New     []bool     // per current row: no matching row in the previous result
+	Changed [][]bool   // per current row, per column: the value changed; nil for unchanged rows
+	Gone    [][]string // previous rows with no match in the current result
+}
+
+// diffResults matches rows of cur to rows of prev by the key column and reports what's new,
+// changed and gone. Rows sharing a key are matched in order. It returns nil when there's
+// nothing to compare against, including when the columns changed.
+func diffResults(prev *resultSnapshot, cur resultSnapshot, keyColumn string) *rowDiff {
+	if prev == nil || !slices.Equal(prev.Columns, cur.Columns) || len(cur.Columns) == 0 {
+		return nil
+	}
+	key := 0
+	for i, col := range cur.Columns {
+		if col == keyColumn {
+			key = i
+			break
+		}
+	}
+
+	// rowKeys tells apart rows that share a key value by how many came before
+	rowKeys := func(rows [][]string) []string {
+		seen := map[string]int{}
+		keys := make([]string, len(rows))
+		for i, row := range rows {
+			keys[i] = fmt.Sprintf("%s\x00%d", row[key], seen[row[key]])
+			seen[row[key]]++
+		}
+		return keys
+	}
+
+	previous := map[string][]string{}
+	for i, k := range rowKeys(prev.Rows) {
+		previous[k] = prev.Rows[i]
+	}
+
+	d := &rowDiff{New: make([]bool, len(cur.Rows)), Changed: make([][]bool, len(cur.Rows))}
+	matched := map[string]bool{}
+	for i, k := range rowKeys(cur.Rows) {
+		old, ok := previous[k]
+		if !ok {
+			d.New[i] = true
+			continue
+		}
+		matched[k] = true
+		for c := range cur.Rows[i] {
+			if cur.Rows[i][c] != old[c] {
+				if d.Changed[i] == nil {
+					d.Changed[i] = make([]bool, len(cur.Columns))
+				}
+				d.Changed[i][c] = true
+			}
+		}
+	}
+	for i, k := range rowKeys(prev.Rows) {
+		if !matched[k] {
+			d.Gone = append(d.Gone, append([]string(nil), prev.Rows[i]...))
+		}
+	}
+	return d
+}
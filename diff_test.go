@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestDiffResults(t *testing.T) {
+	columns := []string{"pid", "state", "query"}
+	prev := &resultSnapshot{Columns: columns, Rows: [][]string{
+		{"1", "active", "SELECT 1"},
+		{"2", "idle", "SELECT 2"},
+		{"3", "active", "SELECT 3"},
+	}}
+	cur := resultSnapshot{Columns: columns, Rows: [][]string{
+		{"1", "active", "SELECT 1"},
+		{"3", "idle", "SELECT 3"},
+		{"4", "active", "SELECT 4"},
+	}}
+
+	d := diffResults(prev, cur, "")
+	if want := []bool{false, false, true}; !reflect.DeepEqual(d.New, want) {
+		t.Errorf("New = %v, want %v", d.New, want)
+	}
+	if d.Changed[0] != nil || !reflect.DeepEqual(d.Changed[1], []bool{false, true, false}) {
+		t.Errorf("Changed = %v, want only row 2's state", d.Changed)
+	}
+	if want := [][]string{{"2", "idle", "SELECT 2"}}; !reflect.DeepEqual(d.Gone, want) {
+		t.Errorf("Gone = %v, want %v", d.Gone, want)
+	}
+
+	// Keyed by another column, the same rows match differently
+	d = diffResults(prev, cur, "query")
+	if d.New[2] != true || len(d.Gone) != 1 || d.Changed[1] == nil {
+		t.Errorf("diff keyed by query = %+v", d)
+	}
+
+	// Rows sharing a key match in order
+	dup := resultSnapshot{Columns: []string{"state", "n"}, Rows: [][]string{{"active", "1"}, {"active", "2"}}}
+	d = diffResults(&resultSnapshot{Columns: dup.Columns, Rows: [][]string{{"active", "1"}}}, dup, "")
+	if !reflect.DeepEqual(d.New, []bool{false, true}) || len(d.Gone) != 0 {
+		t.Errorf("diff with duplicate keys = %+v", d)
+	}
+
+	// Nothing to compare on the first run or after the columns changed
+	if diffResults(nil, cur, "") != nil {
+		t.Errorf("diff against no previous result should be nil")
+	}
+	if diffResults(&resultSnapshot{Columns: []string{"pid"}}, cur, "") != nil {
+		t.Errorf("diff across different columns should be nil")
+	}
+}
+
+func TestRenderTableDiff(t *testing.T) {
+	columns := []string{"pid", "state"}
+	prev := &resultSnapshot{Columns: columns, Rows: [][]string{{"1", "active"}, {"2", "idle"}}}
+	rows := [][]string{{"1", "idle"}, {"3", "active"}}
+	opts := TableOptions{Diff: true}
+	opts.changes = diffResults(prev, resultSnapshot{Columns: columns, Rows: rows}, "")
+
+	lines := strings.Split(strings.TrimRight(renderTable(columns, rows, opts), "\n"), "\n")
+	// Header, both current rows, then the gone row
+	if len(lines) != 4 || !strings.Contains(lines[3], "2") || !strings.Contains(lines[3], "idle") {
+		t.Errorf("renderTable(diff) =\n%s\nwant the gone row last", strings.Join(lines, "\n"))
+	}
+}
+
+func TestDiffKeyOnActiveTab(t *testing.T) {
+	zone.NewGlobal()
+	m := &Model{
+		queries:     []Query{ActiveQuery(), {Name: "Locks", SQL: "SELECT 1"}},
+		activeView:  NewActiveView(),
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       80,
+		viewport:    viewport.New(80, 20),
+	}
+	v := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")}
+
+	m.handleKeyMsg(v)
+	if !m.tableOpts.Diff || !strings.Contains(m.status, "not available on this tab") {
+		t.Errorf("diff = %v, status = %q; want diff on and a note that Active can't show it", m.tableOpts.Diff, m.status)
+	}
+
+	m.selected = 1
+	m.status = ""
+	m.handleKeyMsg(v)
+	m.handleKeyMsg(v)
+	if !m.tableOpts.Diff || m.status != "" {
+		t.Errorf("diff = %v, status = %q; want diff on a result tab without a note", m.tableOpts.Diff, m.status)
+	}
+}
@@ -2,27 +2,43 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
+	"unicode"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+const (
+	// sqlEditorMaxLines caps the SQL textarea's content; the textarea's own default of 99 is too few for long queries
+	sqlEditorMaxLines = 1000
+	// editorChromeHeight is the space the header, the other fields, borders and the footer take
+	// around the SQL textarea
+	editorChromeHeight = 27 + footerHeight
+	// editorFields is the number of fields tab cycles through
+	editorFields       = 7
+	minSQLEditorHeight = 5
+	minSQLEditorWidth  = 20
+	// minEditorInputWidth is the narrowest a one-line editor field gets on a small terminal
+	minEditorInputWidth = 10
+)
+
 func (m *Model) initEditor(query Query) {
 	// Initialize name input
 	m.nameInput = textinput.New()
 	m.nameInput.Placeholder = "Query name"
 	m.nameInput.SetValue(query.Name)
 	m.nameInput.CharLimit = 50
-	m.nameInput.Width = 50
 
 	// Initialize description input
 	m.descInput = textinput.New()
 	m.descInput.Placeholder = "Query description"
 	m.descInput.SetValue(query.Description)
 	m.descInput.CharLimit = 100
-	m.descInput.Width = 50
 
 	// Initialize order input
 	m.orderInput = textinput.New()
@@ -31,14 +47,40 @@ func (m *Model) initEditor(query Query) {
 		m.orderInput.SetValue(fmt.Sprintf("%d", *query.OrderPosition))
 	}
 	m.orderInput.CharLimit = 10
-	m.orderInput.Width = 30
 
 	// Initialize SQL textarea
 	m.sqlTextarea = textarea.New()
 	m.sqlTextarea.Placeholder = "Enter your SQL query here..."
+	m.editAutoRefresh = !query.NoAutoRefresh
+
+	// Initialize tags input
+	m.tagsInput = textinput.New()
+	m.tagsInput.Placeholder = "e.g. replication, wal"
+	m.tagsInput.SetValue(query.Tags)
+	m.tagsInput.CharLimit = 100
+
+	// Initialize cache TTL input
+	m.cacheInput = textinput.New()
+	m.cacheInput.Placeholder = "e.g. 10m (empty runs every refresh)"
+	m.cacheInput.SetValue(formatCacheTTL(query.CacheTTL))
+	m.cacheInput.CharLimit = 20
+
+	m.sqlTextarea.MaxHeight = sqlEditorMaxLines
 	m.sqlTextarea.SetValue(query.SQL)
-	m.sqlTextarea.SetWidth(80)
-	m.sqlTextarea.SetHeight(10)
+	m.resizeEditor()
+
+	m.aiState = AIStateNone
+	m.aiResult = ""
+	m.aiErr = ""
+	m.confirmDiscard = false
+	m.confirmPurge = false
+	m.aiSQLUsed = false
+	m.confirmAIWrite = ""
+	m.sqlUndo = nil
+	m.sqlRedo = nil
+	m.sqlTyping = false
+	m.aiUndoSQL = ""
+	m.aiUndoArmed = false
 
 	// Focus on the first input
 	m.editFocus = 0
@@ -46,79 +88,237 @@ func (m *Model) initEditor(query Query) {
 }
 
 func (m *Model) handleEditModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The AI panel takes over input (including esc) while open
+	if m.aiState != AIStateNone {
+		return m.handleChatGPTKeys(msg)
+	}
+
+	if m.confirmDiscard {
+		return m.handleDiscardConfirmKeys(msg)
+	}
+
+	if m.confirmPurge {
+		return m.handlePurgeConfirmKeys(msg)
+	}
+
+	if m.confirmAIWrite != "" {
+		return m.handleAIWriteConfirmKeys(msg)
+	}
+
+	// Right after the AI replaced the SQL, u puts the old SQL back
+	if m.aiUndoArmed {
+		m.aiUndoArmed = false
+		if msg.String() == "u" {
+			m.setSQL(m.aiUndoSQL)
+			m.updateContent()
+			return m, nil
+		}
+	}
+
 	// Check for escape key by type as well as string
-	if msg.Type == tea.KeyEscape || msg.String() == "escape" || msg.String() == "esc" || msg.String() == "ctrl+c" || msg.String() == "ctrl+[" {
-		m.editMode = false
-		// Restore previous selection
-		if m.previousSelected < len(m.queries) {
-			m.selected = m.previousSelected
+	if msg.Type == tea.KeyEscape || key.Matches(msg, keys.Discard) {
+		if m.editorDirty() {
+			m.confirmDiscard = true
+			m.updateContent()
+			return m, nil
 		}
-		m.ensureValidSelection()
-		m.updateContent()
+		m.closeEditor()
 		return m, nil
 	}
 
-	switch msg.String() {
-	case "ctrl+d":
-		return m.handleDeleteQuery()
-	case "ctrl+s":
+	switch {
+	case key.Matches(msg, keys.Delete):
+		return m.handleDeleteQuery(false)
+	case key.Matches(msg, keys.Purge):
+		// Deleting for good can't be undone from the archive, so it asks first
+		if m.editQuery.Name != "" {
+			m.confirmPurge = true
+		}
+		m.updateContent()
+		return m, nil
+	case key.Matches(msg, keys.Save):
 		return m.handleSaveQuery()
-	case "tab", "shift+tab":
+	case key.Matches(msg, keys.Generate):
+		m.startChatGPTPrompt()
+		m.updateContent()
+		return m, textinput.Blink
+	case key.Matches(msg, keys.EditSQL):
+		return m.handleOpenExternalEditor()
+	case key.Matches(msg, keys.Undo):
+		m.undoSQL()
+		m.updateContent()
+		return m, nil
+	case key.Matches(msg, keys.Redo):
+		m.redoSQL()
+		m.updateContent()
+		return m, nil
+	case key.Matches(msg, keys.NextField):
 		return m.handleTabNavigation(msg.String())
 	default:
 		return m.handleEditInput(msg)
 	}
 }
 
-func (m *Model) handleDeleteQuery() (tea.Model, tea.Cmd) {
-	// Delete the query (only for existing queries, not new ones)
-	if m.editQuery.Name != "" {
-		if globalQueryDB != nil {
-			if err := globalQueryDB.DeleteQuery(m.editQuery.Name); err != nil {
-				m.err = fmt.Sprintf("Failed to delete query: %v", err)
-				m.updateContent()
-				return m, nil
-			} else {
-				// Remove from temporary queries if it was temporary
-				if m.isTemporaryQuery(m.editQuery.Name) {
-					delete(m.tempQueries, m.editQuery.Name)
-				}
+// resizeEditor fits the editor's fields to the terminal, leaving the SQL textarea the room
+// the other fields don't need. Before the first window size arrives it falls back to 80x10.
+// It runs again on every resize while editing.
+func (m *Model) resizeEditor() {
+	width, height := 80, 10
+	if m.width > 0 {
+		// Viewport border plus the textarea's focus border
+		width = max(m.width-4, minSQLEditorWidth)
+	}
+	if m.height > 0 {
+		height = max(m.height-editorChromeHeight, minSQLEditorHeight)
+	}
+	m.sqlTextarea.SetWidth(width)
+	m.sqlTextarea.SetHeight(height)
 
-				// Reload queries
-				queries, err := loadQueries()
-				if err != nil {
-					m.err = fmt.Sprintf("Failed to reload queries: %v", err)
-					m.updateContent()
-					return m, nil
-				} else {
-					m.queries = queries
-					// Also reload all queries for search
-					if allQueries, err := globalQueryDB.LoadAllQueries(); err == nil {
-						m.allQueries = allQueries
-					}
+	// One-line fields keep their usual width unless the terminal is too narrow for it
+	inputWidth := func(preferred int) int {
+		if m.width <= 0 {
+			return preferred
+		}
+		// Viewport border, prompt and cursor
+		return max(min(preferred, m.width-6), minEditorInputWidth)
+	}
+	m.nameInput.Width = inputWidth(50)
+	m.descInput.Width = inputWidth(50)
+	m.orderInput.Width = inputWidth(30)
+	m.tagsInput.Width = inputWidth(50)
+	m.cacheInput.Width = inputWidth(40)
+}
 
-					// Adjust selection if needed
-					if m.previousSelected >= len(m.queries) && len(m.queries) > 0 {
-						m.previousSelected = len(m.queries) - 1
-					}
+// editorDirty reports whether any editor field differs from the query being edited
+func (m *Model) editorDirty() bool {
+	order := ""
+	if m.editQuery.OrderPosition != nil {
+		order = fmt.Sprintf("%d", *m.editQuery.OrderPosition)
+	}
+	return m.nameInput.Value() != m.editQuery.Name ||
+		m.descInput.Value() != m.editQuery.Description ||
+		m.orderInput.Value() != order ||
+		m.sqlTextarea.Value() != m.editQuery.SQL ||
+		m.editAutoRefresh == m.editQuery.NoAutoRefresh ||
+		normalizeTags(m.tagsInput.Value()) != normalizeTags(m.editQuery.Tags) ||
+		m.cacheInput.Value() != formatCacheTTL(m.editQuery.CacheTTL)
+}
 
-					m.editMode = false
-					// Restore previous selection
-					if m.previousSelected < len(m.queries) {
-						m.selected = m.previousSelected
-					}
-					m.ensureValidSelection()
-					m.updateContent()
-					return m, nil
-				}
-			}
-		}
+// handleDiscardConfirmKeys handles y/n while confirming that unsaved edits should be thrown away
+func (m *Model) handleDiscardConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.confirmDiscard = false
+		m.closeEditor()
+	case "n", "esc", "ctrl+[":
+		m.confirmDiscard = false
+		m.updateContent()
 	}
+	return m, nil
+}
+
+// handlePurgeConfirmKeys handles y/n while confirming that the query should be deleted for good
+func (m *Model) handlePurgeConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.confirmPurge = false
+		return m.handleDeleteQuery(true)
+	case "n", "esc", "ctrl+[":
+		m.confirmPurge = false
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// handleAIWriteConfirmKeys handles y/n while confirming that AI-generated SQL that writes should run
+func (m *Model) handleAIWriteConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.confirmAIWrite = ""
+		m.aiSQLUsed = false
+		return m.handleSaveQuery()
+	case "n", "esc", "ctrl+[":
+		m.confirmAIWrite = ""
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// closeEditor leaves edit mode without saving and returns to the previously selected tab
+func (m *Model) closeEditor() {
+	m.editMode = false
+	// Restore previous selection
+	if m.previousSelected < len(m.queries) {
+		m.selected = m.previousSelected
+	}
+	m.ensureValidSelection()
+	m.updateContent()
+}
+
+// handleDeleteQuery archives the query being edited, or deletes it for good when
+// permanent is set. New queries that were never saved have nothing to delete.
+func (m *Model) handleDeleteQuery(permanent bool) (tea.Model, tea.Cmd) {
+	if m.editQuery.Name == "" || globalQueryDB == nil {
+		m.updateContent()
+		return m, nil
+	}
+
+	remove, verb := globalQueryDB.ArchiveQuery, "archive"
+	if permanent {
+		remove, verb = globalQueryDB.DeleteQuery, "delete"
+	}
+	if err := remove(m.editQuery.Name); err != nil {
+		m.err = fmt.Sprintf("Failed to %s query: %v", verb, err)
+		m.updateContent()
+		return m, nil
+	}
+
+	// Remove from temporary queries if it was temporary
+	if m.isTemporaryQuery(m.editQuery.Name) {
+		delete(m.tempQueries, m.editQuery.Name)
+	}
+
+	if err := m.reloadQueries(); err != nil {
+		m.err = fmt.Sprintf("Failed to reload queries: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+	if !permanent {
+		m.resultNote = fmt.Sprintf("Archived %s; press A to restore it", m.editQuery.Name)
+	}
+
+	// Adjust selection if needed
+	if m.previousSelected >= len(m.queries) && len(m.queries) > 0 {
+		m.previousSelected = len(m.queries) - 1
+	}
+
+	m.editMode = false
+	// Restore previous selection
+	if m.previousSelected < len(m.queries) {
+		m.selected = m.previousSelected
+	}
+	m.ensureValidSelection()
 	m.updateContent()
 	return m, nil
 }
 
 func (m *Model) handleSaveQuery() (tea.Model, tea.Cmd) {
+	// Saving runs the query, so make sure a write the AI slipped in is really wanted
+	if m.aiSQLUsed {
+		if keyword := mutatingKeyword(m.sqlTextarea.Value()); keyword != "" {
+			m.confirmAIWrite = keyword
+			m.updateContent()
+			return m, nil
+		}
+	}
+
+	cacheTTL, err := parseCacheTTL(m.cacheInput.Value())
+	if err != nil {
+		m.err = err.Error()
+		m.updateContent()
+		return m, nil
+	}
+
 	// Save the query
 	newQuery := Query{
 		Name: func() string {
@@ -127,8 +327,11 @@ func (m *Model) handleSaveQuery() (tea.Model, tea.Cmd) {
 			}
 			return m.nameInput.Value()
 		}(),
-		Description: m.descInput.Value(),
-		SQL:         m.sqlTextarea.Value(),
+		Description:   m.descInput.Value(),
+		SQL:           m.sqlTextarea.Value(),
+		NoAutoRefresh: !m.editAutoRefresh,
+		Tags:          normalizeTags(m.tagsInput.Value()),
+		CacheTTL:      cacheTTL,
 	}
 
 	// Parse order position (but don't save temporary ones)
@@ -196,11 +399,11 @@ func (m *Model) handleSaveQuery() (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleTabNavigation(key string) (tea.Model, tea.Cmd) {
-	// Cycle through inputs (4 total: name, description, order, sql)
+	// Cycle through inputs (name, description, order, sql, auto refresh, tags, cache TTL)
 	if key == "tab" {
-		m.editFocus = (m.editFocus + 1) % 4
+		m.editFocus = (m.editFocus + 1) % editorFields
 	} else {
-		m.editFocus = (m.editFocus + 3) % 4
+		m.editFocus = (m.editFocus + editorFields - 1) % editorFields
 	}
 
 	// Update focus
@@ -208,6 +411,8 @@ func (m *Model) handleTabNavigation(key string) (tea.Model, tea.Cmd) {
 	m.descInput.Blur()
 	m.orderInput.Blur()
 	m.sqlTextarea.Blur()
+	m.tagsInput.Blur()
+	m.cacheInput.Blur()
 
 	switch m.editFocus {
 	case 0:
@@ -218,6 +423,10 @@ func (m *Model) handleTabNavigation(key string) (tea.Model, tea.Cmd) {
 		m.orderInput.Focus()
 	case 3:
 		m.sqlTextarea.Focus()
+	case 5:
+		m.tagsInput.Focus()
+	case 6:
+		m.cacheInput.Focus()
 	}
 	m.updateContent()
 	return m, nil
@@ -234,8 +443,150 @@ func (m *Model) handleEditInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case 2:
 		m.orderInput, cmd = m.orderInput.Update(msg)
 	case 3:
+		before := m.sqlTextarea.Value()
 		m.sqlTextarea, cmd = m.sqlTextarea.Update(msg)
+		if m.sqlTextarea.Value() != before {
+			// Typing a word is one undo step; anything else (spaces, deletes, pastes) starts a new one
+			typing := msg.Type == tea.KeyRunes && len(msg.Runes) == 1 && !unicode.IsSpace(msg.Runes[0])
+			if !typing || !m.sqlTyping {
+				m.pushSQLUndo(before)
+			}
+			m.sqlTyping = typing
+		}
+	case 4:
+		switch msg.String() {
+		case " ", "enter", "x":
+			m.editAutoRefresh = !m.editAutoRefresh
+		case "y":
+			m.editAutoRefresh = true
+		case "n":
+			m.editAutoRefresh = false
+		}
+	case 5:
+		m.tagsInput, cmd = m.tagsInput.Update(msg)
+	case 6:
+		m.cacheInput, cmd = m.cacheInput.Update(msg)
 	}
 	m.updateContent()
 	return m, cmd
 }
+
+// maxSQLUndo bounds the SQL editor's undo history
+const maxSQLUndo = 100
+
+// pushSQLUndo records the SQL as it was before an edit; a new edit clears the redo history
+func (m *Model) pushSQLUndo(before string) {
+	m.sqlUndo = append(m.sqlUndo, before)
+	if len(m.sqlUndo) > maxSQLUndo {
+		m.sqlUndo = m.sqlUndo[len(m.sqlUndo)-maxSQLUndo:]
+	}
+	m.sqlRedo = nil
+}
+
+// setSQL replaces the SQL editor's value as a single undoable step
+func (m *Model) setSQL(sql string) {
+	if sql == m.sqlTextarea.Value() {
+		return
+	}
+	m.pushSQLUndo(m.sqlTextarea.Value())
+	m.sqlTyping = false
+	m.sqlTextarea.SetValue(sql)
+}
+
+// undoSQL restores the SQL editor's previous value
+func (m *Model) undoSQL() {
+	if len(m.sqlUndo) == 0 {
+		return
+	}
+	prev := m.sqlUndo[len(m.sqlUndo)-1]
+	m.sqlUndo = m.sqlUndo[:len(m.sqlUndo)-1]
+	m.sqlRedo = append(m.sqlRedo, m.sqlTextarea.Value())
+	m.sqlTyping = false
+	m.sqlTextarea.SetValue(prev)
+}
+
+// redoSQL reapplies the last value undone with undoSQL
+func (m *Model) redoSQL() {
+	if len(m.sqlRedo) == 0 {
+		return
+	}
+	next := m.sqlRedo[len(m.sqlRedo)-1]
+	m.sqlRedo = m.sqlRedo[:len(m.sqlRedo)-1]
+	m.sqlUndo = append(m.sqlUndo, m.sqlTextarea.Value())
+	m.sqlTyping = false
+	m.sqlTextarea.SetValue(next)
+}
+
+// externalEditMsg carries the SQL back from $EDITOR
+type externalEditMsg struct {
+	SQL string
+	Err error
+}
+
+// editorCommand builds the $EDITOR command (vi when unset) for path. EDITOR may include
+// arguments, e.g. "code -w".
+func editorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// handleOpenExternalEditor suspends the TUI and opens the SQL in $EDITOR through a
+// temporary .sql file, like git does for commit messages
+func (m *Model) handleOpenExternalEditor() (tea.Model, tea.Cmd) {
+	f, err := os.CreateTemp("", "psq-*.sql")
+	if err == nil {
+		_, err = f.WriteString(m.sqlTextarea.Value())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		if f != nil {
+			os.Remove(f.Name())
+		}
+		m.err = fmt.Sprintf("Failed to write SQL for the editor: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+
+	cmd := editorCommand(f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return m, tea.ExecProcess(cmd, externalEditDone(f.Name()))
+}
+
+// externalEditDone reads the edited SQL back and removes the temporary file. An editor
+// that exits with an error (e.g. :cq in vim) discards the edit.
+func externalEditDone(path string) func(error) tea.Msg {
+	return func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return externalEditMsg{Err: err}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return externalEditMsg{Err: err}
+		}
+		// Editors end files with a newline the textarea doesn't need
+		return externalEditMsg{SQL: strings.TrimRight(string(data), "\n")}
+	}
+}
+
+// handleExternalEdit puts the SQL from $EDITOR in the textarea as one undoable change
+func (m *Model) handleExternalEdit(msg externalEditMsg) (tea.Model, tea.Cmd) {
+	if !m.editMode {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.err = fmt.Sprintf("Editor failed, SQL left unchanged: %v", msg.Err)
+	} else {
+		m.err = ""
+		m.setSQL(msg.SQL)
+	}
+	m.updateContent()
+	return m, nil
+}
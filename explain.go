@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// explainTimeout bounds plan checks so a slow catalog can't hold up the review screen
+const explainTimeout = 5 * time.Second
+
+// splitStatements splits query's tokens at each semicolon outside strings, comments and quoted
+// identifiers. Statements with nothing but whitespace and comments are left out.
+func splitStatements(query string) [][]sqlToken {
+	var statements [][]sqlToken
+	var current []sqlToken
+	substantial := false
+	end := func() {
+		if substantial {
+			statements = append(statements, current)
+		}
+		current, substantial = nil, false
+	}
+	for _, tok := range tokenizeSQL(query) {
+		if tok.Kind != sqlText {
+			current = append(current, tok)
+			substantial = substantial || tok.Kind != sqlComment
+			continue
+		}
+		parts := strings.Split(tok.Text, ";")
+		for i, part := range parts {
+			if i > 0 {
+				end()
+			}
+			if part != "" {
+				current = append(current, sqlToken{Kind: sqlText, Text: part})
+				substantial = substantial || strings.TrimSpace(part) != ""
+			}
+		}
+	}
+	end()
+	return statements
+}
+
+// explainSQL asks Postgres to plan query without running it. Only a single statement is
+// accepted: lib/pq sends a query without arguments as one simple-protocol message, which runs
+// every statement in it, so "SELECT 1; COMMIT; DROP TABLE x" would end the read-only
+// transaction and drop the table. The EXPLAIN itself runs in a read-only transaction that is
+// always rolled back.
+func explainSQL(ctx context.Context, db *sql.DB, query string) error {
+	if len(splitStatements(query)) > 1 {
+		return errors.New("only a single statement can be checked with EXPLAIN")
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// aiExplainEnabled reports whether generated SQL should be checked with EXPLAIN before use.
+// Set $PSQ_AI_EXPLAIN=off to skip the check.
+func aiExplainEnabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("PSQ_AI_EXPLAIN"))) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
+
+// aiPlanCheck is the state of the EXPLAIN check on generated SQL
+type aiPlanCheck int
+
+const (
+	aiPlanUnchecked aiPlanCheck = iota
+	aiPlanChecking
+	aiPlanValid
+	aiPlanInvalid
+)
+
+// aiPlanMsg reports whether Postgres could plan the generated SQL
+type aiPlanMsg struct {
+	SQL string
+	Err error
+}
+
+// checkAIPlan EXPLAINs generated SQL on service in the background
+func checkAIPlan(db *sql.DB, service, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+		defer cancel()
+		start := time.Now()
+		err := explainSQL(ctx, db, query)
+		logStatement(service, "EXPLAIN "+query, start, err)
+		return aiPlanMsg{SQL: query, Err: err}
+	}
+}
+
+// readOnlyStatements start statements that only read, as long as nothing inside them writes.
+// Generated SQL starting any other way asks before it runs.
+var readOnlyStatements = map[string]bool{
+	"SELECT": true, "WITH": true, "EXPLAIN": true, "SHOW": true, "TABLE": true, "VALUES": true,
+}
+
+// mutatingKeywords write when they appear inside an otherwise read-only statement, e.g. in a
+// data-modifying CTE or after EXPLAIN ANALYZE
+var mutatingKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "TRUNCATE": true,
+	"DROP": true, "ALTER": true, "CREATE": true, "GRANT": true, "REVOKE": true,
+}
+
+// sideEffectFunctions change state when a SELECT calls them
+var sideEffectFunctions = map[string]bool{
+	"setval": true, "nextval": true, "set_config": true,
+	"pg_terminate_backend": true, "pg_cancel_backend": true, "pg_reload_conf": true,
+	"pg_rotate_logfile": true, "pg_switch_wal": true, "pg_promote": true,
+	"pg_stat_reset": true, "pg_stat_reset_shared": true, "pg_stat_reset_single_table_counters": true,
+	"pg_stat_statements_reset": true, "pg_advisory_lock": true, "pg_advisory_xact_lock": true, "pg_try_advisory_lock": true,
+	"pg_create_physical_replication_slot": true, "pg_create_logical_replication_slot": true,
+	"pg_drop_replication_slot": true, "pg_replication_origin_create": true,
+	"lo_import": true, "lo_export": true, "lo_unlink": true, "lo_create": true,
+	"dblink_exec": true, "pg_file_write": true,
+}
+
+// mutatingKeyword returns what makes query more than a read, e.g. "DELETE", "DO" or
+// "setval()", or "" for SQL that only reads. Every statement must start with a read-only
+// keyword and contain no write, SELECT ... INTO or call to a function with side effects.
+// Strings, comments and quoted identifiers are ignored, and so is the row locking of
+// SELECT ... FOR UPDATE.
+func mutatingKeyword(query string) string {
+	for _, statement := range splitStatements(query) {
+		var prev string
+		first := true
+		for i, tok := range statement {
+			if tok.Kind != sqlKeyword && tok.Kind != sqlIdent {
+				continue
+			}
+			word := strings.ToUpper(tok.Text)
+			if first {
+				first = false
+				if !readOnlyStatements[word] {
+					return word
+				}
+			}
+			switch {
+			case mutatingKeywords[word] && !(word == "UPDATE" && (prev == "FOR" || prev == "KEY")):
+				return word
+			case word == "INTO":
+				return "SELECT ... INTO"
+			case tok.Kind == sqlIdent && sideEffectFunctions[strings.ToLower(tok.Text)] && calledAt(statement, i):
+				return strings.ToLower(tok.Text) + "()"
+			}
+			prev = word
+		}
+		// A statement of only punctuation, e.g. "(", doesn't read anything either
+		if first {
+			return strings.TrimSpace(statement[0].Text)
+		}
+	}
+	return ""
+}
+
+// calledAt reports whether the word at statement[i] is followed by an argument list
+func calledAt(statement []sqlToken, i int) bool {
+	for _, tok := range statement[i+1:] {
+		if tok.Kind == sqlComment {
+			continue
+		}
+		return tok.Kind == sqlText && strings.HasPrefix(strings.TrimSpace(tok.Text), "(")
+	}
+	return false
+}
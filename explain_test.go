@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMutatingKeyword(t *testing.T) {
+	tests := map[string]string{
+		"SELECT pid, state FROM pg_stat_activity":                             "",
+		"SELECT updated_at, deleted FROM jobs":                                "",
+		"SELECT * FROM jobs FOR UPDATE SKIP LOCKED":                           "",
+		"SELECT * FROM jobs FOR NO KEY UPDATE":                                "",
+		"SELECT 'DELETE FROM jobs' AS example -- DROP TABLE jobs":             "",
+		`SELECT "update" FROM audit`:                                          "",
+		"DELETE FROM jobs WHERE done":                                         "DELETE",
+		"with old AS (DELETE FROM jobs RETURNING *) SELECT count(*) FROM old": "DELETE",
+		"SELECT 1; update jobs SET done = true":                               "UPDATE",
+		"INSERT INTO t VALUES (1) ON CONFLICT DO UPDATE SET v = 1":            "INSERT",
+		"truncate jobs":                                          "TRUNCATE",
+		"GRANT SELECT ON jobs TO reporting":                      "GRANT",
+		"DO $$BEGIN DELETE FROM jobs; END$$":                     "DO",
+		"CALL archive_jobs()":                                    "CALL",
+		"COPY jobs FROM '/tmp/jobs.csv'":                         "COPY",
+		"VACUUM FULL jobs":                                       "VACUUM",
+		"REINDEX TABLE jobs":                                     "REINDEX",
+		"CLUSTER jobs USING jobs_pkey":                           "CLUSTER",
+		"REFRESH MATERIALIZED VIEW job_stats":                    "REFRESH",
+		"LOCK TABLE jobs":                                        "LOCK",
+		"COMMENT ON TABLE jobs IS 'queue'":                       "COMMENT",
+		"SET statement_timeout = 0":                              "SET",
+		"SELECT * INTO jobs_copy FROM jobs":                      "SELECT ... INTO",
+		"SELECT setval('jobs_id_seq', 1)":                        "setval()",
+		"SELECT pg_catalog.setval ('jobs_id_seq', 1)":            "setval()",
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity": "pg_terminate_backend()",
+		"EXPLAIN ANALYZE DELETE FROM jobs":                       "DELETE",
+		"SELECT setval FROM settings":                            "",
+		"WITH t AS (SELECT 1) SELECT * FROM t":                   "",
+		"SHOW work_mem":                                          "",
+		"VALUES (1), (2)":                                        "",
+		"TABLE jobs;":                                            "",
+		"-- just a comment\nSELECT 1":                            "",
+	}
+	for query, want := range tests {
+		if got := mutatingKeyword(query); got != want {
+			t.Errorf("mutatingKeyword(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := map[string]int{
+		"SELECT 1":                                 1,
+		"SELECT 1;":                                1,
+		"SELECT 1; -- done\n":                      1,
+		"SELECT ';' AS semi, \"a;b\" FROM t":       1,
+		"SELECT $$;$$":                             1,
+		"SELECT 1; COMMIT; DROP TABLE x":           3,
+		"SELECT 1;SELECT 2":                        2,
+		"/* one */ SELECT 1; /* nothing else */ ;": 1,
+	}
+	for query, want := range tests {
+		if got := len(splitStatements(query)); got != want {
+			t.Errorf("splitStatements(%q) = %d statements, want %d", query, got, want)
+		}
+	}
+}
+
+func TestExplainSQLRejectsMultipleStatements(t *testing.T) {
+	// Rejected before anything is sent, so no connection is needed
+	if err := explainSQL(context.Background(), nil, "SELECT 1; COMMIT; DROP TABLE x"); err == nil {
+		t.Error("explainSQL should refuse more than one statement")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqlFileOrderPrefix marks the optional order position comment in exported .sql files
+const sqlFileOrderPrefix = "-- order:"
+
+// sqlFileAutoRefreshOff marks queries that don't refresh on every tick in exported .sql files
+const sqlFileAutoRefreshOff = "-- auto_refresh: off"
+
+// sqlFileTagsPrefix marks the optional comma-separated tags comment in exported .sql files
+const sqlFileTagsPrefix = "-- tags:"
+
+// sqlFileCacheTTLPrefix marks the optional cache TTL comment, in seconds, in exported .sql files
+const sqlFileCacheTTLPrefix = "-- cache_ttl:"
+
+// ExportQueriesJSON writes queries to a single JSON file as an array of Query objects
+func ExportQueriesJSON(queries []Query, path string) error {
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode queries: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadQueriesJSON reads queries from a file written by ExportQueriesJSON
+func ReadQueriesJSON(path string) ([]Query, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var queries []Query
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i, q := range queries {
+		if strings.TrimSpace(q.Name) == "" {
+			return nil, fmt.Errorf("query %d in %s has no name", i+1, path)
+		}
+	}
+	return queries, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sqlFileName derives a stable, filesystem-safe .sql file name from a query name
+func sqlFileName(name string) string {
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		slug = "query"
+	}
+	return slug + ".sql"
+}
+
+// formatSQLFile renders a query in the "-- title / -- description / sql" format
+// understood by loadQueryFromSQLFile
+func formatSQLFile(q Query) string {
+	var b strings.Builder
+	b.WriteString("-- " + scrubNewlines(q.Name) + "\n")
+	if desc := scrubNewlines(q.Description); desc != "" {
+		b.WriteString("-- " + desc + "\n")
+	} else {
+		b.WriteString("--\n")
+	}
+	if q.OrderPosition != nil {
+		b.WriteString(fmt.Sprintf("%s %d\n", sqlFileOrderPrefix, *q.OrderPosition))
+	}
+	if q.NoAutoRefresh {
+		b.WriteString(sqlFileAutoRefreshOff + "\n")
+	}
+	if tags := normalizeTags(q.Tags); tags != "" {
+		b.WriteString(sqlFileTagsPrefix + " " + tags + "\n")
+	}
+	if q.CacheTTL > 0 {
+		b.WriteString(fmt.Sprintf("%s %d\n", sqlFileCacheTTLPrefix, q.CacheTTL))
+	}
+	b.WriteString(q.SQL + "\n")
+	return b.String()
+}
+
+// parseOrderComment parses an "-- order: N" line from an exported .sql file
+func parseOrderComment(line string) (int, bool) {
+	if !strings.HasPrefix(line, sqlFileOrderPrefix) {
+		return 0, false
+	}
+	pos, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, sqlFileOrderPrefix)))
+	if err != nil {
+		return 0, false
+	}
+	return pos, true
+}
+
+// ExportQueriesSQLDir writes one .sql file per query into dir, creating it if needed
+func ExportQueriesSQLDir(queries []Query, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	used := make(map[string]bool)
+	for _, q := range queries {
+		name := sqlFileName(q.Name)
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d.sql", strings.TrimSuffix(sqlFileName(q.Name), ".sql"), n)
+		}
+		used[name] = true
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(formatSQLFile(q)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readQueriesFromPath reads queries from a directory of .sql files, a JSON export, or a SQLite dump
+func readQueriesFromPath(path string) ([]Query, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	switch {
+	case info.IsDir():
+		return loadQueriesFromSQL(path)
+	case strings.EqualFold(filepath.Ext(path), ".json"):
+		return ReadQueriesJSON(path)
+	default:
+		return ReadDumpFile(path)
+	}
+}
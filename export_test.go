@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func exportTestQueries() []Query {
+	return []Query{
+		{Name: "Lock Information", Description: "Show current locks", SQL: "SELECT pid -- who\nFROM pg_locks;", OrderPosition: intPtr(1), Tags: "locks, blocking"},
+		{Name: "Hidden/Query", Description: "", SQL: "SELECT 2", NoAutoRefresh: true, CacheTTL: 600},
+	}
+}
+
+func TestExportQueriesJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.json")
+	want := exportTestQueries()
+
+	if err := ExportQueriesJSON(want, path); err != nil {
+		t.Fatalf("ExportQueriesJSON() error = %v", err)
+	}
+
+	got, err := readQueriesFromPath(path)
+	if err != nil {
+		t.Fatalf("readQueriesFromPath() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestExportQueriesSQLDirRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "queries")
+	want := exportTestQueries()
+
+	if err := ExportQueriesSQLDir(want, dir); err != nil {
+		t.Fatalf("ExportQueriesSQLDir() error = %v", err)
+	}
+
+	got, err := readQueriesFromPath(dir)
+	if err != nil {
+		t.Fatalf("readQueriesFromPath() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("round trip returned %d queries, want %d", len(got), len(want))
+	}
+
+	// Files are read back in name order: hidden_query.sql, lock_information.sql
+	byName := map[string]Query{}
+	for _, q := range got {
+		byName[q.Name] = q
+	}
+	for _, w := range want {
+		g, ok := byName[w.Name]
+		if !ok {
+			t.Errorf("missing query %q after round trip", w.Name)
+			continue
+		}
+		if !sameQuery(g, w) {
+			t.Errorf("round trip %q = %+v, want %+v", w.Name, g, w)
+		}
+	}
+}
+
+func TestSQLFileKeepsFormatting(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "queries")
+	want := []Query{{
+		Name: "Blocked",
+		SQL: "-- who is waiting on whom\nSELECT blocked.pid,\n       blocking.pid AS blocking_pid\n\n" +
+			"FROM pg_stat_activity blocked\n    JOIN pg_stat_activity blocking\n      ON blocking.pid = ANY (pg_blocking_pids(blocked.pid)) -- direct only\n",
+		Tags: "locks",
+	}}
+	if err := ExportQueriesSQLDir(want, dir); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readQueriesFromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].SQL != want[0].SQL || got[0].Tags != "locks" {
+		t.Fatalf("round trip = %+v, want the SQL unchanged", got)
+	}
+
+	// Importing the unchanged export again is a no-op, not a conflict
+	toSave, conflicts, _, unchanged := planImport(want, nil, got)
+	if len(toSave) != 0 || len(conflicts) != 0 || unchanged != 1 {
+		t.Errorf("planImport() = %d to save, %d conflicts, %d unchanged; want 1 unchanged", len(toSave), len(conflicts), unchanged)
+	}
+}
+
+func TestSQLFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Lock Information", "lock_information.sql"},
+		{"Top Queries (pg_stat_statements)", "top_queries_pg_stat_statements.sql"},
+		{"../../etc/passwd", "etc_passwd.sql"},
+		{"!!!", "query.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqlFileName(tt.name); got != tt.want {
+				t.Errorf("sqlFileName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fleetServiceTimeout bounds the query on each service of a fleet run, so one hung server
+// doesn't stall the rest
+const fleetServiceTimeout = 30 * time.Second
+
+// FleetRun holds the state for the F overlay, which runs the selected query on every service
+// in the service file, one after another, and gathers the rows into one table
+type FleetRun struct {
+	Query      string // name of the query being run
+	SQL        string
+	Services   []string // every service, in service file order
+	Confirming bool     // waiting for y before connecting anywhere
+	Next       int      // index of the service running now; len(Services) once done
+	Columns    []string // result columns, from the first service that answered
+	Rows       [][]string
+	Errors     map[string]string // why a service has no rows, by name
+	Capped     []string          // services whose result was cut off at max_rows
+
+	table  string // Rows rendered, rebuilt as each service answers
+	ctx    context.Context
+	cancel context.CancelFunc // stops the run; nil once it's done
+}
+
+// fleetResultMsg carries one service's result in a fleet run
+type fleetResultMsg struct {
+	run       *FleetRun
+	Service   string
+	Columns   []string
+	Rows      [][]string
+	Truncated bool
+	Err       error
+}
+
+// running reports whether services are still being queried
+func (f *FleetRun) running() bool {
+	return !f.Confirming && f.Next < len(f.Services)
+}
+
+// stop abandons the services not queried yet
+func (f *FleetRun) stop() {
+	if f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+}
+
+// add records service's result. Rows are prefixed with the service name; a service whose
+// columns don't match the first answer is reported as an error instead.
+func (f *FleetRun) add(msg fleetResultMsg) {
+	switch {
+	case msg.Err != nil:
+		f.Errors[msg.Service] = scrubNewlines(msg.Err.Error())
+		return
+	case f.Columns == nil:
+		f.Columns = msg.Columns
+	case !slices.Equal(f.Columns, msg.Columns):
+		f.Errors[msg.Service] = "returned different columns: " + strings.Join(msg.Columns, ", ")
+		return
+	}
+	for _, row := range msg.Rows {
+		f.Rows = append(f.Rows, append([]string{msg.Service}, row...))
+	}
+	if msg.Truncated {
+		f.Capped = append(f.Capped, msg.Service)
+	}
+}
+
+// tableRows returns the combined table: a service column, the query's columns, and an error
+// column when any service failed, with one row per failed service
+func (f *FleetRun) tableRows() ([]string, [][]string) {
+	columns := append([]string{"service"}, f.Columns...)
+	rows := make([][]string, 0, len(f.Rows)+len(f.Errors))
+	for _, row := range f.Rows {
+		rows = append(rows, slices.Clone(row))
+	}
+	if len(f.Errors) == 0 {
+		return columns, rows
+	}
+	columns = append(columns, "error")
+	for i := range rows {
+		rows[i] = append(rows[i], "")
+	}
+	// Failed services keep their place in service file order, after the rows that came back
+	for _, service := range f.Services {
+		if reason, ok := f.Errors[service]; ok {
+			row := make([]string, len(columns))
+			row[0] = service
+			row[len(row)-1] = reason
+			rows = append(rows, row)
+		}
+	}
+	return columns, rows
+}
+
+// render rebuilds the table with the results so far
+func (f *FleetRun) render(opts TableOptions) {
+	if f.Columns == nil && len(f.Errors) == 0 {
+		f.table = ""
+		return
+	}
+	columns, rows := f.tableRows()
+	formatRows(columns, rows, opts)
+	f.table = renderTable(columns, rows, opts)
+}
+
+// fleetQuery runs sql on service. db is psq's own connection when service is the one it's
+// on; other services get a connection, and their SSH tunnel, of their own for the query.
+// keepTunnel leaves service's tunnel open afterwards because psq's own connection uses it,
+// e.g. while that connection is being rebuilt.
+func fleetQuery(ctx context.Context, run *FleetRun, service string, db *sql.DB, keepTunnel bool, maxRows int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, fleetServiceTimeout)
+		defer cancel()
+		if db == nil {
+			if !keepTunnel {
+				defer closeTunnel(service)
+			}
+			conn, err := connectDB(service)
+			if err != nil {
+				return fleetResultMsg{run: run, Service: service, Err: err}
+			}
+			defer conn.Close()
+			db = conn
+		}
+		start := time.Now()
+		columns, rows, truncated, err := fetchRows(ctx, db, run.SQL, maxRows)
+		logStatement(service, run.SQL, start, err)
+		return fleetResultMsg{run: run, Service: service, Columns: columns, Rows: rows, Truncated: truncated, Err: err}
+	}
+}
+
+// handleFleetRun asks before running the selected query on every service
+func (m *Model) handleFleetRun() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	query := m.queries[m.selected]
+	// Home and Active are built-in views, not a single query
+	if IsHomeTab(query.Name) || IsActiveTab(query.Name) || strings.TrimSpace(query.SQL) == "" {
+		return m, nil
+	}
+	services, err := listServices()
+	if err != nil {
+		m.err = fmt.Sprintf("Failed to list services: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+	m.fleetRun = &FleetRun{Query: query.Name, SQL: query.SQL, Services: services, Confirming: true, Errors: map[string]string{}}
+	m.viewport.GotoTop()
+	m.updateContent()
+	return m, nil
+}
+
+// startFleetRun queries the first service; each result queries the next
+func (m *Model) startFleetRun() tea.Cmd {
+	run := m.fleetRun
+	run.Confirming = false
+	if len(run.Services) == 0 {
+		return nil
+	}
+	run.ctx, run.cancel = context.WithCancel(context.Background())
+	return m.fleetQueryNext(run)
+}
+
+// fleetQueryNext queries the service at run.Next
+func (m *Model) fleetQueryNext(run *FleetRun) tea.Cmd {
+	service := run.Services[run.Next]
+	var db *sql.DB
+	own := service == m.service
+	if own && !m.reconnecting {
+		db = m.db
+	}
+	return fleetQuery(run.ctx, run, service, db, own, m.tableOpts.MaxRows)
+}
+
+// handleFleetResult adds a service's rows to the table and moves on to the next service
+func (m *Model) handleFleetResult(msg fleetResultMsg) (tea.Model, tea.Cmd) {
+	run := m.fleetRun
+	// The run was closed, or replaced by a newer one, meanwhile
+	if run == nil || msg.run != run || run.cancel == nil {
+		return m, nil
+	}
+	run.add(msg)
+	opts := m.tableOptions()
+	opts.Diff, opts.Previous, opts.HiddenColumns = false, nil, nil
+	run.render(opts)
+	run.Next++
+
+	var cmd tea.Cmd
+	if run.Next < len(run.Services) {
+		cmd = m.fleetQueryNext(run)
+	} else {
+		run.stop()
+	}
+	m.updateContent()
+	return m, cmd
+}
+
+// handleFleetKeys confirms the run, then scrolls the table; esc closes the view and stops
+// querying the remaining services
+func (m *Model) handleFleetKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	run := m.fleetRun
+	if run.Confirming {
+		switch {
+		case key.Matches(msg, keys.Confirm):
+			cmd := m.startFleetRun()
+			m.updateContent()
+			return m, cmd
+		case key.Matches(msg, keys.Decline):
+			m.closeFleetRun()
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.viewport.ScrollUp(1)
+	case key.Matches(msg, keys.Down):
+		m.viewport.ScrollDown(1)
+	case key.Matches(msg, keys.PageUp):
+		m.viewport.PageUp()
+	case key.Matches(msg, keys.PageDown):
+		m.viewport.PageDown()
+	case msg.Type == tea.KeyEscape || msg.String() == "q" || msg.String() == "ctrl+[":
+		m.closeFleetRun()
+	}
+	return m, nil
+}
+
+// closeFleetRun stops a run still in progress and goes back to the results
+func (m *Model) closeFleetRun() {
+	if m.fleetRun != nil {
+		m.fleetRun.stop()
+		m.fleetRun = nil
+	}
+	m.updateContent()
+}
+
+// RenderFleetRun renders the confirmation, or the combined table with progress while services
+// are still being queried
+func RenderFleetRun(f *FleetRun, spinner string) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("%s on every service (%d)", f.Query, len(f.Services))))
+	b.WriteString("\n\n")
+
+	if f.Confirming {
+		if len(f.Services) == 0 {
+			b.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).Render("  No services in " + serviceFilesLabel()))
+			return b.String()
+		}
+		b.WriteString("  Run this query on " + strings.Join(f.Services, ", ") + ", one after another?\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Warning).Render("  (y/n)"))
+		return b.String()
+	}
+
+	if f.running() {
+		b.WriteString("  " + spinner + dimStyle.Render(fmt.Sprintf(" Running on %s (%d of %d)…", f.Services[f.Next], f.Next+1, len(f.Services))))
+		b.WriteString("\n\n")
+	} else {
+		summary := fmt.Sprintf("  %d of %d services answered", len(f.Services)-len(f.Errors), len(f.Services))
+		if len(f.Errors) > 0 {
+			var failed []string
+			for _, service := range f.Services {
+				if _, ok := f.Errors[service]; ok {
+					failed = append(failed, service)
+				}
+			}
+			summary += " (failed: " + strings.Join(failed, ", ") + ")"
+		}
+		b.WriteString(dimStyle.Render(summary))
+		b.WriteString("\n\n")
+	}
+	if len(f.Capped) > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Warning).Render("  Cut off at max_rows: " + strings.Join(f.Capped, ", ")))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(f.table)
+	return b.String()
+}
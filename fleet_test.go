@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestFleetRunTableRows(t *testing.T) {
+	f := &FleetRun{Services: []string{"a", "b", "c", "d"}, Errors: map[string]string{}}
+	f.add(fleetResultMsg{Service: "a", Columns: []string{"lag"}, Rows: [][]string{{"1"}}})
+	f.add(fleetResultMsg{Service: "b", Err: os.ErrDeadlineExceeded})
+	f.add(fleetResultMsg{Service: "c", Columns: []string{"other"}, Rows: [][]string{{"x"}}})
+	f.add(fleetResultMsg{Service: "d", Columns: []string{"lag"}, Rows: [][]string{{"2"}, {"3"}}})
+
+	columns, rows := f.tableRows()
+	if want := []string{"service", "lag", "error"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	want := [][]string{
+		{"a", "1", ""},
+		{"d", "2", ""},
+		{"d", "3", ""},
+		{"b", "", os.ErrDeadlineExceeded.Error()},
+		{"c", "", "returned different columns: other"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	// Building the table again doesn't grow the stored rows
+	if _, again := f.tableRows(); !reflect.DeepEqual(again, want) || len(f.Rows[0]) != 2 {
+		t.Errorf("tableRows changed the run's rows: %v", f.Rows)
+	}
+}
+
+func TestFleetRunKeepsGoingPastFailures(t *testing.T) {
+	zone.NewGlobal()
+	qdb, tmpDir := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	// "local" is the service psq is on; "broken" has no host and can't be reached
+	path := filepath.Join(tmpDir, "services.conf")
+	if err := os.WriteFile(path, []byte("[local]\nhost=localhost\n\n[broken]\ndbname=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{"PGSERVICEFILE": path, "PGHOST": "", "HOME": tmpDir})
+
+	m := &Model{
+		service:  "local",
+		db:       qdb.db,
+		queries:  []Query{{Name: "One", SQL: "SELECT 1 AS one"}},
+		ready:    true,
+		width:    100,
+		height:   30,
+		viewport: viewport.New(100, 30),
+	}
+	m.handleFleetRun()
+	if m.fleetRun == nil || !m.fleetRun.Confirming {
+		t.Fatal("F should ask before running anywhere")
+	}
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	for cmd != nil {
+		msg, ok := cmd().(fleetResultMsg)
+		if !ok {
+			t.Fatal("expected a fleet result")
+		}
+		_, cmd = m.handleFleetResult(msg)
+	}
+
+	run := m.fleetRun
+	if run.running() || len(run.Rows) != 1 || run.Rows[0][0] != "local" || run.Errors["broken"] == "" {
+		t.Fatalf("run = %+v, want local's row and broken's error", run)
+	}
+	out := RenderFleetRun(run, "")
+	if !strings.Contains(out, "1 of 2 services answered (failed: broken)") || !strings.Contains(out, "broken") {
+		t.Errorf("render should list both services:\n%s", out)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.fleetRun != nil {
+		t.Error("esc should close the view")
+	}
+}
+
+func TestFleetQueryKeepsOwnTunnel(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "services.conf")
+	if err := os.WriteFile(path, []byte("[local]\ndbname=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{"PGSERVICEFILE": path, "PGHOST": "", "HOME": tmpDir})
+
+	// psq's own connection is reconnecting through this tunnel while the fleet runs
+	own := &sshTunnel{done: make(chan struct{})}
+	tunnels.Lock()
+	tunnels.open["local"] = own
+	tunnels.Unlock()
+	defer func() {
+		tunnels.Lock()
+		delete(tunnels.open, "local")
+		tunnels.Unlock()
+	}()
+
+	run := &FleetRun{SQL: "SELECT 1", Errors: map[string]string{}}
+	fleetQuery(context.Background(), run, "local", nil, true, 10)()
+
+	tunnels.Lock()
+	defer tunnels.Unlock()
+	if tunnels.open["local"] != own {
+		t.Error("the fleet run closed the tunnel psq's own connection uses")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// footerHeight is the number of lines View reserves below the viewport for the footer
+const footerHeight = 1
+
+// footerHint is a key and what it does, worded short enough for the footer
+type footerHint struct {
+	key  string
+	desc string
+}
+
+// hint pairs a binding's help key with a short description
+func hint(b key.Binding, desc string) footerHint {
+	return footerHint{b.Help().Key, desc}
+}
+
+// confirmHints are shown whenever a y/n question is pending
+var confirmHints = []footerHint{hint(keys.Confirm, "yes"), hint(keys.Decline, "no")}
+
+// footerHints returns the keys most relevant to what's on screen, most useful first
+func (m *Model) footerHints() []footerHint {
+	switch {
+	case m.showHelp:
+		return []footerHint{hint(keys.Help, "close help"), hint(keys.Up, "scroll")}
+	case m.editMode:
+		if m.confirmDiscard || m.confirmPurge || m.confirmAIWrite != "" {
+			return confirmHints
+		}
+		return []footerHint{hint(keys.Save, "save"), hint(keys.NextField, "next field"), hint(keys.Generate, "generate"), hint(keys.EditSQL, "$EDITOR"),
+			hint(keys.Undo, "undo"), hint(keys.Discard, "cancel"), hint(keys.Delete, "archive"), hint(keys.Purge, "delete")}
+	case m.searchMode:
+		return []footerHint{{"↑/↓", "navigate"}, {"enter", "select"}, {"tab", "sort"}, {"ctrl+f", "names only"}, {"esc", "cancel"}}
+	case m.columnPicker != nil:
+		return []footerHint{{"↑/↓", "select"}, {"space", "show/hide"}, {"a", "show all"}, {"d", "diff key"}, {"enter", "done"}}
+	case m.importView != nil, m.archiveView != nil:
+		return []footerHint{{"↑/↓", "select"}, {"esc", "close"}}
+	case m.aiAnswer != nil && !m.aiAnswer.Done:
+		return []footerHint{{"esc", "stop"}}
+	case m.aiAnswer != nil:
+		return []footerHint{{"↑/↓", "scroll"}, {"esc", "close"}}
+	case m.listenView != nil && m.listenView.Entering:
+		return []footerHint{{"enter", "listen"}, {"esc", "cancel"}}
+	case m.listenView != nil:
+		return []footerHint{{"↑/↓", "scroll"}, {"a", "add channel"}, {"x", "clear"}, {"esc", "stop"}}
+	case m.fleetRun != nil && m.fleetRun.Confirming:
+		return confirmHints
+	case m.fleetRun != nil && m.fleetRun.running():
+		return []footerHint{{"esc", "stop"}}
+	case m.fleetRun != nil:
+		return []footerHint{{"↑/↓", "scroll"}, {"esc", "close"}}
+	case m.schemaBrowser != nil && m.schemaBrowser.Level == schemaLevelSchemas:
+		return []footerHint{{"↑/↓", "select"}, {"enter", "open"}, {"esc", "close"}}
+	case m.schemaBrowser != nil && m.schemaBrowser.Level == schemaLevelTable:
+		return []footerHint{{"↑/↓", "scroll"}, {"s", "SELECT query"}, {"esc", "back"}}
+	case m.schemaBrowser != nil:
+		return []footerHint{{"↑/↓", "select"}, {"enter", "open"}, {"s", "SELECT query"}, {"esc", "back"}}
+	case m.confirmReset:
+		return confirmHints
+	case m.psqlMode:
+		return []footerHint{{"enter", "go"}, {"tab", "sandbox"}, {"esc", "cancel"}}
+	case m.gotoMode, m.dumpMode:
+		return []footerHint{{"enter", "go"}, {"esc", "cancel"}}
+	}
+
+	if m.activeView != nil && m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) {
+		switch m.activeView.Mode {
+		case ActiveModeConfirmTerminate:
+			if m.activeView.typedConfirm() {
+				return []footerHint{{terminateConfirmWord + " enter", "terminate"}, {"esc", "back"}}
+			}
+			return confirmHints
+		case ActiveModeConfirmKill:
+			if m.activeView.killAction() == "terminate" {
+				return []footerHint{{terminateConfirmWord + " enter", "terminate all"}, {"esc", "back"}}
+			}
+			return confirmHints
+		case ActiveModeKillPattern:
+			return []footerHint{{"enter", "find matches"}, {"esc", "cancel"}}
+		case ActiveModeDetail:
+			return []footerHint{hint(keys.Terminate, "terminate"), hint(keys.CancelBackend, "cancel query"),
+				hint(keys.CopyQuery, "copy query"), hint(keys.Back, "back")}
+		default:
+			return []footerHint{{"↑/↓", "select"}, hint(keys.ActiveDetails, "details"), hint(keys.Terminate, "terminate"),
+				hint(keys.CancelBackend, "cancel query"), hint(keys.ShowIdle, "idle"), hint(keys.ShowWorkers, "workers"), hint(keys.ActiveScope, "this db"), {"←/→", "tabs"}, hint(keys.Help, "help"), {"esc", "quit"}}
+		}
+	}
+
+	return []footerHint{hint(keys.Refresh, "run"), {"←/→", "tabs"}, hint(keys.Search, "search"), hint(keys.Edit, "edit"),
+		hint(keys.New, "new"), hint(keys.Columns, "columns"), hint(keys.Psql, "psql"), hint(keys.Help, "help"), {"esc", "quit"}}
+}
+
+// renderFooter lays out hints on one line, leaving off the ones that don't fit in width
+func renderFooter(hints []footerHint, width int) string {
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Primary)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Dim)
+
+	var parts []string
+	used := 0
+	for _, h := range hints {
+		w := len([]rune(h.key)) + 1 + len(h.desc)
+		if len(parts) > 0 {
+			w += 2
+		}
+		if used+w+1 > width {
+			break
+		}
+		used += w
+		parts = append(parts, keyStyle.Render(h.key)+" "+descStyle.Render(h.desc))
+	}
+	return " " + strings.Join(parts, "  ")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestFooterHints(t *testing.T) {
+	activeTab := []Query{{Name: "Active"}}
+
+	tests := []struct {
+		name  string
+		model *Model
+		want  string
+	}{
+		{"normal", &Model{queries: []Query{{Name: "Locks"}}}, "s search"},
+		{"help", &Model{showHelp: true}, "? close help"},
+		{"edit", &Model{editMode: true}, "ctrl+s save"},
+		{"edit discard", &Model{editMode: true, confirmDiscard: true}, "y yes"},
+		{"search", &Model{searchMode: true}, "enter select"},
+		{"stats reset", &Model{confirmReset: true}, "n/esc no"},
+		{"active list", &Model{queries: activeTab, activeView: NewActiveView()}, "enter details"},
+		{"active detail", &Model{queries: activeTab, activeView: &ActiveView{Mode: ActiveModeDetail}}, "y copy query"},
+		{"active confirm cancel", &Model{queries: activeTab, activeView: &ActiveView{Mode: ActiveModeConfirmTerminate, TerminateType: "cancel"}}, "y yes"},
+		{"active confirm terminate", &Model{queries: activeTab, activeView: &ActiveView{Mode: ActiveModeConfirmTerminate, TerminateType: "terminate"}}, "yes enter terminate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parts []string
+			for _, h := range tt.model.footerHints() {
+				parts = append(parts, h.key+" "+h.desc)
+			}
+			if got := strings.Join(parts, ", "); !strings.Contains(got, tt.want) {
+				t.Errorf("footerHints() = %q, want it to include %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderFooter(t *testing.T) {
+	hints := []footerHint{{"a", "first"}, {"b", "second"}, {"c", "third"}}
+
+	if got := renderFooter(hints, 80); !strings.Contains(got, "third") {
+		t.Errorf("all hints should fit in 80 columns: %q", got)
+	}
+
+	got := renderFooter(hints, 18)
+	if !strings.Contains(got, "second") || strings.Contains(got, "third") {
+		t.Errorf("expected hints past the width to be left off: %q", got)
+	}
+	if w := lipgloss.Width(got); w > 18 {
+		t.Errorf("footer is %d wide, want at most 18", w)
+	}
+}
+
+func TestEditorFitsAboveFooter(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{queries: []Query{{Name: "Locks", SQL: "SELECT 1"}}, tempQueries: map[string]int{}, ready: true}
+	m.handleWindowSizeMsg(tea.WindowSizeMsg{Width: 120, Height: 50})
+	m.editMode = true
+	m.editQuery = m.queries[0]
+	m.initEditor(m.editQuery)
+	m.updateContent()
+
+	// Every field down to cache TTL shows without scrolling, with the footer still on screen
+	if total, h := m.viewport.TotalLineCount(), m.viewport.Height; total > h {
+		t.Errorf("editor is %d lines in a %d line viewport", total, h)
+	}
+	if view := m.View(); lipgloss.Height(view) != 50 || !strings.Contains(view, "ctrl+s save") {
+		t.Errorf("view is %d lines, want 50 with the footer:\n%s", lipgloss.Height(view), view)
+	}
+}
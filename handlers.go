@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
 )
 
@@ -21,10 +25,15 @@ type terminateResultMsg struct {
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{m.spinner.Tick, scheduleTPSSample(), scheduleConnUsageSample()}
+	if m.connecting {
+		cmds = append(cmds, m.scheduleStartupConnect())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	logMsg(msg)
 	switch msg := msg.(type) {
 	case tea.MouseMsg:
 		return m.handleMouseMsg(msg)
@@ -32,14 +41,75 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleKeyMsg(msg)
 	case tea.WindowSizeMsg:
 		return m.handleWindowSizeMsg(msg)
+	case queryDoneMsg:
+		if msg.gen != m.queryGen {
+			// Superseded by a newer run, whose own message is still to come
+			return m, nil
+		}
+		m.queryCancel = nil
+		return m.Update(msg.msg)
 	case queryResultMsg:
 		return m.handleQueryResult(msg)
 	case queryErrorMsg:
 		return m.handleQueryError(msg)
+	case connectionLostMsg:
+		return m.handleConnectionLost(msg)
+	case reconnectMsg:
+		if !m.reconnecting {
+			return m, nil
+		}
+		return m, m.attemptReconnect()
+	case reconnectResultMsg:
+		return m.handleReconnectResult(msg)
+	case startupConnectMsg:
+		if !m.connecting {
+			return m, nil
+		}
+		return m, m.attemptStartupConnect()
+	case startupConnectResultMsg:
+		return m.handleStartupConnectResult(msg)
+	case queryCancelledMsg:
+		// The cancel key already restored the previous result; superseded queries need nothing
+		return m, nil
+	case bulkBackendMsg:
+		return m.handleBulkActionResult(msg)
 	case terminateResultMsg:
 		return m.handleTerminateResult(msg)
+	case statsResetMsg:
+		return m.handleStatsResetResult(msg)
+	case chatgptPartialMsg:
+		return m.handleChatGPTPartial(msg)
+	case chatgptResponseMsg:
+		return m.handleChatGPTResponse(msg)
+	case aiPlanMsg:
+		return m.handleAIPlan(msg)
+	case aiAnswerPartialMsg:
+		return m.handleAIAnswerPartial(msg)
+	case aiAnswerMsg:
+		return m.handleAIAnswer(msg)
+	case diagnoseSummaryMsg:
+		return m.handleDiagnoseSummary(msg)
+	case listenStartedMsg:
+		return m.handleListenStarted(msg)
+	case listenNotifyMsg:
+		return m.handleListenNotify(msg)
+	case listenClosedMsg:
+		return m, nil
+	case fleetResultMsg:
+		return m.handleFleetResult(msg)
+	case schemaLoadedMsg:
+		return m.handleSchemaLoaded(msg)
 	case clipboardResultMsg:
-		if m.activeView != nil {
+		if msg.note != "" {
+			var cmd tea.Cmd
+			if msg.err != nil {
+				m.resultNote = fmt.Sprintf("Copy failed: %v", msg.err)
+			} else {
+				cmd = m.setStatus(msg.note)
+			}
+			m.updateContent()
+			return m, cmd
+		} else if m.activeView != nil {
 			if msg.err != nil {
 				m.activeView.CopyStatus = fmt.Sprintf("Copy failed: %v", msg.err)
 			} else {
@@ -50,6 +120,25 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case tickMsg:
 		return m.handleTickMsg()
+	case externalEditMsg:
+		return m.handleExternalEdit(msg)
+	case statusExpiredMsg:
+		return m.handleStatusExpired(msg)
+	case tpsTickMsg:
+		return m.handleTPSTick()
+	case tpsSampleMsg:
+		return m.handleTPSSample(msg)
+	case connUsageTickMsg:
+		return m.handleConnUsageTick()
+	case connUsageMsg:
+		return m.handleConnUsage(msg)
+	case bellMsg:
+		ringBell()
+		return m, nil
+	case alertFlashMsg:
+		return m.handleAlertFlash()
+	case spinner.TickMsg:
+		return m.handleSpinnerTick(msg)
 	case returnToPickerMsg:
 		m.Close()
 		return m, tea.Quit
@@ -64,26 +153,49 @@ func (m *Model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Mouse wheel scrolls the results, or moves the selection in the Active list
+	if msg.Action == tea.MouseActionPress && (msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown) {
+		return m.handleMouseWheel(msg.Button == tea.MouseButtonWheelUp)
+	}
+
+	// Right-clicking an Active row asks to terminate it
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonRight {
+		m.handleActiveClick(msg, true)
+		return m, nil
+	}
+
 	// Only handle left mouse button release (clicks)
 	if msg.Action != tea.MouseActionRelease || msg.Button != tea.MouseButtonLeft {
 		return m, nil
 	}
 
+	// Tab strip scroll affordances shift the visible tabs without changing the selection
+	if zone.Get("tabs_prev").InBounds(msg) {
+		if m.tabOffset > 0 {
+			m.tabOffset--
+			m.updateContent()
+		}
+		return m, nil
+	}
+	if zone.Get("tabs_next").InBounds(msg) {
+		if m.tabOffset < len(m.queries)-1 {
+			m.tabOffset++
+			m.updateContent()
+		}
+		return m, nil
+	}
+
+	if m.handleActiveClick(msg, false) {
+		return m, nil
+	}
+
 	// Check if any query zone was clicked
 	for i := range m.queries {
 		zoneID := fmt.Sprintf("query_%d", i)
 		if zone.Get(zoneID).InBounds(msg) {
 			// Query was clicked, select it and run it
 			if i < len(m.queries) {
-				m.selected = i
-				m.ensureValidSelection()
-				m.syncActiveView()
-				m.loading = true
-				m.err = ""
-				m.results = ""
-				m.lastQuery = m.queries[m.selected]
-				m.updateContent()
-				return m, m.runQuery(m.queries[m.selected])
+				return m.selectTab(i)
 			}
 		}
 	}
@@ -91,6 +203,67 @@ func (m *Model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleActiveClick selects the clicked row of the Active list; clicking the selected row
+// again (or double-clicking) opens its details. Clicking a row's [x], or any click on the row
+// when terminate is set (right-clicks), asks to terminate it; clicking its [c] asks to cancel
+// its query. Returns false if no row was clicked.
+func (m *Model) handleActiveClick(msg tea.MouseMsg, terminate bool) bool {
+	av := m.activeView
+	if m.showHelp || av == nil || av.Mode != ActiveModeList ||
+		m.selected >= len(m.queries) || !IsActiveTab(m.queries[m.selected].Name) {
+		return false
+	}
+	end := min(av.ScrollOffset+av.pageSize(m.height), len(av.Processes))
+	for i := av.ScrollOffset; i < end; i++ {
+		kill := zone.Get(activeKillZone(i)).InBounds(msg)
+		cancel := zone.Get(activeCancelZone(i)).InBounds(msg)
+		if !kill && !cancel && !zone.Get(activeRowZone(i)).InBounds(msg) {
+			continue
+		}
+		switch {
+		case kill || terminate:
+			av.Select(i)
+			av.ConfirmAction("terminate")
+		case cancel:
+			av.Select(i)
+			av.ConfirmAction("cancel")
+		case !av.Select(i):
+			av.OpenDetail()
+		}
+		m.updateContent()
+		return true
+	}
+	return false
+}
+
+// mouseWheelLines is how far one wheel notch scrolls the results viewport
+const mouseWheelLines = 3
+
+func (m *Model) handleMouseWheel(up bool) (tea.Model, tea.Cmd) {
+	if m.confirmReset {
+		return m, nil
+	}
+
+	if !m.showHelp && m.activeView != nil && m.activeView.Mode == ActiveModeList &&
+		m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) {
+		delta := 1
+		if up {
+			delta = -1
+		}
+		if m.activeView.MoveSelection(delta) {
+			m.updateContent()
+		}
+		return m, nil
+	}
+
+	if up {
+		m.viewport.ScrollUp(mouseWheelLines)
+	} else {
+		m.viewport.ScrollDown(mouseWheelLines)
+	}
+	return m, nil
+}
+
 func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if !m.ready {
 		return m, nil
@@ -106,6 +279,61 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleSearchModeKeys(msg)
 	}
 
+	// Handle interactive query import
+	if m.importView != nil {
+		return m.handleImportKeys(msg)
+	}
+
+	// Handle the archived queries browser
+	if m.archiveView != nil {
+		return m.handleArchiveKeys(msg)
+	}
+
+	// Handle the AI answer view
+	if m.aiAnswer != nil {
+		return m.handleAIAnswerKeys(msg)
+	}
+
+	// Handle the LISTEN/NOTIFY view
+	if m.listenView != nil {
+		return m.handleListenKeys(msg)
+	}
+
+	// Handle a query running on every service
+	if m.fleetRun != nil {
+		return m.handleFleetKeys(msg)
+	}
+
+	// Handle the schema browser
+	if m.schemaBrowser != nil {
+		return m.handleSchemaBrowserKeys(msg)
+	}
+
+	// Handle the result column picker
+	if m.columnPicker != nil {
+		return m.handleColumnPickerKeys(msg)
+	}
+
+	// Handle g<number> tab jump
+	if m.gotoMode {
+		return m.handleGotoKeys(msg)
+	}
+
+	// Handle the psql user/database override prompt
+	if m.psqlMode {
+		return m.handlePsqlOverrideKeys(msg)
+	}
+
+	// Handle the dump file name prompt
+	if m.dumpMode {
+		return m.handleDumpPromptKeys(msg)
+	}
+
+	// Handle pending pg_stat_statements reset confirmation
+	if m.confirmReset {
+		return m.handleStatsResetConfirmKeys(msg)
+	}
+
 	// Handle help mode escape
 	if m.showHelp && (msg.Type == tea.KeyEscape || msg.String() == "escape" || msg.String() == "esc" || msg.String() == "ctrl+[") {
 		m.showHelp = false
@@ -152,13 +380,24 @@ func (m *Model) handleSearchModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					break
 				}
 			}
+			m.ensureValidSelection()
 			m.loading = true
 			m.err = ""
-			m.results = ""
+			m.showCachedResult(selectedQuery.Name)
 			m.lastQuery = selectedQuery
 			m.updateContent()
 			return m, m.runQuery(selectedQuery)
 		}
+	case "tab":
+		m.searchByRecent = !m.searchByRecent
+		m.selected = 0
+		m.filterQueries()
+		m.updateContent()
+	case "ctrl+f":
+		m.searchNamesOnly = !m.searchNamesOnly
+		m.selected = 0
+		m.filterQueries()
+		m.updateContent()
 	case "up", "ctrl+k":
 		if m.selected > 0 {
 			m.selected--
@@ -186,21 +425,21 @@ func (m *Model) handleSearchModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Delegate to active view when on the Active tab
-	if m.activeView != nil && m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) {
+	// Delegate to active view when on the Active tab (help stays reachable from the detail view)
+	if !m.showHelp && m.activeView != nil && m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) &&
+		!(m.activeView.Mode == ActiveModeDetail && key.Matches(msg, keys.Help)) {
 		// In detail or confirm mode, fully delegate all keys
 		if m.activeView.Mode != ActiveModeList {
 			return m.handleActiveViewKeys(msg)
 		}
 		// In list mode, delegate navigation/action keys but let tab-switch keys fall through
-		switch msg.String() {
-		case "up", "k", "down", "j", "enter", "t", "c":
+		if key.Matches(msg, keys.ActiveUp, keys.ActiveDown, keys.ActiveDetails, keys.Terminate, keys.CancelBackend, keys.KillMatching, keys.CancelUser, keys.ShowIdle, keys.ShowWorkers, keys.ActiveScope) {
 			return m.handleActiveViewKeys(msg)
 		}
 	}
 
-	switch msg.String() {
-	case "?":
+	switch {
+	case key.Matches(msg, keys.Help):
 		if !m.showHelp {
 			m.previousSelected = m.selected
 		} else {
@@ -213,66 +452,60 @@ func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.showHelp = !m.showHelp
 		m.updateContent()
 		return m, nil
-	case "esc", "ctrl+c":
+	case key.Matches(msg, keys.Quit):
 		m.Close()
 		return m, tea.Quit
-	case "c":
+	case key.Matches(msg, keys.Picker):
 		return m, func() tea.Msg {
 			return returnToPickerMsg{}
 		}
 
-	case "s":
+	case key.Matches(msg, keys.Reload):
+		return m.handleReload()
+
+	case key.Matches(msg, keys.Search):
 		m.previousSelected = m.selected
 		m.searchMode = true
 		m.searchQuery = ""
-		m.filteredQueries = m.allQueries // Use all queries for search
+		m.filterQueries() // All queries, grouped by tag
 		m.selected = 0
 		m.updateContent()
 		return m, nil
 
 	// Query selection
-	case "left", "h":
+	case key.Matches(msg, keys.PrevTab):
 		if m.selected > 0 {
-			m.selected--
-			m.ensureValidSelection()
-			m.syncActiveView()
-			if len(m.queries) > 0 {
-				m.loading = true
-				m.err = ""
-				m.results = ""
-				m.lastQuery = m.queries[m.selected]
-				// Update display immediately
-				return m, m.runQuery(m.queries[m.selected])
-			}
+			return m.selectTab(m.selected - 1)
 		}
-	case "right", "l":
+	case key.Matches(msg, keys.NextTab):
 		if m.selected < len(m.queries)-1 {
-			m.selected++
-			m.ensureValidSelection()
-			m.syncActiveView()
-			if len(m.queries) > 0 {
-				m.loading = true
-				m.err = ""
-				m.results = ""
-				m.lastQuery = m.queries[m.selected]
-				// Update display immediately
-				m.updateContent()
-				return m, m.runQuery(m.queries[m.selected])
-			}
+			return m.selectTab(m.selected + 1)
+		}
+	case key.Matches(msg, keys.JumpTab):
+		if n := int(msg.String()[0] - '0'); n <= len(m.queries) {
+			return m.selectTab(n - 1)
 		}
+	case key.Matches(msg, keys.GotoTab):
+		// g followed by digits and enter jumps to tabs beyond 9
+		m.gotoMode = true
+		m.gotoInput = ""
 
 	// Results viewport scrolling
-	case "up", "k":
+	case key.Matches(msg, keys.Up):
 		m.viewport.ScrollUp(1)
-	case "down", "j":
+	case key.Matches(msg, keys.Down):
 		m.viewport.ScrollDown(1)
-	case "home":
+	case key.Matches(msg, keys.PageUp):
+		m.viewport.PageUp()
+	case key.Matches(msg, keys.PageDown):
+		m.viewport.PageDown()
+	case key.Matches(msg, keys.Top):
 		m.viewport.GotoTop()
-	case "end":
+	case key.Matches(msg, keys.Bottom):
 		m.viewport.GotoBottom()
 
 	// Query execution
-	case "enter", " ", "r":
+	case key.Matches(msg, keys.Refresh):
 		if len(m.queries) > 0 && m.canRefresh() {
 			m.ensureValidSelection()
 			m.loading = true
@@ -280,7 +513,7 @@ func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.lastQuery = m.queries[m.selected]
 			return m, m.runQuery(m.queries[m.selected])
 		}
-	case "e":
+	case key.Matches(msg, keys.Edit):
 		if len(m.queries) > 0 {
 			m.ensureValidSelection()
 			// Don't allow editing the hardcoded Home or Active tabs
@@ -294,7 +527,7 @@ func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.updateContent()
 			return m, nil
 		}
-	case "n":
+	case key.Matches(msg, keys.New):
 		// Create new query
 		m.previousSelected = m.selected
 		m.editMode = true
@@ -302,8 +535,78 @@ func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.initEditor(m.editQuery)
 		m.updateContent()
 		return m, nil
-	case "x":
-		return m.handlePsqlPrompt()
+	case key.Matches(msg, keys.Cancel):
+		// Abort a slow query; auto-refresh carries on from the next tick
+		if m.loading && m.cancelQuery() {
+			m.loading = false
+			m.resultNote = "Query cancelled"
+			m.updateContent()
+			return m, tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+				return tickMsg(t)
+			})
+		}
+	case key.Matches(msg, keys.Explain):
+		return m.handleExplainQuery()
+	case key.Matches(msg, keys.Diagnose):
+		return m.handleDiagnose()
+	case key.Matches(msg, keys.Listen):
+		return m.handleListen()
+	case key.Matches(msg, keys.Fleet):
+		return m.handleFleetRun()
+	case key.Matches(msg, keys.Schema):
+		return m.handleSchemaBrowser()
+	case key.Matches(msg, keys.Psql):
+		return m.handleOpenPsqlOverride()
+	case key.Matches(msg, keys.CopyPsql):
+		return m.handleCopyPsqlCommand()
+	case key.Matches(msg, keys.CopySQL):
+		return m.handleCopySQL()
+	case key.Matches(msg, keys.HumanBytes):
+		m.tableOpts.HumanBytes = !m.tableOpts.HumanBytes
+		return m.rerenderResults()
+	case key.Matches(msg, keys.Wrap):
+		m.tableOpts.Wrap = !m.tableOpts.Wrap
+		return m.rerenderResults()
+	case key.Matches(msg, keys.Diff):
+		m.tableOpts.Diff = !m.tableOpts.Diff
+		// Home and Active aren't result tables, so there's nothing on them to diff
+		if m.tableOpts.Diff && m.selected < len(m.queries) && (IsHomeTab(m.queries[m.selected].Name) || IsActiveTab(m.queries[m.selected].Name)) {
+			cmd := m.setStatus("Diff mode is on, but not available on this tab")
+			m.updateContent()
+			return m, cmd
+		}
+		return m.rerenderResults()
+	case key.Matches(msg, keys.Density):
+		return m.handleToggleDensity()
+	case key.Matches(msg, keys.Pin):
+		return m.handleTogglePin()
+	case key.Matches(msg, keys.Dismiss):
+		return m.handleDismissConnWarning()
+	case key.Matches(msg, keys.Dump):
+		return m.handleOpenDumpPrompt()
+	case key.Matches(msg, keys.Import):
+		m.importView = NewImportView(configDir())
+		m.updateContent()
+		return m, nil
+	case key.Matches(msg, keys.Archived):
+		m.archiveView = NewArchiveView()
+		m.updateContent()
+		return m, nil
+	case key.Matches(msg, keys.Columns):
+		// Only plain table results have columns to choose from, and only once this tab's result is in
+		if len(m.resultColumns) > 0 && m.err == "" && m.lastQuery.Name == m.queries[m.selected].Name {
+			m.columnPicker = NewColumnPicker(m.queries[m.selected], m.resultColumns)
+			m.columnPicker.DiffKey = m.diffKeys[m.queries[m.selected].Name]
+		}
+	case key.Matches(msg, keys.ResetStats):
+		if len(m.queries) > 0 && usesPgStatStatements(m.queries[m.selected].SQL) {
+			if m.readOnly() {
+				m.err = "Resetting pg_stat_statements is disabled in read-only mode"
+			} else {
+				m.err = ""
+				m.confirmReset = true
+			}
+		}
 	}
 
 	// Update content after any key press
@@ -313,86 +616,692 @@ func (m *Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *Model) handlePsqlPrompt() (tea.Model, tea.Cmd) {
-	// Open psql prompt for current service
+// selectTab switches to the tab at index i and runs its query
+func (m *Model) selectTab(i int) (tea.Model, tea.Cmd) {
+	m.selected = i
+	m.ensureValidSelection()
+	m.syncActiveView()
+	if len(m.queries) == 0 {
+		m.updateContent()
+		return m, nil
+	}
+	m.loading = true
+	m.err = ""
+	m.showCachedResult(m.queries[m.selected].Name)
+	m.lastQuery = m.queries[m.selected]
+	// Update display immediately
+	m.updateContent()
+	return m, m.runQuery(m.queries[m.selected])
+}
+
+// showCachedResult shows the last result the named tab produced while its next run is
+// in flight, rather than a blank results area, or clears the results if it has none
+func (m *Model) showCachedResult(name string) {
+	cached, ok := m.resultCache[name]
+	if !ok {
+		m.results, m.resultColumns, m.resultsStale = "", nil, false
+		return
+	}
+	m.results, m.resultColumns, m.resultsStale = cached.Output, cached.Columns, true
+	m.lastRefreshAt = cached.at
+}
+
+// rerenderResults re-runs the current query so changed table display options take effect,
+// since results are formatted while they are rendered
+func (m *Model) rerenderResults() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	m.loading = true
+	m.err = ""
+	m.lastQuery = m.queries[m.selected]
+	return m, m.runQuery(m.queries[m.selected])
+}
+
+// handleGotoKeys collects the tab number typed after g; enter jumps, anything else cancels
+func (m *Model) handleGotoKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	switch {
+	case len(key) == 1 && key[0] >= '0' && key[0] <= '9':
+		m.gotoInput += key
+		m.updateContent()
+		return m, nil
+	case key == "backspace" && len(m.gotoInput) > 0:
+		m.gotoInput = m.gotoInput[:len(m.gotoInput)-1]
+		m.updateContent()
+		return m, nil
+	case key == "enter":
+		m.gotoMode = false
+		var n int
+		if _, err := fmt.Sscanf(m.gotoInput, "%d", &n); err == nil && n >= 1 && n <= len(m.queries) {
+			return m.selectTab(n - 1)
+		}
+	default:
+		m.gotoMode = false
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handleTogglePin promotes a temporary tab to a saved tab, or hides a saved tab from the tab bar.
+// An unpinned tab stays open as a temporary tab until the next restart.
+func (m *Model) handleTogglePin() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 || globalQueryDB == nil {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	current := m.queries[m.selected]
+	if IsHomeTab(current.Name) || IsActiveTab(current.Name) {
+		return m, nil
+	}
+
+	query := current
+	if m.isTemporaryQuery(current.Name) {
+		pos := m.nextPinnedOrder()
+		query.OrderPosition = &pos
+	} else {
+		query.OrderPosition = nil
+	}
+
+	if err := globalQueryDB.SaveQuery(query); err != nil {
+		m.err = fmt.Sprintf("Failed to save query: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+
+	if query.OrderPosition != nil {
+		delete(m.tempQueries, query.Name)
+	} else {
+		m.tempQueries[query.Name] = m.getNextTempOrder()
+	}
+
+	if err := m.reloadQueries(); err != nil {
+		m.err = fmt.Sprintf("Failed to reload queries: %v", err)
+	}
+	status := "Pinned " + query.Name
+	if query.OrderPosition == nil {
+		status = "Unpinned " + query.Name
+	}
+	cmd := m.setStatus(status)
+	m.selectQueryByName(query.Name)
+	m.updateContent()
+	return m, cmd
+}
+
+// handleOpenDumpPrompt asks where to dump the saved queries, suggesting a timestamped name
+func (m *Model) handleOpenDumpPrompt() (tea.Model, tea.Cmd) {
+	if globalQueryDB == nil {
+		return m, nil
+	}
+	m.dumpMode = true
+	m.dumpInput = dumpFileName(time.Now())
+	m.dumpOverwrite = false
+	m.updateContent()
+	return m, nil
+}
+
+// handleDumpPromptKeys edits the dump file name and dumps on enter. An existing file is
+// only replaced after a second enter.
+func (m *Model) handleDumpPromptKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyRunes:
+		m.dumpInput += string(msg.Runes)
+		m.dumpOverwrite = false
+	case tea.KeyBackspace:
+		if r := []rune(m.dumpInput); len(r) > 0 {
+			m.dumpInput = string(r[:len(r)-1])
+		}
+		m.dumpOverwrite = false
+	case tea.KeyEnter:
+		if strings.TrimSpace(m.dumpInput) == "" {
+			break
+		}
+		path := resolveDumpPath(m.dumpInput)
+		if _, err := os.Stat(path); err == nil && !m.dumpOverwrite {
+			m.dumpOverwrite = true
+			break
+		}
+		m.dumpMode = false
+		return m.handleDumpQueries(path)
+	case tea.KeySpace:
+		m.dumpInput += " "
+		m.dumpOverwrite = false
+	case tea.KeyEscape:
+		m.dumpMode = false
+	default:
+		if msg.String() == "ctrl+[" {
+			m.dumpMode = false
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handleDumpQueries writes all saved queries to path
+func (m *Model) handleDumpQueries(path string) (tea.Model, tea.Cmd) {
+	if globalQueryDB == nil {
+		return m, nil
+	}
+	count, err := globalQueryDB.DumpToFile(path)
+	if err != nil {
+		m.err = fmt.Sprintf("Failed to dump queries: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+	cmd := m.setStatus(fmt.Sprintf("Dumped %d queries to %s", count, path))
+	m.updateContent()
+	return m, cmd
+}
+
+// handleImportKeys handles picking a dump file and resolving name collisions
+func (m *Model) handleImportKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	iv := m.importView
+
+	switch iv.Stage {
+	case ImportStagePickFile:
+		switch msg.String() {
+		case "up", "k":
+			if iv.SelectedIndex > 0 {
+				iv.SelectedIndex--
+			}
+		case "down", "j":
+			if iv.SelectedIndex < len(iv.Files)-1 {
+				iv.SelectedIndex++
+			}
+		case "enter":
+			if iv.SelectedIndex < len(iv.Files) {
+				m.startImport(filepath.Join(iv.Dir, iv.Files[iv.SelectedIndex]))
+			}
+		case "esc", "ctrl+[", "q":
+			m.importView = nil
+		}
+
+	case ImportStageConflict:
+		switch msg.String() {
+		case "o":
+			m.importQueries(iv.Conflicts[:1])
+			iv.Conflicts = iv.Conflicts[1:]
+		case "s":
+			iv.Skipped++
+			iv.Conflicts = iv.Conflicts[1:]
+		case "a":
+			m.importQueries(iv.Conflicts)
+			iv.Conflicts = nil
+		case "esc", "ctrl+[":
+			iv.Skipped += len(iv.Conflicts)
+			iv.Conflicts = nil
+		}
+		if len(iv.Conflicts) == 0 {
+			m.finishImport()
+		}
+
+	case ImportStageDone:
+		m.importView = nil
+	}
+
+	m.updateContent()
+	return m, nil
+}
+
+// handleArchiveKeys handles browsing, restoring and permanently deleting archived queries
+func (m *Model) handleArchiveKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	av := m.archiveView
+
+	if av.ConfirmPurge {
+		av.ConfirmPurge = false
+		if msg.String() == "y" && av.SelectedIndex < len(av.Queries) {
+			name := av.Queries[av.SelectedIndex].Name
+			if err := globalQueryDB.DeleteQuery(name); err != nil {
+				av.LastError = fmt.Sprintf("Failed to delete %s: %v", name, err)
+			} else {
+				av.Message = fmt.Sprintf("Deleted %s for good", name)
+				av.load()
+			}
+		}
+		m.updateContent()
+		return m, nil
+	}
+
+	av.Message, av.LastError = "", ""
+	switch msg.String() {
+	case "up", "k":
+		if av.SelectedIndex > 0 {
+			av.SelectedIndex--
+		}
+	case "down", "j":
+		if av.SelectedIndex < len(av.Queries)-1 {
+			av.SelectedIndex++
+		}
+	case "enter", "r":
+		if av.SelectedIndex < len(av.Queries) {
+			name := av.Queries[av.SelectedIndex].Name
+			if err := globalQueryDB.RestoreQuery(name); err != nil {
+				av.LastError = fmt.Sprintf("Failed to restore %s: %v", name, err)
+			} else {
+				av.Message = fmt.Sprintf("Restored %s", name)
+				av.load()
+				if err := m.reloadQueries(); err != nil {
+					av.LastError = fmt.Sprintf("Failed to reload queries: %v", err)
+				}
+			}
+		}
+	case "p":
+		if av.SelectedIndex < len(av.Queries) {
+			av.ConfirmPurge = true
+		}
+	case "esc", "ctrl+[", "q":
+		m.archiveView = nil
+	}
+
+	m.updateContent()
+	return m, nil
+}
+
+// handleColumnPickerKeys moves through the column checklist and applies it when closed
+func (m *Model) handleColumnPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cp := m.columnPicker
+
+	switch msg.String() {
+	case "up", "k":
+		if cp.SelectedIndex > 0 {
+			cp.SelectedIndex--
+		}
+	case "down", "j":
+		if cp.SelectedIndex < len(cp.Columns)-1 {
+			cp.SelectedIndex++
+		}
+	case " ", "x":
+		cp.Toggle()
+	case "a":
+		cp.Hidden = map[string]bool{}
+	case "d":
+		if cp.SelectedIndex < len(cp.Columns) {
+			cp.DiffKey = cp.Columns[cp.SelectedIndex]
+		}
+	case "enter", "esc", "ctrl+[", "C", "q":
+		m.columnPicker = nil
+		if cp.DiffKey != "" {
+			if m.diffKeys == nil {
+				m.diffKeys = map[string]string{}
+			}
+			m.diffKeys[cp.Query] = cp.DiffKey
+		}
+		return m.setHiddenColumns(cp.Query, cp.HiddenColumns())
+	}
+
+	m.updateContent()
+	return m, nil
+}
+
+// setHiddenColumns saves a query's hidden columns and re-runs it when they changed
+func (m *Model) setHiddenColumns(name string, hidden []string) (tea.Model, tea.Cmd) {
+	if m.selected >= len(m.queries) || slices.Equal(m.queries[m.selected].HiddenColumns, hidden) {
+		m.updateContent()
+		return m, nil
+	}
+	if globalQueryDB != nil {
+		if err := globalQueryDB.SetHiddenColumns(name, hidden); err != nil {
+			m.err = fmt.Sprintf("Failed to save hidden columns: %v", err)
+			m.updateContent()
+			return m, nil
+		}
+	}
+	for _, list := range [][]Query{m.queries, m.allQueries} {
+		for i := range list {
+			if list[i].Name == name {
+				list[i].HiddenColumns = hidden
+			}
+		}
+	}
+	return m.rerenderResults()
+}
+
+// startImport saves non-conflicting queries from a dump file and queues name collisions for review
+func (m *Model) startImport(path string) {
+	iv := m.importView
+	if globalQueryDB == nil {
+		return
+	}
+
+	incoming, err := readQueriesFromPath(path)
+	if err != nil {
+		iv.LastError = err.Error()
+		return
+	}
+	existing, err := globalQueryDB.LoadAllQueries()
+	if err != nil {
+		iv.LastError = fmt.Sprintf("Failed to load queries: %v", err)
+		return
+	}
+	archived, err := globalQueryDB.LoadArchivedQueries()
+	if err != nil {
+		iv.LastError = fmt.Sprintf("Failed to load archived queries: %v", err)
+		return
+	}
+
+	toSave, conflicts, held, unchanged := planImport(existing, archived, incoming)
+	iv.LastError = ""
+	iv.Unchanged = unchanged
+	iv.Archived = held
+	iv.Skipped += len(held)
+	iv.Conflicts = conflicts
+	m.importQueries(toSave)
+
+	if len(conflicts) > 0 {
+		iv.Stage = ImportStageConflict
+		return
+	}
+	m.finishImport()
+}
+
+// importQueries saves queries into the live database, recording the first failure
+func (m *Model) importQueries(queries []Query) {
+	iv := m.importView
+	for _, q := range queries {
+		if err := globalQueryDB.SaveQuery(q); err != nil {
+			iv.LastError = fmt.Sprintf("Failed to import %s: %v", q.Name, err)
+			iv.Skipped++
+			continue
+		}
+		iv.Imported++
+	}
+}
+
+// handleReload re-reads the query database, e.g. after an import or an edit from another
+// terminal, staying on the selected tab when it still exists
+func (m *Model) handleReload() (tea.Model, tea.Cmd) {
+	if globalQueryDB == nil {
+		return m, nil
+	}
+	name := ""
+	if m.selected < len(m.queries) {
+		name = m.queries[m.selected].Name
+	}
+	if err := m.reloadQueries(); err != nil {
+		m.err = fmt.Sprintf("Failed to reload queries: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+	m.selectQueryByName(name)
+	m.syncActiveView()
+	m.err = ""
+	saved := 0
+	for _, q := range m.allQueries {
+		if !IsHomeTab(q.Name) && !IsActiveTab(q.Name) {
+			saved++
+		}
+	}
+	status := m.setStatus(fmt.Sprintf("Reloaded %d saved queries", saved))
+	if len(m.queries) == 0 {
+		m.updateContent()
+		return m, status
+	}
+	m.loading = true
+	m.lastQuery = m.queries[m.selected]
+	m.updateContent()
+	return m, tea.Batch(status, m.runQuery(m.lastQuery))
+}
+
+// finishImport reloads tabs so imported queries show up and displays the summary
+func (m *Model) finishImport() {
+	m.importView.Stage = ImportStageDone
+	if err := m.reloadQueries(); err != nil {
+		m.importView.LastError = fmt.Sprintf("Failed to reload queries: %v", err)
+	}
+}
+
+// handleToggleDensity switches between comfortable and compact tables and remembers the
+// choice in config.json for the next start
+func (m *Model) handleToggleDensity() (tea.Model, tea.Cmd) {
+	m.tableOpts.Compact = !m.tableOpts.Compact
+	density := densityComfortable
+	if m.tableOpts.Compact {
+		density = densityCompact
+	}
+	if m.config != nil {
+		m.config.Density = density
+	}
+	_, cmd := m.rerenderResults()
+	// After the rerender, which clears the error line
+	if err := saveConfigSetting("density", density); err != nil {
+		m.err = fmt.Sprintf("Failed to save density: %v", err)
+		m.updateContent()
+	}
+	return m, cmd
+}
+
+// handleOpenPsqlOverride asks which role and database psql should use before spawning it
+func (m *Model) handleOpenPsqlOverride() (tea.Model, tea.Cmd) {
 	config, err := getDBConfig(m.service)
 	if err != nil {
 		m.err = fmt.Sprintf("Failed to get DB config: %v", err)
 		m.updateContent()
 		return m, nil
 	}
+	m.psqlMode = true
+	m.psqlInput = ""
+	m.psqlSandbox = false
+	m.psqlDefault = config.User + "@" + config.Database
+	m.updateContent()
+	return m, nil
+}
 
-	// Build psql command with connection parameters
-	args := []string{
-		"-h", config.Host,
-		"-p", config.Port,
-		"-d", config.Database,
-		"-U", config.User,
+// handlePsqlOverrideKeys collects the [user@]dbname override and opens psql on enter;
+// tab toggles starting the session inside a transaction
+func (m *Model) handlePsqlOverrideKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyTab:
+		m.psqlSandbox = !m.psqlSandbox
+	case tea.KeyRunes:
+		m.psqlInput += string(msg.Runes)
+	case tea.KeyBackspace:
+		if r := []rune(m.psqlInput); len(r) > 0 {
+			m.psqlInput = string(r[:len(r)-1])
+		}
+	case tea.KeyEnter:
+		m.psqlMode = false
+		m.updateContent()
+		return m.handlePsqlPrompt(parsePsqlOverride(m.psqlInput))
+	case tea.KeySpace:
+		m.psqlInput += " "
+	case tea.KeyEscape:
+		m.psqlMode = false
+	default:
+		if msg.String() == "ctrl+[" {
+			m.psqlMode = false
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handlePsqlPrompt opens psql for the current service, optionally as another role or on
+// another database. Empty overrides keep the service's configured user and database. In
+// sandbox mode the session starts with BEGIN, so quitting without COMMIT rolls it back.
+func (m *Model) handlePsqlPrompt(user, database string) (tea.Model, tea.Cmd) {
+	config, err := getTunneledDBConfig(m.service)
+	if err != nil {
+		m.err = fmt.Sprintf("Failed to get DB config: %v", err)
+		m.updateContent()
+		return m, nil
 	}
+	config = withOverride(config, user, database)
 
-	cmd := exec.Command("psql", args...)
+	// Connect exactly as psq does, sslmode included
+	cmd := exec.Command("psql", psqlArgs(config, m.service, m.psqlSandbox)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	// Set PGPASSWORD environment variable
-	cmd.Env = append(os.Environ(), "PGPASSWORD="+config.Password)
+	// Pass the password through a private pgpass file; PGPASSWORD shows up in process listings
+	env := psqlEnv(os.Environ())
+	var pgpassPath string
+	if config.Password != "" {
+		pgpassPath, err = writeTempPgpass(config)
+		if err != nil {
+			m.err = err.Error()
+			m.updateContent()
+			return m, nil
+		}
+		env = append(env, "PGPASSFILE="+pgpassPath)
+	}
+	// A psqlrc that runs the user's own and then BEGINs; -c would exit instead of staying interactive
+	var psqlrcPath string
+	if m.psqlSandbox {
+		psqlrcPath, err = writeTempPsqlrc()
+		if err != nil {
+			if pgpassPath != "" {
+				os.Remove(pgpassPath)
+			}
+			m.err = err.Error()
+			m.updateContent()
+			return m, nil
+		}
+		env = append(env, "PSQLRC="+psqlrcPath)
+	}
+	cmd.Env = env
 
 	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if pgpassPath != "" {
+			os.Remove(pgpassPath)
+		}
+		if psqlrcPath != "" {
+			os.Remove(psqlrcPath)
+		}
 		if err != nil {
-			return queryErrorMsg(fmt.Sprintf("Failed to open psql: %v", err))
+			return queryErrorMsg(fmt.Sprintf("Failed to open psql: %v", redactPassword(err, config.Password)))
 		}
 		return nil
 	})
 }
 
+// handleCopyPsqlCommand copies a psql invocation for the current service, without the password.
+// A service behind a bastion gets the local end of psq's tunnel, which only works while psq runs.
+func (m *Model) handleCopyPsqlCommand() (tea.Model, tea.Cmd) {
+	config, err := getDBConfig(m.service)
+	if err != nil {
+		m.err = fmt.Sprintf("Failed to get DB config: %v", err)
+		m.updateContent()
+		return m, nil
+	}
+	service := m.service
+	return m, func() tea.Msg {
+		tunneled, err := throughTunnel(service, config)
+		if err != nil {
+			return clipboardResultMsg{err: err, note: "psql command"}
+		}
+		line := psqlCommandLine(tunneled)
+		note := "Copied " + line
+		if config.SSH != nil {
+			note += " (through psq's SSH tunnel; works while psq is running)"
+		}
+		return clipboardResultMsg{err: copyToClipboard(line), note: note}
+	}
+}
+
+// toggleActiveScope switches the Active tab between the whole instance and the database psq
+// is connected to
+func (m *Model) toggleActiveScope() {
+	if m.activeFilter.Database != "" {
+		m.activeFilter.Database = ""
+		return
+	}
+	if m.timeouts != nil && m.timeouts.Database != "" {
+		m.activeFilter.Database = m.timeouts.Database
+	} else if config, err := getDBConfig(m.service); err == nil {
+		m.activeFilter.Database = config.Database
+	}
+}
+
+// handleCopySQL copies the selected tab's SQL
+func (m *Model) handleCopySQL() (tea.Model, tea.Cmd) {
+	if len(m.queries) == 0 {
+		return m, nil
+	}
+	sql := m.selectedSQL()
+	return m, func() tea.Msg {
+		return clipboardResultMsg{err: copyToClipboard(sql), note: "Copied SQL"}
+	}
+}
+
+// selectedSQL is the SQL the selected tab runs. The Active tab has no saved SQL, so it's the
+// pg_stat_activity query behind the view, with the current filter.
+func (m *Model) selectedSQL() string {
+	m.ensureValidSelection()
+	query := m.queries[m.selected]
+	if !IsActiveTab(query.Name) {
+		return query.SQL
+	}
+	return activeProcessesSQL(m.activeFilter)
+}
+
 func (m *Model) handleWindowSizeMsg(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	m.width = msg.Width
 	m.height = msg.Height
 
 	if !m.ready {
-		m.viewport = viewport.New(msg.Width, msg.Height)
-		m.viewport.Style = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62"))
+		// updateContent sizes the viewport to the space the header leaves
+		m.viewport = viewport.New(msg.Width, msg.Height-footerHeight)
 		m.ready = true
 
-		// Execute first query immediately when ready
-		if len(m.queries) > 0 {
+		// Execute first query immediately when ready; while still connecting, the first
+		// successful connection runs it instead
+		if len(m.queries) > 0 && !m.connecting {
 			m.ensureValidSelection()
 			m.loading = true
 			m.lastQuery = m.queries[m.selected]
 			m.updateContent()
 			return m, m.runQuery(m.queries[m.selected])
 		}
-	} else {
-		m.viewport.Width = msg.Width
-		m.viewport.Height = msg.Height
 	}
+	if m.editMode {
+		m.resizeEditor()
+	}
+	// A narrower strip can push the selected tab out of view
+	m.scrollTabsToSelected()
 
 	m.updateContent()
 	return m, nil
 }
 
 func (m *Model) handleQueryResult(msg queryResultMsg) (tea.Model, tea.Cmd) {
-	m.results = string(msg)
+	m.results = msg.Output
+	m.resultColumns = msg.Columns
+	m.resultsStale = false
 	m.loading = false
 	m.lastRefreshAt = time.Now()
+	if m.resultCache == nil {
+		m.resultCache = map[string]cachedResult{}
+	}
+	m.resultCache[m.lastQuery.Name] = cachedResult{msg, m.lastRefreshAt}
 	m.updateContent()
-	return m, tea.Tick(1*time.Second, func(t time.Time) tea.Msg {
+	return m, tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
 func (m *Model) handleQueryError(msg queryErrorMsg) (tea.Model, tea.Cmd) {
+	// A failed run is the one case where the previous result goes away
 	m.err = string(msg)
+	m.results, m.resultColumns, m.resultsStale = "", nil, false
+	delete(m.resultCache, m.lastQuery.Name)
 	m.loading = false
 	m.updateContent()
 	return m, nil
 }
 
-
 func (m *Model) handleTickMsg() (tea.Model, tea.Cmd) {
+	// Tabs with auto refresh off wait for r; the next manual run restarts the tick
+	if m.lastQuery.NoAutoRefresh {
+		return m, nil
+	}
+	// The result on screen is still within the query's cache TTL; check again next tick
+	if m.cacheFresh(m.lastQuery, time.Now()) && !m.resultsStale && m.err == "" {
+		return m, tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+			return tickMsg(t)
+		})
+	}
 	if len(m.queries) > 0 && m.canRefresh() {
 		m.loading = true
 		m.updateContent()
@@ -401,6 +1310,18 @@ func (m *Model) handleTickMsg() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleSpinnerTick advances the loading spinner, only redrawing while something is in flight
+func (m *Model) handleSpinnerTick(msg spinner.TickMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	// Also redraw when the header's refresh age or the Active detail's duration ticks over,
+	// even if nothing is running
+	if m.ready && (m.busy() || m.refreshAge() != m.shownRefreshAge || m.detailDuration() != m.shownDuration) {
+		m.updateContent()
+	}
+	return m, cmd
+}
+
 // syncActiveView initializes or clears activeView based on current tab
 func (m *Model) syncActiveView() {
 	if m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) {
@@ -421,78 +1342,64 @@ func (m *Model) handleActiveViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	switch av.Mode {
 	case ActiveModeList:
-		switch msg.String() {
-		case "up", "k":
-			if av.SelectedIndex > 0 {
-				av.SelectedIndex--
-				if len(av.Processes) > 0 {
-					av.SelectedPID = av.Processes[av.SelectedIndex].PID
-				}
-				av.ensureVisible()
+		switch {
+		case key.Matches(msg, keys.ActiveUp):
+			if av.MoveSelection(-1) {
 				m.updateContent()
 			}
-		case "down", "j":
-			if av.SelectedIndex < len(av.Processes)-1 {
-				av.SelectedIndex++
-				if len(av.Processes) > 0 {
-					av.SelectedPID = av.Processes[av.SelectedIndex].PID
-				}
-				av.ensureVisible()
+		case key.Matches(msg, keys.ActiveDown):
+			if av.MoveSelection(1) {
 				m.updateContent()
 			}
-		case "enter":
-			if p := av.SelectedProcess(); p != nil {
-				snap := *p
-				av.DetailProcess = &snap
-				av.Mode = ActiveModeDetail
-				av.LastError = ""
-				av.CopyStatus = ""
+		case key.Matches(msg, keys.ActiveDetails):
+			if av.OpenDetail() {
 				m.updateContent()
 			}
-		case "t":
-			if p := av.SelectedProcess(); p != nil {
-				snap := *p
-				av.DetailProcess = &snap
-				av.TerminateType = "terminate"
-				av.Mode = ActiveModeConfirmTerminate
-				av.LastError = ""
+		case key.Matches(msg, keys.Terminate):
+			if av.ConfirmAction("terminate") {
 				m.updateContent()
 			}
-		case "c":
-			if p := av.SelectedProcess(); p != nil {
-				snap := *p
-				av.DetailProcess = &snap
-				av.TerminateType = "cancel"
-				av.Mode = ActiveModeConfirmTerminate
-				av.LastError = ""
+		case key.Matches(msg, keys.CancelBackend):
+			if av.ConfirmAction("cancel") {
 				m.updateContent()
 			}
+		case key.Matches(msg, keys.KillMatching):
+			av.StartKillPattern()
+			m.updateContent()
+		case key.Matches(msg, keys.CancelUser):
+			av.StartCancelUser()
+			m.updateContent()
+		case key.Matches(msg, keys.ShowIdle):
+			m.activeFilter.ShowIdle = !m.activeFilter.ShowIdle
+			return m, m.refreshActive()
+		case key.Matches(msg, keys.ShowWorkers):
+			m.activeFilter.HideBackground = !m.activeFilter.HideBackground
+			return m, m.refreshActive()
+		case key.Matches(msg, keys.ActiveScope):
+			m.toggleActiveScope()
+			return m, m.refreshActive()
 		}
 
 	case ActiveModeDetail:
-		switch msg.String() {
-		case "esc", "ctrl+[":
+		switch {
+		case key.Matches(msg, keys.Back):
 			av.DetailProcess = nil
 			av.DetailCompleted = false
 			av.Mode = ActiveModeList
 			av.LastError = ""
 			av.CopyStatus = ""
 			m.updateContent()
-		case "t":
+		case key.Matches(msg, keys.Terminate):
 			if !av.DetailCompleted {
-				av.TerminateType = "terminate"
-				av.Mode = ActiveModeConfirmTerminate
-				av.LastError = ""
+				av.confirm("terminate")
 				m.updateContent()
 			}
-		case "c":
+		case key.Matches(msg, keys.CancelBackend):
 			if !av.DetailCompleted {
-				av.TerminateType = "cancel"
-				av.Mode = ActiveModeConfirmTerminate
-				av.LastError = ""
+				av.confirm("cancel")
 				m.updateContent()
 			}
-		case "y":
+		case key.Matches(msg, keys.CopyQuery):
 			if av.DetailProcess != nil {
 				return m, func() tea.Msg {
 					return clipboardResultMsg{err: copyToClipboard(av.DetailProcess.Query)}
@@ -500,17 +1407,23 @@ func (m *Model) handleActiveViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case ActiveModeKillPattern:
+		return m.handleKillPatternKeys(msg)
+
+	case ActiveModeConfirmKill:
+		return m.handleConfirmKillKeys(msg)
+
 	case ActiveModeConfirmTerminate:
-		switch msg.String() {
-		case "y":
+		if av.typedConfirm() {
+			return m.handleTypedConfirmKeys(msg)
+		}
+		switch {
+		case key.Matches(msg, keys.Confirm):
 			if av.DetailProcess != nil {
 				return m, m.executeTerminate(av.DetailProcess.PID, av.TerminateType)
 			}
-		case "n", "esc", "ctrl+[":
-			av.Mode = ActiveModeList
-			av.DetailProcess = nil
-			av.DetailCompleted = false
-			av.LastError = ""
+		case key.Matches(msg, keys.Decline):
+			av.declineConfirm()
 			m.updateContent()
 		}
 	}
@@ -518,17 +1431,53 @@ func (m *Model) handleActiveViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleTypedConfirmKeys edits the answer to the terminate prompt; enter terminates only
+// once it reads terminateConfirmWord, and esc goes back to the list
+func (m *Model) handleTypedConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	av := m.activeView
+	switch msg.Type {
+	case tea.KeyEscape:
+		av.declineConfirm()
+	case tea.KeyEnter:
+		if !strings.EqualFold(strings.TrimSpace(av.ConfirmInput), terminateConfirmWord) {
+			av.LastError = "Type " + terminateConfirmWord + " to terminate, or esc to go back"
+			break
+		}
+		if av.DetailProcess != nil {
+			return m, m.executeTerminate(av.DetailProcess.PID, av.TerminateType)
+		}
+	case tea.KeyBackspace:
+		if r := []rune(av.ConfirmInput); len(r) > 0 {
+			av.ConfirmInput = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		av.ConfirmInput += string(msg.Runes)
+	default:
+		if msg.String() == "ctrl+[" {
+			av.declineConfirm()
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
 // executeTerminate runs pg_terminate_backend or pg_cancel_backend asynchronously
 func (m *Model) executeTerminate(pid int, action string) tea.Cmd {
 	return func() tea.Msg {
+		if m.readOnly() {
+			return terminateResultMsg{PID: pid, Action: action, Error: action + " is disabled in read-only mode"}
+		}
 		if m.db == nil {
 			return terminateResultMsg{PID: pid, Action: action, Error: "no database connection"}
 		}
 		var err error
+		start := time.Now()
 		if action == "cancel" {
 			err = CancelBackend(m.db, pid)
+			logStatement(m.service, fmt.Sprintf("SELECT pg_cancel_backend(%d)", pid), start, err)
 		} else {
 			err = TerminateBackend(m.db, pid)
+			logStatement(m.service, fmt.Sprintf("SELECT pg_terminate_backend(%d)", pid), start, err)
 		}
 		if err != nil {
 			return terminateResultMsg{PID: pid, Action: action, Error: err.Error()}
@@ -537,6 +1486,13 @@ func (m *Model) executeTerminate(pid int, action string) tea.Cmd {
 	}
 }
 
+// refreshActive re-runs the Active tab so a changed filter shows right away
+func (m *Model) refreshActive() tea.Cmd {
+	m.loading = true
+	m.updateContent()
+	return m.runQuery(m.lastQuery)
+}
+
 // handleTerminateResult processes the result of a terminate/cancel action
 func (m *Model) handleTerminateResult(msg terminateResultMsg) (tea.Model, tea.Cmd) {
 	if m.activeView != nil {
@@ -558,3 +1514,45 @@ func (m *Model) handleTerminateResult(msg terminateResultMsg) (tea.Model, tea.Cm
 	}
 	return m, nil
 }
+
+// handleStatsResetConfirmKeys handles y/n while confirming a pg_stat_statements reset
+func (m *Model) handleStatsResetConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.confirmReset = false
+		m.loading = true
+		m.updateContent()
+		return m, m.executeStatsReset()
+	case "n", "esc", "ctrl+[":
+		m.confirmReset = false
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// executeStatsReset runs pg_stat_statements_reset asynchronously
+func (m *Model) executeStatsReset() tea.Cmd {
+	return func() tea.Msg {
+		db := m.db
+		if db == nil {
+			return statsResetMsg{err: fmt.Errorf("no database connection")}
+		}
+		start := time.Now()
+		err := ResetPgStatStatements(db)
+		logStatement(m.service, "SELECT pg_stat_statements_reset()", start, err)
+		return statsResetMsg{err: err}
+	}
+}
+
+// handleStatsResetResult refreshes the current tab after a reset, or shows why it failed
+func (m *Model) handleStatsResetResult(msg statsResetMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err.Error()
+		m.loading = false
+		m.updateContent()
+		return m, nil
+	}
+	m.err = ""
+	m.updateContent()
+	return m, m.runQuery(m.lastQuery)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sqlTokenKind classifies a span of SQL for highlighting
+type sqlTokenKind int
+
+const (
+	sqlText sqlTokenKind = iota // whitespace, operators and punctuation
+	sqlKeyword
+	sqlIdent
+	sqlString
+	sqlNumber
+	sqlComment
+)
+
+// sqlToken is a span of SQL and what kind of text it is
+type sqlToken struct {
+	Kind sqlTokenKind
+	Text string
+}
+
+// sqlKeywords are the words highlighted as keywords; anything else word-like is an identifier
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, k := range strings.Fields(`
+		ALL ALTER ANALYZE AND ANY ARRAY AS ASC BEGIN BETWEEN BY CASE CAST COMMIT CONFLICT CONSTRAINT
+		CREATE CROSS CURRENT_DATE CURRENT_TIMESTAMP CURRENT_USER DEFAULT DELETE DESC DISTINCT DO DROP
+		ELSE END EXCEPT EXISTS EXPLAIN FALSE FETCH FILTER FIRST FOR FROM FULL GROUP HAVING ILIKE IN
+		INDEX INNER INSERT INTERSECT INTERVAL INTO IS JOIN LATERAL LEFT LIKE LIMIT NOT NOTHING NULL
+		NULLS OFFSET ON OR ORDER OUTER OVER PARTITION PRIMARY RECURSIVE RETURNING RIGHT ROLLBACK ROW
+		ROWS SELECT SET SIMILAR TABLE THEN TO TRUE TRUNCATE UNION UNIQUE UPDATE USING VACUUM VALUES
+		VIEW WHEN WHERE WINDOW WITH`) {
+		sqlKeywords[k] = true
+	}
+}
+
+// tokenizeSQL splits SQL into tokens in a single pass. It never fails: anything it doesn't
+// recognize (including unterminated strings and comments) still comes back as a token, so
+// joining the token texts always reproduces the input.
+func tokenizeSQL(s string) []sqlToken {
+	var tokens []sqlToken
+	emit := func(kind sqlTokenKind, text string) {
+		// Merge runs of plain text so rendering doesn't style every space separately
+		if kind == sqlText && len(tokens) > 0 && tokens[len(tokens)-1].Kind == sqlText {
+			tokens[len(tokens)-1].Text += text
+			return
+		}
+		tokens = append(tokens, sqlToken{Kind: kind, Text: text})
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			emit(sqlComment, s[i:i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				end = len(s) - i
+			} else {
+				end += 4
+			}
+			emit(sqlComment, s[i:i+end])
+			i += end
+		case c == '\'' || ((c == 'E' || c == 'e') && i+1 < len(s) && s[i+1] == '\''):
+			start := i
+			if c != '\'' {
+				i++
+			}
+			i = scanQuoted(s, i, '\'', c != '\'')
+			emit(sqlString, s[start:i])
+		case c == '"':
+			end := scanQuoted(s, i, '"', false)
+			emit(sqlIdent, s[i:end])
+			i = end
+		case c == '$':
+			if tag, ok := dollarTag(s[i:]); ok {
+				end := strings.Index(s[i+len(tag):], tag)
+				if end < 0 {
+					end = len(s) - i
+				} else {
+					end += 2 * len(tag)
+				}
+				emit(sqlString, s[i:i+end])
+				i += end
+			} else {
+				emit(sqlText, s[i:i+1])
+				i++
+			}
+		case isDigit(c) || (c == '.' && i+1 < len(s) && isDigit(s[i+1])):
+			end := scanNumber(s, i)
+			emit(sqlNumber, s[i:end])
+			i = end
+		case isWordStart(c):
+			end := i + 1
+			for end < len(s) && isWordChar(s[end]) {
+				end++
+			}
+			word := s[i:end]
+			if sqlKeywords[strings.ToUpper(word)] {
+				emit(sqlKeyword, word)
+			} else {
+				emit(sqlIdent, word)
+			}
+			i = end
+		default:
+			emit(sqlText, s[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// scanQuoted returns the index just past the quote closing the string opened at s[start].
+// A doubled quote is an escaped quote; backslash escapes only count in E'' strings.
+func scanQuoted(s string, start int, quote byte, backslash bool) int {
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case backslash && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// dollarTag returns the $tag$ opening a dollar-quoted string at the start of s
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1], true
+		}
+		if !isWordChar(s[i]) || (i == 1 && isDigit(s[i])) {
+			// $1 is a parameter, not a tag
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// scanNumber returns the index just past the numeric literal starting at s[start]
+func scanNumber(s string, start int) int {
+	i := start
+	for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			i = j
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool { return isWordStart(c) || isDigit(c) || c == '$' }
+
+// highlightSQL colors keywords, string literals, numbers and comments in s
+func highlightSQL(s string) string {
+	styles := map[sqlTokenKind]lipgloss.Style{
+		sqlKeyword: lipgloss.NewStyle().Bold(true).Foreground(theme.Key),
+		sqlString:  lipgloss.NewStyle().Foreground(theme.Success),
+		sqlNumber:  lipgloss.NewStyle().Foreground(theme.Caution),
+		sqlComment: lipgloss.NewStyle().Italic(true).Foreground(theme.Muted),
+	}
+
+	var b strings.Builder
+	for _, tok := range tokenizeSQL(s) {
+		style, ok := styles[tok.Kind]
+		if !ok {
+			b.WriteString(tok.Text)
+			continue
+		}
+		// Style line by line so multi-line strings and comments don't bleed into padding
+		lines := strings.Split(tok.Text, "\n")
+		for i, line := range lines {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if line != "" {
+				b.WriteString(style.Render(line))
+			}
+		}
+	}
+	return b.String()
+}
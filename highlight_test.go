@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []sqlToken
+	}{
+		{
+			name: "keywords and identifiers",
+			sql:  "select datname from pg_database",
+			want: []sqlToken{
+				{sqlKeyword, "select"}, {sqlText, " "}, {sqlIdent, "datname"}, {sqlText, " "},
+				{sqlKeyword, "from"}, {sqlText, " "}, {sqlIdent, "pg_database"},
+			},
+		},
+		{
+			name: "quoted identifier is not a keyword",
+			sql:  `SELECT "select"`,
+			want: []sqlToken{{sqlKeyword, "SELECT"}, {sqlText, " "}, {sqlIdent, `"select"`}},
+		},
+		{
+			name: "string with doubled quote",
+			sql:  "WHERE state = 'it''s idle'",
+			want: []sqlToken{
+				{sqlKeyword, "WHERE"}, {sqlText, " "}, {sqlIdent, "state"}, {sqlText, " = "},
+				{sqlString, "'it''s idle'"},
+			},
+		},
+		{
+			name: "escape string",
+			sql:  `E'a\'b'`,
+			want: []sqlToken{{sqlString, `E'a\'b'`}},
+		},
+		{
+			name: "dollar quoting and parameters",
+			sql:  "$fn$ don't $fn$ = $1",
+			want: []sqlToken{{sqlString, "$fn$ don't $fn$"}, {sqlText, " = $"}, {sqlNumber, "1"}},
+		},
+		{
+			name: "numbers and casts",
+			sql:  "1.5e3::int",
+			want: []sqlToken{{sqlNumber, "1.5e3"}, {sqlText, "::"}, {sqlIdent, "int"}},
+		},
+		{
+			name: "comments",
+			sql:  "-- top\nSELECT /* inline */ 1",
+			want: []sqlToken{
+				{sqlComment, "-- top"}, {sqlText, "\n"}, {sqlKeyword, "SELECT"}, {sqlText, " "},
+				{sqlComment, "/* inline */"}, {sqlText, " "}, {sqlNumber, "1"},
+			},
+		},
+		{
+			name: "unterminated string runs to the end",
+			sql:  "SELECT 'oops",
+			want: []sqlToken{{sqlKeyword, "SELECT"}, {sqlText, " "}, {sqlString, "'oops"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokenizeSQL(tt.sql)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenizeSQL(%q) = %v, want %v", tt.sql, got, tt.want)
+			}
+
+			var joined strings.Builder
+			for _, tok := range got {
+				joined.WriteString(tok.Text)
+			}
+			if joined.String() != tt.sql {
+				t.Errorf("tokens rejoin to %q, want %q", joined.String(), tt.sql)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,17 +22,86 @@ func HomeQuery() Query {
 	}
 }
 
-// RenderHomeChart renders the PostgreSQL activity state chart for the Home tab
-func RenderHomeChart(db *sql.DB, query string, chartWidth int) (string, error) {
+// homeChartMinHeight is the Home state chart's height when there are only a few states
+const homeChartMinHeight = 5
+
+// RenderHomeChart renders the PostgreSQL activity state chart for the Home tab.
+// The chart grows with the number of states, up to maxHeight rows.
+func RenderHomeChart(db queryer, query string, chartWidth, maxHeight int) (string, error) {
+	chartData, err := queryBarData(db, query, stateColor)
+	if err != nil {
+		return "", err
+	}
+	if len(chartData) == 0 {
+		return "No data to display", nil
+	}
+	return renderStateChart("Connections", chartData, chartWidth, maxHeight), nil
+}
+
+// stateColor picks a connection state's bar color
+func stateColor(state string) lipgloss.Color {
+	switch strings.ToLower(state) {
+	case "active":
+		return theme.Success // Green
+	case "idle":
+		return theme.Muted // Gray
+	case "idle in transaction":
+		return theme.Warning // Yellow
+	case "idle in transaction (aborted)":
+		return theme.Error // Red
+	default:
+		return theme.Info // Blue
+	}
+}
+
+// clientCountsQuery counts client connections by where they come from, busiest first. Each
+// application on a host gets its own bar so one misbehaving service stands out.
+const clientCountsQuery = `SELECT COALESCE(host(client_addr), 'local socket') ||
+		CASE WHEN application_name <> '' THEN ' ' || application_name ELSE '' END AS client,
+		COUNT(*) AS count
+	FROM pg_stat_activity
+	WHERE backend_type = 'client backend'
+	GROUP BY 1
+	ORDER BY 2 DESC, 1`
+
+// RenderClientChart renders connection counts by client address and application for the
+// Home tab. A client holding more than half the connections is drawn in the warning color.
+func RenderClientChart(db queryer, chartWidth, maxHeight int) (string, error) {
+	chartData, err := queryBarData(db, clientCountsQuery, func(string) lipgloss.Color { return theme.Info })
+	if err != nil {
+		return "", err
+	}
+	if len(chartData) == 0 {
+		return "No client connections", nil
+	}
+	highlightDominantBar(chartData)
+	return renderStateChart("Connections by client", chartData, chartWidth, maxHeight), nil
+}
+
+// highlightDominantBar colors the first bar as a warning when it holds more than half the
+// total. Bars are sorted busiest first.
+func highlightDominantBar(chartData []barchart.BarData) {
+	total := 0.0
+	for _, bar := range chartData {
+		total += bar.Values[0].Value
+	}
+	if len(chartData) > 1 && chartData[0].Values[0].Value > total/2 {
+		chartData[0].Values[0].Style = lipgloss.NewStyle().Foreground(theme.Warning)
+	}
+}
+
+// queryBarData runs query and turns each row into a bar: the first column is the label
+// and the second the value, colored by colorOf(label)
+func queryBarData(db queryer, query string, colorOf func(string) lipgloss.Color) ([]barchart.BarData, error) {
 	rows, err := db.Query(query)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute query: %w", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
 	columns, err := rows.Columns()
 	if err != nil {
-		return "", fmt.Errorf("failed to get columns: %w", err)
+		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	var chartData []barchart.BarData
@@ -43,77 +113,36 @@ func RenderHomeChart(db *sql.DB, query string, chartWidth int) (string, error) {
 
 	for rows.Next() {
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return "", fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		var state string
-		var count float64
-
-		// Extract state (first column)
-		if values[0] == nil {
-			state = "NULL"
-		} else if bytes, ok := values[0].([]byte); ok {
-			state = string(bytes)
-		} else {
-			state = fmt.Sprintf("%v", values[0])
-		}
-
-		// Extract count (second column)
-		if values[1] == nil {
-			count = 0
-		} else if bytes, ok := values[1].([]byte); ok {
-			if parsed, err := strconv.ParseFloat(string(bytes), 64); err == nil {
-				count = parsed
-			}
-		} else if str, ok := values[1].(string); ok {
-			if parsed, err := strconv.ParseFloat(str, 64); err == nil {
-				count = parsed
-			}
-		} else if i64, ok := values[1].(int64); ok {
-			count = float64(i64)
-		} else if f64, ok := values[1].(float64); ok {
-			count = f64
-		}
-
-		// Choose colors based on state
-		var color lipgloss.Color
-		switch strings.ToLower(state) {
-		case "active":
-			color = lipgloss.Color("10") // Green
-		case "idle":
-			color = lipgloss.Color("8") // Gray
-		case "idle in transaction":
-			color = lipgloss.Color("11") // Yellow
-		case "idle in transaction (aborted)":
-			color = lipgloss.Color("9") // Red
-		default:
-			color = lipgloss.Color("12") // Blue
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
+		label := chartLabel(values[0])
 		chartData = append(chartData, barchart.BarData{
-			Label: fmt.Sprintf("%s (%d)", state, int(count)),
+			Label: label,
 			Values: []barchart.BarValue{
 				{
-					Value: count,
-					Style: lipgloss.NewStyle().Foreground(color),
+					Value: chartValue(values[1]),
+					Style: lipgloss.NewStyle().Foreground(colorOf(label)),
 				},
 			},
 		})
 	}
 
 	if err := rows.Err(); err != nil {
-		return "", fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	if len(chartData) == 0 {
-		return "No data to display", nil
+		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
+	return chartData, nil
+}
 
+// renderStateChart draws the counts as horizontal bars labelled "state (count)" under
+// title and the total. Each bar gets its own row, spaced out while they fit in maxHeight;
+// past that the smallest ones are left off the chart but still counted in the title.
+func renderStateChart(title string, chartData []barchart.BarData, chartWidth, maxHeight int) string {
 	var axisStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("3")) // yellow
+		Foreground(theme.ChartAxis) // yellow
 
 	var labelStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("63")).
+		Foreground(theme.ChartLabel).
 		Align(lipgloss.Right)
 
 	// Calculate responsive chart dimensions
@@ -123,30 +152,89 @@ func RenderHomeChart(db *sql.DB, query string, chartWidth int) (string, error) {
 		responsiveWidth = 20 // Minimum width
 	}
 
+	// Calculate total connections before any states are left off
+	totalConnectionsCount := 0
+	for _, bar := range chartData {
+		totalConnectionsCount += int(bar.Values[0].Value)
+	}
+
+	// One row per state with a blank row between them, dropping the gaps and then
+	// the smallest states when there isn't room
+	maxHeight = max(maxHeight, homeChartMinHeight)
+	height := max(2*len(chartData)-1, homeChartMinHeight)
+	gap := 1
+	if height > maxHeight {
+		gap = 0
+		chartData = chartData[:min(len(chartData), maxHeight)]
+		height = max(len(chartData), homeChartMinHeight)
+	}
+
+	// Keep labels to half the chart so the bars stay readable, truncating the
+	// state name rather than its count
+	maxLabel := responsiveWidth / 2
+	bars := make([]barchart.BarData, len(chartData))
+	for i, bar := range chartData {
+		count := fmt.Sprintf(" (%d)", int(bar.Values[0].Value))
+		bar.Label = truncate(bar.Label, maxLabel-len(count)) + count
+		if len(bar.Label) > maxLabel {
+			bar.Label = truncate(bar.Label, maxLabel)
+		}
+		bars[i] = bar
+	}
+
 	// Create the bar chart with responsive width
 	bc := barchart.New(
-		responsiveWidth, 5,
-		barchart.WithDataSet(chartData),            // Your data
+		responsiveWidth, height,
+		barchart.WithDataSet(bars),                 // Your data
 		barchart.WithStyles(axisStyle, labelStyle), // Style axis & labels
 		barchart.WithHorizontalBars(),              // Horizontal bar layout
+		barchart.WithBarGap(gap),
 	)
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
 	bc.Draw()
 
-	// Calculate total connections
-	totalConnectionsCount := 0
-	for _, bar := range chartData {
-		totalConnectionsCount += int(bar.Values[0].Value)
+	return titleStyle.Render(fmt.Sprintf("%s (%d)", title, totalConnectionsCount)) + "\n" + bc.View()
+}
+
+// chartLabel converts a scanned column value into a bar label
+func chartLabel(v interface{}) string {
+	if v == nil {
+		return "NULL"
+	} else if bytes, ok := v.([]byte); ok {
+		return string(bytes)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// chartValue converts a scanned numeric column value into a float64. lib/pq returns
+// integers as int64 but numeric and some aggregates as []byte, so both are handled the
+// same way; NULL and unparseable values count as 0.
+func chartValue(v interface{}) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case []byte:
+		return parseChartValue(string(v))
+	case string:
+		return parseChartValue(v)
 	}
-	title := fmt.Sprintf("Connections (%d)", totalConnectionsCount)
-	result := titleStyle.Render(title) + "\n" + bc.View()
+	return 0
+}
 
-	return result, nil
+// parseChartValue parses a value the driver returned as text, or 0 if it isn't a number
+func parseChartValue(s string) float64 {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
 }
 
 // IsHomeTab checks if the given query is the Home tab
@@ -182,20 +270,30 @@ func (s *SparklineData) AddPoint(value float64, timestamp time.Time) {
 	}
 }
 
+// Clone copies s, so a query goroutine can render it while samples keep arriving
+func (s *SparklineData) Clone() *SparklineData {
+	return &SparklineData{
+		Values:     slices.Clone(s.Values),
+		Timestamps: slices.Clone(s.Timestamps),
+		MaxPoints:  s.MaxPoints,
+	}
+}
+
 // GetTransactionCommits queries the database for transaction commits and the current timestamp
-func GetTransactionCommits(db *sql.DB) (float64, time.Time, error) {
-	var commits float64
+func GetTransactionCommits(db queryer) (float64, time.Time, error) {
+	// SUM of a bigint is numeric, which lib/pq returns as text
+	var commits interface{}
 	var now time.Time
 	err := db.QueryRow("SELECT SUM(xact_commit), NOW() FROM pg_stat_database").Scan(&commits, &now)
 	if err != nil {
 		return 0, time.Time{}, fmt.Errorf("failed to query transaction commits: %w", err)
 	}
-	return commits, now, nil
+	return chartValue(commits), now, nil
 }
 
 // RenderSparklineChart renders the transaction commits sparkline
 func RenderSparklineChart(sparklineData *SparklineData, chartWidth int) string {
-	if len(sparklineData.Values) == 0 {
+	if sparklineData == nil || len(sparklineData.Values) == 0 {
 		return "No data yet..."
 	}
 
@@ -219,7 +317,7 @@ func RenderSparklineChart(sparklineData *SparklineData, chartWidth int) string {
 	// Add title with current TPS count
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
 	title := fmt.Sprintf("Transactions/sec (%.1f)", currentTPS)
@@ -228,7 +326,7 @@ func RenderSparklineChart(sparklineData *SparklineData, chartWidth int) string {
 }
 
 // GetCacheHitRatio queries the database for cache hit ratio percentage
-func GetCacheHitRatio(db *sql.DB) (float64, bool, error) {
+func GetCacheHitRatio(db queryer) (float64, bool, error) {
 	var ratio sql.NullFloat64
 	err := db.QueryRow("SELECT ROUND(SUM(blks_hit) * 100.0 / NULLIF(SUM(blks_hit) + SUM(blks_read), 0), 2) as ratio FROM pg_stat_database").Scan(&ratio)
 	if err != nil {
@@ -253,7 +351,7 @@ type ReplicationLagInfo struct {
 }
 
 // GetReplicationLag queries replication lag — replica replay lag or primary slot lag
-func GetReplicationLag(db *sql.DB) (ReplicationLagInfo, error) {
+func GetReplicationLag(db queryer) (ReplicationLagInfo, error) {
 	var isInRecovery bool
 	if err := db.QueryRow("SELECT pg_is_in_recovery()").Scan(&isInRecovery); err != nil {
 		return ReplicationLagInfo{}, fmt.Errorf("failed to check recovery state: %w", err)
@@ -335,26 +433,26 @@ func GetReplicationLag(db *sql.DB) (ReplicationLagInfo, error) {
 }
 
 // RenderCacheHitRatio renders the cache hit ratio widget
-func RenderCacheHitRatio(db *sql.DB) string {
+func RenderCacheHitRatio(db queryer) string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
 	ratio, valid, err := GetCacheHitRatio(db)
 	if err != nil || !valid {
 		return titleStyle.Render("Cache Hit Ratio") + "\n" +
-			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("N/A")
+			lipgloss.NewStyle().Foreground(theme.Muted).Render("N/A")
 	}
 
 	var color lipgloss.Color
 	switch {
 	case ratio >= 99:
-		color = lipgloss.Color("10") // Green
+		color = theme.Success // Green
 	case ratio >= 95:
-		color = lipgloss.Color("11") // Yellow
+		color = theme.Warning // Yellow
 	default:
-		color = lipgloss.Color("9") // Red
+		color = theme.Error // Red
 	}
 
 	valueStyle := lipgloss.NewStyle().
@@ -401,15 +499,223 @@ func formatDuration(seconds int) string {
 	return fmt.Sprintf("%dh %dm", hours, mins)
 }
 
+// timeAgo describes how long before now t was, e.g. "3 days ago"
+func timeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year")
+	}
+}
+
+// ConnectionUsage holds the current backend count and the server's connection limit
+type ConnectionUsage struct {
+	Current        int
+	MaxConnections int
+}
+
+// Percent returns current connections as a percentage of max_connections
+func (u ConnectionUsage) Percent() float64 {
+	if u.MaxConnections <= 0 {
+		return 0
+	}
+	return float64(u.Current) * 100 / float64(u.MaxConnections)
+}
+
+// GetConnectionUsage queries the number of backends and the max_connections setting
+func GetConnectionUsage(db queryer) (ConnectionUsage, error) {
+	var usage ConnectionUsage
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM pg_stat_activity)::int AS current,
+			current_setting('max_connections')::int AS max_connections`).
+		Scan(&usage.Current, &usage.MaxConnections)
+	if err != nil {
+		return ConnectionUsage{}, fmt.Errorf("failed to query connection usage: %w", err)
+	}
+	return usage, nil
+}
+
+// connectionUsageColor picks the gauge color: yellow past 70%, red past 90%
+func connectionUsageColor(percent float64) lipgloss.Color {
+	switch {
+	case percent > 90:
+		return theme.Error // Red
+	case percent > 70:
+		return theme.Warning // Yellow
+	default:
+		return theme.Success // Green
+	}
+}
+
+// renderGaugeBar renders a horizontal progress bar of the given width filled to percent
+func renderGaugeBar(percent float64, width int, color lipgloss.Color) string {
+	if width < 1 {
+		return ""
+	}
+	filled := int(percent / 100 * float64(width))
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(theme.TabBg).Render(strings.Repeat("░", width-filled))
+}
+
+// RenderConnectionUsage renders the connections vs max_connections gauge (full width)
+func RenderConnectionUsage(db queryer, width int) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	usage, err := GetConnectionUsage(db)
+	if err != nil {
+		return titleStyle.Render("Connection Usage") + "\n" +
+			lipgloss.NewStyle().Foreground(theme.Muted).Render("N/A")
+	}
+
+	percent := usage.Percent()
+	color := connectionUsageColor(percent)
+	label := fmt.Sprintf(" %d / %d (%.0f%%)", usage.Current, usage.MaxConnections, percent)
+
+	// Account for the widget border/padding and the trailing label
+	barWidth := width - 8 - len(label)
+	if barWidth < 10 {
+		barWidth = 10
+	}
+
+	return titleStyle.Render("Connection Usage") + "\n" +
+		renderGaugeBar(percent, barWidth, color) +
+		lipgloss.NewStyle().Bold(true).Foreground(color).Render(label)
+}
+
+// topTablesQuery lists the largest user tables by total size (heap + indexes + toast).
+// Candidates are picked by relpages first, which reads only the catalog and takes no
+// lock, so the size functions run on a handful of tables instead of every relation.
+const topTablesQuery = `
+	SELECT
+		t.table_name,
+		pg_total_relation_size(t.oid) AS total_bytes,
+		pg_size_pretty(pg_total_relation_size(t.oid)) AS total_size
+	FROM (
+		SELECT c.oid, n.nspname || '.' || c.relname AS table_name
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'm')
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg_toast%'
+		ORDER BY c.relpages DESC
+		LIMIT $2
+	) t
+	ORDER BY total_bytes DESC
+	LIMIT $1`
+
+// topTablesCandidates is how many tables, by relpages, get their total size measured for
+// a top-N chart: relpages ignores indexes and toast, so look a little wider than N
+func topTablesCandidates(topN int) int {
+	return topN * 4
+}
+
+// RenderDatabaseSize renders the current database size and a bar chart of its largest tables
+func RenderDatabaseSize(db queryer, chartWidth int, topN int) (string, error) {
+	var dbSize string
+	if err := db.QueryRow("SELECT pg_size_pretty(pg_database_size(current_database()))").Scan(&dbSize); err != nil {
+		return "", fmt.Errorf("failed to query database size: %w", err)
+	}
+
+	rows, err := db.Query(topTablesQuery, topN, topTablesCandidates(topN))
+	if err != nil {
+		return "", fmt.Errorf("failed to query table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	var chartData []barchart.BarData
+	values := make([]interface{}, 3)
+	valuePtrs := make([]interface{}, 3)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return "", fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		chartData = append(chartData, barchart.BarData{
+			Label: fmt.Sprintf("%s (%s)", chartLabel(values[0]), chartLabel(values[2])),
+			Values: []barchart.BarValue{
+				{
+					Value: chartValue(values[1]),
+					Style: lipgloss.NewStyle().Foreground(theme.Info), // Blue
+				},
+			},
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary).
+		MarginBottom(1)
+
+	title := titleStyle.Render(fmt.Sprintf("Database Size (%s)", dbSize))
+	if len(chartData) == 0 {
+		return title + "\n" + lipgloss.NewStyle().Foreground(theme.Muted).Render("No tables"), nil
+	}
+
+	var axisStyle = lipgloss.NewStyle().
+		Foreground(theme.ChartAxis) // yellow
+
+	var labelStyle = lipgloss.NewStyle().
+		Foreground(theme.ChartLabel).
+		Align(lipgloss.Right)
+
+	responsiveWidth := chartWidth - 6 // Account for border and padding
+	if responsiveWidth < 20 {
+		responsiveWidth = 20 // Minimum width
+	}
+
+	bc := barchart.New(
+		responsiveWidth, len(chartData),
+		barchart.WithDataSet(chartData),
+		barchart.WithStyles(axisStyle, labelStyle),
+		barchart.WithHorizontalBars(),
+	)
+	bc.Draw()
+
+	return title + "\n" + bc.View(), nil
+}
+
 // RenderReplicationLag renders the replication lag widget
-func RenderReplicationLag(db *sql.DB) string {
+func RenderReplicationLag(db queryer) string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
 	dimStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
+		Foreground(theme.Muted)
 
 	info, err := GetReplicationLag(db)
 	if err != nil {
@@ -424,11 +730,11 @@ func RenderReplicationLag(db *sql.DB) string {
 		var color lipgloss.Color
 		switch {
 		case info.LagSeconds < 1:
-			color = lipgloss.Color("10") // Green
+			color = theme.Success // Green
 		case info.LagSeconds <= 10:
-			color = lipgloss.Color("11") // Yellow
+			color = theme.Warning // Yellow
 		default:
-			color = lipgloss.Color("9") // Red
+			color = theme.Error // Red
 		}
 		valueStyle := lipgloss.NewStyle().Bold(true).Foreground(color).PaddingTop(1)
 		return titleStyle.Render("Replica Lag") + "\n" +
@@ -464,15 +770,15 @@ func RenderReplicationLag(db *sql.DB) string {
 	var color lipgloss.Color
 	switch {
 	case info.LagSeconds < 1<<20: // < 1 MB
-		color = lipgloss.Color("10") // Green
+		color = theme.Success // Green
 	case info.LagSeconds < 100<<20: // < 100 MB
-		color = lipgloss.Color("11") // Yellow
+		color = theme.Warning // Yellow
 	default:
-		color = lipgloss.Color("9") // Red
+		color = theme.Error // Red
 	}
 
 	valueStyle := lipgloss.NewStyle().Bold(true).Foreground(color)
-	detailStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	detailStyle := lipgloss.NewStyle().Foreground(theme.Subtle)
 
 	return titleStyle.Render("Replication Slots") + "\n" +
 		dimStyle.Render(slotSummary) + "\n" +
@@ -480,10 +786,51 @@ func RenderReplicationLag(db *sql.DB) string {
 		detailStyle.Render(" · "+info.SlotType+" · "+info.SlotName)
 }
 
-// RenderHomeDashboard renders all widgets: 2x2 grid + full-width blocking locks
-func RenderHomeDashboard(barChart, sparklineChart, cacheHitRatio, replicationLag, blockingLocks string, width int) string {
+// Home dashboard widget names, as used in the home_widgets config setting
+const (
+	WidgetBlockingLocks  = "blocking_locks"
+	WidgetStateCounts    = "state_counts"
+	WidgetTPS            = "tps"
+	WidgetCacheHitRatio  = "cache_hit_ratio"
+	WidgetReplicationLag = "replication_lag"
+	WidgetConnections    = "connections"
+	WidgetDatabaseSize   = "db_size"
+	WidgetClients        = "clients"
+)
+
+// defaultHomeWidgets is the Home layout used when config.json doesn't set one. db_size
+// is left out: measuring table sizes every refresh is too heavy to run unasked.
+var defaultHomeWidgets = []string{
+	WidgetBlockingLocks,
+	WidgetConnections,
+	WidgetStateCounts,
+	WidgetTPS,
+	WidgetCacheHitRatio,
+	WidgetReplicationLag,
+}
+
+// isHomeWidget checks if the given name is a known Home dashboard widget
+func isHomeWidget(name string) bool {
+	switch name {
+	case WidgetBlockingLocks, WidgetStateCounts, WidgetTPS, WidgetCacheHitRatio, WidgetReplicationLag,
+		WidgetConnections, WidgetDatabaseSize, WidgetClients:
+		return true
+	}
+	return false
+}
+
+// HomeWidget is a rendered block on the Home dashboard
+type HomeWidget struct {
+	Content   string
+	FullWidth bool // spans the whole dashboard instead of half
+	Compact   bool // single-value display rendered at a fixed short height
+}
+
+// RenderHomeDashboard lays out widgets in order: full-width widgets take their own row,
+// half-width widgets are paired side by side (or stacked when the terminal is narrow)
+func RenderHomeDashboard(widgets []HomeWidget, width int) string {
 	halfWidth := GetChartWidth(width)
-	borderColor := lipgloss.Color("62")
+	borderColor := theme.Border
 
 	leftStyle := lipgloss.NewStyle().
 		Width(halfWidth).
@@ -498,30 +845,60 @@ func RenderHomeDashboard(barChart, sparklineChart, cacheHitRatio, replicationLag
 		BorderForeground(borderColor).
 		Padding(0, 1)
 
-	// Bottom widgets: shorter height for single-value displays
-	bottomLeftStyle := leftStyle.Height(5)
-	bottomRightStyle := rightStyle.Height(5)
-
-	topRow := lipgloss.JoinHorizontal(lipgloss.Top,
-		leftStyle.Render(barChart),
-		rightStyle.Render(sparklineChart),
-	)
-
-	bottomRow := lipgloss.JoinHorizontal(lipgloss.Top,
-		bottomLeftStyle.Render(cacheHitRatio),
-		bottomRightStyle.Render(replicationLag),
-	)
-
-	// Full-width blocking locks widget at the top
 	fullWidthStyle := lipgloss.NewStyle().
 		Width(width-4).
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(borderColor).
 		Padding(0, 1)
 
-	blockingLocksRow := fullWidthStyle.Render(blockingLocks)
+	// Too narrow for two columns: give every widget its own row
+	perRow := 2
+	if halfWidth < 30 {
+		perRow = 1
+	}
+
+	var rows []string
+	var pending []HomeWidget
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		var blocks []string
+		for i, w := range pending {
+			style := rightStyle
+			if perRow == 1 {
+				style = fullWidthStyle
+			} else if i < len(pending)-1 {
+				style = leftStyle
+			}
+			if w.Compact {
+				style = style.Height(5)
+			}
+			blocks = append(blocks, style.Render(w.Content))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, blocks...))
+		pending = nil
+	}
+
+	for _, w := range widgets {
+		if w.FullWidth {
+			flush()
+			rows = append(rows, fullWidthStyle.Render(w.Content))
+			continue
+		}
+		pending = append(pending, w)
+		if len(pending) == perRow {
+			flush()
+		}
+	}
+	flush()
+
+	if len(rows) == 0 {
+		return "No Home widgets configured"
+	}
 
-	return lipgloss.JoinVertical(lipgloss.Left, blockingLocksRow, topRow, bottomRow)
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
 }
 
 // RenderHomeSideBySide renders both charts in side-by-side blocks (legacy, unused)
@@ -531,13 +908,13 @@ func RenderHomeSideBySide(barChart, sparklineChart string, width int) string {
 	leftStyle := lipgloss.NewStyle().
 		Width(halfWidth).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(theme.Border).
 		MarginRight(2)
 
 	rightStyle := lipgloss.NewStyle().
 		Width(halfWidth).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62"))
+		BorderForeground(theme.Border)
 
 	return lipgloss.JoinHorizontal(
 		lipgloss.Top,
@@ -555,7 +932,7 @@ func RenderHomeWithTable(barChart, sparklineChart, activeTable string, width int
 	tableStyle := lipgloss.NewStyle().
 		Width(width - 4).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(theme.Border).
 		Padding(1)
 
 	// Combine charts and table vertically
@@ -579,7 +956,7 @@ type BlockingLockInfo struct {
 }
 
 // GetBlockingLockInfo queries for the count of blocked queries and max wait time
-func GetBlockingLockInfo(db *sql.DB) (BlockingLockInfo, error) {
+func GetBlockingLockInfo(db queryer) (BlockingLockInfo, error) {
 	sqlQuery := `
 		SELECT
 			COUNT(DISTINCT l.pid) AS blocked_count,
@@ -610,13 +987,13 @@ func GetBlockingLockInfo(db *sql.DB) (BlockingLockInfo, error) {
 }
 
 // RenderBlockingLocks renders the blocking locks widget (full width)
-func RenderBlockingLocks(db *sql.DB) string {
+func RenderBlockingLocks(db queryer) string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86"))
+		Foreground(theme.Primary)
 
 	errorStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("9"))
+		Foreground(theme.Error)
 
 	info, err := GetBlockingLockInfo(db)
 	if err != nil {
@@ -626,7 +1003,7 @@ func RenderBlockingLocks(db *sql.DB) string {
 
 	if !info.Valid {
 		okStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
+			Foreground(theme.Success).
 			PaddingTop(1)
 		return titleStyle.Render("Blocked Queries") + "\n" +
 			okStyle.Render("✓ No blocked queries")
@@ -635,16 +1012,16 @@ func RenderBlockingLocks(db *sql.DB) string {
 	var waitColor lipgloss.Color
 	switch {
 	case info.LongestWaitSec < 5:
-		waitColor = lipgloss.Color("11") // Yellow - short wait
+		waitColor = theme.Warning // Yellow - short wait
 	case info.LongestWaitSec < 30:
-		waitColor = lipgloss.Color("208") // Orange - concerning
+		waitColor = theme.Caution // Orange - concerning
 	default:
-		waitColor = lipgloss.Color("9") // Red - critical
+		waitColor = theme.Error // Red - critical
 	}
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("9")).
+		Foreground(theme.Error).
 		PaddingTop(1)
 
 	waitStyle := lipgloss.NewStyle().
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestConnectionUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		usage       ConnectionUsage
+		wantPercent float64
+		wantColor   lipgloss.Color
+	}{
+		{
+			name:        "low usage is green",
+			usage:       ConnectionUsage{Current: 10, MaxConnections: 100},
+			wantPercent: 10,
+			wantColor:   lipgloss.Color("10"),
+		},
+		{
+			name:        "exactly 70 percent is still green",
+			usage:       ConnectionUsage{Current: 70, MaxConnections: 100},
+			wantPercent: 70,
+			wantColor:   lipgloss.Color("10"),
+		},
+		{
+			name:        "past 70 percent is yellow",
+			usage:       ConnectionUsage{Current: 75, MaxConnections: 100},
+			wantPercent: 75,
+			wantColor:   lipgloss.Color("11"),
+		},
+		{
+			name:        "past 90 percent is red",
+			usage:       ConnectionUsage{Current: 95, MaxConnections: 100},
+			wantPercent: 95,
+			wantColor:   lipgloss.Color("9"),
+		},
+		{
+			name:        "zero max connections",
+			usage:       ConnectionUsage{Current: 5, MaxConnections: 0},
+			wantPercent: 0,
+			wantColor:   lipgloss.Color("10"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.usage.Percent()
+			if got != tt.wantPercent {
+				t.Errorf("Percent() = %v, want %v", got, tt.wantPercent)
+			}
+			if color := connectionUsageColor(got); color != tt.wantColor {
+				t.Errorf("connectionUsageColor(%v) = %v, want %v", got, color, tt.wantColor)
+			}
+		})
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{5 * time.Hour, "5 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{60 * 24 * time.Hour, "2 months ago"},
+		{400 * 24 * time.Hour, "1 year ago"},
+	}
+	for _, tt := range tests {
+		if got := timeAgo(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("timeAgo(-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestRenderStateChart(t *testing.T) {
+	states := func(names ...string) []barchart.BarData {
+		var data []barchart.BarData
+		for i, name := range names {
+			data = append(data, barchart.BarData{
+				Label:  name,
+				Values: []barchart.BarValue{{Value: float64(len(names) - i)}},
+			})
+		}
+		return data
+	}
+
+	t.Run("grows with the number of states", func(t *testing.T) {
+		names := []string{"active", "idle", "idle in transaction", "idle in transaction (aborted)", "fastpath function call", "disabled"}
+		chart := renderStateChart("Connections", states(names...), 80, 40)
+		for i, name := range names {
+			if want := fmt.Sprintf("%s (%d)", name, len(names)-i); !strings.Contains(chart, want) {
+				t.Errorf("chart is missing %q:\n%s", want, chart)
+			}
+		}
+		if !strings.Contains(chart, "Connections (21)") {
+			t.Errorf("chart title should total every state:\n%s", chart)
+		}
+	})
+
+	t.Run("drops the smallest states past the max height", func(t *testing.T) {
+		chart := renderStateChart("Connections", states("a", "b", "c", "d", "e", "f", "g", "h"), 80, 5)
+		if !strings.Contains(chart, "e (4)") || strings.Contains(chart, "f (3)") {
+			t.Errorf("expected the five largest states only:\n%s", chart)
+		}
+		if !strings.Contains(chart, "Connections (36)") {
+			t.Errorf("dropped states should still be counted in the title:\n%s", chart)
+		}
+	})
+
+	t.Run("truncates long labels but keeps the count", func(t *testing.T) {
+		chart := renderStateChart("Connections", states(strings.Repeat("x", 100)), 40, 10)
+		if !strings.Contains(chart, "~ (1)") {
+			t.Errorf("expected a truncated label ending in its count:\n%s", chart)
+		}
+		for _, line := range strings.Split(chart, "\n") {
+			if w := lipgloss.Width(line); w > 34 {
+				t.Errorf("line is %d wide, want at most 34: %q", w, line)
+			}
+		}
+	})
+}
+
+func TestChartValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  float64
+	}{
+		{"int64", int64(42), 42},
+		{"float64", 3.5, 3.5},
+		{"bytes", []byte("17"), 17},
+		{"numeric bytes", []byte("123456789012.5"), 123456789012.5},
+		{"string", "8", 8},
+		{"padded string", " 9 ", 9},
+		{"nil", nil, 0},
+		{"unparseable bytes", []byte("n/a"), 0},
+		{"unsupported type", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chartValue(tt.value); got != tt.want {
+				t.Errorf("chartValue(%#v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightDominantBar(t *testing.T) {
+	bars := func(values ...float64) []barchart.BarData {
+		var data []barchart.BarData
+		for _, v := range values {
+			data = append(data, barchart.BarData{Label: "client", Values: []barchart.BarValue{{Value: v, Style: lipgloss.NewStyle().Foreground(theme.Info)}}})
+		}
+		return data
+	}
+
+	storm := bars(80, 10, 10)
+	highlightDominantBar(storm)
+	if got := storm[0].Values[0].Style.GetForeground(); got != theme.Warning {
+		t.Errorf("a client with most connections should be highlighted, got %v", got)
+	}
+
+	even := bars(40, 30, 30)
+	highlightDominantBar(even)
+	if got := even[0].Values[0].Style.GetForeground(); got != theme.Info {
+		t.Errorf("a client under half the connections shouldn't be highlighted, got %v", got)
+	}
+
+	// A single client is all of its own connections; that's no storm
+	alone := bars(5)
+	highlightDominantBar(alone)
+	if got := alone[0].Values[0].Style.GetForeground(); got != theme.Info {
+		t.Errorf("a lone client shouldn't be highlighted, got %v", got)
+	}
+}
+
+func TestDatabaseSizeNotDefault(t *testing.T) {
+	// Sizing tables every refresh is too heavy to run unless asked for
+	for _, w := range defaultHomeWidgets {
+		if w == WidgetDatabaseSize {
+			t.Errorf("%s should not be a default Home widget", WidgetDatabaseSize)
+		}
+	}
+	if !isHomeWidget(WidgetDatabaseSize) {
+		t.Errorf("%s should still be accepted in home_widgets", WidgetDatabaseSize)
+	}
+}
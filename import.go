@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ImportStage represents the current step of an interactive query import
+type ImportStage int
+
+const (
+	ImportStagePickFile ImportStage = iota
+	ImportStageConflict
+	ImportStageDone
+)
+
+// ImportView holds the state for importing queries from a dump file
+type ImportView struct {
+	Stage         ImportStage
+	Dir           string   // directory the dump files were listed from
+	Files         []string // dump file names in Dir
+	SelectedIndex int
+	Conflicts     []Query // incoming queries whose name exists with different content
+	Imported      int
+	Skipped       int
+	Unchanged     int
+	Archived      []string // incoming names an archived query still holds, skipped
+	LastError     string
+}
+
+// NewImportView creates an ImportView listing the dump files in dir
+func NewImportView(dir string) *ImportView {
+	iv := &ImportView{Stage: ImportStagePickFile, Dir: dir}
+	files, err := listDumpFiles(dir)
+	if err != nil {
+		iv.LastError = err.Error()
+	}
+	iv.Files = files
+	return iv
+}
+
+// defaultDumpPath returns the dump psq seeds a new query database from on startup
+func defaultDumpPath() string {
+	return filepath.Join(configDir(), "default_queries.db")
+}
+
+// dumpFileName is the name the d key suggests, timestamped so successive dumps don't
+// replace each other
+func dumpFileName(now time.Time) string {
+	return "queries-" + now.Format("20060102-150405") + ".db"
+}
+
+// resolveDumpPath turns the name typed at the dump prompt into a path. Bare names go in
+// the config directory, where the import browser lists them, and get a .db extension
+// if they have none.
+func resolveDumpPath(input string) string {
+	path := strings.TrimSpace(input)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir(), path)
+	}
+	if filepath.Ext(path) == "" {
+		path += ".db"
+	}
+	return path
+}
+
+// listDumpFiles returns the .db dumps and .json exports in dir, excluding the live query database
+func listDumpFiles(dir string) ([]string, error) {
+	var matches []string
+	for _, pattern := range []string{"*.db", "*.json"} {
+		found, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dump files: %w", err)
+		}
+		matches = append(matches, found...)
+	}
+
+	var files []string
+	for _, match := range matches {
+		name := filepath.Base(match)
+		if name == "queries.db" || name == "config.json" {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// sameQuery checks if two queries have identical content
+func sameQuery(a, b Query) bool {
+	if a.Name != b.Name || a.Description != b.Description || a.SQL != b.SQL || a.NoAutoRefresh != b.NoAutoRefresh ||
+		normalizeTags(a.Tags) != normalizeTags(b.Tags) || a.CacheTTL != b.CacheTTL {
+		return false
+	}
+	if a.OrderPosition == nil || b.OrderPosition == nil {
+		return a.OrderPosition == nil && b.OrderPosition == nil
+	}
+	return *a.OrderPosition == *b.OrderPosition
+}
+
+// planImport splits incoming queries into ones that can be saved without asking,
+// ones that would overwrite a different existing query, the names an archived query
+// still holds, and a count of exact duplicates
+func planImport(existing, archived, incoming []Query) (toSave []Query, conflicts []Query, held []string, unchanged int) {
+	byName := make(map[string]Query, len(existing))
+	for _, q := range existing {
+		byName[q.Name] = q
+	}
+	archivedNames := make(map[string]bool, len(archived))
+	for _, q := range archived {
+		archivedNames[q.Name] = true
+	}
+
+	for _, q := range incoming {
+		current, exists := byName[q.Name]
+		switch {
+		case archivedNames[q.Name]:
+			held = append(held, q.Name)
+		case !exists:
+			toSave = append(toSave, q)
+		case sameQuery(current, q):
+			unchanged++
+		default:
+			conflicts = append(conflicts, q)
+		}
+	}
+	return toSave, conflicts, held, unchanged
+}
+
+// RenderImportView renders the dump file picker, conflict prompt, or import summary
+func RenderImportView(iv *ImportView) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary).
+		Background(theme.SelectedBg)
+
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+
+	switch iv.Stage {
+	case ImportStagePickFile:
+		b.WriteString(titleStyle.Render("Import Queries"))
+		b.WriteString("\n\n")
+		if len(iv.Files) == 0 {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  No dump files found in %s", iv.Dir)))
+			b.WriteString("\n")
+		}
+		for i, file := range iv.Files {
+			if i == iv.SelectedIndex {
+				b.WriteString(selectedStyle.Render("▶ " + file))
+			} else {
+				b.WriteString("  " + file)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  up/down: select  enter: import  esc: cancel"))
+
+	case ImportStageConflict:
+		q := iv.Conflicts[0]
+		b.WriteString(warnStyle.Render(fmt.Sprintf("%q already exists. Overwrite? (%d remaining)", q.Name, len(iv.Conflicts))))
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  Description: " + q.Description))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  SQL: " + truncate(scrubNewlines(q.SQL), 60)))
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  o: overwrite  s: skip  a: overwrite all  esc: skip all"))
+
+	case ImportStageDone:
+		b.WriteString(titleStyle.Render("Import Complete"))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("  %d imported, %d skipped, %d already up to date\n\n", iv.Imported, iv.Skipped, iv.Unchanged))
+		if len(iv.Archived) > 0 {
+			b.WriteString(dimStyle.Render("  Archived, restore or delete them from the archive (A) first: " + strings.Join(iv.Archived, ", ")))
+			b.WriteString("\n\n")
+		}
+		b.WriteString(dimStyle.Render("  press any key to continue"))
+	}
+
+	if iv.LastError != "" {
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render("  Error: " + iv.LastError))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestPlanImport(t *testing.T) {
+	existing := []Query{
+		{Name: "Same", Description: "Unchanged", SQL: "SELECT 1", OrderPosition: intPtr(1)},
+		{Name: "Changed", Description: "Old", SQL: "SELECT 2"},
+		{Name: "Moved", Description: "Position differs", SQL: "SELECT 3", OrderPosition: intPtr(2)},
+	}
+	incoming := []Query{
+		{Name: "Same", Description: "Unchanged", SQL: "SELECT 1", OrderPosition: intPtr(1)},
+		{Name: "Changed", Description: "New", SQL: "SELECT 2"},
+		{Name: "Moved", Description: "Position differs", SQL: "SELECT 3"},
+		{Name: "Brand New", Description: "Not in library", SQL: "SELECT 4"},
+	}
+
+	toSave, conflicts, _, unchanged := planImport(existing, nil, incoming)
+
+	if len(toSave) != 1 || toSave[0].Name != "Brand New" {
+		t.Errorf("toSave = %v, want [Brand New]", toSave)
+	}
+	if len(conflicts) != 2 || conflicts[0].Name != "Changed" || conflicts[1].Name != "Moved" {
+		t.Errorf("conflicts = %v, want [Changed Moved]", conflicts)
+	}
+	if unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", unchanged)
+	}
+}
+
+func TestResolveDumpPath(t *testing.T) {
+	setEnv(t, map[string]string{"HOME": "/home/test", "PSQ_CONFIG_DIR": "/srv/psq"})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"queries-20240101-120000.db", "/srv/psq/queries-20240101-120000.db"},
+		{"before-upgrade", "/srv/psq/before-upgrade.db"},
+		{" team.db ", "/srv/psq/team.db"},
+		{"backups/team.db", "/srv/psq/backups/team.db"},
+		{"~/shared/team.db", "/home/test/shared/team.db"},
+		{"/tmp/team.db", "/tmp/team.db"},
+	}
+	for _, tt := range tests {
+		if got := resolveDumpPath(tt.input); got != tt.want {
+			t.Errorf("resolveDumpPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if got := dumpFileName(time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)); got != "queries-20240309-140507.db" {
+		t.Errorf("dumpFileName() = %q", got)
+	}
+}
+
+func TestDumpPrompt(t *testing.T) {
+	zone.NewGlobal()
+	dir := t.TempDir()
+	setEnv(t, map[string]string{"PSQ_CONFIG_DIR": dir})
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+	if err := qdb.SaveQuery(Query{Name: "Locks", SQL: "SELECT 1"}); err != nil {
+		t.Fatalf("SaveQuery() error = %v", err)
+	}
+
+	m := &Model{ready: true, width: 80, viewport: viewport.New(80, 40)}
+	m.handleOpenDumpPrompt()
+	if !m.dumpMode || !strings.HasPrefix(m.dumpInput, "queries-") {
+		t.Fatalf("dump prompt = %v with %q, want a timestamped suggestion", m.dumpMode, m.dumpInput)
+	}
+
+	m.dumpInput = "team"
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	path := filepath.Join(dir, "team.db")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("dump file not written: %v", err)
+	}
+	if m.dumpMode || !strings.Contains(m.status, path) || m.err != "" {
+		t.Errorf("dumpMode = %v, status = %q, err = %q; want the dump confirmed", m.dumpMode, m.status, m.err)
+	}
+
+	// An existing file needs a second enter
+	m.handleOpenDumpPrompt()
+	m.dumpInput = "team.db"
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.dumpMode || !m.dumpOverwrite {
+		t.Fatalf("dumpMode = %v, dumpOverwrite = %v; want an overwrite warning", m.dumpMode, m.dumpOverwrite)
+	}
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.dumpMode {
+		t.Error("second enter should replace the file and close the prompt")
+	}
+
+	// Spaces go into the name, and keys the prompt doesn't use leave it open
+	m.handleOpenDumpPrompt()
+	m.dumpInput = "team"
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeySpace})
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyTab})
+	if !m.dumpMode || m.dumpInput != "team b" {
+		t.Errorf("dumpMode = %v, dumpInput = %q; want the prompt open with %q", m.dumpMode, m.dumpInput, "team b")
+	}
+
+	m.handleDumpPromptKeys(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.dumpMode {
+		t.Error("esc should close the prompt")
+	}
+}
+
+func TestImportSkipsArchivedNames(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+	if err := qdb.SaveQuery(Query{Name: "a", SQL: "SELECT 1"}); err != nil {
+		t.Fatalf("SaveQuery() error = %v", err)
+	}
+	if err := qdb.ArchiveQuery("a"); err != nil {
+		t.Fatalf("ArchiveQuery() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "queries.json")
+	if err := ExportQueriesJSON([]Query{{Name: "b", SQL: "SELECT 2"}, {Name: "a", SQL: "SELECT 3"}}, path); err != nil {
+		t.Fatalf("ExportQueriesJSON() error = %v", err)
+	}
+
+	m := &Model{importView: &ImportView{}}
+	m.startImport(path)
+	iv := m.importView
+	if iv.Imported != 1 || iv.Skipped != 1 || len(iv.Archived) != 1 || iv.Archived[0] != "a" || iv.LastError != "" {
+		t.Errorf("import = %d imported, %d skipped, archived %v, error %q; want b imported and a skipped", iv.Imported, iv.Skipped, iv.Archived, iv.LastError)
+	}
+	if _, err := qdb.GetQuery("b"); err != nil {
+		t.Errorf("b was not imported: %v", err)
+	}
+	archived, err := qdb.LoadArchivedQueries()
+	if err != nil || len(archived) != 1 || archived[0].SQL != "SELECT 1" {
+		t.Errorf("LoadArchivedQueries() = %+v, %v; want a archived unchanged", archived, err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the key bindings for the query view. The key handlers match against
+// these bindings and the help screen is rendered from them, so the two can't drift.
+type keyMap struct {
+	// Query navigation
+	PrevTab key.Binding
+	NextTab key.Binding
+	JumpTab key.Binding
+	GotoTab key.Binding
+	Refresh key.Binding
+	Cancel  key.Binding
+
+	// Viewport navigation
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+
+	// Query operations
+	Search     key.Binding
+	Edit       key.Binding
+	New        key.Binding
+	Pin        key.Binding
+	Wrap       key.Binding
+	HumanBytes key.Binding
+	Diff       key.Binding
+	Density    key.Binding
+	Dump       key.Binding
+	Import     key.Binding
+	Archived   key.Binding
+	Columns    key.Binding
+	Explain    key.Binding
+	Diagnose   key.Binding
+	Listen     key.Binding
+	Fleet      key.Binding
+	Schema     key.Binding
+	Psql       key.Binding
+	CopyPsql   key.Binding
+	CopySQL    key.Binding
+	ResetStats key.Binding
+	Dismiss    key.Binding
+
+	// Editor
+	Save      key.Binding
+	Delete    key.Binding
+	Purge     key.Binding
+	Generate  key.Binding
+	EditSQL   key.Binding
+	NextField key.Binding
+	Undo      key.Binding
+	Redo      key.Binding
+	Discard   key.Binding
+
+	// Active tab
+	ActiveUp      key.Binding
+	ActiveDown    key.Binding
+	ActiveDetails key.Binding
+	Terminate     key.Binding
+	CancelBackend key.Binding
+	KillMatching  key.Binding
+	CancelUser    key.Binding
+	ShowIdle      key.Binding
+	ShowWorkers   key.Binding
+	ActiveScope   key.Binding
+	ActiveTabs    key.Binding
+	CopyQuery     key.Binding
+	Back          key.Binding
+	Confirm       key.Binding
+	ConfirmCancel key.Binding
+	TypedConfirm  key.Binding
+	Decline       key.Binding
+
+	// System
+	Help   key.Binding
+	Reload key.Binding
+	Picker key.Binding
+	Quit   key.Binding
+}
+
+// keys is the query view's key map
+var keys = newKeyMap()
+
+func newKeyMap() keyMap {
+	return keyMap{
+		PrevTab: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "previous query (or click a tab)")),
+		NextTab: key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "next query")),
+		JumpTab: key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "jump to tab by number")),
+		GotoTab: key.NewBinding(key.WithKeys("g"), key.WithHelp("g<n> enter", "jump to tab n (for tabs past 9)")),
+		Refresh: key.NewBinding(key.WithKeys("enter", " ", "r"), key.WithHelp("enter/space/r", "execute query")),
+		Cancel:  key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "cancel running query")),
+
+		Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "scroll up (or mouse wheel)")),
+		Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "scroll down")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+		PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+		Top:      key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "go to top")),
+		Bottom:   key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "go to bottom")),
+
+		Search:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "search queries and their SQL (↑/↓ navigate, enter select, tab sort, ctrl+f names only, esc cancel)")),
+		Edit:       key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit query")),
+		New:        key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new query")),
+		Pin:        key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "pin search-opened tab / unpin saved tab")),
+		Wrap:       key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "wrap long cells in result tables instead of truncating")),
+		HumanBytes: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "toggle KB/MB/GB for byte-count columns (size, bytes)")),
+		Diff:       key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "diff mode: highlight new, changed and gone rows since the last refresh")),
+		Density:    key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "toggle compact tables: tighter columns and no border")),
+		Dump:       key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "dump queries to a file in ~/.psq (name prompted, timestamped by default)")),
+		Import:     key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "import queries from a dump file in ~/.psq")),
+		Archived:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "archived queries (restore, or delete for good)")),
+		Columns:    key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "choose which result columns to show (saved per query)")),
+		Explain:    key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "explain this query in plain English with ChatGPT or Ollama")),
+		Diagnose:   key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "diagnose current activity with ChatGPT or Ollama (Home and Active tabs)")),
+		Listen:     key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "LISTEN on a channel and watch NOTIFY payloads arrive")),
+		Fleet:      key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "run this query on every service and combine the results")),
+		Schema:     key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "browse schemas, tables, columns and indexes (s drafts a SELECT)")),
+		Psql:       key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "psql prompt (optionally as another user@dbname)")),
+		CopyPsql:   key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "copy the psql command for this service (no password)")),
+		CopySQL:    key.NewBinding(key.WithKeys("Y"), key.WithHelp("Y", "copy this tab's SQL")),
+		ResetStats: key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "reset pg_stat_statements (Top Queries tab)")),
+		Dismiss:    key.NewBinding(key.WithKeys("W"), key.WithHelp("W", "dismiss the connection count warning for this session")),
+
+		Save:      key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save query")),
+		Delete:    key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "archive query (restore it with A)")),
+		Purge:     key.NewBinding(key.WithKeys("alt+d"), key.WithHelp("alt+d", "delete query for good")),
+		Generate:  key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "generate SQL with ChatGPT or Ollama")),
+		EditSQL:   key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "edit SQL in $EDITOR (discarded if the editor exits with an error)")),
+		NextField: key.NewBinding(key.WithKeys("tab", "shift+tab"), key.WithHelp("tab/shift+tab", "next/previous field")),
+		Undo:      key.NewBinding(key.WithKeys("ctrl+z"), key.WithHelp("ctrl+z", "undo SQL change (including AI replacements)")),
+		Redo:      key.NewBinding(key.WithKeys("ctrl+y"), key.WithHelp("ctrl+y", "redo SQL change")),
+		Discard:   key.NewBinding(key.WithKeys("esc", "escape", "ctrl+c", "ctrl+["), key.WithHelp("esc", "leave the editor without saving")),
+
+		ActiveUp:      key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "select previous process (or mouse wheel)")),
+		ActiveDown:    key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "select next process")),
+		ActiveDetails: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "view process details")),
+		Terminate:     key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "terminate backend (pg_terminate_backend; type yes to confirm)")),
+		CancelBackend: key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "cancel query (pg_cancel_backend; y to confirm)")),
+		KillMatching:  key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "terminate every backend whose query matches a pattern (lists PIDs; type yes to confirm)")),
+		CancelUser:    key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "cancel every running query of a user (lists PIDs, asks first)")),
+		ShowIdle:      key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "show/hide idle connections")),
+		ShowWorkers:   key.NewBinding(key.WithKeys("B"), key.WithHelp("B", "show/hide background workers (autovacuum, replication, parallel workers)")),
+		ActiveScope:   key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "only this database / whole instance")),
+		ActiveTabs:    key.NewBinding(key.WithKeys("left", "right", "h", "l"), key.WithHelp("←/→ 1-9", "switch tabs")),
+		CopyQuery:     key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "copy query to clipboard")),
+		Back:          key.NewBinding(key.WithKeys("esc", "ctrl+["), key.WithHelp("esc", "back to process list")),
+		Confirm:       key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm")),
+		ConfirmCancel: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm cancelling")),
+		TypedConfirm:  key.NewBinding(key.WithKeys("enter"), key.WithHelp(terminateConfirmWord+" ⏎", "confirm terminating: type "+terminateConfirmWord+", then enter (esc backs out)")),
+		Decline:       key.NewBinding(key.WithKeys("n", "esc", "ctrl+["), key.WithHelp("n/esc", "back without changes")),
+
+		Help:   key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "toggle help")),
+		Reload: key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "reload saved queries, e.g. after changing them from another terminal")),
+		Picker: key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "return to connection picker (outside the Active tab)")),
+		Quit:   key.NewBinding(key.WithKeys("esc", "ctrl+c"), key.WithHelp("esc/ctrl+c", "quit")),
+	}
+}
+
+// helpSection is a titled group of bindings on the help screen
+type helpSection struct {
+	title    string
+	bindings []key.Binding
+}
+
+// sections groups the query view's bindings the way the help screen shows them
+func (k keyMap) sections() []helpSection {
+	return []helpSection{
+		{"Query Navigation", []key.Binding{k.PrevTab, k.NextTab, k.JumpTab, k.GotoTab, k.Refresh, k.Cancel}},
+		{"Viewport Navigation", []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom}},
+		{"Query Operations", []key.Binding{k.Search, k.Edit, k.New, k.Pin, k.Wrap, k.HumanBytes, k.Diff, k.Density, k.Columns, k.Explain, k.Diagnose, k.Listen, k.Fleet, k.Schema, k.Dump, k.Import, k.Archived, k.Psql, k.CopyPsql, k.CopySQL, k.ResetStats, k.Dismiss}},
+		{"Editor", []key.Binding{k.Save, k.Delete, k.Purge, k.Generate, k.EditSQL, k.NextField, k.Undo, k.Redo, k.Discard}},
+		{"System", []key.Binding{k.Help, k.Reload, k.Picker, k.Quit}},
+	}
+}
+
+// activeHelpSection is a group of Active tab bindings and the view mode they apply in
+type activeHelpSection struct {
+	helpSection
+	mode ActiveViewMode
+}
+
+// activeSections groups the Active tab's bindings by view mode
+func (k keyMap) activeSections() []activeHelpSection {
+	return []activeHelpSection{
+		{helpSection{"Active View - Process List", []key.Binding{k.ActiveUp, k.ActiveDown, k.ActiveDetails, k.Terminate, k.CancelBackend, k.KillMatching, k.CancelUser, k.ShowIdle, k.ShowWorkers, k.ActiveScope, k.ActiveTabs}}, ActiveModeList},
+		{helpSection{"Active View - Process Details", []key.Binding{k.Terminate, k.CancelBackend, k.CopyQuery, k.Back}}, ActiveModeDetail},
+		{helpSection{"Active View - Confirm Terminate/Cancel", []key.Binding{k.TypedConfirm, k.ConfirmCancel, k.Decline}}, ActiveModeConfirmTerminate},
+		{helpSection{"Active View - Confirm Terminate Matching/Cancel User", []key.Binding{k.TypedConfirm, k.ConfirmCancel, k.Decline}}, ActiveModeConfirmKill},
+	}
+}
+
+// ShortHelp implements help.KeyMap
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Refresh, k.Search, k.Edit, k.Help, k.Quit}
+}
+
+// FullHelp implements help.KeyMap
+func (k keyMap) FullHelp() [][]key.Binding {
+	var groups [][]key.Binding
+	for _, s := range k.sections() {
+		groups = append(groups, s.bindings)
+	}
+	return groups
+}
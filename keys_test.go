@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestHelpCoversKeyMap(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{
+		queries:    []Query{HomeQuery(), ActiveQuery()},
+		selected:   1,
+		activeView: &ActiveView{Mode: ActiveModeList},
+	}
+	help := m.customHelpView()
+
+	sections := keys.sections()
+	for _, s := range keys.activeSections() {
+		sections = append(sections, s.helpSection)
+	}
+	for _, s := range sections {
+		if !strings.Contains(help, s.title) {
+			t.Errorf("help is missing section %q", s.title)
+		}
+		for _, b := range s.bindings {
+			if len(b.Keys()) == 0 || b.Help().Key == "" || b.Help().Desc == "" {
+				t.Errorf("%s: binding %v has no keys or help text", s.title, b.Keys())
+				continue
+			}
+			if !strings.Contains(help, b.Help().Desc) {
+				t.Errorf("help is missing %q (%s)", b.Help().Desc, b.Help().Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bulkBackendMsg is sent after terminating every backend a kill pattern matched, or
+// cancelling every query a user is running
+type bulkBackendMsg struct {
+	Action string // "terminate" or "cancel"
+	Done   []int
+	Errors []string // one per PID that failed, e.g. "PID 42: ..."
+}
+
+// parseKillPattern turns what was typed at the kill prompt into a query matcher. Text
+// between slashes, like /^alter table/, is a regular expression; anything else is a
+// substring. Both ignore case.
+func parseKillPattern(input string) (func(query string) bool, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	if len(input) > 2 && strings.HasPrefix(input, "/") && strings.HasSuffix(input, "/") {
+		re, err := regexp.Compile("(?i)" + input[1:len(input)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return re.MatchString, nil
+	}
+	needle := strings.ToLower(input)
+	return func(query string) bool {
+		return strings.Contains(strings.ToLower(query), needle)
+	}, nil
+}
+
+// matchingProcesses returns the processes whose query match accepts, in list order. An idle
+// session's query is the last one it ran, not one it's running, so idle sessions never match.
+func matchingProcesses(processes []ActiveProcess, match func(string) bool) []ActiveProcess {
+	var matched []ActiveProcess
+	for _, p := range processes {
+		if p.State != "idle" && p.Query != "" && match(p.Query) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// userQueries returns the queries username is running, in list order. Idle sessions have
+// nothing to cancel.
+func userQueries(processes []ActiveProcess, username string) []ActiveProcess {
+	var matched []ActiveProcess
+	for _, p := range processes {
+		if p.Username == username && p.State == "active" && p.Query != "" {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// StartKillPattern opens the prompt for a query pattern to terminate
+func (av *ActiveView) StartKillPattern() {
+	av.Mode = ActiveModeKillPattern
+	av.KillInput = ""
+	av.KillMatches = nil
+	av.KillSent = false
+	av.ConfirmInput = ""
+	av.LastError = ""
+	av.CancelUser = false
+}
+
+// StartCancelUser opens the prompt for a user whose running queries to cancel
+func (av *ActiveView) StartCancelUser() {
+	av.StartKillPattern()
+	av.CancelUser = true
+}
+
+// killAction is what confirming the prompt does to the listed backends
+func (av *ActiveView) killAction() string {
+	if av.CancelUser {
+		return "cancel"
+	}
+	return "terminate"
+}
+
+// killMatches looks up the backends for what was typed at the prompt: a query pattern, or a
+// user name when cancelling a user's queries
+func (av *ActiveView) killMatches() ([]ActiveProcess, error) {
+	// Match against the list as last fetched, which never includes psq's own backends
+	if av.CancelUser {
+		username := strings.TrimSpace(av.KillInput)
+		if username == "" {
+			return nil, fmt.Errorf("empty user name")
+		}
+		matches := userQueries(av.Processes, username)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s has no running queries", username)
+		}
+		return matches, nil
+	}
+	match, err := parseKillPattern(av.KillInput)
+	if err != nil {
+		return nil, err
+	}
+	matches := matchingProcesses(av.Processes, match)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no sessions match %s", av.KillInput)
+	}
+	return matches, nil
+}
+
+// closeKillPattern returns to the list without terminating anything
+func (av *ActiveView) closeKillPattern() {
+	av.Mode = ActiveModeList
+	av.KillMatches = nil
+	av.KillSent = false
+	av.ConfirmInput = ""
+}
+
+// handleKillPatternKeys edits the pattern or user name; enter looks up the backends it matches
+func (m *Model) handleKillPatternKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	av := m.activeView
+	switch msg.Type {
+	case tea.KeyEscape:
+		av.closeKillPattern()
+		av.LastError = ""
+	case tea.KeyEnter:
+		matches, err := av.killMatches()
+		if err != nil {
+			av.LastError = err.Error()
+			break
+		}
+		av.KillMatches = matches
+		av.Mode = ActiveModeConfirmKill
+		av.ConfirmInput = ""
+		av.LastError = ""
+	case tea.KeyBackspace:
+		if r := []rune(av.KillInput); len(r) > 0 {
+			av.KillInput = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		av.KillInput += " "
+	case tea.KeyRunes:
+		av.KillInput += string(msg.Runes)
+	default:
+		if msg.String() == "ctrl+[" {
+			av.closeKillPattern()
+			av.LastError = ""
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handleConfirmKillKeys cancels the listed backends' queries on y, or goes back to the list.
+// Terminating them takes terminateConfirmWord, like terminating a single backend.
+func (m *Model) handleConfirmKillKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	av := m.activeView
+	if av.killAction() == "terminate" {
+		return m.handleTypedKillKeys(msg)
+	}
+	switch {
+	case key.Matches(msg, keys.Confirm):
+		return m, m.sendKillMatches()
+	case key.Matches(msg, keys.Decline):
+		av.closeKillPattern()
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// handleTypedKillKeys edits the answer to the terminate-matching prompt; enter terminates
+// every listed backend only once it reads terminateConfirmWord, and esc goes back to the list
+func (m *Model) handleTypedKillKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	av := m.activeView
+	switch msg.Type {
+	case tea.KeyEscape:
+		av.closeKillPattern()
+		av.LastError = ""
+	case tea.KeyEnter:
+		if !strings.EqualFold(strings.TrimSpace(av.ConfirmInput), terminateConfirmWord) {
+			av.LastError = "Type " + terminateConfirmWord + " to terminate, or esc to go back"
+			break
+		}
+		return m, m.sendKillMatches()
+	case tea.KeyBackspace:
+		if r := []rune(av.ConfirmInput); len(r) > 0 {
+			av.ConfirmInput = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		av.ConfirmInput += string(msg.Runes)
+	default:
+		if msg.String() == "ctrl+[" {
+			av.closeKillPattern()
+			av.LastError = ""
+		}
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// sendKillMatches terminates or cancels the listed backends, once: confirming again before
+// the result arrives does nothing
+func (m *Model) sendKillMatches() tea.Cmd {
+	av := m.activeView
+	if av.KillSent {
+		return nil
+	}
+	av.KillSent = true
+	av.LastError = ""
+	pids := make([]int, len(av.KillMatches))
+	for i, p := range av.KillMatches {
+		pids[i] = p.PID
+	}
+	return m.executeBulkAction(pids, av.killAction())
+}
+
+// executeBulkAction calls pg_terminate_backend, or pg_cancel_backend when action is
+// "cancel", for each PID in the background. A PID that fails doesn't stop the rest.
+func (m *Model) executeBulkAction(pids []int, action string) tea.Cmd {
+	return func() tea.Msg {
+		if m.readOnly() {
+			return bulkBackendMsg{Action: action, Errors: []string{action + " is disabled in read-only mode"}}
+		}
+		db := m.db
+		if db == nil {
+			return bulkBackendMsg{Action: action, Errors: []string{"no database connection"}}
+		}
+		result := bulkBackendMsg{Action: action}
+		for _, pid := range pids {
+			var err error
+			start := time.Now()
+			if action == "cancel" {
+				err = CancelBackend(db, pid)
+				logStatement(m.service, fmt.Sprintf("SELECT pg_cancel_backend(%d)", pid), start, err)
+			} else {
+				err = TerminateBackend(db, pid)
+				logStatement(m.service, fmt.Sprintf("SELECT pg_terminate_backend(%d)", pid), start, err)
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("PID %d: %v", pid, err))
+				continue
+			}
+			result.Done = append(result.Done, pid)
+		}
+		return result
+	}
+}
+
+// bulkResultStatus names the PIDs a bulk action went through for, e.g.
+// "Cancelled 2 sessions (PIDs 101, 103)"
+func bulkResultStatus(action string, done []int) string {
+	verb := "Terminated"
+	if action == "cancel" {
+		verb = "Cancelled"
+	}
+	pids := make([]string, len(done))
+	for i, pid := range done {
+		pids[i] = fmt.Sprint(pid)
+	}
+	label := "PIDs"
+	if len(done) == 1 {
+		label = "PID"
+	}
+	return fmt.Sprintf("%s %s (%s %s)", verb, sessionCount(len(done)), label, strings.Join(pids, ", "))
+}
+
+// handleBulkActionResult reports which backends were terminated or cancelled, and why any
+// failed, then refreshes the list
+func (m *Model) handleBulkActionResult(msg bulkBackendMsg) (tea.Model, tea.Cmd) {
+	if m.activeView == nil {
+		return m, nil
+	}
+	m.activeView.closeKillPattern()
+	m.activeView.LastError = strings.Join(msg.Errors, "; ")
+	var cmds []tea.Cmd
+	if len(msg.Done) > 0 {
+		cmds = append(cmds, m.setStatus(bulkResultStatus(msg.Action, msg.Done)))
+	}
+	m.loading = true
+	m.updateContent()
+	cmds = append(cmds, m.runQuery(m.lastQuery))
+	return m, tea.Batch(cmds...)
+}
+
+// sessionCount formats n as "1 session" or "n sessions"
+func sessionCount(n int) string {
+	if n == 1 {
+		return "1 session"
+	}
+	return fmt.Sprintf("%d sessions", n)
+}
+
+// RenderKillPattern renders the prompt for a query pattern to terminate, or a user whose
+// queries to cancel
+func RenderKillPattern(av *ActiveView) string {
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var b strings.Builder
+	if av.CancelUser {
+		b.WriteString(warnStyle.Render("Cancel every running query of user: ") + av.KillInput + "█")
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  The exact role name, as in the user column. Sessions stay connected; only their queries stop."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  The user's queries are listed for confirmation before anything is cancelled."))
+	} else {
+		b.WriteString(warnStyle.Render("Terminate sessions whose query matches: ") + av.KillInput + "█")
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  Text matches anywhere in the query, ignoring case; /regex/ for a regular expression."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  Matching sessions are listed for confirmation before anything is terminated."))
+	}
+	if av.LastError != "" {
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  " + av.LastError))
+	}
+	return b.String()
+}
+
+// RenderConfirmKill lists every backend the pattern or user matched and asks before
+// terminating them or cancelling their queries
+func RenderConfirmKill(av *ActiveView, width int) string {
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	pids := make([]string, len(av.KillMatches))
+	for i, p := range av.KillMatches {
+		pids[i] = fmt.Sprint(p.PID)
+	}
+
+	var b strings.Builder
+	if av.CancelUser {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Cancel the queries of %s for user %s? (y/n)", sessionCount(len(av.KillMatches)), strings.TrimSpace(av.KillInput))))
+	} else {
+		dangerStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.HeaderFg).
+			Background(theme.Error)
+		b.WriteString(dangerStyle.Render(fmt.Sprintf(" Terminate %s matching %q? ", sessionCount(len(av.KillMatches)), av.KillInput)))
+		b.WriteString(warnStyle.Render(" This closes each connection and rolls back any open transaction."))
+	}
+	b.WriteString("\n\n")
+	b.WriteString("  PIDs: " + strings.Join(pids, ", "))
+	b.WriteString("\n\n")
+	queryW := max(width-50, 20)
+	for _, p := range av.KillMatches {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %-8d %-24s %-10s %s", p.PID, truncate(p.Username+"@"+p.Database, 24), p.Duration, truncate(scrubNewlines(p.Query), queryW))))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	if av.CancelUser {
+		b.WriteString(dimStyle.Render("  y: cancel all listed  n/esc: back without changes"))
+	} else {
+		b.WriteString(fmt.Sprintf("  Type %s and press enter to terminate all listed: %s█", terminateConfirmWord, av.ConfirmInput))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  esc: back without changes"))
+	}
+	if av.LastError != "" {
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  " + av.LastError))
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestParseKillPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		query   string
+		want    bool
+	}{
+		{"alter table", "ALTER TABLE orders ADD COLUMN x int", true},
+		{"alter table", "SELECT * FROM orders", false},
+		{"select *", "select * from orders", true},
+		{"/^update orders/", "UPDATE orders SET x = 1", true},
+		{"/^update orders/", "-- job\nUPDATE orders SET x = 1", false},
+		{"/orders|invoices/", "DELETE FROM invoices", true},
+	}
+	for _, tt := range tests {
+		match, err := parseKillPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("parseKillPattern(%q): %v", tt.pattern, err)
+		}
+		if got := match(tt.query); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.query, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "  ", "/(unclosed/"} {
+		if _, err := parseKillPattern(bad); err == nil {
+			t.Errorf("parseKillPattern(%q) should fail", bad)
+		}
+	}
+}
+
+func TestKillPatternFlow(t *testing.T) {
+	zone.NewGlobal()
+
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{
+		{PID: 101, State: "active", Query: "ALTER TABLE orders ADD COLUMN x int"},
+		{PID: 102, State: "active", Query: "SELECT 1"},
+		{PID: 103, State: "active", Query: "alter table orders drop column y"},
+		{PID: 104, State: "idle", Query: "ALTER TABLE orders ADD COLUMN z int"}, // ran it once, now idle in the pool
+	})
+	m := &Model{
+		queries:    []Query{ActiveQuery()},
+		activeView: av,
+		ready:      true,
+		width:      120,
+		height:     40,
+		viewport:   viewport.New(120, 40),
+	}
+	press := func(s string) {
+		m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+	}
+
+	press("K")
+	if av.Mode != ActiveModeKillPattern {
+		t.Fatalf("K should open the pattern prompt, mode %d", av.Mode)
+	}
+	press("nomatch")
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if av.Mode != ActiveModeKillPattern || av.LastError == "" {
+		t.Fatalf("a pattern matching nothing should stay on the prompt with an error, mode %d", av.Mode)
+	}
+
+	av.KillInput = "alter"
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if av.Mode != ActiveModeConfirmKill || len(av.KillMatches) != 2 {
+		t.Fatalf("mode %d, matches %v; want to confirm two sessions", av.Mode, av.KillMatches)
+	}
+	out := RenderConfirmKill(av, 120)
+	if !strings.Contains(out, "PIDs: 101, 103") || !strings.Contains(out, "Terminate 2 sessions") {
+		t.Errorf("confirmation should list exactly the matching PIDs:\n%s", out)
+	}
+
+	// Terminating takes the typed word, like a single terminate; y alone does nothing
+	if _, cmd := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); cmd != nil {
+		t.Fatal("y alone shouldn't terminate the matching sessions")
+	}
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if av.Mode != ActiveModeConfirmKill || av.LastError == "" {
+		t.Errorf("enter without %s should stay on the prompt with an error, mode %d", terminateConfirmWord, av.Mode)
+	}
+	av.ConfirmInput = ""
+	press(terminateConfirmWord)
+	_, cmd := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("%s and enter should terminate the matching sessions", terminateConfirmWord)
+	}
+	if _, again := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter}); again != nil {
+		t.Error("a second enter before the result arrived terminated the sessions again")
+	}
+
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEscape})
+	if av.Mode != ActiveModeList || av.KillMatches != nil {
+		t.Errorf("esc should return to the list, mode %d", av.Mode)
+	}
+}
+
+func TestBulkTerminateReadOnly(t *testing.T) {
+	m := &Model{config: &Config{ReadOnly: true}}
+	msg := m.executeBulkAction([]int{101, 103}, "terminate")().(bulkBackendMsg)
+	if len(msg.Done) != 0 || len(msg.Errors) != 1 || !strings.Contains(msg.Errors[0], "read-only") {
+		t.Errorf("read-only mode should terminate nothing, got %+v", msg)
+	}
+}
+
+func TestCancelUserFlow(t *testing.T) {
+	zone.NewGlobal()
+
+	av := NewActiveView()
+	av.UpdateSelection([]ActiveProcess{
+		{PID: 101, Username: "app", State: "active", Query: "SELECT pg_sleep(600)"},
+		{PID: 102, Username: "app", State: "idle in transaction", Query: "UPDATE orders SET x = 1"},
+		{PID: 103, Username: "report", State: "active", Query: "SELECT count(*) FROM orders"},
+		{PID: 104, Username: "app", State: "active", Query: "SELECT * FROM orders"},
+	})
+	m := &Model{
+		queries:    []Query{ActiveQuery()},
+		activeView: av,
+		ready:      true,
+		width:      120,
+		height:     40,
+		viewport:   viewport.New(120, 40),
+	}
+	press := func(s string) {
+		m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+	}
+
+	press("U")
+	if av.Mode != ActiveModeKillPattern || !av.CancelUser {
+		t.Fatalf("U should open the user prompt, mode %d", av.Mode)
+	}
+	press("App")
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if av.Mode != ActiveModeKillPattern || av.LastError == "" {
+		t.Fatal("role names are case-sensitive; App should match nothing")
+	}
+
+	av.KillInput = "app"
+	m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	if av.Mode != ActiveModeConfirmKill || len(av.KillMatches) != 2 {
+		t.Fatalf("mode %d, matches %v; want app's two running queries", av.Mode, av.KillMatches)
+	}
+	out := RenderConfirmKill(av, 120)
+	if !strings.Contains(out, "PIDs: 101, 104") || !strings.Contains(out, "Cancel the queries of 2 sessions for user app") {
+		t.Errorf("confirmation should list app's running queries only:\n%s", out)
+	}
+	if av.killAction() != "cancel" {
+		t.Errorf("killAction() = %q, want cancel", av.killAction())
+	}
+
+	// Cancelling stays on y, sent once however often it's pressed
+	if _, cmd := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); cmd == nil {
+		t.Fatal("y should cancel the user's queries")
+	}
+	if _, again := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); again != nil {
+		t.Error("a second y before the result arrived cancelled the queries again")
+	}
+
+	// K afterwards is back to terminating by pattern
+	press("n")
+	press("K")
+	if av.CancelUser || av.killAction() != "terminate" {
+		t.Error("K should terminate by query pattern")
+	}
+}
+
+func TestBulkResultStatus(t *testing.T) {
+	if got, want := bulkResultStatus("cancel", []int{101, 104}), "Cancelled 2 sessions (PIDs 101, 104)"; got != want {
+		t.Errorf("bulkResultStatus() = %q, want %q", got, want)
+	}
+	if got, want := bulkResultStatus("terminate", []int{7}), "Terminated 1 session (PID 7)"; got != want {
+		t.Errorf("bulkResultStatus() = %q, want %q", got, want)
+	}
+}
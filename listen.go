@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lib/pq"
+)
+
+const (
+	// listenMaxEvents caps how many notifications the view keeps; older ones scroll away
+	listenMaxEvents = 500
+	// listenPingInterval is how often an idle listener checks its connection is still alive
+	listenPingInterval = 90 * time.Second
+)
+
+// ListenView holds the state for the L overlay, which LISTENs on channels and shows each
+// NOTIFY as it arrives
+type ListenView struct {
+	Input    string   // channel name being typed
+	Entering bool     // the channel prompt is open
+	Channels []string // channels listened on, in the order they were added
+	Events   []listenEvent
+	Status   string // connection state, e.g. "connecting…" or "reconnecting: ..."
+	Err      string // why the last LISTEN or connection attempt failed
+
+	listener *pq.Listener
+	states   chan listenerStatus // connection state changes reported by the listener
+}
+
+// listenerStatus is a connection state change, with the error behind a failed attempt or a
+// dropped connection
+type listenerStatus struct {
+	Status string
+	Err    string
+}
+
+// listenEvent is one line of the view: a notification, or a note such as a reconnect
+type listenEvent struct {
+	At      time.Time
+	Channel string
+	PID     int
+	Payload string
+	Note    string // set instead of Channel for connection changes
+}
+
+// listenStartedMsg is sent once LISTEN on Channel has gone through, or failed
+type listenStartedMsg struct {
+	listener *pq.Listener
+	Channel  string
+	Err      error
+}
+
+// listenNotifyMsg carries a notification, or a connection change when Status is set
+type listenNotifyMsg struct {
+	listener *pq.Listener
+	Event    listenEvent
+	Status   string
+	Err      string // why the connection failed or dropped; "" once it's back
+}
+
+// listenClosedMsg is sent when a listener's notification channel closes
+type listenClosedMsg struct{}
+
+// newListenView opens the view with the channel prompt showing
+func newListenView() *ListenView {
+	return &ListenView{Entering: true, states: make(chan listenerStatus, 16)}
+}
+
+// listenerState describes a listener connection event for the view's status line, and the
+// error behind it with the password redacted
+func listenerState(event pq.ListenerEventType, err error, password string) listenerStatus {
+	var status listenerStatus
+	switch event {
+	case pq.ListenerEventConnected:
+		return listenerStatus{Status: "listening"}
+	case pq.ListenerEventReconnected:
+		return listenerStatus{Status: "reconnected"}
+	case pq.ListenerEventDisconnected:
+		status.Status = "disconnected, reconnecting…"
+	default:
+		status.Status = "connection attempt failed, retrying…"
+	}
+	if err != nil {
+		status.Err = redactPassword(err, password).Error()
+	}
+	return status
+}
+
+// listen starts LISTEN on channel, opening the view's listener on first use. The listener
+// reconnects by itself and listens on every channel again once it's back. Its connection
+// states are followed from the start, so a first connection that keeps failing shows why
+// while LISTEN waits.
+func (m *Model) listen(lv *ListenView, channel string) tea.Cmd {
+	var wait tea.Cmd
+	if lv.listener == nil {
+		config, err := getTunneledDBConfig(m.service)
+		if err != nil {
+			return func() tea.Msg { return listenStartedMsg{Channel: channel, Err: err} }
+		}
+		states := lv.states
+		lv.listener = pq.NewListener(dsnFor(config), time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+			// Never block the listener on a slow UI; a dropped state is replaced by the next one
+			select {
+			case states <- listenerState(event, err, config.Password):
+			default:
+			}
+			debugLog.Info("listener event", "service", m.service, "event", int(event), "error", err)
+		})
+		lv.Status = "connecting…"
+		wait = waitForNotification(lv.listener, lv.states)
+	}
+	l := lv.listener
+	service := m.service
+	return tea.Batch(wait, func() tea.Msg {
+		// Blocks until connected; closing the view unblocks it
+		start := time.Now()
+		err := l.Listen(channel)
+		logStatement(service, "LISTEN "+pq.QuoteIdentifier(channel), start, err)
+		return listenStartedMsg{listener: l, Channel: channel, Err: err}
+	})
+}
+
+// waitForNotification waits for the next notification or connection change on l
+func waitForNotification(l *pq.Listener, states <-chan listenerStatus) tea.Cmd {
+	return func() tea.Msg {
+		for {
+			select {
+			case n, ok := <-l.Notify:
+				if !ok {
+					return listenClosedMsg{}
+				}
+				// lib/pq sends nil after reconnecting; anything sent while it was down is lost
+				if n == nil {
+					return listenNotifyMsg{listener: l, Event: listenEvent{At: time.Now(), Note: "reconnected; notifications sent while disconnected were missed"}}
+				}
+				return listenNotifyMsg{listener: l, Event: listenEvent{At: time.Now(), Channel: n.Channel, PID: n.BePid, Payload: n.Extra}}
+			case state := <-states:
+				return listenNotifyMsg{listener: l, Status: state.Status, Err: state.Err}
+			case <-time.After(listenPingInterval):
+				// A failed ping makes the listener notice a dead connection and reconnect
+				l.Ping()
+			}
+		}
+	}
+}
+
+// add appends an event, dropping the oldest past listenMaxEvents
+func (lv *ListenView) add(e listenEvent) {
+	lv.Events = append(lv.Events, e)
+	if len(lv.Events) > listenMaxEvents {
+		lv.Events = lv.Events[len(lv.Events)-listenMaxEvents:]
+	}
+}
+
+// close stops listening and closes the listener's connection
+func (lv *ListenView) close() {
+	if lv.listener != nil {
+		lv.listener.Close()
+		lv.listener = nil
+	}
+}
+
+// handleListen opens the LISTEN/NOTIFY view
+func (m *Model) handleListen() (tea.Model, tea.Cmd) {
+	m.listenView = newListenView()
+	m.viewport.GotoTop()
+	m.updateContent()
+	return m, nil
+}
+
+// handleListenKeys edits the channel prompt, or scrolls the notifications. a listens on
+// another channel, x clears the list and esc closes the view, stopping the listener.
+func (m *Model) handleListenKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	lv := m.listenView
+	if lv.Entering {
+		switch msg.Type {
+		case tea.KeyEscape:
+			lv.Entering = false
+			lv.Input = ""
+			if len(lv.Channels) == 0 {
+				m.closeListenView()
+				return m, nil
+			}
+		case tea.KeyEnter:
+			channel := strings.TrimSpace(lv.Input)
+			if channel == "" {
+				break
+			}
+			if slices.Contains(lv.Channels, channel) {
+				lv.Err = "Already listening on " + channel
+				break
+			}
+			lv.Entering = false
+			lv.Input = ""
+			lv.Err = ""
+			m.updateContent()
+			return m, m.listen(lv, channel)
+		case tea.KeyBackspace:
+			if r := []rune(lv.Input); len(r) > 0 {
+				lv.Input = string(r[:len(r)-1])
+			}
+		case tea.KeySpace:
+			lv.Input += " "
+		case tea.KeyRunes:
+			lv.Input += string(msg.Runes)
+		}
+		m.updateContent()
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.viewport.ScrollUp(1)
+	case key.Matches(msg, keys.Down):
+		m.viewport.ScrollDown(1)
+	case key.Matches(msg, keys.PageUp):
+		m.viewport.PageUp()
+	case key.Matches(msg, keys.PageDown):
+		m.viewport.PageDown()
+	case msg.String() == "a":
+		lv.Entering = true
+		m.updateContent()
+	case msg.String() == "x":
+		lv.Events = nil
+		m.updateContent()
+	case msg.Type == tea.KeyEscape || msg.String() == "q" || msg.String() == "ctrl+[":
+		m.closeListenView()
+	}
+	return m, nil
+}
+
+// closeListenView stops listening and goes back to the results
+func (m *Model) closeListenView() {
+	if m.listenView != nil {
+		m.listenView.close()
+		m.listenView = nil
+	}
+	m.updateContent()
+}
+
+// handleListenStarted records a channel once LISTEN succeeded
+func (m *Model) handleListenStarted(msg listenStartedMsg) (tea.Model, tea.Cmd) {
+	lv := m.listenView
+	if lv == nil || msg.listener != lv.listener {
+		return m, nil
+	}
+	if msg.Err != nil {
+		lv.Err = fmt.Sprintf("LISTEN %s failed: %v", msg.Channel, msg.Err)
+		m.updateContent()
+		return m, nil
+	}
+	lv.Channels = append(lv.Channels, msg.Channel)
+	lv.Status = "listening"
+	lv.Err = ""
+	m.updateContent()
+	return m, nil
+}
+
+// handleListenNotify adds a notification to the view, following new ones while scrolled to the bottom
+func (m *Model) handleListenNotify(msg listenNotifyMsg) (tea.Model, tea.Cmd) {
+	lv := m.listenView
+	if lv == nil || msg.listener != lv.listener {
+		return m, nil
+	}
+	if msg.Status != "" {
+		lv.Status = msg.Status
+		lv.Err = msg.Err
+	} else {
+		lv.add(msg.Event)
+	}
+	follow := m.viewport.AtBottom()
+	m.updateContent()
+	if follow {
+		m.viewport.GotoBottom()
+	}
+	return m, waitForNotification(lv.listener, lv.states)
+}
+
+// RenderListenView renders the channels, the connection state and the notifications received, oldest first
+func RenderListenView(lv *ListenView) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	channelStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Accent)
+
+	noteStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	var b strings.Builder
+	title := "LISTEN"
+	if len(lv.Channels) > 0 {
+		title += " " + strings.Join(lv.Channels, ", ")
+	}
+	b.WriteString(titleStyle.Render(title))
+	if lv.Status != "" {
+		b.WriteString(dimStyle.Render("  (" + lv.Status + ")"))
+	}
+	b.WriteString("\n\n")
+
+	if lv.Entering {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Primary).Render("  Channel: " + lv.Input + "█"))
+		b.WriteString("\n\n")
+	}
+	if lv.Err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  Error: " + lv.Err))
+		b.WriteString("\n\n")
+	}
+
+	if len(lv.Events) == 0 && len(lv.Channels) > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).Render("  Waiting for notifications…"))
+		b.WriteString("\n")
+	}
+	for _, e := range lv.Events {
+		b.WriteString("  " + dimStyle.Render(e.At.Format("15:04:05.000")) + " ")
+		if e.Note != "" {
+			b.WriteString(noteStyle.Render(e.Note))
+		} else {
+			b.WriteString(channelStyle.Render(e.Channel) + dimStyle.Render(fmt.Sprintf(" pid %d", e.PID)))
+			if e.Payload != "" {
+				b.WriteString("  " + scrubNewlines(e.Payload))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
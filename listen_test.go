@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lib/pq"
+)
+
+func TestListenViewKeys(t *testing.T) {
+	m := &Model{ready: true, width: 100, height: 30, viewport: viewport.New(100, 30)}
+	m.handleListen()
+	if m.listenView == nil || !m.listenView.Entering {
+		t.Fatal("handleListen() should open the channel prompt")
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("jobs")})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.listenView.Input != "job" {
+		t.Errorf("Input = %q, want %q", m.listenView.Input, "job")
+	}
+
+	// Leaving the prompt before listening on anything closes the view
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.listenView != nil {
+		t.Error("esc at the first channel prompt should close the view")
+	}
+}
+
+func TestListenNotify(t *testing.T) {
+	m := &Model{ready: true, width: 100, height: 30, viewport: viewport.New(100, 30)}
+	m.listenView = newListenView()
+	m.listenView.Entering = false
+	m.listenView.Channels = []string{"jobs"}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m.handleListenNotify(listenNotifyMsg{Event: listenEvent{At: at, Channel: "jobs", PID: 42, Payload: "job 7\ndone"}})
+	m.handleListenNotify(listenNotifyMsg{Status: "disconnected, reconnecting…"})
+	m.handleListenNotify(listenNotifyMsg{Event: listenEvent{At: at, Note: "reconnected"}})
+	// Messages from a listener that has since been closed are dropped
+	m.handleListenNotify(listenNotifyMsg{listener: &pq.Listener{}, Event: listenEvent{At: at, Channel: "stale"}})
+
+	lv := m.listenView
+	if len(lv.Events) != 2 || lv.Status != "disconnected, reconnecting…" {
+		t.Fatalf("events %+v, status %q", lv.Events, lv.Status)
+	}
+	out := RenderListenView(lv)
+	for _, want := range []string{"LISTEN jobs", "03:04:05.000", "pid 42", "job 7 done", "reconnected"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("RenderListenView() missing %q:\n%s", want, out)
+		}
+	}
+
+	for i := 0; i < listenMaxEvents+10; i++ {
+		lv.add(listenEvent{Channel: "jobs"})
+	}
+	if len(lv.Events) != listenMaxEvents {
+		t.Errorf("kept %d events, want %d", len(lv.Events), listenMaxEvents)
+	}
+}
+
+func TestListenerState(t *testing.T) {
+	err := errors.New(`password authentication failed for "hunter2"`)
+	got := listenerState(pq.ListenerEventConnectionAttemptFailed, err, "hunter2")
+	if !strings.Contains(got.Status, "retrying") || !strings.Contains(got.Err, "password authentication failed") || strings.Contains(got.Err, "hunter2") {
+		t.Errorf("listenerState() = %+v", got)
+	}
+	if got := listenerState(pq.ListenerEventConnected, nil, ""); got != (listenerStatus{Status: "listening"}) {
+		t.Errorf("listenerState(connected) = %+v", got)
+	}
+}
+
+func TestListenFirstConnectFails(t *testing.T) {
+	m := &Model{ready: true, width: 100, height: 30, viewport: viewport.New(100, 30)}
+	m.listenView = newListenView()
+	m.listenView.Entering = false
+
+	// LISTEN is still waiting for a connection, but the failed attempts come through
+	m.handleListenNotify(listenNotifyMsg{Status: "connection attempt failed, retrying…", Err: "dial tcp: connection refused"})
+	lv := m.listenView
+	out := RenderListenView(lv)
+	if !strings.Contains(out, "retrying") || !strings.Contains(out, "connection refused") {
+		t.Errorf("the view should show why connecting fails:\n%s", out)
+	}
+
+	m.handleListenNotify(listenNotifyMsg{Status: "listening"})
+	if lv.Err != "" {
+		t.Errorf("Err = %q, want it cleared once connected", lv.Err)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+)
+
+// llmProvider generates a completion from a chat-style conversation. Providers that can
+// stream call onDelta with each new piece of text as it arrives; onDelta may be nil.
+type llmProvider interface {
+	Generate(ctx context.Context, messages []chatMessage, onDelta func(string)) (string, error)
+}
+
+// newLLMProvider returns the provider selected by $PSQ_LLM_PROVIDER (openai or ollama)
+func newLLMProvider() (llmProvider, error) {
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("PSQ_LLM_PROVIDER"))); provider {
+	case "", "openai":
+		settings, err := openAISettingsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return &openAIProvider{settings: settings}, nil
+	case "ollama":
+		return newOllamaProvider()
+	default:
+		return nil, fmt.Errorf("unknown PSQ_LLM_PROVIDER %q (use openai or ollama)", provider)
+	}
+}
+
+// llmProviderLabel names the configured provider for display
+func llmProviderLabel() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("PSQ_LLM_PROVIDER")), "ollama") {
+		return "Ollama"
+	}
+	return "ChatGPT"
+}
+
+// ollamaProvider sends prompts to a local Ollama server's /api/generate endpoint
+type ollamaProvider struct {
+	baseURL string
+	model   string
+}
+
+// newOllamaProvider reads $PSQ_OLLAMA_URL and $PSQ_OLLAMA_MODEL
+func newOllamaProvider() (*ollamaProvider, error) {
+	p := &ollamaProvider{
+		baseURL: strings.TrimRight(strings.TrimSpace(os.Getenv("PSQ_OLLAMA_URL")), "/"),
+		model:   strings.TrimSpace(os.Getenv("PSQ_OLLAMA_MODEL")),
+	}
+	if p.baseURL == "" {
+		p.baseURL = defaultOllamaURL
+	}
+	if p.model == "" {
+		p.model = defaultOllamaModel
+	}
+	if err := validateBaseURL("PSQ_OLLAMA_URL", p.baseURL, defaultOllamaURL); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type ollamaGenerateRequest struct {
+	Model  string `json:"model"`
+	System string `json:"system,omitempty"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaGenerateResponse struct {
+	Response string `json:"response"`
+	Error    string `json:"error"`
+}
+
+// ollamaPrompt flattens a conversation into /api/generate's system + prompt fields
+func ollamaPrompt(messages []chatMessage) (system, prompt string) {
+	var parts []string
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			system = msg.Content
+		case "assistant":
+			parts = append(parts, "Assistant: "+msg.Content)
+		default:
+			parts = append(parts, msg.Content)
+		}
+	}
+	return system, strings.Join(parts, "\n\n")
+}
+
+func (p *ollamaProvider) Generate(ctx context.Context, messages []chatMessage, onDelta func(string)) (string, error) {
+	system, prompt := ollamaPrompt(messages)
+	body, err := json.Marshal(ollamaGenerateRequest{
+		Model:  p.model,
+		System: system,
+		Prompt: prompt,
+		Stream: false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	// Local models can be slow to load on first use
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", p.baseURL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return "", fmt.Errorf("Ollama is not running at %s (start it with `ollama serve`)", p.baseURL)
+		}
+		return "", fmt.Errorf("request to %s failed: %w", p.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from %s: %w", p.baseURL, err)
+	}
+
+	var generated ollamaGenerateResponse
+	if err := json.Unmarshal(respBody, &generated); err != nil {
+		return "", fmt.Errorf("%s returned %s: %s", p.baseURL, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if resp.StatusCode != http.StatusOK || generated.Error != "" {
+		return "", fmt.Errorf("%s returned %s: %s", p.baseURL, resp.Status, generated.Error)
+	}
+
+	return generated.Response, nil
+}
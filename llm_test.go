@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewLLMProvider(t *testing.T) {
+	setEnv(t, map[string]string{"PSQ_LLM_PROVIDER": "ollama", "PSQ_OLLAMA_URL": "", "PSQ_OLLAMA_MODEL": "", "OPENAI_API_KEY": ""})
+	provider, err := newLLMProvider()
+	if err != nil {
+		t.Fatalf("newLLMProvider() error = %v, want no API key required for ollama", err)
+	}
+	ollama, ok := provider.(*ollamaProvider)
+	if !ok {
+		t.Fatalf("newLLMProvider() = %T, want *ollamaProvider", provider)
+	}
+	if ollama.baseURL != defaultOllamaURL || ollama.model != defaultOllamaModel {
+		t.Errorf("ollama defaults = %s %s, want %s %s", ollama.baseURL, ollama.model, defaultOllamaURL, defaultOllamaModel)
+	}
+
+	setEnv(t, map[string]string{"PSQ_LLM_PROVIDER": "bard"})
+	if _, err := newLLMProvider(); err == nil || !strings.Contains(err.Error(), "unknown PSQ_LLM_PROVIDER") {
+		t.Errorf("newLLMProvider() error = %v, want unknown provider", err)
+	}
+}
+
+func TestCallChatGPTOllama(t *testing.T) {
+	var got ollamaGenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"response":"SELECT 2;","done":true}`))
+	}))
+	defer server.Close()
+
+	setEnv(t, map[string]string{
+		"PSQ_LLM_PROVIDER": "ollama",
+		"PSQ_OLLAMA_URL":   server.URL,
+		"PSQ_OLLAMA_MODEL": "sqlcoder",
+	})
+
+	msg := callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "count tables"}})().(chatgptResponseMsg)
+	if msg.Err != nil {
+		t.Fatalf("callChatGPT() error = %v", msg.Err)
+	}
+	if msg.SQL != "SELECT 2;" {
+		t.Errorf("SQL = %q, want %q", msg.SQL, "SELECT 2;")
+	}
+	if got.Model != "sqlcoder" || got.Stream || got.System != chatgptSystemPrompt || got.Prompt != "count tables" {
+		t.Errorf("request = %+v, want shared prompt sent to sqlcoder without streaming", got)
+	}
+
+	// A server that isn't running gets a clear hint rather than a raw dial error
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	setEnv(t, map[string]string{"PSQ_OLLAMA_URL": closedURL})
+	msg = callChatGPT(context.Background(), nil, "", []chatMessage{{Role: "user", Content: "count tables"}})().(chatgptResponseMsg)
+	if msg.Err == nil || !strings.Contains(msg.Err.Error(), "Ollama is not running at "+closedURL) {
+		t.Errorf("callChatGPT() error = %v, want not-running hint", msg.Err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	zone "github.com/lrstanley/bubblezone"
 	"github.com/spf13/cobra"
@@ -17,6 +18,8 @@ func main() {
 	defer zone.Close()
 
 	var service string
+	var execSQL, queryName, format string
+	var themeName string
 
 	var rootCmd = &cobra.Command{
 		Use:   "psq [service]",
@@ -37,6 +40,12 @@ Examples:
   psq                    # Show service picker
   psq prod               # Connect directly to 'prod' service
   psq -s staging         # Connect to 'staging' service
+  psq export q.json      # Export saved queries (see 'psq export --help')
+  psq prod --exec "SELECT now()"           # Run SQL once and print the result
+  psq prod --query Connections -f csv      # Run a saved query once as CSV
+  psq services --json    # List services from ~/.pg_service.conf
+  psq -s prod active --json                # Print the Active tab's backends as JSON
+  source <(psq completion bash)            # Tab-complete services (also zsh, fish)
 
 Keyboard Shortcuts:
   Navigation:    ←/→ (h/l) switch tabs, ↑/↓ (k/j) scroll, Home/End jump
@@ -46,11 +55,52 @@ Keyboard Shortcuts:
 
 Configuration:
   Queries:       ~/.psq/queries.db (SQLite, auto-created)
-  Connections:   ~/.pg_service.conf (PostgreSQL service file)
-  AI Features:   $OPENAI_API_KEY (optional, for query generation)`,
-		Version: version,
-		Args:    cobra.MaximumNArgs(1),
+  Data dir:      --config-dir or $PSQ_CONFIG_DIR relocates ~/.psq
+  Connections:   ~/.pg_service.conf (PostgreSQL service file); --service-file or
+                 $PGSERVICEFILE merges a colon-separated list, later files winning
+  Query log:     --log or $PSQ_LOG_FILE appends each statement run
+  AI Features:   $OPENAI_API_KEY (optional, for query generation)
+                 $PSQ_OPENAI_MODEL, $PSQ_OPENAI_BASE_URL (optional overrides)`,
+		Version:           version,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Config errors are reported again inside the TUI; only an explicit --theme typo is fatal
+			config, _ := loadConfig()
+			name := config.Theme
+			if themeName != "" {
+				name = themeName
+			}
+			if err := applyTheme(name, config.ThemeColors); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			detectColorSupport()
+			if err := initDebugLog(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := initQueryLog(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
+			// One-shot mode prints a single result without starting the TUI
+			if execSQL != "" || queryName != "" {
+				if len(args) > 0 {
+					service = args[0]
+				}
+				if service == "" {
+					service = "default"
+				}
+				if err := runOneShot(service, execSQL, queryName, format, os.Stdout); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
 			// Use provided service name or show picker if none provided
 			if len(args) > 0 {
 				service = args[0]
@@ -91,6 +141,19 @@ Configuration:
 	}
 
 	rootCmd.Flags().StringVarP(&service, "service", "s", "", "Database service name from ~/.pg_service.conf (default: 'default')")
+	rootCmd.Flags().StringVarP(&execSQL, "exec", "e", "", "Run this SQL once, print the result, and exit")
+	rootCmd.Flags().StringVarP(&queryName, "query", "q", "", "Run the saved query with this name once, print the result, and exit")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "Output format for --exec/--query: table or csv")
+	rootCmd.PersistentFlags().StringVar(&configDirFlag, "config-dir", "", "Directory for saved queries, dumps and config.json (default $PSQ_CONFIG_DIR, else ~/.psq)")
+	rootCmd.PersistentFlags().StringVar(&serviceFileFlag, "service-file", "", "Service files to read, separated by colons; later files override earlier ones (default $PGSERVICEFILE, else ~/.pg_service.conf)")
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "Write a troubleshooting log of connections, queries and errors to ~/.psq/psq.log")
+	rootCmd.PersistentFlags().StringVar(&queryLogFlag, "log", "", "Append every statement psq runs, with time, service and elapsed time, to this file (default $PSQ_LOG_FILE)")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "Color theme: "+strings.Join(themeNames(), ", ")+" (default from config, else dark)")
+	rootCmd.RegisterFlagCompletionFunc("service", completeServices)
+	rootCmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(themeNames(), cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("query", completeQueryNames)
+	rootCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"table", "csv"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(newExportCmd(), newImportCmd(), newServicesCmd(), newActiveCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
+	"sort"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type Model struct {
@@ -38,27 +42,112 @@ type Model struct {
 	descInput        textinput.Model
 	orderInput       textinput.Model
 	sqlTextarea      textarea.Model
-	editFocus        int    // 0=name, 1=description, 2=order, 3=sql
+	editFocus        int // 0=name, 1=description, 2=order, 3=sql, 4=auto refresh, 5=tags, 6=cache TTL
 	help             help.Model
 	showHelp         bool
 	sparklineData    *SparklineData // Transaction commits sparkline data
 	lastCommits      float64        // Last transaction commit count for rate calculation
 	lastCommitTime   time.Time      // DB timestamp of last commit query for accurate TPS
 	activeView       *ActiveView    // Interactive active connections view (nil when not on Active tab)
+	activeFilter     ActiveFilter   // which backends the Active tab lists; kept across tab switches
+	config           *Config        // User preferences from ~/.psq/config.json
+	confirmReset     bool           // awaiting y/n before resetting pg_stat_statements
+	tabOffset        int            // index of the first tab shown in the tab strip
+	gotoMode         bool           // g pressed; collecting a tab number
+	gotoInput        string         // digits typed after g
+	psqlMode         bool           // x pressed; collecting a [user@]dbname override for psql
+	psqlInput        string         // override typed after x
+	psqlDefault      string         // user@dbname psql connects as when the override is empty
+	psqlSandbox      bool           // tab at the psql prompt; the session starts inside a transaction
+	dumpMode         bool           // d pressed; typing the file to dump queries to
+	dumpInput        string         // dump file name or path, prefilled with a timestamped name
+	dumpOverwrite    bool           // the typed file exists; enter again replaces it
+	importView       *ImportView    // Query import flow (nil when not importing)
+	archiveView      *ArchiveView   // Archived queries browser (nil when closed)
+	aiAnswer         *AIAnswer      // Read-only LLM answer, e.g. explaining a query (nil when closed)
+	aiState          AIState        // ChatGPT panel step in the editor
+	aiInput          textinput.Model
+	aiResult         string // SQL returned by ChatGPT, awaiting review
+	aiErr            string
+	aiStatus         string             // pending retry while waiting on the AI, e.g. "rate limited, retrying in 2s…"
+	spinner          spinner.Model      // animates in the header while work is in flight
+	queryCancel      context.CancelFunc // aborts the in-flight query (nil when idle)
+	queryGen         int                // counts runQuery calls; only the latest run's messages are handled
+	resultNote       string             // shown above results, e.g. after cancelling a query
+	status           string             // transient confirmation shown above results, e.g. after a dump
+	statusSeq        int                // bumped per status so only the latest one's expiry clears it
+	reconnecting     bool               // connection lost; backing off between reconnect attempts
+	reconnectTries   int
+	timeouts         *SessionTimeouts   // read once at connect for the header
+	tableOpts        TableOptions       // results table display settings; b toggles HumanBytes
+	confirmDiscard   bool               // esc pressed in the editor with unsaved changes; awaiting y/n
+	confirmPurge     bool               // alt+d pressed in the editor; awaiting y/n before deleting for good
+	aiSQLUsed        bool               // AI-generated SQL went into the editor; saving writes asks first
+	confirmAIWrite   string             // keyword of the AI-generated write awaiting y/n before it runs, e.g. "DELETE"
+	sqlUndo          []string           // earlier SQL editor values for ctrl+z, newest last
+	sqlRedo          []string           // values undone with ctrl+z, for ctrl+y
+	sqlTyping        bool               // last SQL edit was a typed word character; coalesces undo steps
+	aiUndoSQL        string             // SQL the last AI generation replaced
+	aiUndoArmed      bool               // next key u restores aiUndoSQL; any other key dismisses the hint
+	aiCancel         context.CancelFunc // abandons the in-flight AI request (nil when idle)
+	aiMessages       []chatMessage      // AI conversation so far: requests, follow-ups and generated SQL
+	aiPlan           aiPlanCheck        // EXPLAIN check of the SQL under review
+	aiPlanErr        string             // Postgres error when the generated SQL doesn't plan
+	editAutoRefresh  bool               // auto refresh toggle in the editor
+	tagsInput        textinput.Model
+	cacheInput       textinput.Model
+	searchByRecent   bool                    // search results sorted by last edit instead of grouped by tag
+	searchNamesOnly  bool                    // search skips query SQL, matching only name, description and tags
+	resultColumns    []string                // every column of the current table result, for the column picker
+	columnPicker     *ColumnPicker           // C overlay choosing which result columns to show (nil when closed)
+	resultCache      map[string]cachedResult // last result per tab, shown again when switching back
+	resultsStale     bool                    // results are a cached result awaiting the tab's fresh run
+	diffKeys         map[string]string       // per query, the column chosen to match rows when diffing refreshes
+	shownRefreshAge  string                  // header's refresh age as last drawn, to redraw when it changes
+	header           string                  // status line, tabs and status message, drawn above the viewport
+	footer           string                  // key hints for the current mode, drawn below the viewport
+	connUsage        *ConnectionUsage        // latest connection count for the warning banner; nil until read
+	connWarnHidden   bool                    // W pressed; the connection warning stays hidden this session
+	alerts           map[int]string          // firing alert rules by index in config, with why they fire
+	alertFlash       int                     // header flips left after an alert started firing
+	shownDuration    string                  // Active detail's live duration as last drawn, to redraw when it ticks
+	connecting       bool                    // the first connection failed; retrying with backoff (reconnectTries counts attempts)
+	listenView       *ListenView             // L overlay showing NOTIFY payloads as they arrive (nil when closed)
+	rendered         renderedContent         // viewport content as last set, to skip redraws that change nothing
+	fleetRun         *FleetRun               // F overlay running the selected query on every service (nil when closed)
+	schemaBrowser    *SchemaBrowser          // T overlay browsing schemas, tables and columns (nil when closed)
 }
 
 type Query struct {
 	Name          string `json:"name"`
 	Description   string `json:"description"`
 	SQL           string `json:"sql"`
-	OrderPosition *int   `json:"order_position,omitempty"` // nil means hidden from top bar
+	OrderPosition *int   `json:"order_position,omitempty"`  // nil means hidden from top bar
+	NoAutoRefresh bool   `json:"no_auto_refresh,omitempty"` // only run when selected or refreshed by hand
+	Tags          string `json:"tags,omitempty"`            // comma-separated, e.g. "replication, wal"
+	CacheTTL      int    `json:"cache_ttl,omitempty"`       // seconds auto refresh reuses the last result for; 0 runs every tick
+
+	// Kept out of exports so they stay diff-friendly
+	CreatedAt     time.Time `json:"-"`
+	UpdatedAt     time.Time `json:"-"`
+	HiddenColumns []string  `json:"-"` // result columns the table leaves out, chosen with C
 }
 
+const (
+	// refreshInterval is how often auto-refreshing tabs re-run their query
+	refreshInterval = time.Second
+	// staleAfter is how old a result gets before the header flags it as overdue
+	staleAfter = 5 * refreshInterval
+)
+
 // Message types for Bubble Tea
 type tickMsg time.Time
 type returnToPickerMsg struct{}
 
 func NewModel(service string) *Model {
+	// A broken config file shouldn't block startup; fall back to defaults and surface the error
+	config, configErr := loadConfig()
+
 	dbQueries, err := loadQueries()
 	if err != nil {
 		return &Model{
@@ -69,6 +158,8 @@ func NewModel(service string) *Model {
 			err:         fmt.Sprintf("Failed to load queries: %v", err),
 			service:     service,
 			ready:       false,
+			config:      config,
+			spinner:     newLoadingSpinner(),
 		}
 	}
 
@@ -87,29 +178,11 @@ func NewModel(service string) *Model {
 		}
 	}
 
-	// Open persistent database connection
-	db, err := connectDB(service)
-	if err != nil {
-		return &Model{
-			queries:         queries,
-			allQueries:      allQueries,
-			tempQueries:     make(map[string]int),
-			selected:        0,
-			err:             fmt.Sprintf("Failed to connect to database: %v", err),
-			service:         service,
-			ready:           false,
-			searchMode:      false,
-			searchQuery:     "",
-			filteredQueries: queries,
-			editMode:        false,
-			help:            help.New(),
-			showHelp:        false,
-			sparklineData:   NewSparklineData(60),
-			lastCommits:     0,
-		}
-	}
+	// Open persistent database connection; if the server isn't accepting connections yet,
+	// Init keeps retrying for a while instead of failing straight away
+	db, connectErr := connectDB(service)
 
-	return &Model{
+	m := &Model{
 		queries:         queries,
 		allQueries:      allQueries,
 		tempQueries:     make(map[string]int),
@@ -126,7 +199,59 @@ func NewModel(service string) *Model {
 		showHelp:        false,
 		sparklineData:   NewSparklineData(60), // Keep 60 data points (1 minute at 1 second intervals)
 		lastCommits:     0,
+		config:          config,
+		spinner:         newLoadingSpinner(),
 	}
+	// Every problem is shown; none hides the others
+	var startupErrs []error
+	if connectErr != nil {
+		// Only a server that isn't reachable yet is worth waiting for; a bad service name
+		// or password won't fix itself
+		if isConnectionError(connectErr) {
+			m.startConnecting(connectErr)
+		} else {
+			startupErrs = append(startupErrs, fmt.Errorf("Failed to connect to database: %w", connectErr))
+		}
+	}
+	if configErr != nil {
+		startupErrs = append(startupErrs, fmt.Errorf("Failed to load config: %w", configErr))
+	}
+	tableOpts, err := tableOptionsFromEnv()
+	if err != nil {
+		startupErrs = append(startupErrs, err)
+	}
+	if err := errors.Join(startupErrs...); err != nil {
+		m.err = err.Error()
+	}
+	tableOpts.HumanBytes = config.HumanBytes
+	tableOpts.Compact = config.Density == densityCompact
+	tableOpts.MaxRows = config.MaxRows
+	m.tableOpts = tableOpts
+	// Informational only; older or restricted servers just don't get the indicator
+	if db != nil {
+		if timeouts, err := GetSessionTimeouts(db); err == nil {
+			m.timeouts = timeouts
+		}
+	}
+	return m
+}
+
+// newLoadingSpinner creates the spinner shown while queries or AI requests are running
+func newLoadingSpinner() spinner.Model {
+	return spinner.New(
+		spinner.WithSpinner(spinner.Dot),
+		spinner.WithStyle(lipgloss.NewStyle().Foreground(theme.Primary)),
+	)
+}
+
+// tableOptions returns the current results table display settings
+func (m *Model) tableOptions() TableOptions {
+	return m.tableOpts
+}
+
+// busy reports whether a query or AI request is in flight
+func (m *Model) busy() bool {
+	return m.loading || m.connecting || m.aiState == AIStateWaiting || (m.aiAnswer != nil && !m.aiAnswer.Done) || (m.fleetRun != nil && m.fleetRun.running()) || (m.aiState == AIStateReview && m.aiPlan == aiPlanChecking)
 }
 
 func (m *Model) getNextTempOrder() int {
@@ -164,16 +289,95 @@ func (m *Model) isTemporaryQuery(queryName string) bool {
 	return exists
 }
 
+// nextPinnedOrder returns the order position after every saved tab
+func (m *Model) nextPinnedOrder() int {
+	maxOrder := 0
+	for _, q := range m.allQueries {
+		if m.isTemporaryQuery(q.Name) {
+			continue
+		}
+		if q.OrderPosition != nil && *q.OrderPosition > maxOrder {
+			maxOrder = *q.OrderPosition
+		}
+	}
+	return maxOrder + 1
+}
+
+// reloadQueries refreshes tabs and search results from the query database,
+// keeping the built-in tabs and any temporary tabs opened from search
+func (m *Model) reloadQueries() error {
+	dbQueries, err := loadQueries()
+	if err != nil {
+		return err
+	}
+	m.queries = append([]Query{HomeQuery(), ActiveQuery()}, dbQueries...)
+
+	if allQueriesFromDB, err := globalQueryDB.LoadAllQueries(); err == nil {
+		m.allQueries = append([]Query{HomeQuery(), ActiveQuery()}, allQueriesFromDB...)
+	}
+
+	// Re-append temporary tabs in the order they were opened
+	var tempNames []string
+	for name := range m.tempQueries {
+		tempNames = append(tempNames, name)
+	}
+	sort.Slice(tempNames, func(i, j int) bool {
+		return m.tempQueries[tempNames[i]] < m.tempQueries[tempNames[j]]
+	})
+	for _, name := range tempNames {
+		found := false
+		for _, q := range m.allQueries {
+			if q.Name == name && q.OrderPosition == nil {
+				tempOrder := m.tempQueries[name]
+				tempQuery := q
+				tempQuery.OrderPosition = &tempOrder
+				m.queries = append(m.queries, tempQuery)
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Deleted or pinned since it was opened
+			delete(m.tempQueries, name)
+		}
+	}
+
+	m.ensureValidSelection()
+	return nil
+}
+
+// selectQueryByName moves the selection to the visible tab with the given name
+func (m *Model) selectQueryByName(name string) {
+	for i, q := range m.queries {
+		if q.Name == name {
+			m.selected = i
+			break
+		}
+	}
+	m.ensureValidSelection()
+}
+
 func (m *Model) Close() {
+	m.cancelQuery()
+	if m.listenView != nil {
+		m.listenView.close()
+	}
+	if m.fleetRun != nil {
+		m.fleetRun.stop()
+	}
+	m.reconnecting = false
 	if m.db != nil {
 		m.db.Close()
 		m.db = nil
 	}
+	closeTunnel(m.service)
 }
 
+// ensureValidSelection clamps the selection to the tabs there are and scrolls the tab strip to it
 func (m *Model) ensureValidSelection() {
 	if len(m.queries) == 0 {
 		m.selected = 0
+		m.tabOffset = 0
 		return
 	}
 	if m.selected >= len(m.queries) {
@@ -182,6 +386,36 @@ func (m *Model) ensureValidSelection() {
 	if m.selected < 0 {
 		m.selected = 0
 	}
+	m.scrollTabsToSelected()
+}
+
+// readOnly reports whether actions that change server state are disabled
+func (m *Model) readOnly() bool {
+	return m.config != nil && m.config.ReadOnly
+}
+
+// refreshAge describes how old the shown result is, e.g. "updated 3s ago"
+func (m *Model) refreshAge() string {
+	if m.lastRefreshAt.IsZero() {
+		return ""
+	}
+	return "updated " + formatDuration(int(time.Since(m.lastRefreshAt).Seconds())) + " ago"
+}
+
+// detailDuration is the Active detail view's live duration, or "" outside it
+func (m *Model) detailDuration() string {
+	if m.activeView == nil || m.activeView.Mode != ActiveModeDetail {
+		return ""
+	}
+	return m.activeView.detailDuration(time.Now())
+}
+
+// refreshOverdue reports whether an auto-refreshing tab has missed several refreshes in a
+// row, which means queries are failing, hanging or paused. Manual-refresh tabs never are,
+// and a cached result isn't until its TTL has passed too.
+func (m *Model) refreshOverdue() bool {
+	return !m.lastRefreshAt.IsZero() && !m.lastQuery.NoAutoRefresh &&
+		time.Since(m.lastRefreshAt) > staleAfter+time.Duration(m.lastQuery.CacheTTL)*time.Second
 }
 
 func (m *Model) canRefresh() bool {
@@ -189,22 +423,19 @@ func (m *Model) canRefresh() bool {
 }
 
 func (m *Model) filterQueries() {
-	if m.searchQuery == "" {
-		m.filteredQueries = m.allQueries // Show all queries when no search
-		return
-	}
-
 	var filtered []Query
-	searchLower := strings.ToLower(m.searchQuery)
-
 	for _, query := range m.allQueries { // Search through all queries
-		if strings.Contains(strings.ToLower(query.Name), searchLower) ||
-			strings.Contains(strings.ToLower(query.Description), searchLower) {
+		if matchesSearch(query, m.searchQuery, !m.searchNamesOnly) {
 			filtered = append(filtered, query)
 		}
 	}
 
-	m.filteredQueries = filtered
+	// Results are clustered by tag (or ordered by last edit), so selection indexes follow that order
+	if m.searchByRecent {
+		m.filteredQueries = sortByUpdated(filtered)
+	} else {
+		m.filteredQueries = groupByTag(filtered)
+	}
 
 	// Reset selection if out of bounds
 	if m.selected >= len(m.filteredQueries) {
@@ -1,7 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 func TestFilterQueries(t *testing.T) {
@@ -177,3 +187,642 @@ func TestIsTemporaryQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestTabNumberJump(t *testing.T) {
+	// Rendering the tab strip marks bubblezone zones
+	zone.NewGlobal()
+
+	newModel := func() *Model {
+		queries := make([]Query, 12)
+		for i := range queries {
+			queries[i] = Query{Name: fmt.Sprintf("Query %d", i+1), SQL: "SELECT 1"}
+		}
+		return &Model{queries: queries, tempQueries: map[string]int{}, ready: true, width: 80}
+	}
+	keys := func(m *Model, keys ...string) {
+		for _, k := range keys {
+			var msg tea.KeyMsg
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			} else {
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			}
+			m.handleKeyMsg(msg)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		keys       []string
+		wantSelect int
+	}{
+		{name: "digit selects tab", keys: []string{"3"}, wantSelect: 2},
+		{name: "g sequence selects tab past 9", keys: []string{"g", "1", "1", "enter"}, wantSelect: 10},
+		{name: "g sequence out of range is ignored", keys: []string{"g", "4", "0", "enter"}, wantSelect: 0},
+		{name: "g sequence cancelled by other key", keys: []string{"g", "1", "x", "5"}, wantSelect: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newModel()
+			keys(m, tt.keys...)
+			if m.selected != tt.wantSelect {
+				t.Errorf("selected = %d, want %d", m.selected, tt.wantSelect)
+			}
+			if m.gotoMode {
+				t.Errorf("gotoMode still active after %v", tt.keys)
+			}
+		})
+	}
+}
+
+func TestTogglePin(t *testing.T) {
+	zone.NewGlobal()
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	saved := Query{Name: "Saved", Description: "Saved tab", SQL: "SELECT 1", OrderPosition: intPtr(1)}
+	hidden := Query{Name: "Hidden", Description: "Search only", SQL: "SELECT 2"}
+	for _, q := range []Query{saved, hidden} {
+		if err := qdb.SaveQuery(q); err != nil {
+			t.Fatalf("SaveQuery() error = %v", err)
+		}
+	}
+
+	model := &Model{tempQueries: map[string]int{}, ready: true, width: 80}
+	if err := model.reloadQueries(); err != nil {
+		t.Fatalf("reloadQueries() error = %v", err)
+	}
+
+	// Open the hidden query from search, then pin it
+	model.addTemporaryQuery(hidden)
+	model.selectQueryByName("Hidden")
+	model.handleTogglePin()
+
+	if model.isTemporaryQuery("Hidden") {
+		t.Errorf("Hidden should no longer be temporary after pinning")
+	}
+	got, err := qdb.GetQuery("Hidden")
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v", err)
+	}
+	if got.OrderPosition == nil || *got.OrderPosition != 2 {
+		t.Errorf("pinned OrderPosition = %v, want 2", got.OrderPosition)
+	}
+	if model.status != "Pinned Hidden" || model.err != "" {
+		t.Errorf("status = %q, err = %q; want the pin confirmed as a status", model.status, model.err)
+	}
+	if model.queries[model.selected].Name != "Hidden" {
+		t.Errorf("selected tab = %q, want Hidden", model.queries[model.selected].Name)
+	}
+
+	// Unpin the saved tab: hidden in the database but still open as a temporary tab
+	model.selectQueryByName("Saved")
+	model.handleTogglePin()
+
+	got, err = qdb.GetQuery("Saved")
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v", err)
+	}
+	if got.OrderPosition != nil {
+		t.Errorf("unpinned OrderPosition = %v, want nil", *got.OrderPosition)
+	}
+	if !model.isTemporaryQuery("Saved") {
+		t.Errorf("Saved should stay open as a temporary tab after unpinning")
+	}
+	if model.status != "Unpinned Saved" {
+		t.Errorf("status = %q, want Unpinned Saved", model.status)
+	}
+	if model.queries[model.selected].Name != "Saved" {
+		t.Errorf("selected tab = %q, want Saved", model.queries[model.selected].Name)
+	}
+}
+
+func TestCancelQueryKey(t *testing.T) {
+	zone.NewGlobal()
+
+	cancelled := false
+	m := &Model{
+		queries:     []Query{{Name: "Slow", SQL: "SELECT pg_sleep(60)"}},
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       80,
+		loading:     true,
+		results:     "previous result",
+		queryCancel: func() { cancelled = true },
+	}
+
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlG})
+
+	if !cancelled {
+		t.Error("ctrl+g did not cancel the in-flight query")
+	}
+	if cmd == nil {
+		t.Error("ctrl+g should schedule the next auto-refresh tick")
+	}
+	if m.loading || m.queryCancel != nil {
+		t.Errorf("loading = %v, queryCancel set = %v; want query cleared", m.loading, m.queryCancel != nil)
+	}
+	if m.results != "previous result" || m.resultNote != "Query cancelled" {
+		t.Errorf("results = %q, note = %q; want previous result with cancelled note", m.results, m.resultNote)
+	}
+}
+
+func TestSupersededQueryResultIgnored(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{
+		queries:     []Query{{Name: "Locks", SQL: "SELECT 1"}, {Name: "Bloat", SQL: "SELECT 2"}},
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       80,
+		height:      24,
+		viewport:    viewport.New(80, 20),
+	}
+	m.runQuery(m.queries[0])
+	m.lastQuery = m.queries[1]
+	m.loading = true
+	m.runQuery(m.queries[1])
+	if m.queryCancel == nil {
+		t.Fatal("runQuery should set queryCancel")
+	}
+
+	// Locks finished just as Bloat started; its result must not land on Bloat
+	m.Update(queryDoneMsg{gen: m.queryGen - 1, msg: queryResultMsg{Output: "locks result"}})
+	if m.results != "" || !m.loading || m.queryCancel == nil {
+		t.Fatalf("results = %q, loading = %v, queryCancel set = %v; want the stale result dropped", m.results, m.loading, m.queryCancel != nil)
+	}
+
+	m.Update(queryDoneMsg{gen: m.queryGen, msg: queryResultMsg{Output: "bloat result"}})
+	if m.results != "bloat result" || m.loading || m.queryCancel != nil {
+		t.Errorf("results = %q, loading = %v, queryCancel set = %v; want Bloat's result", m.results, m.loading, m.queryCancel != nil)
+	}
+}
+
+func TestActiveHelp(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{
+		queries:     []Query{HomeQuery(), ActiveQuery()},
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       100,
+		selected:    1,
+		activeView:  &ActiveView{Mode: ActiveModeDetail, Processes: []ActiveProcess{{PID: 1}}},
+	}
+
+	// ? works from the detail view rather than being swallowed by it
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if !m.showHelp {
+		t.Fatal("? in the Active detail view did not open help")
+	}
+
+	help := m.customHelpView()
+	for _, want := range []string{"Process Details (current):", "copy query to clipboard", "Confirm Terminate/Cancel"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Active help missing %q", want)
+		}
+	}
+
+	m.selected = 0
+	m.activeView = nil
+	if help := m.customHelpView(); strings.Contains(help, "Process Details") {
+		t.Error("help on other tabs should not expand the Active view keys")
+	}
+}
+
+func TestDiscardUnsavedEdits(t *testing.T) {
+	zone.NewGlobal()
+
+	esc := tea.KeyMsg{Type: tea.KeyEscape}
+	m := &Model{
+		queries:     []Query{{Name: "Locks", SQL: "SELECT 1"}},
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       80,
+		editMode:    true,
+		editQuery:   Query{Name: "Locks", SQL: "SELECT 1"},
+	}
+	m.initEditor(m.editQuery)
+
+	// Nothing changed: esc leaves straight away
+	m.handleKeyMsg(esc)
+	if m.editMode || m.confirmDiscard {
+		t.Fatalf("editMode = %v, confirmDiscard = %v; want editor closed without prompting", m.editMode, m.confirmDiscard)
+	}
+
+	m.editMode = true
+	m.initEditor(m.editQuery)
+	m.sqlTextarea.SetValue("SELECT 2")
+
+	m.handleKeyMsg(esc)
+	if !m.editMode || !m.confirmDiscard {
+		t.Fatal("esc with unsaved changes should ask before discarding")
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if !m.editMode || m.confirmDiscard || m.sqlTextarea.Value() != "SELECT 2" {
+		t.Fatal("n should return to the editor with the edits intact")
+	}
+
+	m.handleKeyMsg(esc)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.editMode || m.confirmDiscard {
+		t.Error("y should discard the edits and leave the editor")
+	}
+}
+
+func TestEditorFitsTerminal(t *testing.T) {
+	zone.NewGlobal()
+
+	long := strings.Repeat("SELECT 1\nUNION ALL\n", 80) + "SELECT 1"
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 120, height: 50}
+	m.editMode = true
+	m.initEditor(Query{Name: "Long", SQL: long})
+
+	if got := m.sqlTextarea.Value(); got != long {
+		t.Errorf("textarea kept %d of %d lines", strings.Count(got, "\n")+1, strings.Count(long, "\n")+1)
+	}
+	if w, h := m.sqlTextarea.Width(), m.sqlTextarea.Height(); h != 50-editorChromeHeight || w > 116 || w < 100 {
+		t.Errorf("textarea = %dx%d, want about 116x%d", w, h, 50-editorChromeHeight)
+	}
+
+	m.handleWindowSizeMsg(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if h := m.sqlTextarea.Height(); h != minSQLEditorHeight {
+		t.Errorf("textarea height after shrinking = %d, want %d", h, minSQLEditorHeight)
+	}
+
+	// Narrower still, the one-line fields give up width too
+	m.handleWindowSizeMsg(tea.WindowSizeMsg{Width: 50, Height: 20})
+	if h := m.sqlTextarea.Height(); h != minSQLEditorHeight {
+		t.Errorf("textarea height at 50x20 = %d, want %d", h, minSQLEditorHeight)
+	}
+	if w := m.sqlTextarea.Width(); w > 46 {
+		t.Errorf("textarea width after shrinking = %d, want at most 46", w)
+	}
+	if w := m.nameInput.Width; w != 44 {
+		t.Errorf("name input width after shrinking = %d, want 44", w)
+	}
+	if w := m.orderInput.Width; w != 30 {
+		t.Errorf("order input width after shrinking = %d, want 30", w)
+	}
+
+	m.handleWindowSizeMsg(tea.WindowSizeMsg{Width: 120, Height: 50})
+	if w := m.nameInput.Width; w != 50 {
+		t.Errorf("name input width after growing = %d, want 50", w)
+	}
+}
+
+func TestSQLUndoRedo(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true}
+	m.initEditor(Query{Name: "Q", SQL: ""})
+	for m.editFocus != 3 {
+		m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	}
+
+	typeText := func(s string) {
+		for _, r := range s {
+			if r == ' ' {
+				m.handleKeyMsg(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+			} else {
+				m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			}
+		}
+	}
+	undo := tea.KeyMsg{Type: tea.KeyCtrlZ}
+	redo := tea.KeyMsg{Type: tea.KeyCtrlY}
+
+	typeText("SELECT 1")
+	m.setSQL("SELECT now()") // as the AI panel does
+
+	m.handleKeyMsg(undo)
+	if got := m.sqlTextarea.Value(); got != "SELECT 1" {
+		t.Fatalf("after undoing the replacement SQL = %q, want %q", got, "SELECT 1")
+	}
+	m.handleKeyMsg(undo)
+	if got := m.sqlTextarea.Value(); got != "SELECT " {
+		t.Fatalf("typing a word should be one undo step, got %q", got)
+	}
+	m.handleKeyMsg(redo)
+	m.handleKeyMsg(redo)
+	if got := m.sqlTextarea.Value(); got != "SELECT now()" {
+		t.Fatalf("after redo SQL = %q, want %q", got, "SELECT now()")
+	}
+
+	m.handleKeyMsg(undo)
+	typeText("x")
+	m.handleKeyMsg(redo)
+	if got := m.sqlTextarea.Value(); got != "SELECT 1x" {
+		t.Errorf("a new edit should clear redo, got %q", got)
+	}
+}
+
+func TestTickSkipsManualRefreshTabs(t *testing.T) {
+	zone.NewGlobal()
+
+	settings := Query{Name: "Settings", SQL: "SELECT 1", NoAutoRefresh: true}
+	m := &Model{queries: []Query{settings}, tempQueries: map[string]int{}, ready: true, width: 80, lastQuery: settings}
+
+	if _, cmd := m.handleTickMsg(); cmd != nil || m.loading {
+		t.Error("tick re-ran a query with auto refresh off")
+	}
+	if !strings.Contains(m.renderNormalMode(), "⏸ Settings") {
+		t.Error("tab for a non-refreshing query is missing the ⏸ marker")
+	}
+}
+
+func TestArchiveFromEditorAndRestore(t *testing.T) {
+	zone.NewGlobal()
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	for _, q := range []Query{
+		{Name: "Locks", SQL: "SELECT 1", OrderPosition: intPtr(1)},
+		{Name: "Bloat", SQL: "SELECT 2", OrderPosition: intPtr(2)},
+	} {
+		if err := qdb.SaveQuery(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, height: 40}
+	if err := m.reloadQueries(); err != nil {
+		t.Fatal(err)
+	}
+	hasTab := func(name string) bool {
+		for _, q := range m.queries {
+			if q.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	// ctrl+d in the editor archives rather than deletes, keeping Home and Active
+	m.selectQueryByName("Locks")
+	m.previousSelected = m.selected
+	m.editMode = true
+	m.editQuery = m.queries[m.selected]
+	m.initEditor(m.editQuery)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if m.editMode || hasTab("Locks") || !hasTab(HomeQuery().Name) || !hasTab(ActiveQuery().Name) {
+		t.Fatalf("after ctrl+d: editMode = %v, tabs = %+v; want Locks gone and Home/Active kept", m.editMode, m.queries)
+	}
+
+	// A opens the archive and enter restores the selected query
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m.archiveView == nil || len(m.archiveView.Queries) != 1 || m.archiveView.Queries[0].Name != "Locks" {
+		t.Fatalf("archiveView = %+v, want Locks listed", m.archiveView)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if !hasTab("Locks") || len(m.archiveView.Queries) != 0 {
+		t.Errorf("after restore: tabs = %+v, archived = %+v; want Locks back", m.queries, m.archiveView.Queries)
+	}
+
+	// alt+d deletes for good, so nothing shows up in the archive
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEsc})
+	m.selectQueryByName("Bloat")
+	m.previousSelected = m.selected
+	m.editMode = true
+	m.editQuery = m.queries[m.selected]
+	m.initEditor(m.editQuery)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true})
+	if !m.confirmPurge || !hasTab("Bloat") {
+		t.Fatalf("confirmPurge = %v; alt+d should ask before deleting Bloat", m.confirmPurge)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.confirmPurge || !m.editMode || !hasTab("Bloat") {
+		t.Fatalf("n should keep Bloat and the editor open")
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if hasTab("Bloat") {
+		t.Errorf("Bloat still open after alt+d and y")
+	}
+	if archived, err := qdb.LoadArchivedQueries(); err != nil || len(archived) != 0 {
+		t.Errorf("LoadArchivedQueries() = %+v, %v; want nothing after a permanent delete", archived, err)
+	}
+}
+
+func TestColumnPicker(t *testing.T) {
+	zone.NewGlobal()
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	if err := qdb.SaveQuery(Query{Name: "Settings", SQL: "SELECT name, setting, unit FROM pg_settings", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, height: 40}
+	if err := m.reloadQueries(); err != nil {
+		t.Fatal(err)
+	}
+	m.selectQueryByName("Settings")
+	m.lastQuery = m.queries[m.selected]
+	m.handleQueryResult(queryResultMsg{Output: "table", Columns: []string{"name", "setting", "unit"}})
+
+	press := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "space":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		m.handleKeyMsg(msg)
+	}
+
+	press("C")
+	if m.columnPicker == nil {
+		t.Fatal("C should open the column picker for a table result")
+	}
+	// Hide unit, then try to hide the rest: the last visible column stays
+	press("down")
+	press("down")
+	press("space")
+	m.columnPicker.SelectedIndex = 0
+	press("space")
+	m.columnPicker.SelectedIndex = 1
+	press("space")
+	if got := m.columnPicker.HiddenColumns(); !reflect.DeepEqual(got, []string{"name", "unit"}) {
+		t.Fatalf("HiddenColumns() = %v, want [name unit]", got)
+	}
+	press("enter")
+
+	if m.columnPicker != nil {
+		t.Errorf("enter should close the picker")
+	}
+	if got := m.queries[m.selected].HiddenColumns; !reflect.DeepEqual(got, []string{"name", "unit"}) {
+		t.Errorf("tab HiddenColumns = %v, want [name unit]", got)
+	}
+	saved, err := qdb.GetQuery("Settings")
+	if err != nil || !reflect.DeepEqual(saved.HiddenColumns, []string{"name", "unit"}) {
+		t.Errorf("saved HiddenColumns = %v, %v; want them persisted per query", saved.HiddenColumns, err)
+	}
+	if !m.loading {
+		t.Errorf("changing the hidden columns should re-run the query")
+	}
+}
+
+func TestResultKeptWhileRefreshing(t *testing.T) {
+	zone.NewGlobal()
+
+	m := &Model{
+		queries:     []Query{{Name: "Locks", SQL: "SELECT 1"}, {Name: "Bloat", SQL: "SELECT 2"}},
+		tempQueries: map[string]int{},
+		ready:       true,
+		width:       80,
+		height:      40,
+		viewport:    viewport.New(80, 40),
+	}
+	m.selectTab(0)
+	m.handleQueryResult(queryResultMsg{Output: "locks result"})
+
+	// A tick-driven refresh leaves the result in place until the new one arrives
+	m.lastRefreshAt = time.Now().Add(-time.Second)
+	m.handleTickMsg()
+	if !m.loading || m.results != "locks result" || m.resultsStale {
+		t.Fatalf("during refresh: loading = %v, results = %q, stale = %v; want the current result kept", m.loading, m.results, m.resultsStale)
+	}
+	m.handleQueryResult(queryResultMsg{Output: "locks result 2"})
+
+	// A tab that hasn't run yet starts blank
+	m.selectTab(1)
+	if m.results != "" {
+		t.Errorf("results = %q on a tab that never ran, want blank", m.results)
+	}
+	m.handleQueryResult(queryResultMsg{Output: "bloat result"})
+
+	// Switching back shows the tab's last result, marked as stale, while it re-runs
+	m.selectTab(0)
+	if m.results != "locks result 2" || !m.resultsStale {
+		t.Fatalf("results = %q, stale = %v; want the cached Locks result", m.results, m.resultsStale)
+	}
+	if view := m.viewport.View(); !strings.Contains(view, "while refreshing") {
+		t.Errorf("stale result should be labelled while refreshing:\n%s", view)
+	}
+
+	// Only an error clears it
+	m.handleQueryError(queryErrorMsg("Query failed"))
+	if m.results != "" {
+		t.Errorf("results = %q after an error, want cleared", m.results)
+	}
+	m.selectTab(1)
+	m.selectTab(0)
+	if m.resultsStale {
+		t.Errorf("a failed tab's old result should not come back")
+	}
+}
+
+func TestRefreshAge(t *testing.T) {
+	m := &Model{}
+	if got := m.refreshAge(); got != "" {
+		t.Errorf("refreshAge() = %q before any result, want empty", got)
+	}
+
+	m.lastRefreshAt = time.Now().Add(-3 * time.Second)
+	if got := m.refreshAge(); got != "updated 3s ago" {
+		t.Errorf("refreshAge() = %q, want %q", got, "updated 3s ago")
+	}
+	if m.refreshOverdue() {
+		t.Errorf("a 3s old result shouldn't be overdue")
+	}
+
+	m.lastRefreshAt = time.Now().Add(-staleAfter - time.Second)
+	if !m.refreshOverdue() {
+		t.Errorf("a result older than %v should be overdue", staleAfter)
+	}
+	m.lastQuery.NoAutoRefresh = true
+	if m.refreshOverdue() {
+		t.Errorf("manual-refresh tabs are never overdue")
+	}
+}
+
+func TestExternalEditor(t *testing.T) {
+	zone.NewGlobal()
+
+	setEnv(t, map[string]string{"EDITOR": "code -w"})
+	if got := editorCommand("/tmp/q.sql").Args; !reflect.DeepEqual(got, []string{"code", "-w", "/tmp/q.sql"}) {
+		t.Errorf("editorCommand() args = %q", got)
+	}
+	setEnv(t, map[string]string{"EDITOR": ""})
+	if got := editorCommand("/tmp/q.sql").Args; !reflect.DeepEqual(got, []string{"vi", "/tmp/q.sql"}) {
+		t.Errorf("editorCommand() args without EDITOR = %q", got)
+	}
+
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, editMode: true}
+	m.initEditor(Query{Name: "Locks", SQL: "SELECT 1"})
+
+	// The edited file is read back, trailing newline dropped, and the temp file removed
+	path := filepath.Join(t.TempDir(), "psq-edit.sql")
+	if err := os.WriteFile(path, []byte("SELECT 2\nFROM pg_locks\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.handleExternalEdit(externalEditDone(path)(nil).(externalEditMsg))
+	if got := m.sqlTextarea.Value(); got != "SELECT 2\nFROM pg_locks" {
+		t.Errorf("SQL after editing = %q", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("temp file should be removed, stat err = %v", err)
+	}
+	m.undoSQL()
+	if got := m.sqlTextarea.Value(); got != "SELECT 1" {
+		t.Errorf("undo after editing = %q, want SELECT 1", got)
+	}
+
+	// A failed editor leaves the SQL alone
+	if err := os.WriteFile(path, []byte("half typed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.handleExternalEdit(externalEditDone(path)(fmt.Errorf("exit status 1")).(externalEditMsg))
+	if got := m.sqlTextarea.Value(); got != "SELECT 1" || !strings.Contains(m.err, "left unchanged") {
+		t.Errorf("SQL = %q, err = %q; want the edit discarded with an error", got, m.err)
+	}
+}
+
+func TestReloadKeepsSelection(t *testing.T) {
+	zone.NewGlobal()
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	originalQueryDB := globalQueryDB
+	globalQueryDB = qdb
+	defer func() { globalQueryDB = originalQueryDB }()
+
+	if err := qdb.SaveQuery(Query{Name: "Locks", SQL: "SELECT 1", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+	m := &Model{tempQueries: map[string]int{}, ready: true, width: 80, height: 40, viewport: viewport.New(80, 40)}
+	if err := m.reloadQueries(); err != nil {
+		t.Fatal(err)
+	}
+	m.selectQueryByName("Locks")
+
+	// Another terminal adds a query ahead of the selected one
+	if err := qdb.SaveQuery(Query{Name: "Bloat", SQL: "SELECT 2", OrderPosition: intPtr(0)}); err != nil {
+		t.Fatal(err)
+	}
+	_, cmd := m.handleNormalModeKeys(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if cmd == nil || !m.loading {
+		t.Error("ctrl+r should re-run the selected tab")
+	}
+	if got := m.queries[m.selected].Name; got != "Locks" {
+		t.Errorf("selected %q after reload, want Locks", got)
+	}
+	if len(m.queries) != 4 || m.status != "Reloaded 2 saved queries" {
+		t.Errorf("tabs %d, status %q; want the new query picked up", len(m.queries), m.status)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// runOneShot runs SQL, or a saved query by name, once against service and writes the result to out
+func runOneShot(service, execSQL, queryName, format string, out io.Writer) error {
+	if execSQL != "" && queryName != "" {
+		return fmt.Errorf("use either --exec or --query, not both")
+	}
+
+	query := execSQL
+	if queryName != "" {
+		if err := initQueryDB(); err != nil {
+			return fmt.Errorf("failed to initialize query database: %w", err)
+		}
+		saved, err := globalQueryDB.GetQuery(queryName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("saved query %q not found", queryName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load query %q: %w", queryName, err)
+		}
+		query = saved.SQL
+	}
+
+	defer closeTunnel(service)
+	db, err := connectDB(service)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	start := time.Now()
+	// Scripts get every row; the cap only protects the TUI
+	columns, rows, _, err := fetchRows(context.Background(), db, query, 0)
+	logStatement(service, query, start, err)
+	if err != nil {
+		return err
+	}
+	return writeResult(out, format, columns, rows)
+}
+
+// writeResult writes rows as the same table the TUI shows, or as CSV for scripts
+func writeResult(out io.Writer, format string, columns []string, rows [][]string) error {
+	switch strings.ToLower(format) {
+	case "", "table":
+		for _, row := range rows {
+			for i := range row {
+				row[i] = scrubNewlines(row[i])
+			}
+		}
+		opts, err := tableOptionsFromEnv()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, renderTable(columns, rows, opts))
+		return err
+	case "csv":
+		w := csv.NewWriter(out)
+		if err := w.Write(columns); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		return fmt.Errorf("unknown format %q (use table or csv)", format)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteResult(t *testing.T) {
+	columns := []string{"name", "note"}
+	rows := func() [][]string {
+		return [][]string{{"alpha", "line one\nline two"}, {"beta", "NULL"}}
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeResult(&csvOut, "csv", columns, rows()); err != nil {
+		t.Fatalf("writeResult(csv) error = %v", err)
+	}
+	wantCSV := "name,note\nalpha,\"line one\nline two\"\nbeta,NULL\n"
+	if csvOut.String() != wantCSV {
+		t.Errorf("writeResult(csv) = %q, want %q", csvOut.String(), wantCSV)
+	}
+
+	var tableOut bytes.Buffer
+	if err := writeResult(&tableOut, "table", columns, rows()); err != nil {
+		t.Fatalf("writeResult(table) error = %v", err)
+	}
+	if !strings.Contains(tableOut.String(), "alpha") || strings.Contains(tableOut.String(), "one\nline") {
+		t.Errorf("writeResult(table) = %q, want single-line rows", tableOut.String())
+	}
+
+	if err := writeResult(&bytes.Buffer{}, "xml", columns, rows()); err == nil {
+		t.Error("writeResult(xml) error = nil, want unknown format")
+	}
+}
+
+func TestRunOneShotFlagConflict(t *testing.T) {
+	if err := runOneShot("default", "SELECT 1", "Connections", "table", &bytes.Buffer{}); err == nil {
+		t.Error("runOneShot() with --exec and --query error = nil, want conflict")
+	}
+}
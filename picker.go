@@ -79,6 +79,28 @@ func (m *PickerModel) ensureValidSelection() {
 	}
 }
 
+// reloadServices re-reads the service files, staying on the selected service when it's still there
+func (m *PickerModel) reloadServices() error {
+	services, err := listServices()
+	if err != nil {
+		return err
+	}
+	selected := ""
+	if m.selected < len(m.services) {
+		selected = m.services[m.selected]
+	}
+	m.services = services
+	m.err = ""
+	for i, name := range services {
+		if name == selected {
+			m.selected = i
+			return nil
+		}
+	}
+	m.ensureValidSelection()
+	return nil
+}
+
 func (m *PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.MouseMsg:
@@ -143,8 +165,9 @@ func (m *PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "e":
-			// Edit pg_service.conf
-			configPath := os.ExpandEnv("$HOME/.pg_service.conf")
+			// Edit the last service file, whose settings win when files are merged
+			files := serviceFiles()
+			configPath := files[len(files)-1]
 			editor := os.Getenv("EDITOR")
 			if editor == "" {
 				editor = "vi"
@@ -158,18 +181,18 @@ func (m *PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if err != nil {
 					return fmt.Sprintf("Failed to edit config: %v", err)
 				}
-				// Reload services
-				services, err := listServices()
-				if err != nil {
+				if err := m.reloadServices(); err != nil {
 					return fmt.Sprintf("Failed to reload services: %v", err)
 				}
-				m.services = services
-				if m.selected >= len(services) && len(services) > 0 {
-					m.selected = len(services) - 1
-				}
 				m.updateContent()
 				return nil
 			})
+
+		case "ctrl+r":
+			if err := m.reloadServices(); err != nil {
+				m.err = fmt.Sprintf("Failed to reload services: %v", err)
+			}
+			m.updateContent()
 		}
 
 	case tea.WindowSizeMsg:
@@ -180,7 +203,7 @@ func (m *PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport = viewport.New(msg.Width, msg.Height)
 			m.viewport.Style = lipgloss.NewStyle().
 				BorderStyle(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("62"))
+				BorderForeground(theme.Border)
 			m.ready = true
 			m.updateContent()
 		} else {
@@ -200,10 +223,10 @@ func (m *PickerModel) updateContent() {
 	// Header
 	content += lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		Padding(0, 1).
 		Render("psq - Service Picker")
-	content += lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Press ? for help")
+	content += lipgloss.NewStyle().Foreground(theme.Dim).Render("Press ? for help")
 	content += "\n\n"
 
 	// Show help if requested
@@ -218,7 +241,7 @@ func (m *PickerModel) updateContent() {
 	if m.err != "" {
 		content += "Error: " + m.err
 	} else if len(m.services) == 0 {
-		content += "No services found in ~/.pg_service.conf\nPress 'e' to edit the configuration file."
+		content += "No services found in " + serviceFilesLabel() + "\nPress 'e' to edit the configuration file, ctrl+r to reload it."
 	} else {
 		for i, service := range m.services {
 			var serviceText string
@@ -226,14 +249,14 @@ func (m *PickerModel) updateContent() {
 				// Selected service: bold, colored, with background
 				style := lipgloss.NewStyle().
 					Bold(true).
-					Foreground(lipgloss.Color("86")).
-					Background(lipgloss.Color("235"))
+					Foreground(theme.Primary).
+					Background(theme.SelectedBg)
 				serviceText = style.Render("▶ " + service)
 			} else {
 				// Non-selected services: subtle background and padding to show they're clickable
 				baseStyle := lipgloss.NewStyle().
-					Background(lipgloss.Color("238")).
-					Foreground(lipgloss.Color("252"))
+					Background(theme.TabBg).
+					Foreground(theme.Text)
 				serviceText = baseStyle.Render(service)
 			}
 
@@ -251,15 +274,15 @@ func (m *PickerModel) customHelpView() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
 	keyStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("39"))
+		Foreground(theme.Key)
 
 	descStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("244"))
+		Foreground(theme.Subtle)
 
 	helpText.WriteString(titleStyle.Render("Service Picker Help") + "\n\n")
 
@@ -272,7 +295,8 @@ func (m *PickerModel) customHelpView() string {
 
 	// Configuration
 	helpText.WriteString(titleStyle.Render("Configuration:") + "\n")
-	helpText.WriteString(keyStyle.Render("e") + " " + descStyle.Render("edit ~/.pg_service.conf") + "\n\n")
+	helpText.WriteString(keyStyle.Render("e") + " " + descStyle.Render("edit the service file ("+serviceFiles()[len(serviceFiles())-1]+")") + "\n")
+	helpText.WriteString(keyStyle.Render("ctrl+r") + " " + descStyle.Render("reload services, e.g. after editing the file elsewhere") + "\n\n")
 
 	// System
 	helpText.WriteString(titleStyle.Render("System:") + "\n")
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPickerReloadServices(t *testing.T) {
+	tmpDir := t.TempDir()
+	conf := filepath.Join(tmpDir, ".pg_service.conf")
+	if err := os.WriteFile(conf, []byte("[prod]\nhost=a\n[staging]\nhost=b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{"HOME": tmpDir, "PGSERVICEFILE": ""})
+
+	m := NewServicePicker().model
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.selected = 1 // staging
+
+	if err := os.WriteFile(conf, []byte("[dev]\nhost=c\n[prod]\nhost=a\n[staging]\nhost=b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if len(m.services) != 3 || m.services[m.selected] != "staging" {
+		t.Errorf("services %v, selected %d; want dev added and staging still selected", m.services, m.selected)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sslMode is the sslmode psq connects with; spawned psql sessions use it too
+const sslMode = "require"
+
+// connInfo returns the libpq connection string for config without the password,
+// which psql gets from a private pgpass file instead
+func connInfo(config *DBConfig) string {
+	return fmt.Sprintf("host=%s port=%s dbname=%s user=%s sslmode=%s",
+		connValue(config.Host), connValue(config.Port), connValue(config.Database), connValue(config.User), sslMode)
+}
+
+// connValue quotes a connection string value only when it needs it, so copied
+// commands stay readable
+func connValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t'\\=") {
+		return dsnQuote(value)
+	}
+	return value
+}
+
+// psqlArgs returns the arguments for a psql session on the same connection psq uses,
+// with a prompt naming the service so it's clear which database the shell is on. A
+// sandbox session's prompt says so and shows the transaction status.
+func psqlArgs(config *DBConfig, service string, sandbox bool) []string {
+	prompt := "%n@" + strings.ReplaceAll(service, "%", "%%") + "/%/%R%# "
+	if sandbox {
+		prompt = "%n@" + strings.ReplaceAll(service, "%", "%%") + "[sandbox]/%/%R%x%# "
+	}
+	return []string{"-d", connInfo(config), "--set=PROMPT1=" + prompt}
+}
+
+// sandboxPsqlrc returns a psqlrc that runs userRC, the user's own psqlrc ("" for none), and
+// then opens a transaction. Quitting psql without COMMIT rolls back everything the session
+// did. No savepoints are used, so an error aborts the transaction until ROLLBACK.
+func sandboxPsqlrc(userRC string) string {
+	var b strings.Builder
+	if userRC != "" {
+		b.WriteString(`\i ` + psqlQuote(userRC) + "\n")
+	}
+	b.WriteString("BEGIN;\n")
+	b.WriteString(`\echo 'psq sandbox: this session is inside a transaction. Quitting rolls it back; only COMMIT keeps changes.'` + "\n")
+	return b.String()
+}
+
+// userPsqlrc returns the psqlrc psql reads by default, $PSQLRC or ~/.psqlrc, or "" if it
+// doesn't exist
+func userPsqlrc() string {
+	path := os.Getenv("PSQLRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".psqlrc")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// writeTempPsqlrc writes a sandbox psqlrc to a temporary file for PSQLRC
+func writeTempPsqlrc() (string, error) {
+	f, err := os.CreateTemp("", "psq-psqlrc-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary psqlrc: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(sandboxPsqlrc(userPsqlrc())); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temporary psqlrc: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// psqlQuote single-quotes a psql meta-command argument
+func psqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// parsePsqlOverride splits a psql override typed as [user@]dbname into its role and
+// database. Either part may be empty to keep the service's own.
+func parsePsqlOverride(input string) (user, database string) {
+	input = strings.TrimSpace(input)
+	if i := strings.LastIndex(input, "@"); i >= 0 {
+		return strings.TrimSpace(input[:i]), strings.TrimSpace(input[i+1:])
+	}
+	return "", input
+}
+
+// psqlConnVars are libpq environment variables that would change how a psql session from
+// psq connects. PGPASSWORD in particular wins over PGPASSFILE, so it could log psql in with a
+// password psq didn't use.
+var psqlConnVars = map[string]bool{
+	"PGPASSWORD": true, "PGPASSFILE": true, "PGSERVICE": true, "PGSERVICEFILE": true,
+	"PGHOST": true, "PGHOSTADDR": true, "PGPORT": true, "PGUSER": true, "PGDATABASE": true,
+}
+
+// psqlEnv returns environ without psqlConnVars, so psql connects only with the settings
+// psq hands it
+func psqlEnv(environ []string) []string {
+	var env []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !psqlConnVars[name] {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// withOverride returns a copy of config connecting as user to database. Host, port and
+// password stay the service's; an empty user or database keeps the configured one.
+func withOverride(config *DBConfig, user, database string) *DBConfig {
+	c := *config
+	if user != "" {
+		c.User = user
+	}
+	if database != "" {
+		c.Database = database
+	}
+	return &c
+}
+
+// psqlCommandLine is a psql invocation for config that can be pasted into a shell.
+// It never includes the password.
+func psqlCommandLine(config *DBConfig) string {
+	return "psql " + shellQuote(connInfo(config))
+}
+
+// shellQuote single-quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPsqlArgs(t *testing.T) {
+	config := &DBConfig{Host: "db.example.com", Port: "5432", Database: "app", User: "o'brien", Password: "s3cret"}
+
+	want := []string{
+		"-d", `host=db.example.com port=5432 dbname=app user='o\'brien' sslmode=require`,
+		"--set=PROMPT1=%n@prod%%1/%/%R%# ",
+	}
+	if got := psqlArgs(config, "prod%1", false); !reflect.DeepEqual(got, want) {
+		t.Errorf("psqlArgs() = %q, want %q", got, want)
+	}
+	if got := psqlArgs(config, "prod", true); got[2] != "--set=PROMPT1=%n@prod[sandbox]/%/%R%x%# " {
+		t.Errorf("psqlArgs(sandbox) prompt = %q", got[2])
+	}
+
+	line := psqlCommandLine(config)
+	if strings.Contains(line, "s3cret") {
+		t.Errorf("psqlCommandLine() = %q leaks the password", line)
+	}
+	if want := `psql 'host=db.example.com port=5432 dbname=app user='\''o\'\''brien'\'' sslmode=require'`; line != want {
+		t.Errorf("psqlCommandLine() = %q, want %q", line, want)
+	}
+}
+
+func TestPsqlOverride(t *testing.T) {
+	config := &DBConfig{Host: "db.example.com", Port: "5432", Database: "app", User: "alice", Password: "s3cret"}
+
+	tests := []struct {
+		input        string
+		user, dbname string
+	}{
+		{"", "alice", "app"},
+		{"postgres", "alice", "postgres"},
+		{"admin@", "admin", "app"},
+		{"admin@postgres", "admin", "postgres"},
+		{" @postgres ", "alice", "postgres"},
+	}
+	for _, tt := range tests {
+		user, dbname := parsePsqlOverride(tt.input)
+		got := withOverride(config, user, dbname)
+		if got.User != tt.user || got.Database != tt.dbname {
+			t.Errorf("override %q connects as %s@%s, want %s@%s", tt.input, got.User, got.Database, tt.user, tt.dbname)
+		}
+		if got.Host != config.Host || got.Port != config.Port || got.Password != config.Password {
+			t.Errorf("override %q changed host, port or password: %+v", tt.input, got)
+		}
+	}
+	if config.User != "alice" || config.Database != "app" {
+		t.Errorf("withOverride() modified the service config: %+v", config)
+	}
+}
+
+func TestPsqlEnv(t *testing.T) {
+	environ := []string{"HOME=/home/alice", "PGPASSWORD=other", "PGSERVICE=prod", "PGHOST=elsewhere",
+		"PGPORT=6543", "PGUSER=bob", "PGDATABASE=x", "PGPASSFILE=/tmp/old", "PGCONNECT_TIMEOUT=5", "PAGER=less"}
+	got := psqlEnv(environ)
+	want := []string{"HOME=/home/alice", "PGCONNECT_TIMEOUT=5", "PAGER=less"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("psqlEnv() = %q, want %q", got, want)
+	}
+}
+
+func TestSandboxPsqlrc(t *testing.T) {
+	rc := sandboxPsqlrc("/home/o'brien/.psqlrc")
+	if !strings.HasPrefix(rc, `\i '/home/o''brien/.psqlrc'`+"\n") {
+		t.Errorf("sandboxPsqlrc() should run the user's psqlrc first:\n%s", rc)
+	}
+	if !strings.Contains(rc, "\nBEGIN;\n") || strings.Contains(strings.ToUpper(rc), "SAVEPOINT") || strings.Contains(rc, "ON_ERROR_ROLLBACK") {
+		t.Errorf("sandboxPsqlrc() should open a plain transaction:\n%s", rc)
+	}
+	if rc := sandboxPsqlrc(""); strings.Contains(rc, `\i`) {
+		t.Errorf("sandboxPsqlrc(\"\") = %q, want no include", rc)
+	}
+
+	dir := t.TempDir()
+	setEnv(t, map[string]string{"PSQLRC": filepath.Join(dir, "missing")})
+	if got := userPsqlrc(); got != "" {
+		t.Errorf("userPsqlrc() = %q for a missing file, want \"\"", got)
+	}
+}
+
+func TestPsqlSandboxToggle(t *testing.T) {
+	m := &Model{psqlMode: true}
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeyTab})
+	if !m.psqlSandbox || !m.psqlMode {
+		t.Fatalf("tab at the psql prompt: sandbox %v, prompt open %v; want sandbox on and the prompt still open", m.psqlSandbox, m.psqlMode)
+	}
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeyTab})
+	if m.psqlSandbox {
+		t.Error("a second tab should turn the sandbox off")
+	}
+
+	// Database names can have spaces, and keys the prompt doesn't use leave it open
+	m.psqlInput = "admin@my"
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeySpace})
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("db")})
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeyUp})
+	if !m.psqlMode || m.psqlInput != "admin@my db" {
+		t.Errorf("psqlMode = %v, psqlInput = %q; want the prompt open with %q", m.psqlMode, m.psqlInput, "admin@my db")
+	}
+	m.handlePsqlOverrideKeys(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.psqlMode {
+		t.Error("esc should close the prompt")
+	}
+}
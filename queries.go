@@ -1,19 +1,46 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type queryResultMsg string
+// queryResultMsg is a rendered result. Columns lists every column of a plain table
+// result, hidden ones included, for the column picker; it's nil for Home and Active.
+// Snapshot is the table as fetched, for diffing the next refresh against.
+type queryResultMsg struct {
+	Output   string
+	Columns  []string
+	Snapshot *resultSnapshot
+}
 type queryErrorMsg string
 
+// queryCancelledMsg is sent when an in-flight query is aborted with ctrl+g or superseded by another
+type queryCancelledMsg struct{}
+
+// queryDoneMsg carries whatever a run of runQuery ended with, tagged with the run's generation
+// so a late message from a superseded run can't touch the one in flight
+type queryDoneMsg struct {
+	gen int
+	msg tea.Msg
+}
+
 var globalQueryDB *QueryDB
 
 func initQueryDB() error {
+	dir := configDir()
+	// Only the default location inherits ~/.psqi; a relocated dir starts clean
+	if dir == defaultConfigDir() {
+		if err := migrateLegacyDir(legacyConfigDir(), dir); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", legacyConfigDir(), err)
+		}
+	}
 	var err error
-	globalQueryDB, err = NewQueryDB()
+	globalQueryDB, err = NewQueryDB(dir)
 	return err
 }
 
@@ -28,31 +55,78 @@ func loadQueries() ([]Query, error) {
 }
 
 func (m *Model) runQuery(query Query) tea.Cmd {
-	return func() tea.Msg {
-		// Check if connection is still alive, reconnect if needed
-		if m.db == nil || m.db.Ping() != nil {
-			newDB, err := connectDB(m.service)
-			if err != nil {
-				return queryErrorMsg(fmt.Sprintf("Failed to reconnect: %v", err))
-			}
-			// Close old connection if it exists
-			if m.db != nil {
-				m.db.Close()
-			}
-			m.db = newDB
-		}
+	// Only one query is in flight at a time; starting another abandons the previous one
+	m.cancelQuery()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.queryCancel = cancel
+	m.queryGen++
+	gen := m.queryGen
+	m.resultNote = ""
+
+	// Read the model's state now; the query runs on another goroutine
+	opts := m.tableOptions()
+	opts.DiffKey = m.diffKeys[query.Name]
+	if cached, ok := m.resultCache[query.Name]; ok {
+		opts.Previous = cached.Snapshot
+	}
+
+	// The TPS sampler appends to the sparkline on the main goroutine; Home renders a copy
+	var sparkline *SparklineData
+	if IsHomeTab(query.Name) && m.sparklineData != nil {
+		sparkline = m.sparklineData.Clone()
+	}
 
-		// Capture local ref — another goroutine (Close) may nil out m.db
-		db := m.db
+	// Capture the pool now: only handleReconnectResult replaces m.db, on the main goroutine
+	db := m.db
+
+	fetch := func() tea.Msg {
 		if db == nil {
-			return queryErrorMsg("Connection closed")
+			return connectionLostMsg{err: errors.New("not connected")}
+		}
+		// A dead connection is replaced by the reconnect loop, never from here
+		if err := db.PingContext(ctx); err != nil {
+			if ctx.Err() != nil {
+				return queryCancelledMsg{}
+			}
+			return connectionLostMsg{err: err}
 		}
 
-		result, err := renderConnectionBarChart(db, query.SQL, query.Name, m)
+		debugLog.Debug("running query", "query", query.Name)
+		start := time.Now()
+		result, err := renderConnectionBarChart(ctx, db, query, opts, sparkline, m)
+		debugLog.Debug("query finished", "query", query.Name, "elapsed", time.Since(start), "error", err)
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+			return queryCancelledMsg{}
+		}
 		if err != nil {
+			if isConnectionError(err) {
+				return connectionLostMsg{err: err}
+			}
+			if isPgStatStatementsMissing(err) {
+				return queryErrorMsg(pgStatStatementsHint)
+			}
 			return queryErrorMsg(fmt.Sprintf("Query failed: %v", err))
 		}
 
-		return queryResultMsg(result)
+		return result
+	}
+	return func() tea.Msg {
+		return queryDoneMsg{gen: gen, msg: fetch()}
+	}
+}
+
+// cachedResult is a tab's last result and when it arrived
+type cachedResult struct {
+	queryResultMsg
+	at time.Time
+}
+
+// cancelQuery aborts the in-flight query, if any
+func (m *Model) cancelQuery() bool {
+	if m.queryCancel == nil {
+		return false
 	}
+	m.queryCancel()
+	m.queryCancel = nil
+	return true
 }
@@ -5,30 +5,46 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	_ "modernc.org/sqlite"
 )
 
+// sqliteDriver is the database/sql name of the pure-Go modernc.org/sqlite driver.
+// It's the only SQLite driver psq links, so builds need no cgo and tests run the
+// same engine as the app.
+const sqliteDriver = "sqlite"
+
 type QueryDB struct {
-	db *sql.DB
+	db  *sql.DB
+	dir string // directory migrateFromFiles looks in for dumps and old .sql files
+}
+
+// sqliteDSN adds the live database's pragmas to path. They're passed in the DSN
+// (the modernc driver's _pragma parameter) rather than executed once, because
+// busy_timeout and foreign_keys are per connection and database/sql may open several.
+// WAL keeps readers and a writer (say, a second psq dumping) from blocking each other.
+// Dump files are opened without these so they stay a single self-contained file.
+func sqliteDSN(path string) string {
+	return path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
 }
 
-func NewQueryDB() (*QueryDB, error) {
-	configDir := filepath.Join(os.ExpandEnv("$HOME"), ".psq")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+// NewQueryDB opens (creating if needed) dir/queries.db
+func NewQueryDB(dir string) (*QueryDB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	dbPath := filepath.Join(configDir, "queries.db")
+	dbPath := filepath.Join(dir, "queries.db")
 
 	// Connect to SQLite database
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open(sqliteDriver, sqliteDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	queryDB := &QueryDB{db: db}
+	queryDB := &QueryDB{db: db, dir: dir}
 
 	// Initialize schema
 	if err := queryDB.initSchema(); err != nil {
@@ -42,9 +58,42 @@ func NewQueryDB() (*QueryDB, error) {
 		return nil, fmt.Errorf("failed to migrate queries: %w", err)
 	}
 
+	if err := queryDB.addNewDefaults(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to add new default queries: %w", err)
+	}
+
 	return queryDB, nil
 }
 
+// migrateLegacyDir copies the query database and default dump from an old data
+// directory into dir, unless dir already has a query database. The old directory
+// is left in place.
+func migrateLegacyDir(legacy, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "queries.db")); err == nil {
+		return nil
+	}
+	for _, name := range []string{"queries.db", "default_queries.db"} {
+		data, err := os.ReadFile(filepath.Join(legacy, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (qdb *QueryDB) initSchema() error {
 	schema := `
 		CREATE TABLE IF NOT EXISTS queries (
@@ -53,12 +102,21 @@ func (qdb *QueryDB) initSchema() error {
 			description TEXT NOT NULL,
 			sql TEXT NOT NULL,
 			order_position INTEGER,
+			auto_refresh INTEGER NOT NULL DEFAULT 1,
+			tags TEXT NOT NULL DEFAULT '',
+			archived INTEGER NOT NULL DEFAULT 0,
+			hidden_columns TEXT NOT NULL DEFAULT '',
+			cache_ttl INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
 
 		CREATE INDEX IF NOT EXISTS idx_queries_name ON queries(name);
 		CREATE INDEX IF NOT EXISTS idx_queries_order ON queries(order_position);
+
+		CREATE TABLE IF NOT EXISTS added_defaults (
+			name TEXT PRIMARY KEY
+		);
 	`
 
 	_, err := qdb.db.Exec(schema)
@@ -112,6 +170,42 @@ func (qdb *QueryDB) initSchema() error {
 			return err
 		}
 	}
+	rows.Close()
+
+	// Add auto_refresh column if it doesn't exist; existing queries keep refreshing
+	if !qdb.hasColumn("auto_refresh") {
+		if _, err := qdb.db.Exec("ALTER TABLE queries ADD COLUMN auto_refresh INTEGER NOT NULL DEFAULT 1"); err != nil {
+			return err
+		}
+	}
+
+	// Add tags column if it doesn't exist
+	if !qdb.hasColumn("tags") {
+		if _, err := qdb.db.Exec("ALTER TABLE queries ADD COLUMN tags TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	// Add archived column if it doesn't exist
+	if !qdb.hasColumn("archived") {
+		if _, err := qdb.db.Exec("ALTER TABLE queries ADD COLUMN archived INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+
+	// Add hidden_columns column if it doesn't exist
+	if !qdb.hasColumn("hidden_columns") {
+		if _, err := qdb.db.Exec("ALTER TABLE queries ADD COLUMN hidden_columns TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	// Add cache_ttl column if it doesn't exist; existing queries are never cached
+	if !qdb.hasColumn("cache_ttl") {
+		if _, err := qdb.db.Exec("ALTER TABLE queries ADD COLUMN cache_ttl INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -130,11 +224,10 @@ func (qdb *QueryDB) migrateFromFiles() error {
 	}
 
 	// Try to load queries from the old file system
-	configDir := filepath.Join(os.ExpandEnv("$HOME"), ".psq")
-	sqlDir := filepath.Join(configDir, "queries")
+	sqlDir := filepath.Join(qdb.dir, "queries")
 
 	// Check for a default dump file first
-	defaultDumpFile := filepath.Join(configDir, "default_queries.db")
+	defaultDumpFile := filepath.Join(qdb.dir, "default_queries.db")
 
 	if _, err := os.Stat(defaultDumpFile); err == nil {
 		// Load from default dump file
@@ -154,17 +247,83 @@ func (qdb *QueryDB) migrateFromFiles() error {
 	}
 
 	// Insert loaded queries into database
-	for _, query := range queries {
-		if err := qdb.SaveQuery(query); err != nil {
-			return fmt.Errorf("failed to migrate query %s: %w", query.Name, err)
-		}
+	if err := qdb.saveAll(queries); err != nil {
+		return fmt.Errorf("failed to migrate queries: %w", err)
 	}
 
 	return nil
 }
 
+// newDefaultQueries are default queries added after the first release. Installs that
+// predate one get it once from addNewDefaults; append here when adding a default.
+var newDefaultQueries = []string{
+	"Vacuum Progress",
+	"Replication Health",
+	"Table Bloat",
+	"Index Usage",
+}
+
+// addNewDefaults inserts each of newDefaultQueries that this database hasn't been
+// offered yet, after the last tab. A name already taken, live or archived, is left
+// alone. Either way the name is recorded in added_defaults, so a default the user
+// later deletes doesn't come back.
+func (qdb *QueryDB) addNewDefaults() error {
+	defaults := map[string]Query{}
+	for _, q := range defaultQueries() {
+		defaults[q.Name] = q
+	}
+
+	hasOrderColumn := qdb.hasOrderPositionColumn()
+	tx, err := qdb.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, name := range newDefaultQueries {
+		var offered int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM added_defaults WHERE name = ?", name).Scan(&offered); err != nil {
+			return err
+		}
+		if offered > 0 {
+			continue
+		}
+
+		var taken int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM queries WHERE name = ?", name).Scan(&taken); err != nil {
+			return err
+		}
+		if taken == 0 {
+			query := defaults[name]
+			var last int
+			if err := tx.QueryRow("SELECT COALESCE(MAX(order_position), 0) FROM queries WHERE archived = 0").Scan(&last); err != nil {
+				return err
+			}
+			pos := last + 1
+			query.OrderPosition = &pos
+			if err := saveQuery(tx, query, hasOrderColumn); err != nil {
+				return fmt.Errorf("failed to save query %s: %w", name, err)
+			}
+		}
+
+		if _, err := tx.Exec("INSERT INTO added_defaults (name) VALUES (?)", name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (qdb *QueryDB) createDefaultQueries() error {
-	defaultQueries := []Query{
+	if err := qdb.saveAll(defaultQueries()); err != nil {
+		return fmt.Errorf("failed to create default queries: %w", err)
+	}
+
+	return nil
+}
+
+// defaultQueries are the tabs a new install starts with
+func defaultQueries() []Query {
+	return []Query{
 		{
 			Name:          "Lock Information",
 			Description:   "Show current locks",
@@ -200,16 +359,77 @@ func (qdb *QueryDB) createDefaultQueries() error {
 			Description:   "All current PostgreSQL configuration settings",
 			SQL:           "SELECT name, setting, unit, category, short_desc FROM pg_settings ORDER BY category, name;",
 			OrderPosition: &[]int{6}[0],
+			NoAutoRefresh: true, // settings rarely change; refresh with r
+		},
+		{
+			Name:          "Vacuum Progress",
+			Description:   "Show progress of running VACUUMs, autovacuum included",
+			SQL:           "SELECT p.pid, CASE WHEN a.backend_type = 'autovacuum worker' THEN 'auto' ELSE 'manual' END AS kind, p.datname, COALESCE(c.relname, 'oid ' || p.relid) AS table_name, p.phase, p.heap_blks_scanned || '/' || p.heap_blks_total AS heap_scanned, ROUND(100.0 * p.heap_blks_scanned / NULLIF(p.heap_blks_total, 0), 1) AS scanned_pct, p.heap_blks_vacuumed || '/' || p.heap_blks_total AS heap_vacuumed, p.index_vacuum_count AS index_passes, COALESCE(to_jsonb(p)->>'num_dead_item_ids', to_jsonb(p)->>'num_dead_tuples') AS dead_tuples, date_trunc('second', now() - a.xact_start) AS running_for FROM pg_stat_progress_vacuum p LEFT JOIN pg_stat_activity a ON a.pid = p.pid LEFT JOIN pg_class c ON c.oid = p.relid AND p.datname = current_database() ORDER BY a.xact_start;",
+			OrderPosition: &[]int{7}[0],
+		},
+		{
+			Name:        "Replication Health",
+			Description: "Standby lag and WAL held back by replication slots; rows turn amber or red past the thresholds in the SQL",
+			SQL: `WITH wal AS (SELECT CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END AS lsn)
+SELECT 'standby' AS kind, r.application_name AS name, COALESCE(r.client_addr::text, 'local') AS client, r.state,
+       COALESCE(pg_size_pretty(pg_wal_lsn_diff(wal.lsn, r.replay_lsn)), '-') AS behind,
+       COALESCE(date_trunc('milliseconds', r.write_lag)::text, '-') AS write_lag,
+       COALESCE(date_trunc('milliseconds', r.flush_lag)::text, '-') AS flush_lag,
+       COALESCE(date_trunc('milliseconds', r.replay_lag)::text, '-') AS replay_lag,
+       CASE WHEN r.state <> 'streaming' OR r.replay_lag > interval '5 minutes' THEN 'critical'
+            WHEN r.replay_lag > interval '30 seconds' THEN 'warn'
+            ELSE 'ok' END AS health
+FROM pg_stat_replication r, wal
+UNION ALL
+SELECT 'slot', s.slot_name, s.slot_type || COALESCE(' on ' || s.database, ''), CASE WHEN s.active THEN 'active' ELSE 'inactive' END,
+       COALESCE(pg_size_pretty(pg_wal_lsn_diff(wal.lsn, s.restart_lsn)), '-'), '-', '-', '-',
+       CASE WHEN pg_wal_lsn_diff(wal.lsn, s.restart_lsn) > 10737418240 THEN 'critical' -- 10 GB
+            WHEN NOT s.active OR pg_wal_lsn_diff(wal.lsn, s.restart_lsn) > 1073741824 THEN 'warn' -- 1 GB
+            ELSE 'ok' END
+FROM pg_replication_slots s, wal
+ORDER BY 1 DESC, 2;`,
+			OrderPosition: &[]int{8}[0],
+		},
+		{
+			Name:        "Table Bloat",
+			Description: "Tables with the most dead tuples, estimated bloat and when they were last vacuumed and analyzed",
+			SQL: `SELECT schemaname || '.' || relname AS table_name,
+       n_live_tup AS live_tuples, n_dead_tup AS dead_tuples,
+       ROUND(100.0 * n_dead_tup / NULLIF(n_live_tup + n_dead_tup, 0), 1) AS dead_pct,
+       pg_size_pretty(pg_table_size(relid)) AS table_size,
+       pg_size_pretty((pg_table_size(relid) * n_dead_tup / NULLIF(n_live_tup + n_dead_tup, 0))::bigint) AS est_bloat,
+       COALESCE(date_trunc('second', now() - GREATEST(last_vacuum, last_autovacuum))::text, 'never') AS vacuumed_ago,
+       COALESCE(date_trunc('second', now() - GREATEST(last_analyze, last_autoanalyze))::text, 'never') AS analyzed_ago,
+       autovacuum_count,
+       CASE WHEN n_dead_tup > 10000 AND n_dead_tup > 0.2 * (n_live_tup + n_dead_tup) THEN 'critical'
+            WHEN n_dead_tup > 1000 AND n_dead_tup > 0.1 * (n_live_tup + n_dead_tup) THEN 'warn'
+            ELSE 'ok' END AS health
+FROM pg_stat_user_tables
+ORDER BY n_dead_tup DESC
+LIMIT 25;`,
+			OrderPosition: &[]int{9}[0],
+		},
+		{
+			Name:        "Index Usage",
+			Description: "Indexes by size with their scan counts since the stats were reset; unused ones turn red, rarely used ones amber",
+			SQL: `SELECT s.schemaname || '.' || s.indexrelname AS index_name,
+       s.relname AS table_name,
+       s.idx_scan AS scans,
+       pg_size_pretty(pg_relation_size(s.indexrelid)) AS index_size,
+       i.indisunique AS is_unique,
+       CASE WHEN i.indisunique THEN 'ok' -- enforces a constraint even when never scanned
+            WHEN s.idx_scan = 0 THEN 'critical'
+            WHEN s.idx_scan < 50 THEN 'warn'
+            ELSE 'ok' END AS health,
+       x.indexdef AS definition
+FROM pg_stat_user_indexes s
+JOIN pg_index i ON i.indexrelid = s.indexrelid
+JOIN pg_indexes x ON x.schemaname = s.schemaname AND x.tablename = s.relname AND x.indexname = s.indexrelname
+ORDER BY pg_relation_size(s.indexrelid) DESC
+LIMIT 50;`,
+			OrderPosition: &[]int{10}[0],
 		},
 	}
-
-	for _, query := range defaultQueries {
-		if err := qdb.SaveQuery(query); err != nil {
-			return fmt.Errorf("failed to create default query %s: %w", query.Name, err)
-		}
-	}
-
-	return nil
 }
 
 func (qdb *QueryDB) LoadQueries() ([]Query, error) {
@@ -219,15 +439,16 @@ func (qdb *QueryDB) LoadQueries() ([]Query, error) {
 	var query string
 	if hasOrderColumn {
 		query = `
-			SELECT name, description, sql, order_position 
+			SELECT ` + queryColumns + ` 
 			FROM queries 
-			WHERE order_position IS NOT NULL 
+			WHERE order_position IS NOT NULL AND archived = 0 
 			ORDER BY order_position, name
 		`
 	} else {
 		query = `
 			SELECT name, description, sql 
 			FROM queries 
+			WHERE archived = 0 
 			ORDER BY name
 		`
 	}
@@ -241,22 +462,15 @@ func (qdb *QueryDB) LoadQueries() ([]Query, error) {
 	var queries []Query
 	for rows.Next() {
 		var query Query
+		var err error
 		if hasOrderColumn {
-			var orderPos sql.NullInt64
-			if err := rows.Scan(&query.Name, &query.Description, &query.SQL, &orderPos); err != nil {
-				return nil, err
-			}
-
-			if orderPos.Valid {
-				pos := int(orderPos.Int64)
-				query.OrderPosition = &pos
-			}
+			query, err = scanQuery(rows)
 		} else {
-			if err := rows.Scan(&query.Name, &query.Description, &query.SQL); err != nil {
-				return nil, err
-			}
+			err = rows.Scan(&query.Name, &query.Description, &query.SQL)
+		}
+		if err != nil {
+			return nil, err
 		}
-
 		queries = append(queries, query)
 	}
 
@@ -264,6 +478,11 @@ func (qdb *QueryDB) LoadQueries() ([]Query, error) {
 }
 
 func (qdb *QueryDB) hasOrderPositionColumn() bool {
+	return qdb.hasColumn("order_position")
+}
+
+// hasColumn reports whether the queries table has the named column
+func (qdb *QueryDB) hasColumn(column string) bool {
 	rows, err := qdb.db.Query("PRAGMA table_info(queries)")
 	if err != nil {
 		return false
@@ -280,13 +499,39 @@ func (qdb *QueryDB) hasOrderPositionColumn() bool {
 			continue
 		}
 
-		if name == "order_position" {
+		if name == column {
 			return true
 		}
 	}
 	return false
 }
 
+// queryColumns are the columns scanQuery reads, in order
+const queryColumns = "name, description, sql, order_position, auto_refresh, tags, created_at, updated_at, hidden_columns, cache_ttl"
+
+// scanQuery reads one row of queryColumns
+func scanQuery(row interface{ Scan(...interface{}) error }) (Query, error) {
+	var query Query
+	var orderPos sql.NullInt64
+	var autoRefresh bool
+	var createdAt, updatedAt sql.NullTime
+	var hiddenColumns string
+	if err := row.Scan(&query.Name, &query.Description, &query.SQL, &orderPos, &autoRefresh, &query.Tags, &createdAt, &updatedAt, &hiddenColumns, &query.CacheTTL); err != nil {
+		return query, err
+	}
+	query.NoAutoRefresh = !autoRefresh
+	query.CreatedAt, query.UpdatedAt = createdAt.Time, updatedAt.Time
+	if hiddenColumns != "" {
+		query.HiddenColumns = strings.Split(hiddenColumns, "\n")
+	}
+
+	if orderPos.Valid {
+		pos := int(orderPos.Int64)
+		query.OrderPosition = &pos
+	}
+	return query, nil
+}
+
 func (qdb *QueryDB) LoadAllQueries() ([]Query, error) {
 	// First check if order_position column exists
 	hasOrderColumn := qdb.hasOrderPositionColumn()
@@ -294,14 +539,16 @@ func (qdb *QueryDB) LoadAllQueries() ([]Query, error) {
 	var query string
 	if hasOrderColumn {
 		query = `
-			SELECT name, description, sql, order_position 
+			SELECT ` + queryColumns + ` 
 			FROM queries 
+			WHERE archived = 0 
 			ORDER BY COALESCE(order_position, 999999), name
 		`
 	} else {
 		query = `
 			SELECT name, description, sql 
 			FROM queries 
+			WHERE archived = 0 
 			ORDER BY name
 		`
 	}
@@ -315,46 +562,87 @@ func (qdb *QueryDB) LoadAllQueries() ([]Query, error) {
 	var queries []Query
 	for rows.Next() {
 		var query Query
+		var err error
 		if hasOrderColumn {
-			var orderPos sql.NullInt64
-			if err := rows.Scan(&query.Name, &query.Description, &query.SQL, &orderPos); err != nil {
-				return nil, err
-			}
-
-			if orderPos.Valid {
-				pos := int(orderPos.Int64)
-				query.OrderPosition = &pos
-			}
+			query, err = scanQuery(rows)
 		} else {
-			if err := rows.Scan(&query.Name, &query.Description, &query.SQL); err != nil {
-				return nil, err
-			}
+			err = rows.Scan(&query.Name, &query.Description, &query.SQL)
+		}
+		if err != nil {
+			return nil, err
 		}
-
 		queries = append(queries, query)
 	}
 
 	return queries, rows.Err()
 }
 
+// SaveQuery creates query or updates the one with its name. A name an archived query
+// still holds is refused rather than overwriting it.
 func (qdb *QueryDB) SaveQuery(query Query) error {
-	// Check if order_position column exists
+	return saveQuery(qdb.db, query, qdb.hasOrderPositionColumn())
+}
+
+// SaveQueries saves queries inside tx; the caller commits or rolls back
+func (qdb *QueryDB) SaveQueries(tx *sql.Tx, queries []Query) error {
 	hasOrderColumn := qdb.hasOrderPositionColumn()
+	for _, query := range queries {
+		if err := saveQuery(tx, query, hasOrderColumn); err != nil {
+			return fmt.Errorf("failed to save query %s: %w", query.Name, err)
+		}
+	}
+	return nil
+}
 
+// saveAll saves queries in a single transaction, so a failure partway through
+// leaves the database as it was instead of half-populated
+func (qdb *QueryDB) saveAll(queries []Query) error {
+	tx, err := qdb.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := qdb.SaveQueries(tx, queries); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func saveQuery(db execer, query Query, hasOrderColumn bool) error {
 	if hasOrderColumn {
 		var orderPos interface{}
 		if query.OrderPosition != nil {
 			orderPos = *query.OrderPosition
 		}
 
-		_, err := qdb.db.Exec(`
-			INSERT OR REPLACE INTO queries (name, description, sql, order_position, updated_at) 
-			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-		`, query.Name, query.Description, query.SQL, orderPos)
-
-		return err
+		// Upsert rather than replace so created_at survives edits; archived queries are left alone
+		res, err := db.Exec(`
+			INSERT INTO queries (name, description, sql, order_position, auto_refresh, tags, cache_ttl, updated_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(name) DO UPDATE SET
+				description = excluded.description,
+				sql = excluded.sql,
+				order_position = excluded.order_position,
+				auto_refresh = excluded.auto_refresh,
+				tags = excluded.tags,
+				cache_ttl = excluded.cache_ttl,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE archived = 0
+		`, query.Name, query.Description, query.SQL, orderPos, !query.NoAutoRefresh, normalizeTags(query.Tags), query.CacheTTL)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("an archived query is already named %q: restore or delete it from the archive (A), or pick another name", query.Name)
+		}
+		return nil
 	} else {
-		_, err := qdb.db.Exec(`
+		_, err := db.Exec(`
 			INSERT OR REPLACE INTO queries (name, description, sql, updated_at) 
 			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
 		`, query.Name, query.Description, query.SQL)
@@ -363,11 +651,59 @@ func (qdb *QueryDB) SaveQuery(query Query) error {
 	}
 }
 
+// DeleteQuery removes a query for good; ArchiveQuery is the recoverable delete
 func (qdb *QueryDB) DeleteQuery(name string) error {
 	_, err := qdb.db.Exec("DELETE FROM queries WHERE name = ?", name)
 	return err
 }
 
+// ArchiveQuery hides a query from tabs and search until it's restored
+func (qdb *QueryDB) ArchiveQuery(name string) error {
+	return qdb.setArchived(name, true)
+}
+
+// RestoreQuery brings an archived query back, at its old tab position if it had one
+func (qdb *QueryDB) RestoreQuery(name string) error {
+	return qdb.setArchived(name, false)
+}
+
+func (qdb *QueryDB) setArchived(name string, archived bool) error {
+	res, err := qdb.db.Exec("UPDATE queries SET archived = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?", archived, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("query %q not found", name)
+	}
+	return nil
+}
+
+// SetHiddenColumns records which result columns to leave out when showing a query.
+// It's a display preference, so it doesn't count as an edit.
+func (qdb *QueryDB) SetHiddenColumns(name string, columns []string) error {
+	_, err := qdb.db.Exec("UPDATE queries SET hidden_columns = ? WHERE name = ?", strings.Join(columns, "\n"), name)
+	return err
+}
+
+// LoadArchivedQueries returns archived queries, most recently archived first
+func (qdb *QueryDB) LoadArchivedQueries() ([]Query, error) {
+	rows, err := qdb.db.Query("SELECT " + queryColumns + " FROM queries WHERE archived = 1 ORDER BY updated_at DESC, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queries []Query
+	for rows.Next() {
+		query, err := scanQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+	}
+	return queries, rows.Err()
+}
+
 func (qdb *QueryDB) GetQuery(name string) (Query, error) {
 	var query Query
 
@@ -375,20 +711,9 @@ func (qdb *QueryDB) GetQuery(name string) (Query, error) {
 	hasOrderColumn := qdb.hasOrderPositionColumn()
 
 	if hasOrderColumn {
-		var orderPos sql.NullInt64
-		err := qdb.db.QueryRow("SELECT name, description, sql, order_position FROM queries WHERE name = ?", name).
-			Scan(&query.Name, &query.Description, &query.SQL, &orderPos)
-
-		if err != nil {
-			return query, err
-		}
-
-		if orderPos.Valid {
-			pos := int(orderPos.Int64)
-			query.OrderPosition = &pos
-		}
+		return scanQuery(qdb.db.QueryRow("SELECT "+queryColumns+" FROM queries WHERE name = ? AND archived = 0", name))
 	} else {
-		err := qdb.db.QueryRow("SELECT name, description, sql FROM queries WHERE name = ?", name).
+		err := qdb.db.QueryRow("SELECT name, description, sql FROM queries WHERE name = ? AND archived = 0", name).
 			Scan(&query.Name, &query.Description, &query.SQL)
 
 		if err != nil {
@@ -400,22 +725,63 @@ func (qdb *QueryDB) GetQuery(name string) (Query, error) {
 }
 
 func (qdb *QueryDB) LoadFromDumpFile(filePath string) error {
+	queries, err := ReadDumpFile(filePath)
+	if err != nil {
+		return err
+	}
+	archived, err := qdb.LoadArchivedQueries()
+	if err != nil {
+		return fmt.Errorf("failed to load archived queries: %w", err)
+	}
+	// Names an archived query still holds can't be saved, so leave them out
+	// rather than failing the whole dump
+	queries, _, _, _ = planImport(nil, archived, queries)
+
+	// Insert queries into current database, all or nothing
+	if err := qdb.saveAll(queries); err != nil {
+		return fmt.Errorf("failed to load dump: %w", err)
+	}
+
+	return nil
+}
+
+// ReadDumpFile reads all queries from a dump file without touching the live database
+func ReadDumpFile(filePath string) ([]Query, error) {
 	// Check if dump file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("dump file does not exist: %s", filePath)
+		return nil, fmt.Errorf("dump file does not exist: %s", filePath)
 	}
 
 	// Open the dump database
-	dumpDB, err := sql.Open("sqlite", filePath)
+	dumpDB, err := sql.Open(sqliteDriver, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open dump database: %w", err)
+		return nil, fmt.Errorf("failed to open dump database: %w", err)
 	}
 	defer dumpDB.Close()
 
+	// Dumps written before auto_refresh, tags and cache_ttl existed refresh every query,
+	// have no tags and cache nothing
+	dump := &QueryDB{db: dumpDB}
+	autoRefreshColumn, tagsColumn, cacheTTLColumn := "1", "''", "0"
+	if dump.hasColumn("auto_refresh") {
+		autoRefreshColumn = "auto_refresh"
+	}
+	if dump.hasColumn("tags") {
+		tagsColumn = "tags"
+	}
+	if dump.hasColumn("cache_ttl") {
+		cacheTTLColumn = "cache_ttl"
+	}
+	// Archived queries stay behind when a live database is read as a dump
+	where := ""
+	if dump.hasColumn("archived") {
+		where = " WHERE archived = 0"
+	}
+
 	// Load queries from dump database
-	rows, err := dumpDB.Query("SELECT name, description, sql, order_position FROM queries ORDER BY COALESCE(order_position, 999999), name")
+	rows, err := dumpDB.Query("SELECT name, description, sql, order_position, " + autoRefreshColumn + ", " + tagsColumn + ", " + cacheTTLColumn + " FROM queries" + where + " ORDER BY COALESCE(order_position, 999999), name")
 	if err != nil {
-		return fmt.Errorf("failed to query dump database: %w", err)
+		return nil, fmt.Errorf("failed to query dump database: %w", err)
 	}
 	defer rows.Close()
 
@@ -423,9 +789,11 @@ func (qdb *QueryDB) LoadFromDumpFile(filePath string) error {
 	for rows.Next() {
 		var query Query
 		var orderPos sql.NullInt64
-		if err := rows.Scan(&query.Name, &query.Description, &query.SQL, &orderPos); err != nil {
-			return fmt.Errorf("failed to scan query from dump: %w", err)
+		var autoRefresh bool
+		if err := rows.Scan(&query.Name, &query.Description, &query.SQL, &orderPos, &autoRefresh, &query.Tags, &query.CacheTTL); err != nil {
+			return nil, fmt.Errorf("failed to scan query from dump: %w", err)
 		}
+		query.NoAutoRefresh = !autoRefresh
 
 		if orderPos.Valid {
 			pos := int(orderPos.Int64)
@@ -436,17 +804,40 @@ func (qdb *QueryDB) LoadFromDumpFile(filePath string) error {
 	}
 
 	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating dump queries: %w", err)
+		return nil, fmt.Errorf("error iterating dump queries: %w", err)
 	}
 
-	// Insert queries into current database
-	for _, query := range queries {
-		if err := qdb.SaveQuery(query); err != nil {
-			return fmt.Errorf("failed to load query %s from dump: %w", query.Name, err)
-		}
+	return queries, nil
+}
+
+// DumpToFile writes every saved query (including hidden ones) to a standalone SQLite
+// file that LoadFromDumpFile can read, replacing the file if it exists
+func (qdb *QueryDB) DumpToFile(filePath string) (int, error) {
+	queries, err := qdb.LoadAllQueries()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load queries: %w", err)
 	}
 
-	return nil
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to replace dump file: %w", err)
+	}
+
+	dumpDB, err := sql.Open(sqliteDriver, filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create dump database: %w", err)
+	}
+	defer dumpDB.Close()
+
+	dump := &QueryDB{db: dumpDB}
+	if err := dump.initSchema(); err != nil {
+		return 0, fmt.Errorf("failed to initialize dump schema: %w", err)
+	}
+
+	if err := dump.saveAll(queries); err != nil {
+		return 0, fmt.Errorf("failed to dump queries: %w", err)
+	}
+
+	return len(queries), nil
 }
 
 func (qdb *QueryDB) Close() error {
@@ -489,30 +880,52 @@ func loadQueryFromSQLFile(filename string) (Query, error) {
 		return Query{}, fmt.Errorf("missing title in first line")
 	}
 
-	// Parse description from second line (-- Description)
-	description := strings.TrimSpace(strings.TrimPrefix(lines[1], "--"))
-	if description == "" {
+	// Parse description from second line (-- Description); exports write "--" for an empty one
+	if !strings.HasPrefix(strings.TrimSpace(lines[1]), "--") {
 		return Query{}, fmt.Errorf("missing description in second line")
 	}
-
-	// Join remaining lines as SQL (excluding comment lines)
-	var sqlLines []string
-	for i := 2; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line != "" && !strings.HasPrefix(line, "--") {
-			sqlLines = append(sqlLines, line)
+	description := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[1]), "--"))
+
+	// Metadata comments follow the header; everything after them is the SQL, kept as written
+	var orderPosition *int
+	noAutoRefresh := false
+	tags := ""
+	cacheTTL := 0
+	body := 2
+metadata:
+	for ; body < len(lines); body++ {
+		line := strings.TrimSpace(lines[body])
+		if pos, ok := parseOrderComment(line); ok {
+			orderPosition = &pos
+			continue
+		}
+		switch {
+		case line == "":
+			// Hand-written files may leave a blank line before the SQL
+		case line == sqlFileAutoRefreshOff:
+			noAutoRefresh = true
+		case strings.HasPrefix(line, sqlFileTagsPrefix):
+			tags = normalizeTags(strings.TrimPrefix(line, sqlFileTagsPrefix))
+		case strings.HasPrefix(line, sqlFileCacheTTLPrefix):
+			cacheTTL, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, sqlFileCacheTTLPrefix)))
+		default:
+			break metadata
 		}
 	}
 
-	if len(sqlLines) == 0 {
+	// The file ends with the newline formatSQLFile adds after the SQL
+	sql := strings.TrimSuffix(strings.Join(lines[body:], "\n"), "\n")
+	if strings.TrimSpace(sql) == "" {
 		return Query{}, fmt.Errorf("no SQL content found")
 	}
 
-	sql := strings.Join(sqlLines, " ")
-
 	return Query{
-		Name:        title,
-		Description: description,
-		SQL:         sql,
+		Name:          title,
+		Description:   description,
+		SQL:           sql,
+		OrderPosition: orderPosition,
+		NoAutoRefresh: noAutoRefresh,
+		Tags:          tags,
+		CacheTTL:      max(cacheTTL, 0),
 	}, nil
 }
@@ -3,16 +3,16 @@ package main
 import (
 	"database/sql"
 	"path/filepath"
+	"strings"
 	"testing"
-
-	_ "modernc.org/sqlite"
+	"time"
 )
 
 func setupTestQueryDB(t *testing.T) (*QueryDB, string) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test_queries.db")
 
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open(sqliteDriver, dbPath)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
@@ -238,3 +238,464 @@ func TestGetQuery(t *testing.T) {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestDumpToFileRoundTrip(t *testing.T) {
+	qdb, tmpDir := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	queries := []Query{
+		{Name: "Visible", Description: "Shown in tabs", SQL: "SELECT 1", OrderPosition: intPtr(1), Tags: "replication, wal"},
+		{Name: "Hidden", Description: "Search only", SQL: "SELECT 2", NoAutoRefresh: true},
+	}
+	for _, q := range queries {
+		if err := qdb.SaveQuery(q); err != nil {
+			t.Fatalf("SaveQuery() error = %v", err)
+		}
+	}
+
+	dumpPath := filepath.Join(tmpDir, "dump.db")
+	count, err := qdb.DumpToFile(dumpPath)
+	if err != nil {
+		t.Fatalf("DumpToFile() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("DumpToFile() count = %d, want 2", count)
+	}
+
+	// Dumping again replaces the file rather than failing on existing rows
+	if _, err := qdb.DumpToFile(dumpPath); err != nil {
+		t.Fatalf("second DumpToFile() error = %v", err)
+	}
+
+	dumped, err := ReadDumpFile(dumpPath)
+	if err != nil {
+		t.Fatalf("ReadDumpFile() error = %v", err)
+	}
+	if len(dumped) != 2 {
+		t.Fatalf("ReadDumpFile() returned %d queries, want 2", len(dumped))
+	}
+	if dumped[0].Name != "Visible" || dumped[0].OrderPosition == nil || *dumped[0].OrderPosition != 1 || dumped[0].Tags != "replication, wal" {
+		t.Errorf("dumped[0] = %+v, want Visible at position 1 with its tags", dumped[0])
+	}
+	if dumped[1].Name != "Hidden" || dumped[1].OrderPosition != nil || !dumped[1].NoAutoRefresh {
+		t.Errorf("dumped[1] = %+v, want hidden, non-refreshing query Hidden", dumped[1])
+	}
+}
+
+func TestAutoRefreshMigration(t *testing.T) {
+	db, err := sql.Open(sqliteDriver, filepath.Join(t.TempDir(), "old.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A queries table from before auto_refresh existed
+	if _, err := db.Exec(`CREATE TABLE queries (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL, sql TEXT NOT NULL, order_position INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		INSERT INTO queries (name, description, sql, order_position) VALUES ('Old', '', 'SELECT 1', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	qdb := &QueryDB{db: db}
+	defer qdb.Close()
+	if err := qdb.initSchema(); err != nil {
+		t.Fatalf("initSchema() error = %v", err)
+	}
+
+	old, err := qdb.GetQuery("Old")
+	if err != nil || old.NoAutoRefresh {
+		t.Fatalf("GetQuery() = %+v, %v; want existing queries to keep refreshing", old, err)
+	}
+
+	if err := qdb.SaveQuery(Query{Name: "Settings", SQL: "SELECT 2", OrderPosition: intPtr(2), NoAutoRefresh: true}); err != nil {
+		t.Fatal(err)
+	}
+	queries, err := qdb.LoadQueries()
+	if err != nil || len(queries) != 2 || !queries[1].NoAutoRefresh {
+		t.Errorf("LoadQueries() = %+v, %v; want Settings with auto refresh off", queries, err)
+	}
+}
+
+func TestSaveQueryKeepsCreatedAt(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	if err := qdb.SaveQuery(Query{Name: "Locks", SQL: "SELECT 1", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+	// Backdate the row so the edit below is distinguishable at second resolution
+	if _, err := qdb.db.Exec(`UPDATE queries SET created_at = '2024-01-02 03:04:05', updated_at = '2024-01-02 03:04:05'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := qdb.SaveQuery(Query{Name: "Locks", SQL: "SELECT 2", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := qdb.GetQuery("Locks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !got.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v kept across saves", got.CreatedAt, want)
+	}
+	if !got.UpdatedAt.After(want) {
+		t.Errorf("UpdatedAt = %v, want it bumped past %v", got.UpdatedAt, want)
+	}
+
+	queries, err := qdb.LoadAllQueries()
+	if err != nil || len(queries) != 1 || !queries[0].UpdatedAt.Equal(got.UpdatedAt) {
+		t.Errorf("LoadAllQueries() = %+v, %v; want UpdatedAt %v", queries, err, got.UpdatedAt)
+	}
+}
+
+func TestDumpRemigratesIntoFreshDatabase(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	if err := qdb.SaveQuery(Query{Name: "Custom", Description: "mine", SQL: "SELECT 42", OrderPosition: intPtr(1), Tags: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dump written where the d key puts it is picked up when a fresh database starts in that directory
+	dir := t.TempDir()
+	if _, err := qdb.DumpToFile(filepath.Join(dir, "default_queries.db")); err != nil {
+		t.Fatalf("DumpToFile() error = %v", err)
+	}
+	fresh, err := NewQueryDB(dir)
+	if err != nil {
+		t.Fatalf("NewQueryDB() error = %v", err)
+	}
+	defer fresh.Close()
+
+	got, err := fresh.GetQuery("Custom")
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v; dump was not migrated", err)
+	}
+	if got.SQL != "SELECT 42" || got.Tags != "ops" {
+		t.Errorf("GetQuery() = %+v, want the dumped query", got)
+	}
+}
+
+func TestMigrateLegacyDir(t *testing.T) {
+	home := t.TempDir()
+	legacy, dir := filepath.Join(home, ".psqi"), filepath.Join(home, ".psq")
+
+	old, err := NewQueryDB(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := old.SaveQuery(Query{Name: "Legacy", SQL: "SELECT 1", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	if err := migrateLegacyDir(legacy, dir); err != nil {
+		t.Fatalf("migrateLegacyDir() error = %v", err)
+	}
+	qdb, err := NewQueryDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := qdb.GetQuery("Legacy"); err != nil {
+		t.Errorf("GetQuery(Legacy) error = %v; want it carried over from %s", err, legacy)
+	}
+	if err := qdb.SaveQuery(Query{Name: "New", SQL: "SELECT 2"}); err != nil {
+		t.Fatal(err)
+	}
+	qdb.Close()
+
+	// Once the new directory has a database, the legacy one is never copied over it again
+	if err := migrateLegacyDir(legacy, dir); err != nil {
+		t.Fatal(err)
+	}
+	qdb, err = NewQueryDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qdb.Close()
+	if _, err := qdb.GetQuery("New"); err != nil {
+		t.Errorf("GetQuery(New) error = %v; second migration overwrote the database", err)
+	}
+}
+
+func TestNewQueryDBPragmas(t *testing.T) {
+	qdb, err := NewQueryDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qdb.Close()
+
+	// Several connections, since each one applies the pragmas itself
+	qdb.db.SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		var journal string
+		var busy, fk int
+		if err := qdb.db.QueryRow("PRAGMA journal_mode").Scan(&journal); err != nil || journal != "wal" {
+			t.Errorf("journal_mode = %q, %v; want wal", journal, err)
+		}
+		if err := qdb.db.QueryRow("PRAGMA busy_timeout").Scan(&busy); err != nil || busy != 5000 {
+			t.Errorf("busy_timeout = %d, %v; want 5000", busy, err)
+		}
+		if err := qdb.db.QueryRow("PRAGMA foreign_keys").Scan(&fk); err != nil || fk != 1 {
+			t.Errorf("foreign_keys = %d, %v; want 1", fk, err)
+		}
+	}
+}
+
+func TestLoadFromDumpFileIsAtomic(t *testing.T) {
+	src, dir := setupTestQueryDB(t)
+	defer src.Close()
+	for i, name := range []string{"First", "Bad", "Last"} {
+		if err := src.SaveQuery(Query{Name: name, SQL: "SELECT 1", OrderPosition: intPtr(i + 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dumpPath := filepath.Join(dir, "dump.db")
+	if _, err := src.DumpToFile(dumpPath); err != nil {
+		t.Fatal(err)
+	}
+
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	if err := qdb.SaveQuery(Query{Name: "Existing", SQL: "SELECT 0", OrderPosition: intPtr(1)}); err != nil {
+		t.Fatal(err)
+	}
+	// Reject one query partway through the import
+	if _, err := qdb.db.Exec(`CREATE TRIGGER reject_bad BEFORE INSERT ON queries WHEN NEW.name = 'Bad'
+		BEGIN SELECT RAISE(ABORT, 'rejected'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := qdb.LoadFromDumpFile(dumpPath); err == nil {
+		t.Fatal("LoadFromDumpFile() error = nil, want the rejected query to fail the import")
+	}
+	queries, err := qdb.LoadAllQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || queries[0].Name != "Existing" {
+		t.Errorf("LoadAllQueries() = %+v, want only Existing after a failed import", queries)
+	}
+}
+
+func TestArchiveRestoreAndPurge(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+	for _, q := range []Query{
+		{Name: "Locks", SQL: "SELECT 1", OrderPosition: intPtr(1)},
+		{Name: "Bloat", SQL: "SELECT 2", OrderPosition: intPtr(2)},
+	} {
+		if err := qdb.SaveQuery(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(queries []Query, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, q := range queries {
+			got = append(got, q.Name)
+		}
+		return got
+	}
+
+	// Archive hides the query everywhere except the archived list
+	if err := qdb.ArchiveQuery("Locks"); err != nil {
+		t.Fatalf("ArchiveQuery() error = %v", err)
+	}
+	if got := names(qdb.LoadQueries()); len(got) != 1 || got[0] != "Bloat" {
+		t.Errorf("LoadQueries() = %v, want [Bloat]", got)
+	}
+	if got := names(qdb.LoadAllQueries()); len(got) != 1 || got[0] != "Bloat" {
+		t.Errorf("LoadAllQueries() = %v, want [Bloat]", got)
+	}
+	if _, err := qdb.GetQuery("Locks"); err == nil {
+		t.Errorf("GetQuery(Locks) found an archived query")
+	}
+	if got := names(qdb.LoadArchivedQueries()); len(got) != 1 || got[0] != "Locks" {
+		t.Errorf("LoadArchivedQueries() = %v, want [Locks]", got)
+	}
+
+	// Restore brings it back at its old tab position
+	if err := qdb.RestoreQuery("Locks"); err != nil {
+		t.Fatalf("RestoreQuery() error = %v", err)
+	}
+	restored, err := qdb.GetQuery("Locks")
+	if err != nil || restored.OrderPosition == nil || *restored.OrderPosition != 1 {
+		t.Errorf("GetQuery(Locks) = %+v, %v; want it restored at position 1", restored, err)
+	}
+	if got := names(qdb.LoadArchivedQueries()); len(got) != 0 {
+		t.Errorf("LoadArchivedQueries() = %v after restore, want none", got)
+	}
+
+	// Purging an archived query removes it for good
+	if err := qdb.ArchiveQuery("Locks"); err != nil {
+		t.Fatal(err)
+	}
+	if err := qdb.DeleteQuery("Locks"); err != nil {
+		t.Fatalf("DeleteQuery() error = %v", err)
+	}
+	if got := names(qdb.LoadArchivedQueries()); len(got) != 0 {
+		t.Errorf("LoadArchivedQueries() = %v after purge, want none", got)
+	}
+	if err := qdb.RestoreQuery("Locks"); err == nil {
+		t.Errorf("RestoreQuery() of a purged query succeeded")
+	}
+
+	// Saving under an archived query's name is refused instead of overwriting it
+	if err := qdb.ArchiveQuery("Bloat"); err != nil {
+		t.Fatal(err)
+	}
+	err = qdb.SaveQuery(Query{Name: "Bloat", SQL: "SELECT 3", OrderPosition: intPtr(2)})
+	if err == nil || !strings.Contains(err.Error(), "archived") {
+		t.Fatalf("SaveQuery() over an archived name error = %v, want a name conflict", err)
+	}
+	archived, err := qdb.LoadArchivedQueries()
+	if err != nil || len(archived) != 1 || archived[0].SQL != "SELECT 2" {
+		t.Errorf("LoadArchivedQueries() = %+v, %v; want Bloat archived unchanged", archived, err)
+	}
+}
+
+func TestCreateDefaultQueries(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	if err := qdb.createDefaultQueries(); err != nil {
+		t.Fatal(err)
+	}
+	queries, err := qdb.LoadQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := map[int]string{}
+	for _, q := range queries {
+		if q.OrderPosition == nil {
+			t.Errorf("default query %q has no tab position", q.Name)
+			continue
+		}
+		if other, ok := positions[*q.OrderPosition]; ok {
+			t.Errorf("default queries %q and %q share position %d", other, q.Name, *q.OrderPosition)
+		}
+		positions[*q.OrderPosition] = q.Name
+	}
+	if q, err := qdb.GetQuery("Vacuum Progress"); err != nil || !strings.Contains(q.SQL, "pg_stat_progress_vacuum") {
+		t.Errorf("GetQuery(Vacuum Progress) = %+v, %v; want the vacuum progress default", q, err)
+	}
+	if q, err := qdb.GetQuery("Index Usage"); err != nil || !strings.Contains(q.SQL, "pg_stat_user_indexes") || !strings.Contains(q.SQL, "AS health") {
+		t.Errorf("GetQuery(Index Usage) = %+v, %v; want the index usage default with a health column", q, err)
+	}
+}
+
+func TestAddNewDefaults(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	// An install from before the new defaults
+	for _, q := range []Query{
+		{Name: "Lock Information", SQL: "SELECT 1", OrderPosition: intPtr(1)},
+		{Name: "Mine", SQL: "SELECT 2", OrderPosition: intPtr(2)},
+	} {
+		if err := qdb.SaveQuery(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := qdb.addNewDefaults(); err != nil {
+		t.Fatalf("addNewDefaults() error = %v", err)
+	}
+	queries, err := qdb.LoadQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added []string
+	for _, q := range queries[2:] {
+		added = append(added, q.Name)
+	}
+	if strings.Join(added, ",") != strings.Join(newDefaultQueries, ",") {
+		t.Errorf("tabs after the existing ones = %v, want %v", added, newDefaultQueries)
+	}
+
+	// A default the user deletes stays deleted
+	for _, name := range added {
+		if err := qdb.DeleteQuery(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := qdb.addNewDefaults(); err != nil {
+		t.Fatalf("second addNewDefaults() error = %v", err)
+	}
+	if queries, err := qdb.LoadQueries(); err != nil || len(queries) != 2 {
+		t.Errorf("LoadQueries() after a second run = %+v, %v; want the two existing tabs only", queries, err)
+	}
+}
+
+func TestAddNewDefaultsSkipsTakenNames(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	// The user already has a query by a default's name, live or archived
+	name := newDefaultQueries[0]
+	for _, archive := range []bool{false, true} {
+		if err := qdb.SaveQuery(Query{Name: name, SQL: "SELECT 3", OrderPosition: intPtr(1)}); err != nil {
+			t.Fatal(err)
+		}
+		if archive {
+			if err := qdb.ArchiveQuery(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := qdb.db.Exec("DELETE FROM added_defaults"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := qdb.addNewDefaults(); err != nil {
+			t.Fatalf("addNewDefaults() error = %v", err)
+		}
+		var sqls []string
+		rows, err := qdb.db.Query("SELECT sql FROM queries WHERE name = ?", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var s string
+			if err := rows.Scan(&s); err != nil {
+				t.Fatal(err)
+			}
+			sqls = append(sqls, s)
+		}
+		rows.Close()
+		if len(sqls) != 1 || sqls[0] != "SELECT 3" {
+			t.Errorf("archived=%v: %s SQL = %v, want the user's query left alone", archive, name, sqls)
+		}
+		if err := qdb.DeleteQuery(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAddNewDefaultsOnFreshInstall(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	defer qdb.Close()
+
+	if err := qdb.createDefaultQueries(); err != nil {
+		t.Fatal(err)
+	}
+	before, err := qdb.LoadQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := qdb.addNewDefaults(); err != nil {
+		t.Fatalf("addNewDefaults() error = %v", err)
+	}
+	after, err := qdb.LoadQueries()
+	if err != nil || len(after) != len(before) {
+		t.Errorf("LoadQueries() = %d queries, %v; want the %d defaults unchanged", len(after), err, len(before))
+	}
+	for _, name := range newDefaultQueries {
+		if _, err := qdb.GetQuery(name); err != nil {
+			t.Errorf("%s is in newDefaultQueries but not a default query", name)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queryLogFlag is set by --log and takes precedence over $PSQ_LOG_FILE
+var queryLogFlag string
+
+// queryLog records every statement psq runs; nil when logging is off
+var queryLog *QueryLog
+
+// QueryLog appends executed statements to a file for later review. Each entry is an SQL
+// comment with the time, service and elapsed time, followed by the statement, so the log
+// reads as a script. Only statements are written, never their results.
+type QueryLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// queryLogPath returns the log file to use: --log, then $PSQ_LOG_FILE, or "" for none
+func queryLogPath() string {
+	if queryLogFlag != "" {
+		return queryLogFlag
+	}
+	return os.Getenv("PSQ_LOG_FILE")
+}
+
+// openQueryLog opens path for appending, creating it readable only by the user
+func openQueryLog(path string) (*QueryLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query log: %w", err)
+	}
+	return &QueryLog{file: f}, nil
+}
+
+// initQueryLog starts logging when --log or $PSQ_LOG_FILE names a file
+func initQueryLog() error {
+	path := queryLogPath()
+	if path == "" {
+		return nil
+	}
+	l, err := openQueryLog(path)
+	if err != nil {
+		return err
+	}
+	queryLog = l
+	return nil
+}
+
+// Write appends one statement and syncs the file, so the entry survives a crash right after
+func (l *QueryLog) Write(at time.Time, service, statement string, elapsed time.Duration, err error) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s service=%s elapsed=%s", at.Format(time.RFC3339), service, elapsed.Round(time.Millisecond))
+	if err != nil {
+		fmt.Fprintf(&b, " error=%q", err.Error())
+	}
+	b.WriteString("\n")
+	statement = strings.TrimSpace(statement)
+	b.WriteString(statement)
+	if !strings.HasSuffix(statement, ";") {
+		b.WriteString(";")
+	}
+	b.WriteString("\n\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.WriteString(b.String()); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Close closes the log file
+func (l *QueryLog) Close() error {
+	return l.file.Close()
+}
+
+// logStatement records a statement that started at start against service, if logging is on.
+// A failing log never stops the statement's result from being shown.
+func logStatement(service, statement string, start time.Time, err error) {
+	if queryLog == nil {
+		return
+	}
+	_ = queryLog.Write(start, service, statement, time.Since(start), err)
+}
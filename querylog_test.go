@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "psq.log")
+	at := time.Date(2026, 3, 4, 12, 30, 0, 0, time.UTC)
+
+	l, err := openQueryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Write(at, "prod", "SELECT 1", 12*time.Millisecond, nil); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	// Reopening appends rather than truncating
+	l, err = openQueryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Write(at, "prod", "SELECT pg_terminate_backend(42);\n", 3*time.Millisecond, errors.New("permission denied")); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-- 2026-03-04T12:30:00Z service=prod elapsed=12ms\nSELECT 1;\n\n" +
+		"-- 2026-03-04T12:30:00Z service=prod elapsed=3ms error=\"permission denied\"\nSELECT pg_terminate_backend(42);\n\n"
+	if string(data) != want {
+		t.Errorf("log =\n%s\nwant\n%s", data, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("log permissions = %o, want 600", perm)
+	}
+}
+
+func TestQueryLogPath(t *testing.T) {
+	setEnv(t, map[string]string{"PSQ_LOG_FILE": "/tmp/from-env.log"})
+	if got := queryLogPath(); got != "/tmp/from-env.log" {
+		t.Errorf("queryLogPath() = %q, want the env var", got)
+	}
+
+	queryLogFlag = "/tmp/from-flag.log"
+	defer func() { queryLogFlag = "" }()
+	if got := queryLogPath(); got != "/tmp/from-flag.log" {
+		t.Errorf("queryLogPath() = %q, want --log to win", got)
+	}
+}
+
+func TestLogStatementDisabled(t *testing.T) {
+	if queryLog != nil {
+		t.Fatal("query log should be off in tests")
+	}
+	// With logging off there's nothing to write to, and nothing should panic
+	logStatement("prod", "SELECT 1", time.Now(), nil)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lib/pq"
+)
+
+const (
+	maxReconnectAttempts = 8
+	maxReconnectDelay    = 30 * time.Second
+)
+
+// connectionLostMsg is sent when a query fails because the server is unreachable
+type connectionLostMsg struct {
+	err error
+}
+
+// reconnectMsg fires when the backoff delay before the next reconnect attempt has elapsed
+type reconnectMsg struct{}
+
+// reconnectResultMsg carries the outcome of a reconnect attempt
+type reconnectResultMsg struct {
+	db  *sql.DB
+	err error
+}
+
+// isConnectionError checks if err means the connection is gone rather than the query being bad
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection_exception; 57P01-57P03 are admin/crash shutdown and cannot_connect_now
+		return pqErr.Code.Class() == "08" || strings.HasPrefix(string(pqErr.Code), "57P0")
+	}
+	return false
+}
+
+// reconnectDelay returns the exponential backoff before the given (1-based) reconnect attempt
+func reconnectDelay(attempt int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempt && delay < maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxReconnectDelay {
+		delay = maxReconnectDelay
+	}
+	return delay
+}
+
+// handleConnectionLost starts reconnecting when a query finds the server unreachable
+func (m *Model) handleConnectionLost(msg connectionLostMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if m.reconnecting || m.connecting {
+		// A manual refresh failed while a reconnect or startup retry is already scheduled
+		m.updateContent()
+		return m, nil
+	}
+	return m.scheduleReconnect(msg.err)
+}
+
+// scheduleReconnect backs off before the next reconnect attempt, giving up after maxReconnectAttempts
+func (m *Model) scheduleReconnect(err error) (tea.Model, tea.Cmd) {
+	if m.reconnectTries >= maxReconnectAttempts {
+		m.reconnecting = false
+		m.reconnectTries = 0
+		m.err = fmt.Sprintf("Connection lost: %v (press r to retry)", err)
+		m.updateContent()
+		return m, nil
+	}
+
+	m.reconnecting = true
+	m.reconnectTries++
+	m.updateContent()
+	return m, tea.Tick(reconnectDelay(m.reconnectTries), func(time.Time) tea.Msg {
+		return reconnectMsg{}
+	})
+}
+
+// attemptReconnect opens a fresh connection to the current service
+func (m *Model) attemptReconnect() tea.Cmd {
+	service := m.service
+	return func() tea.Msg {
+		db, err := connectDB(service)
+		return reconnectResultMsg{db: db, err: err}
+	}
+}
+
+// handleReconnectResult swaps in the new connection and resumes the refresh loop, or backs off again
+func (m *Model) handleReconnectResult(msg reconnectResultMsg) (tea.Model, tea.Cmd) {
+	if !m.reconnecting {
+		// The user quit or gave up in the meantime
+		if msg.db != nil {
+			msg.db.Close()
+		}
+		return m, nil
+	}
+	if msg.err != nil {
+		return m.scheduleReconnect(msg.err)
+	}
+
+	if m.db != nil {
+		m.db.Close()
+	}
+	m.db = msg.db
+	m.reconnecting = false
+	m.reconnectTries = 0
+	m.err = ""
+	m.loading = true
+	m.updateContent()
+	return m, m.runQuery(m.lastQuery)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "bad conn", err: fmt.Errorf("query: %w", driver.ErrBadConn), want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, want: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}, want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, w := range want {
+		if got := reconnectDelay(i + 1); got != w {
+			t.Errorf("reconnectDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRunQueryLeavesReconnectingToUpdate(t *testing.T) {
+	qdb, _ := setupTestQueryDB(t)
+	qdb.Close() // a pool whose server has gone away
+
+	m := &Model{service: "nowhere", db: qdb.db}
+	done, ok := m.runQuery(Query{Name: "Test", SQL: "SELECT 1"})().(queryDoneMsg)
+	if !ok {
+		t.Fatal("runQuery() didn't report a queryDoneMsg")
+	}
+	if _, ok := done.msg.(connectionLostMsg); !ok {
+		t.Errorf("runQuery() on a dead pool = %#v, want connectionLostMsg", done.msg)
+	}
+	if m.db != qdb.db {
+		t.Error("runQuery() replaced m.db; only handleReconnectResult may")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxSchemaSummaryBytes caps the schema sent with AI prompts so large databases don't blow the token budget
+const maxSchemaSummaryBytes = 6000
+
+// schemaSummaryQuery lists public tables with their columns in definition order
+const schemaSummaryQuery = `SELECT table_name, string_agg(column_name, ', ' ORDER BY ordinal_position)
+FROM information_schema.columns
+WHERE table_schema = 'public'
+GROUP BY table_name
+ORDER BY table_name`
+
+// schemaCache holds schema summaries per service so each session only fetches them once
+var schemaCache = struct {
+	sync.Mutex
+	summaries map[string]string
+}{summaries: make(map[string]string)}
+
+// aiSchemaEnabled reports whether the schema should be sent with AI prompts.
+// Set $PSQ_AI_SCHEMA=off to save tokens.
+func aiSchemaEnabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("PSQ_AI_SCHEMA"))) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
+
+// schemaSummaryForService returns the cached schema summary for service, fetching it on first use
+func schemaSummaryForService(db *sql.DB, service string) (string, error) {
+	schemaCache.Lock()
+	summary, ok := schemaCache.summaries[service]
+	schemaCache.Unlock()
+	if ok {
+		return summary, nil
+	}
+
+	summary, err := fetchSchemaSummary(db)
+	if err != nil {
+		return "", err
+	}
+
+	schemaCache.Lock()
+	schemaCache.summaries[service] = summary
+	schemaCache.Unlock()
+	return summary, nil
+}
+
+// fetchSchemaSummary builds a compact "table(col, col)" listing of the public schema
+func fetchSchemaSummary(db *sql.DB) (string, error) {
+	rows, err := db.Query(schemaSummaryQuery)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var table, columns string
+		if err := rows.Scan(&table, &columns); err != nil {
+			return "", fmt.Errorf("failed to read schema: %w", err)
+		}
+		lines = append(lines, fmt.Sprintf("%s(%s)", table, columns))
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	return formatSchemaSummary(lines, maxSchemaSummaryBytes), nil
+}
+
+// formatSchemaSummary joins table lines, stopping before limit bytes and noting how many tables were left out
+func formatSchemaSummary(lines []string, limit int) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if b.Len()+len(line)+1 > limit {
+			fmt.Fprintf(&b, "... %d more tables omitted\n", len(lines)-i)
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatSchemaSummary(t *testing.T) {
+	lines := []string{"accounts(id, email)", "orders(id, account_id, total)", "payments(id, order_id)"}
+
+	if got := formatSchemaSummary(lines, 1000); got != strings.Join(lines, "\n") {
+		t.Errorf("formatSchemaSummary() = %q, want all tables", got)
+	}
+
+	got := formatSchemaSummary(lines, 40)
+	want := "accounts(id, email)\n... 2 more tables omitted"
+	if got != want {
+		t.Errorf("formatSchemaSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSQLMessagesSchema(t *testing.T) {
+	conversation := []chatMessage{{Role: "user", Content: "biggest orders"}}
+	messages := buildSQLMessages(conversation, "orders(id, total)")
+	if !strings.Contains(messages[0].Content, "orders(id, total)") {
+		t.Errorf("system message = %q, want it to include the schema", messages[0].Content)
+	}
+	if messages[1].Content != "biggest orders" {
+		t.Errorf("user message = %q, want the prompt unchanged", messages[1].Content)
+	}
+
+	if messages := buildSQLMessages(conversation, ""); messages[0].Content != chatgptSystemPrompt {
+		t.Errorf("system message = %q, want the base prompt without a schema", messages[0].Content)
+	}
+}
+
+func TestAISchemaEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "on": true, "off": false, "0": false, "FALSE": false} {
+		setEnv(t, map[string]string{"PSQ_AI_SCHEMA": value})
+		if got := aiSchemaEnabled(); got != want {
+			t.Errorf("aiSchemaEnabled() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
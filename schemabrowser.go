@@ -0,0 +1,503 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lib/pq"
+)
+
+// schemaLevel is how far into the schema browser the user has gone
+type schemaLevel int
+
+const (
+	schemaLevelSchemas schemaLevel = iota // every schema in the database
+	schemaLevelTables                     // the tables and views of one schema
+	schemaLevelTable                      // one table's columns and indexes
+)
+
+// SchemaBrowser holds the state for the T overlay, which walks schemas → tables → columns and
+// indexes of the current database. It only reads the catalog.
+type SchemaBrowser struct {
+	Level   schemaLevel
+	Schemas []schemaEntry
+	Tables  []schemaTable
+	Columns []schemaColumn
+	Indexes []schemaIndex
+	Schema  string // schema whose tables are listed
+	Table   string // table whose columns are shown
+	Loading bool
+	Err     string
+
+	selected     [schemaLevelTable]int // selected row on the schema and table lists, kept when going back
+	scrollOffset [schemaLevelTable]int
+}
+
+// schemaEntry is one schema and how many tables and views it holds
+type schemaEntry struct {
+	Name   string
+	Tables int
+}
+
+// schemaTable is one relation in a schema
+type schemaTable struct {
+	Name string
+	Kind string // table, partitioned, view, matview or foreign
+	Rows int64  // planner estimate; -1 when the table was never analyzed
+	Size string
+}
+
+// schemaColumn is one column of a table
+type schemaColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default string
+}
+
+// schemaIndex is one index on a table
+type schemaIndex struct {
+	Name       string
+	Definition string
+}
+
+// schemaLoadedMsg carries the rows for a level of the browser, or why they couldn't be read
+type schemaLoadedMsg struct {
+	browser *SchemaBrowser
+	Level   schemaLevel
+	Schema  string // schema the tables or table belong to
+	Table   string // table the columns and indexes belong to
+	Schemas []schemaEntry
+	Tables  []schemaTable
+	Columns []schemaColumn
+	Indexes []schemaIndex
+	Err     error
+}
+
+const schemasSQL = `SELECT n.nspname,
+  count(c.oid) FILTER (WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND NOT c.relispartition)
+FROM pg_namespace n
+LEFT JOIN pg_class c ON c.relnamespace = n.oid
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname NOT LIKE 'pg\_toast%'
+  AND n.nspname NOT LIKE 'pg\_temp\_%'
+GROUP BY n.nspname
+ORDER BY n.nspname`
+
+const schemaTablesSQL = `SELECT c.relname,
+  CASE c.relkind WHEN 'r' THEN 'table' WHEN 'p' THEN 'partitioned' WHEN 'v' THEN 'view'
+    WHEN 'm' THEN 'matview' ELSE 'foreign' END,
+  c.reltuples::bigint,
+  pg_size_pretty(pg_total_relation_size(c.oid))
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1
+  AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+  AND NOT c.relispartition
+ORDER BY c.relname`
+
+const schemaColumnsSQL = `SELECT a.attname,
+  format_type(a.atttypid, a.atttypmod),
+  a.attnotnull,
+  coalesce(pg_get_expr(d.adbin, d.adrelid), '')
+FROM pg_attribute a
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = $1::regclass
+  AND a.attnum > 0
+  AND NOT a.attisdropped
+ORDER BY a.attnum`
+
+const schemaIndexesSQL = `SELECT c.relname, pg_get_indexdef(i.indexrelid)
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+WHERE i.indrelid = $1::regclass
+ORDER BY i.indisprimary DESC, c.relname`
+
+// qualifiedName quotes schema and table for use in SQL
+func qualifiedName(schema, table string) string {
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(table)
+}
+
+// loadSchemas lists the database's schemas
+func loadSchemas(db *sql.DB, sb *SchemaBrowser) tea.Cmd {
+	return func() tea.Msg {
+		msg := schemaLoadedMsg{browser: sb, Level: schemaLevelSchemas}
+		rows, err := db.Query(schemasSQL)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var s schemaEntry
+			if err := rows.Scan(&s.Name, &s.Tables); err != nil {
+				msg.Err = err
+				return msg
+			}
+			msg.Schemas = append(msg.Schemas, s)
+		}
+		msg.Err = rows.Err()
+		return msg
+	}
+}
+
+// loadSchemaTables lists the tables, views and foreign tables in schema. Partitions are left
+// out; their parent stands for them.
+func loadSchemaTables(db *sql.DB, sb *SchemaBrowser, schema string) tea.Cmd {
+	return func() tea.Msg {
+		msg := schemaLoadedMsg{browser: sb, Level: schemaLevelTables, Schema: schema}
+		rows, err := db.Query(schemaTablesSQL, schema)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var t schemaTable
+			if err := rows.Scan(&t.Name, &t.Kind, &t.Rows, &t.Size); err != nil {
+				msg.Err = err
+				return msg
+			}
+			msg.Tables = append(msg.Tables, t)
+		}
+		msg.Err = rows.Err()
+		return msg
+	}
+}
+
+// loadSchemaTable reads the columns and indexes of schema.table
+func loadSchemaTable(db *sql.DB, sb *SchemaBrowser, schema, table string) tea.Cmd {
+	return func() tea.Msg {
+		msg := schemaLoadedMsg{browser: sb, Level: schemaLevelTable, Schema: schema, Table: table}
+		name := qualifiedName(schema, table)
+		rows, err := db.Query(schemaColumnsSQL, name)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var c schemaColumn
+			if err := rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default); err != nil {
+				msg.Err = err
+				return msg
+			}
+			msg.Columns = append(msg.Columns, c)
+		}
+		if msg.Err = rows.Err(); msg.Err != nil {
+			return msg
+		}
+
+		indexes, err := db.Query(schemaIndexesSQL, name)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		defer indexes.Close()
+		for indexes.Next() {
+			var idx schemaIndex
+			if err := indexes.Scan(&idx.Name, &idx.Definition); err != nil {
+				msg.Err = err
+				return msg
+			}
+			msg.Indexes = append(msg.Indexes, idx)
+		}
+		msg.Err = indexes.Err()
+		return msg
+	}
+}
+
+// listLen is the number of selectable rows on the current level; the table level has none
+func (sb *SchemaBrowser) listLen() int {
+	switch sb.Level {
+	case schemaLevelSchemas:
+		return len(sb.Schemas)
+	case schemaLevelTables:
+		return len(sb.Tables)
+	}
+	return 0
+}
+
+// move shifts the selection on a list level by delta, staying within the list
+func (sb *SchemaBrowser) move(delta int) {
+	n := sb.listLen()
+	if n == 0 {
+		return
+	}
+	sel := sb.selected[sb.Level] + delta
+	sel = max(min(sel, n-1), 0)
+	sb.selected[sb.Level] = sel
+}
+
+// selectedTable returns the table under the cursor, or the one being shown
+func (sb *SchemaBrowser) selectedTable() (string, bool) {
+	switch sb.Level {
+	case schemaLevelTables:
+		if sel := sb.selected[schemaLevelTables]; sel < len(sb.Tables) {
+			return sb.Tables[sel].Name, true
+		}
+	case schemaLevelTable:
+		return sb.Table, true
+	}
+	return "", false
+}
+
+// pageSize is how many list rows fit on screen below the title and above the hints
+func (sb *SchemaBrowser) pageSize(height int) int {
+	return max(height-10, 5)
+}
+
+// visibleRange returns the rows of an n-row list to show, scrolled so the selection is on screen
+func (sb *SchemaBrowser) visibleRange(n, height int) (int, int) {
+	ps := sb.pageSize(height)
+	sel, off := sb.selected[sb.Level], sb.scrollOffset[sb.Level]
+	if sel < off {
+		off = sel
+	}
+	if sel >= off+ps {
+		off = sel - ps + 1
+	}
+	sb.scrollOffset[sb.Level] = off
+	return off, min(off+ps, n)
+}
+
+// handleSchemaBrowser opens the schema browser on the current database
+func (m *Model) handleSchemaBrowser() (tea.Model, tea.Cmd) {
+	sb := &SchemaBrowser{Loading: true}
+	m.schemaBrowser = sb
+	m.viewport.GotoTop()
+	m.updateContent()
+	if m.db == nil {
+		sb.Loading = false
+		sb.Err = "Not connected"
+		m.updateContent()
+		return m, nil
+	}
+	return m, loadSchemas(m.db, sb)
+}
+
+// handleSchemaLoaded fills in the level that finished loading
+func (m *Model) handleSchemaLoaded(msg schemaLoadedMsg) (tea.Model, tea.Cmd) {
+	sb := m.schemaBrowser
+	// The browser was closed, or the user went back up and opened something else, meanwhile
+	if sb == nil || msg.browser != sb || msg.Level != sb.Level {
+		return m, nil
+	}
+	if (msg.Level >= schemaLevelTables && msg.Schema != sb.Schema) || (msg.Level == schemaLevelTable && msg.Table != sb.Table) {
+		return m, nil
+	}
+	sb.Loading = false
+	if msg.Err != nil {
+		sb.Err = scrubNewlines(msg.Err.Error())
+	}
+	switch msg.Level {
+	case schemaLevelSchemas:
+		sb.Schemas = msg.Schemas
+	case schemaLevelTables:
+		sb.Tables = msg.Tables
+	case schemaLevelTable:
+		sb.Columns, sb.Indexes = msg.Columns, msg.Indexes
+	}
+	m.updateContent()
+	return m, nil
+}
+
+// handleSchemaBrowserKeys moves through the lists: enter opens a schema or table, esc goes
+// back up and closes the browser from the schema list. s drafts a query on a table.
+func (m *Model) handleSchemaBrowserKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sb := m.schemaBrowser
+	var cmd tea.Cmd
+
+	switch {
+	case msg.Type == tea.KeyEscape || msg.String() == "ctrl+[" || msg.String() == "left" || msg.String() == "h" || msg.String() == "q":
+		if sb.Level == schemaLevelSchemas || msg.String() == "q" {
+			m.closeSchemaBrowser()
+			return m, nil
+		}
+		sb.Level--
+		sb.Loading, sb.Err = false, ""
+		m.viewport.GotoTop()
+	case sb.Level == schemaLevelTable && key.Matches(msg, keys.Up):
+		m.viewport.ScrollUp(1)
+		return m, nil
+	case sb.Level == schemaLevelTable && key.Matches(msg, keys.Down):
+		m.viewport.ScrollDown(1)
+		return m, nil
+	case sb.Level == schemaLevelTable && key.Matches(msg, keys.PageUp):
+		m.viewport.PageUp()
+		return m, nil
+	case sb.Level == schemaLevelTable && key.Matches(msg, keys.PageDown):
+		m.viewport.PageDown()
+		return m, nil
+	case key.Matches(msg, keys.Up):
+		sb.move(-1)
+	case key.Matches(msg, keys.Down):
+		sb.move(1)
+	case key.Matches(msg, keys.PageUp):
+		sb.move(-sb.pageSize(m.viewport.Height))
+	case key.Matches(msg, keys.PageDown):
+		sb.move(sb.pageSize(m.viewport.Height))
+	case msg.Type == tea.KeyEnter || msg.String() == "right" || msg.String() == "l":
+		if sb.Loading || sb.listLen() == 0 {
+			return m, nil
+		}
+		sel := sb.selected[sb.Level]
+		switch sb.Level {
+		case schemaLevelSchemas:
+			sb.Schema = sb.Schemas[sel].Name
+			sb.Tables = nil
+			sb.selected[schemaLevelTables], sb.scrollOffset[schemaLevelTables] = 0, 0
+			cmd = loadSchemaTables(m.db, sb, sb.Schema)
+		case schemaLevelTables:
+			sb.Table = sb.Tables[sel].Name
+			sb.Columns, sb.Indexes = nil, nil
+			cmd = loadSchemaTable(m.db, sb, sb.Schema, sb.Table)
+		}
+		sb.Level++
+		sb.Loading, sb.Err = true, ""
+		m.viewport.GotoTop()
+	case msg.String() == "s":
+		if table, ok := sb.selectedTable(); ok {
+			return m.draftTableQuery(sb.Schema, table)
+		}
+		return m, nil
+	default:
+		return m, nil
+	}
+	m.updateContent()
+	return m, cmd
+}
+
+// draftTableQuery closes the browser and opens the editor on a new query reading the first
+// rows of schema.table. Nothing runs until the query is saved.
+func (m *Model) draftTableQuery(schema, table string) (tea.Model, tea.Cmd) {
+	m.schemaBrowser = nil
+	m.previousSelected = m.selected
+	m.editMode = true
+	m.editQuery = Query{} // Saved as a new query
+	m.initEditor(Query{
+		Name: schema + "." + table,
+		SQL:  fmt.Sprintf("SELECT * FROM %s LIMIT 100", qualifiedName(schema, table)),
+	})
+	m.updateContent()
+	return m, nil
+}
+
+// closeSchemaBrowser goes back to the results
+func (m *Model) closeSchemaBrowser() {
+	m.schemaBrowser = nil
+	m.updateContent()
+}
+
+// RenderSchemaBrowser renders the current level: schemas, a schema's tables, or a table's
+// columns and indexes
+func RenderSchemaBrowser(sb *SchemaBrowser, height int) string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Primary)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Accent)
+
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	var b strings.Builder
+	switch sb.Level {
+	case schemaLevelSchemas:
+		b.WriteString(titleStyle.Render("Schemas"))
+	case schemaLevelTables:
+		b.WriteString(titleStyle.Render("Schema " + sb.Schema))
+	case schemaLevelTable:
+		b.WriteString(titleStyle.Render(sb.Schema + "." + sb.Table))
+	}
+	b.WriteString("\n\n")
+
+	if sb.Err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render("  Error: " + sb.Err))
+		b.WriteString("\n\n")
+	}
+	if sb.Loading {
+		b.WriteString(mutedStyle.Render("  Loading…"))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	// row renders one list line, marking the selected one
+	row := func(i int, text string) string {
+		if i == sb.selected[sb.Level] {
+			return selectedStyle.Render("> "+text) + "\n"
+		}
+		return "  " + text + "\n"
+	}
+
+	switch sb.Level {
+	case schemaLevelSchemas:
+		if len(sb.Schemas) == 0 && sb.Err == "" {
+			b.WriteString(mutedStyle.Render("  No schemas") + "\n")
+		}
+		nameW := 0
+		for _, s := range sb.Schemas {
+			nameW = max(nameW, len(s.Name))
+		}
+		start, end := sb.visibleRange(len(sb.Schemas), height)
+		for i := start; i < end; i++ {
+			s := sb.Schemas[i]
+			b.WriteString(row(i, fmt.Sprintf("%-*s  %s", nameW, s.Name, dimStyle.Render(fmt.Sprintf("%d tables", s.Tables)))))
+		}
+
+	case schemaLevelTables:
+		if len(sb.Tables) == 0 && sb.Err == "" {
+			b.WriteString(mutedStyle.Render("  No tables or views") + "\n")
+		}
+		nameW := 0
+		for _, t := range sb.Tables {
+			nameW = max(nameW, len(t.Name))
+		}
+		start, end := sb.visibleRange(len(sb.Tables), height)
+		for i := start; i < end; i++ {
+			t := sb.Tables[i]
+			rows := "?"
+			if t.Rows >= 0 {
+				rows = fmt.Sprintf("~%d", t.Rows)
+			}
+			detail := fmt.Sprintf("%-11s %12s rows  %s", t.Kind, rows, t.Size)
+			if t.Kind == "view" || t.Kind == "foreign" {
+				detail = t.Kind
+			}
+			b.WriteString(row(i, fmt.Sprintf("%-*s  %s", nameW, t.Name, dimStyle.Render(detail))))
+		}
+
+	case schemaLevelTable:
+		nameW, typeW := len("column"), len("type")
+		for _, c := range sb.Columns {
+			nameW, typeW = max(nameW, len(c.Name)), max(typeW, len(c.Type))
+		}
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %-*s  %-*s  %-8s  %s", nameW, "column", typeW, "type", "null", "default")))
+		b.WriteString("\n")
+		for _, c := range sb.Columns {
+			null := "null"
+			if c.NotNull {
+				null = "not null"
+			}
+			b.WriteString(fmt.Sprintf("  %-*s  %-*s  %-8s  %s\n", nameW, c.Name, typeW, c.Type, null, scrubNewlines(c.Default)))
+		}
+
+		b.WriteString("\n" + titleStyle.Render("Indexes") + "\n")
+		if len(sb.Indexes) == 0 {
+			b.WriteString(mutedStyle.Render("  None") + "\n")
+		}
+		for _, idx := range sb.Indexes {
+			b.WriteString("  " + selectedStyle.Render(idx.Name) + "  " + dimStyle.Render(idx.Definition) + "\n")
+		}
+	}
+	return b.String()
+}
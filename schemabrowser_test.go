@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestSchemaBrowserNavigation(t *testing.T) {
+	zone.NewGlobal()
+	m := &Model{
+		queries:  []Query{{Name: "One", SQL: "SELECT 1"}},
+		ready:    true,
+		width:    100,
+		height:   30,
+		viewport: viewport.New(100, 30),
+	}
+	press := func(k string) tea.Cmd {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEscape}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		}
+		_, cmd := m.handleKeyMsg(msg)
+		return cmd
+	}
+
+	m.handleSchemaBrowser()
+	sb := m.schemaBrowser
+	if sb == nil || sb.Err != "Not connected" {
+		t.Fatalf("browser without a connection = %+v", sb)
+	}
+
+	// Results arrive as they would from the catalog queries
+	sb.Err = ""
+	m.handleSchemaLoaded(schemaLoadedMsg{browser: sb, Level: schemaLevelSchemas, Schemas: []schemaEntry{{"audit", 1}, {"public", 2}}})
+	press("down")
+	if cmd := press("enter"); cmd == nil || sb.Level != schemaLevelTables || sb.Schema != "public" || !sb.Loading {
+		t.Fatalf("enter should load public's tables: %+v", sb)
+	}
+	// A stale schema list arriving now is ignored
+	m.handleSchemaLoaded(schemaLoadedMsg{browser: sb, Level: schemaLevelSchemas})
+	m.handleSchemaLoaded(schemaLoadedMsg{browser: sb, Level: schemaLevelTables, Schema: "public", Tables: []schemaTable{
+		{Name: "orders", Kind: "table", Rows: 1200, Size: "96 kB"},
+		{Name: "users", Kind: "table", Rows: -1, Size: "16 kB"},
+	}})
+	if len(sb.Schemas) != 2 || sb.Loading {
+		t.Fatalf("tables should be loaded and the schemas kept: %+v", sb)
+	}
+	out := RenderSchemaBrowser(sb, 30)
+	if !strings.Contains(out, "Schema public") || !strings.Contains(out, "~1200") || !strings.Contains(out, "users") {
+		t.Errorf("tables render:\n%s", out)
+	}
+
+	press("down")
+	press("enter")
+	m.handleSchemaLoaded(schemaLoadedMsg{browser: sb, Level: schemaLevelTable, Schema: "public", Table: "users",
+		Columns: []schemaColumn{{Name: "id", Type: "bigint", NotNull: true, Default: "nextval('users_id_seq'::regclass)"}, {Name: "email", Type: "text"}},
+		Indexes: []schemaIndex{{Name: "users_pkey", Definition: "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)"}},
+	})
+	out = RenderSchemaBrowser(sb, 30)
+	for _, want := range []string{"public.users", "bigint", "not null", "nextval", "users_pkey", "USING btree (id)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table render missing %q:\n%s", want, out)
+		}
+	}
+
+	// esc goes back up with the selection where it was
+	press("esc")
+	if sb.Level != schemaLevelTables || sb.selected[schemaLevelTables] != 1 {
+		t.Fatalf("esc should go back to the tables with users selected: %+v", sb)
+	}
+
+	press("s")
+	if m.schemaBrowser != nil || !m.editMode || m.editQuery.Name != "" {
+		t.Fatal("s should close the browser and open the editor on a new query")
+	}
+	if got := m.nameInput.Value(); got != "public.users" {
+		t.Errorf("name = %q, want public.users", got)
+	}
+	if got := m.sqlTextarea.Value(); got != `SELECT * FROM "public"."users" LIMIT 100` {
+		t.Errorf("sql = %q", got)
+	}
+
+	// A table list from a schema left behind doesn't land under the one opened since
+	sb = &SchemaBrowser{Level: schemaLevelTables, Schema: "billing", Loading: true}
+	m.schemaBrowser = sb
+	m.handleSchemaLoaded(schemaLoadedMsg{browser: sb, Level: schemaLevelTables, Schema: "audit", Tables: []schemaTable{{Name: "log"}}})
+	if sb.Tables != nil || !sb.Loading {
+		t.Errorf("audit's tables were shown under billing: %+v", sb.Tables)
+	}
+}
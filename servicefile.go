@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// serviceFileFlag is set by --service-file and takes precedence over $PGSERVICEFILE
+var serviceFileFlag string
+
+// serviceFiles returns the service files to read, in merge order: --service-file, then
+// $PGSERVICEFILE, then ~/.pg_service.conf. The first two may list several files separated
+// by colons, e.g. a shared host list followed by personal credentials.
+func serviceFiles() []string {
+	list := serviceFileFlag
+	if list == "" {
+		list = os.Getenv("PGSERVICEFILE")
+	}
+	if list == "" {
+		return []string{os.ExpandEnv("$HOME/.pg_service.conf")}
+	}
+	var files []string
+	for _, path := range filepath.SplitList(list) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(os.ExpandEnv("$HOME"), path[2:])
+		}
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return []string{os.ExpandEnv("$HOME/.pg_service.conf")}
+	}
+	return files
+}
+
+// serviceFilesLabel names the service files for messages, e.g. "~/.pg_service.conf"
+func serviceFilesLabel() string {
+	files := serviceFiles()
+	home := os.ExpandEnv("$HOME")
+	for i, f := range files {
+		if home != "" && strings.HasPrefix(f, home+string(filepath.Separator)) {
+			files[i] = "~" + f[len(home):]
+		}
+	}
+	return strings.Join(files, ", ")
+}
+
+// serviceStanzas is the merged contents of the service files: service names in the order
+// first seen, and each service's settings
+type serviceStanzas struct {
+	Names    []string
+	Settings map[string]map[string]string
+}
+
+// parseServiceFile adds the stanzas in data to s. Settings for a service already seen
+// override its earlier values key by key, so a later file can add a password to a
+// shared entry without repeating its host.
+func (s *serviceStanzas) parseServiceFile(data string) {
+	var current string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.Trim(line, "[]")
+			if _, ok := s.Settings[current]; !ok {
+				s.Names = append(s.Names, current)
+				s.Settings[current] = map[string]string{}
+			}
+			continue
+		}
+
+		if current == "" {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			s.Settings[current][strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+}
+
+// loadServiceStanzas reads and merges every service file. Files that don't exist are
+// skipped, so a personal override file is optional, but at least one must be readable.
+func loadServiceStanzas() (*serviceStanzas, error) {
+	s := &serviceStanzas{Settings: map[string]map[string]string{}}
+	read := 0
+	var missing error
+	for _, path := range serviceFiles() {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			if missing == nil {
+				missing = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		s.parseServiceFile(string(data))
+		read++
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("failed to read %s: %w", serviceFilesLabel(), missing)
+	}
+	return s, nil
+}
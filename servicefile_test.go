@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergedServiceFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	shared := filepath.Join(tmpDir, "shared.conf")
+	personal := filepath.Join(tmpDir, "personal.conf")
+	if err := os.WriteFile(shared, []byte(`# checked in
+[prod]
+host=prod.example.com
+port=5433
+dbname=app
+user=readonly
+
+[staging]
+host=staging.example.com
+dbname=app
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(personal, []byte(`[prod]
+user=alice
+password=secret
+
+[local]
+host=localhost
+dbname=dev
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{
+		"HOME":          tmpDir,
+		"PGSERVICEFILE": shared + string(os.PathListSeparator) + filepath.Join(tmpDir, "missing.conf") + string(os.PathListSeparator) + personal,
+		"PGUSER":        "",
+		"PGPASSWORD":    "",
+	})
+
+	services, err := listServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prod", "staging", "local"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("listServices() = %v, want %v (deduplicated, first-seen order)", services, want)
+	}
+
+	config, err := getDBConfig("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DBConfig{Host: "prod.example.com", Port: "5433", Database: "app", User: "alice", Password: "secret"}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("prod = %+v, want %+v (later file's user and password over the shared host)", config, want)
+	}
+
+	// --service-file wins over $PGSERVICEFILE
+	serviceFileFlag = personal
+	defer func() { serviceFileFlag = "" }()
+	if _, err := getDBConfig("staging"); err == nil || !strings.Contains(err.Error(), "personal.conf") {
+		t.Errorf("staging is only in the shared file, want a not-found error naming personal.conf, got %v", err)
+	}
+}
+
+func TestServiceFilesNoneReadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	setEnv(t, map[string]string{"HOME": tmpDir, "PGSERVICEFILE": filepath.Join(tmpDir, "nope.conf")})
+	if _, err := listServices(); err == nil {
+		t.Error("listServices() should fail when no service file exists")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxStartupAttempts bounds how long psq waits for a database that isn't accepting
+// connections at launch: with the reconnect backoff, about 15 seconds
+const maxStartupAttempts = 5
+
+// startupConnectMsg fires when the backoff before the next startup connection attempt has elapsed
+type startupConnectMsg struct{}
+
+// startupConnectResultMsg carries the outcome of a startup connection attempt
+type startupConnectResultMsg struct {
+	db       *sql.DB
+	timeouts *SessionTimeouts
+	err      error
+}
+
+// startConnecting records the failed first connection attempt; Init takes it from there
+func (m *Model) startConnecting(err error) {
+	m.connecting = true
+	m.reconnectTries = 1
+	m.results = ""
+	m.resultNote = fmt.Sprintf("Can't connect yet: %v", err)
+}
+
+// scheduleStartupConnect backs off before the next startup connection attempt
+func (m *Model) scheduleStartupConnect() tea.Cmd {
+	return tea.Tick(reconnectDelay(m.reconnectTries), func(time.Time) tea.Msg {
+		return startupConnectMsg{}
+	})
+}
+
+// attemptStartupConnect tries the service again in the background
+func (m *Model) attemptStartupConnect() tea.Cmd {
+	m.reconnectTries++
+	m.updateContent()
+	service := m.service
+	return func() tea.Msg {
+		db, err := connectDB(service)
+		if err != nil {
+			return startupConnectResultMsg{err: err}
+		}
+		msg := startupConnectResultMsg{db: db}
+		if timeouts, err := GetSessionTimeouts(db); err == nil {
+			msg.timeouts = timeouts
+		}
+		return msg
+	}
+}
+
+// handleStartupConnectResult runs the selected tab once connected, backs off again, or
+// gives up with the last error after maxStartupAttempts or on one retrying won't fix
+func (m *Model) handleStartupConnectResult(msg startupConnectResultMsg) (tea.Model, tea.Cmd) {
+	if !m.connecting {
+		if msg.db != nil {
+			msg.db.Close()
+		}
+		return m, nil
+	}
+	if msg.err != nil {
+		transient := isConnectionError(msg.err)
+		if !transient || m.reconnectTries >= maxStartupAttempts {
+			m.err = fmt.Sprintf("Failed to connect to database: %v (press r to retry)", msg.err)
+			if transient {
+				m.err = fmt.Sprintf("Failed to connect to database after %d attempts: %v (press r to retry)", maxStartupAttempts, msg.err)
+			}
+			m.connecting = false
+			m.reconnectTries = 0
+			m.resultNote = ""
+			m.updateContent()
+			return m, nil
+		}
+		m.resultNote = fmt.Sprintf("Can't connect yet: %v", msg.err)
+		m.updateContent()
+		return m, m.scheduleStartupConnect()
+	}
+
+	m.db = msg.db
+	m.timeouts = msg.timeouts
+	m.connecting = false
+	m.reconnectTries = 0
+	m.resultNote = ""
+	if !m.ready || len(m.queries) == 0 {
+		return m, nil
+	}
+	m.ensureValidSelection()
+	m.loading = true
+	m.lastQuery = m.queries[m.selected]
+	m.updateContent()
+	return m, m.runQuery(m.lastQuery)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestStartupConnectRetry(t *testing.T) {
+	m := &Model{service: "prod", queries: []Query{HomeQuery()}}
+	m.startConnecting(&pq.Error{Code: "57P03", Message: "the database system is starting up"})
+	if !m.connecting || m.reconnectTries != 1 || !m.busy() {
+		t.Fatalf("connecting %v, tries %d; want retrying after the first attempt", m.connecting, m.reconnectTries)
+	}
+
+	for attempt := 2; attempt <= maxStartupAttempts; attempt++ {
+		m.reconnectTries = attempt
+		_, cmd := m.handleStartupConnectResult(startupConnectResultMsg{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}})
+		if attempt < maxStartupAttempts && (cmd == nil || !m.connecting) {
+			t.Fatalf("attempt %d failed; another should be scheduled", attempt)
+		}
+	}
+	if m.connecting || !strings.Contains(m.err, "after 5 attempts") || !strings.Contains(m.err, "connection refused") {
+		t.Errorf("after the last attempt: connecting %v, err %q; want to give up with the last error", m.connecting, m.err)
+	}
+
+	// A bad password won't clear up, so there's no point trying it again
+	m = &Model{service: "prod", queries: []Query{HomeQuery()}}
+	m.startConnecting(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	_, cmd := m.handleStartupConnectResult(startupConnectResultMsg{err: &pq.Error{Code: "28P01", Message: "password authentication failed"}})
+	if cmd != nil || m.connecting || !strings.Contains(m.err, "password authentication failed") {
+		t.Errorf("connecting %v, err %q; want to stop at the bad password", m.connecting, m.err)
+	}
+}
+
+func TestStartupServiceNotFound(t *testing.T) {
+	dir := t.TempDir()
+	serviceFile := filepath.Join(dir, "pg_service.conf")
+	if err := os.WriteFile(serviceFile, []byte("[prod]\nhost=localhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{"HOME": dir, "PSQ_CONFIG_DIR": dir, "PGSERVICEFILE": serviceFile})
+	originalQueryDB := globalQueryDB
+	globalQueryDB = nil
+	defer func() {
+		if globalQueryDB != nil {
+			globalQueryDB.Close()
+		}
+		globalQueryDB = originalQueryDB
+	}()
+
+	m := NewModel("prdo")
+	if m.connecting || m.reconnectTries != 0 || !strings.Contains(m.err, "service 'prdo' not found") {
+		t.Errorf("connecting %v, tries %d, err %q; want the missing service reported straight away", m.connecting, m.reconnectTries, m.err)
+	}
+}
+
+func TestStartupConnectSuccess(t *testing.T) {
+	db, err := sql.Open("postgres", "host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m := &Model{service: "prod", queries: []Query{HomeQuery()}}
+	m.startConnecting(errors.New("connection refused"))
+	m.reconnectTries = 2
+	m.handleStartupConnectResult(startupConnectResultMsg{db: db})
+	if m.connecting || m.db != db || m.reconnectTries != 0 || m.resultNote != "" {
+		t.Errorf("connecting %v, tries %d, note %q; want connected", m.connecting, m.reconnectTries, m.resultNote)
+	}
+
+	// A late result after giving up is closed, not used
+	late, _ := sql.Open("postgres", "host=localhost")
+	m.db = nil
+	m.handleStartupConnectResult(startupConnectResultMsg{db: late})
+	if m.db != nil {
+		t.Error("a result arriving after connecting stopped shouldn't be used")
+	}
+}
+
+func TestNewModelShowsEveryStartupProblem(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, map[string]string{
+		"HOME":              dir,
+		"PSQ_CONFIG_DIR":    dir,
+		"PGSERVICEFILE":     filepath.Join(dir, "missing.conf"),
+		"PSQ_MIN_COL_WIDTH": "wide",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"density": "cozy"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	originalQueryDB := globalQueryDB
+	globalQueryDB = nil
+	defer func() {
+		if globalQueryDB != nil {
+			globalQueryDB.Close()
+		}
+		globalQueryDB = originalQueryDB
+	}()
+
+	m := NewModel("prod")
+	if !strings.Contains(m.err, "density") || !strings.Contains(m.err, "PSQ_MIN_COL_WIDTH") {
+		t.Errorf("err = %q, want both the config and the column width problem", m.err)
+	}
+}
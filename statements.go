@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lib/pq"
+)
+
+// statsResetMsg is sent after a pg_stat_statements_reset call
+type statsResetMsg struct {
+	err error
+}
+
+// usesPgStatStatements checks if a query reads from pg_stat_statements (e.g. the Top Queries tab)
+func usesPgStatStatements(query string) bool {
+	return strings.Contains(strings.ToLower(query), "pg_stat_statements")
+}
+
+// ResetPgStatStatements zeroes all pg_stat_statements counters
+func ResetPgStatStatements(db *sql.DB) error {
+	if _, err := db.Exec("SELECT pg_stat_statements_reset()"); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42501" { // insufficient_privilege
+			return fmt.Errorf("permission denied: resetting pg_stat_statements requires superuser or EXECUTE on pg_stat_statements_reset()")
+		}
+		return fmt.Errorf("pg_stat_statements_reset failed: %w", err)
+	}
+	return nil
+}
+
+// GetPgStatStatementsReset returns when pg_stat_statements was last reset.
+// pg_stat_statements_info only exists on PostgreSQL 14+, so ok is false when unavailable.
+func GetPgStatStatementsReset(db queryer) (string, bool) {
+	var statsReset sql.NullString
+	err := db.QueryRow("SELECT date_trunc('second', stats_reset)::text FROM pg_stat_statements_info").Scan(&statsReset)
+	if err != nil || !statsReset.Valid {
+		return "", false
+	}
+	return statsReset.String, true
+}
+
+// RenderStatStatementsFooter renders the last-reset time and reset key hint below pg_stat_statements results
+func RenderStatStatementsFooter(db queryer, readOnly bool) string {
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	var parts []string
+	if statsReset, ok := GetPgStatStatementsReset(db); ok {
+		parts = append(parts, "stats last reset: "+statsReset)
+	}
+	if !readOnly {
+		parts = append(parts, "R: reset stats")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n" + dimStyle.Render("  "+strings.Join(parts, "  •  "))
+}
+
+// RenderStatsResetConfirm renders the pg_stat_statements reset confirmation prompt
+func RenderStatsResetConfirm() string {
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Error)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	return warnStyle.Render("Reset pg_stat_statements? (y/n)") + "\n\n" +
+		dimStyle.Render("  All statement statistics on this server will be zeroed.")
+}
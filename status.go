@@ -0,0 +1,44 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusDuration is how long a status message stays up
+const statusDuration = 4 * time.Second
+
+// statusExpiredMsg clears the status message it was scheduled for, unless a newer one replaced it
+type statusExpiredMsg struct {
+	seq int
+}
+
+// setStatus shows a transient confirmation such as "Dumped 12 queries" and schedules
+// its removal. Failures belong in m.err instead.
+func (m *Model) setStatus(text string) tea.Cmd {
+	m.status = text
+	m.statusSeq++
+	seq := m.statusSeq
+	return tea.Tick(statusDuration, func(time.Time) tea.Msg {
+		return statusExpiredMsg{seq: seq}
+	})
+}
+
+// handleStatusExpired clears the status message once it has been shown long enough
+func (m *Model) handleStatusExpired(msg statusExpiredMsg) (tea.Model, tea.Cmd) {
+	if msg.seq == m.statusSeq && m.status != "" {
+		m.status = ""
+		m.updateContent()
+	}
+	return m, nil
+}
+
+// renderStatus renders the status message line, or nothing when there isn't one
+func (m *Model) renderStatus() string {
+	if m.status == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(theme.Success).Render("✓ "+m.status) + "\n\n"
+}
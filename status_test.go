@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+func TestStatusExpiry(t *testing.T) {
+	zone.NewGlobal()
+	m := &Model{ready: true, width: 80, viewport: viewport.New(80, 40)}
+
+	if cmd := m.setStatus("Dumped 3 queries"); cmd == nil {
+		t.Fatal("setStatus should schedule its expiry")
+	}
+	m.updateContent()
+	if view := m.View(); !strings.Contains(view, "Dumped 3 queries") || strings.Contains(view, "Error") {
+		t.Errorf("status should render outside the error line:\n%s", view)
+	}
+
+	// A newer status outlives the earlier one's expiry
+	m.setStatus("Pinned Locks")
+	m.handleStatusExpired(statusExpiredMsg{seq: 1})
+	if m.status != "Pinned Locks" {
+		t.Errorf("status = %q after a stale expiry, want Pinned Locks", m.status)
+	}
+
+	m.handleStatusExpired(statusExpiredMsg{seq: m.statusSeq})
+	if m.status != "" {
+		t.Errorf("status = %q after expiring, want it cleared", m.status)
+	}
+}
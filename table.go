@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMinColWidth = 6
+	defaultMaxColWidth = 50
+	// compactMinColWidth replaces defaultMinColWidth in compact tables; the ~ marker needs 2
+	compactMinColWidth = 2
+)
+
+// TableOptions controls how query results are rendered in the results table
+type TableOptions struct {
+	HumanBytes  bool // show byte-count columns (e.g. "size", "total_bytes") as KB/MB/GB
+	Wrap        bool // wrap long cells over several lines instead of truncating them
+	Compact     bool // size columns to their contents without padding, and drop the border around results
+	MinColWidth int  // 0 means defaultMinColWidth, or compactMinColWidth when Compact
+	MaxColWidth int  // 0 means defaultMaxColWidth; longer cells are truncated
+
+	HiddenColumns []string // column names left out of the table
+	MaxRows       int      // rows read before the rest of a result is left out; 0 reads them all
+
+	Diff     bool            // highlight rows and cells that changed since Previous
+	DiffKey  string          // column identifying a row when diffing; "" means the first column
+	Previous *resultSnapshot // the previous result of the same query, nil on its first run
+
+	changes *rowDiff // what changed since Previous, worked out by executeQuery
+}
+
+// columnWidthBounds returns the configured column width limits, falling back to the defaults
+func (o TableOptions) columnWidthBounds() (int, int) {
+	minWidth, maxWidth := o.MinColWidth, o.MaxColWidth
+	if minWidth <= 0 {
+		minWidth = defaultMinColWidth
+		if o.Compact {
+			minWidth = compactMinColWidth
+		}
+	}
+	if maxWidth <= 0 {
+		maxWidth = defaultMaxColWidth
+	}
+	return minWidth, maxWidth
+}
+
+// tableOptionsFromEnv reads $PSQ_MIN_COL_WIDTH and $PSQ_MAX_COL_WIDTH. Invalid values are
+// reported and replaced by the defaults so the table still renders.
+func tableOptionsFromEnv() (TableOptions, error) {
+	var opts TableOptions
+	var errs []string
+
+	parse := func(envVar string) int {
+		value := strings.TrimSpace(os.Getenv(envVar))
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(value)
+		// The ~ truncation marker needs at least 2 columns to leave room for a character
+		if err != nil || n < 2 {
+			errs = append(errs, fmt.Sprintf("%s must be a whole number of at least 2, got %q", envVar, value))
+			return 0
+		}
+		return n
+	}
+	opts.MinColWidth = parse("PSQ_MIN_COL_WIDTH")
+	opts.MaxColWidth = parse("PSQ_MAX_COL_WIDTH")
+
+	if minWidth, maxWidth := opts.columnWidthBounds(); minWidth > maxWidth {
+		errs = append(errs, fmt.Sprintf("PSQ_MIN_COL_WIDTH (%d) is larger than PSQ_MAX_COL_WIDTH (%d)", minWidth, maxWidth))
+		opts.MinColWidth, opts.MaxColWidth = 0, 0
+	}
+
+	if len(errs) > 0 {
+		return opts, fmt.Errorf("invalid column width settings: %s", strings.Join(errs, "; "))
+	}
+	return opts, nil
+}
+
+// hideColumns drops the named columns from columns and rows. If that would leave
+// nothing to show, everything is kept.
+func hideColumns(columns []string, rows [][]string, hidden []string) ([]string, [][]string) {
+	if len(hidden) == 0 {
+		return columns, rows
+	}
+	isHidden := map[string]bool{}
+	for _, name := range hidden {
+		isHidden[name] = true
+	}
+	var keep []int
+	for i, col := range columns {
+		if !isHidden[col] {
+			keep = append(keep, i)
+		}
+	}
+	if len(keep) == len(columns) || len(keep) == 0 {
+		return columns, rows
+	}
+
+	pick := func(row []string) []string {
+		out := make([]string, len(keep))
+		for j, i := range keep {
+			out[j] = row[i]
+		}
+		return out
+	}
+	visibleRows := make([][]string, len(rows))
+	for r, row := range rows {
+		visibleRows[r] = pick(row)
+	}
+	return pick(columns), visibleRows
+}
+
+// healthColumn names the column whose value colors its whole row: "warn" or "critical".
+// Default queries work it out from their own thresholds, and any query can return one.
+const healthColumn = "health"
+
+// rowHealth returns a row's health value in lower case, or "" when there's no health column
+func rowHealth(columns, row []string) string {
+	for i, col := range columns {
+		if strings.EqualFold(col, healthColumn) && i < len(row) {
+			return strings.ToLower(strings.TrimSpace(row[i]))
+		}
+	}
+	return ""
+}
+
+// isBytesColumn guesses from its name whether a column holds a byte count
+func isBytesColumn(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "size") || strings.Contains(name, "bytes")
+}
+
+// humanizeBytesColumns rewrites integer byte-count columns with formatBytes. Columns with any
+// non-integer value (e.g. already pg_size_pretty'd) are left alone. Rows are modified in place.
+func humanizeBytesColumns(columns []string, rows [][]string) {
+	for col, name := range columns {
+		if !isBytesColumn(name) {
+			continue
+		}
+
+		values := make([]int64, len(rows))
+		allInts := true
+		for i, row := range rows {
+			if row[col] == "NULL" {
+				continue
+			}
+			n, err := strconv.ParseInt(row[col], 10, 64)
+			if err != nil {
+				allInts = false
+				break
+			}
+			values[i] = n
+		}
+		if !allInts {
+			continue
+		}
+
+		for i, row := range rows {
+			if row[col] != "NULL" {
+				row[col] = formatBytes(int(values[i]))
+			}
+		}
+	}
+}
+
+// byteUnits are the suffixes formatBytes adds, so humanized sizes still count as numeric
+var byteUnits = []string{" B", " KB", " MB", " GB"}
+
+// isNumericValue checks if a cell holds a number, including formatBytes output like "1.5 MB"
+func isNumericValue(s string) bool {
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit) {
+			s = strings.TrimSuffix(s, unit)
+			break
+		}
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// numericColumns reports for each column whether every non-NULL value is numeric.
+// Columns with only NULLs stay left-aligned.
+func numericColumns(numColumns int, rows [][]string) []bool {
+	numeric := make([]bool, numColumns)
+	for col := range numeric {
+		sawValue := false
+		numeric[col] = true
+		for _, row := range rows {
+			if row[col] == "NULL" {
+				continue
+			}
+			sawValue = true
+			if !isNumericValue(row[col]) {
+				numeric[col] = false
+				break
+			}
+		}
+		numeric[col] = numeric[col] && sawValue
+	}
+	return numeric
+}
+
+// padCell pads s to width, right-aligning numeric cells. The trailing column of space
+// renderTable reserves stays on the right so right-aligned values don't touch the next column.
+func padCell(s string, width int, rightAlign bool) string {
+	if rightAlign && len(s) < width {
+		return fmt.Sprintf("%*s ", width-1, s)
+	}
+	return fmt.Sprintf("%-*s", width, s)
+}
+
+// wrapCell splits s into lines of at most width characters, breaking after spaces where possible
+func wrapCell(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var lines []string
+	for len(s) > width {
+		cut := strings.LastIndex(s[:width+1], " ")
+		if cut <= 0 {
+			// No space to break at; split mid-word
+			cut = width
+		}
+		lines = append(lines, strings.TrimRight(s[:cut], " "))
+		s = strings.TrimLeft(s[cut:], " ")
+	}
+	return append(lines, s)
+}
+
+// wrapRow renders one result row as several visual lines, wrapping each cell within its column
+func wrapRow(row []string, colWidths []int, numeric []bool) []string {
+	cells := make([][]string, len(row))
+	height := 1
+	for i, cell := range row {
+		// Keep the column's trailing space free so wrapped text doesn't run into the next column
+		cells[i] = wrapCell(cell, colWidths[i]-1)
+		if len(cells[i]) > height {
+			height = len(cells[i])
+		}
+	}
+
+	lines := make([]string, height)
+	for l := range lines {
+		parts := make([]string, len(row))
+		for i := range row {
+			var text string
+			if l < len(cells[i]) {
+				text = cells[i][l]
+			}
+			parts[i] = padCell(text, colWidths[i], numeric[i])
+		}
+		lines[l] = strings.Join(parts, " ")
+	}
+	return lines
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHumanizeBytesColumns(t *testing.T) {
+	columns := []string{"relname", "total_bytes", "table_size", "n_live_tup", "pretty_size"}
+	rows := [][]string{
+		{"orders", "2097152", "1536", "100", "2048 kB"},
+		{"users", "NULL", "512", "5", "8192 bytes"},
+	}
+
+	humanizeBytesColumns(columns, rows)
+
+	want := [][]string{
+		{"orders", "2.0 MB", "1.5 KB", "100", "2048 kB"},
+		{"users", "NULL", "512 B", "5", "8192 bytes"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("humanizeBytesColumns() = %v, want %v", rows, want)
+	}
+}
+
+func TestRenderTableAlignment(t *testing.T) {
+	columns := []string{"name", "calls", "mixed", "empty"}
+	rows := [][]string{
+		{"a", "5", "1", "NULL"},
+		{"bb", "12345", "x", "NULL"},
+		{"ccc", "NULL", "2", "NULL"},
+	}
+
+	if got := numericColumns(len(columns), rows); !reflect.DeepEqual(got, []bool{false, true, false, false}) {
+		t.Errorf("numericColumns() = %v, want only calls numeric", got)
+	}
+
+	lines := strings.Split(renderTable(columns, rows, TableOptions{}), "\n")
+	// calls is 6 wide (5 digits + spacing): values end at the same column
+	if !strings.Contains(lines[1], "a    ") || !strings.Contains(lines[1], "     5 ") {
+		t.Errorf("row 1 = %q, want text left-aligned and 5 right-aligned", lines[1])
+	}
+	if !strings.Contains(lines[2], " 12345 ") {
+		t.Errorf("row 2 = %q, want 12345 right-aligned", lines[2])
+	}
+	if strings.Index(lines[1], "5 ") != strings.Index(lines[2], "12345 ")+4 {
+		t.Errorf("numeric values are not right-aligned:\n%s\n%s", lines[1], lines[2])
+	}
+}
+
+func TestIsNumericValue(t *testing.T) {
+	for value, want := range map[string]bool{"42": true, "-3.5": true, "1e3": true, "1.5 MB": true, "512 B": true, "12 apples": false, "abc": false, "": false} {
+		if got := isNumericValue(value); got != want {
+			t.Errorf("isNumericValue(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestTableOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max string
+		wantMin  int
+		wantMax  int
+		wantErr  bool
+	}{
+		{name: "defaults", wantMin: defaultMinColWidth, wantMax: defaultMaxColWidth},
+		{name: "custom", min: "4", max: "120", wantMin: 4, wantMax: 120},
+		{name: "not a number", max: "wide", wantMin: defaultMinColWidth, wantMax: defaultMaxColWidth, wantErr: true},
+		{name: "too small", min: "1", wantMin: defaultMinColWidth, wantMax: defaultMaxColWidth, wantErr: true},
+		{name: "min above max", min: "30", max: "20", wantMin: defaultMinColWidth, wantMax: defaultMaxColWidth, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PSQ_MIN_COL_WIDTH", tt.min)
+			t.Setenv("PSQ_MAX_COL_WIDTH", tt.max)
+			opts, err := tableOptionsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tableOptionsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if minWidth, maxWidth := opts.columnWidthBounds(); minWidth != tt.wantMin || maxWidth != tt.wantMax {
+				t.Errorf("bounds = %d..%d, want %d..%d", minWidth, maxWidth, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRenderTableCompact(t *testing.T) {
+	columns := []string{"id", "state"}
+	rows := [][]string{{"a", "idle"}}
+
+	comfortable := strings.Split(renderTable(columns, rows, TableOptions{}), "\n")
+	if comfortable[0] != "id     state " {
+		t.Errorf("comfortable header = %q, want padded columns", comfortable[0])
+	}
+	compact := strings.Split(renderTable(columns, rows, TableOptions{Compact: true}), "\n")
+	if compact[0] != "id state" || compact[1] != "a  idle " {
+		t.Errorf("compact table = %q, want columns sized to their contents", compact[:2])
+	}
+}
+
+func TestRenderTableMaxColWidth(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	out := renderTable([]string{"query"}, [][]string{{long}}, TableOptions{MaxColWidth: 20})
+
+	lines := strings.Split(out, "\n")
+	want := strings.Repeat("x", 19) + "~"
+	if lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+}
+
+func TestWrapCell(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  []string
+	}{
+		{s: "short", width: 10, want: []string{"short"}},
+		{s: "SELECT id FROM users WHERE x", width: 10, want: []string{"SELECT id", "FROM users", "WHERE x"}},
+		{s: "abcdefghijkl", width: 5, want: []string{"abcde", "fghij", "kl"}},
+	}
+	for _, tt := range tests {
+		if got := wrapCell(tt.s, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrapCell(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTableWrap(t *testing.T) {
+	columns := []string{"calls", "query"}
+	rows := [][]string{{"7", "SELECT id FROM users WHERE x"}}
+
+	out := renderTable(columns, rows, TableOptions{MaxColWidth: 11, Wrap: true})
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{
+		"calls  query      ",
+		"    7  SELECT id  ",
+		"       FROM users ",
+		"       WHERE x    ",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("renderTable(wrap) =\n%q\nwant\n%q", lines, want)
+	}
+}
+
+func TestHideColumns(t *testing.T) {
+	columns := []string{"name", "setting", "unit", "category"}
+	rows := [][]string{{"work_mem", "4096", "kB", "Resource Usage"}}
+
+	gotCols, gotRows := hideColumns(columns, rows, []string{"unit", "category", "missing"})
+	if want := []string{"name", "setting"}; !reflect.DeepEqual(gotCols, want) {
+		t.Errorf("columns = %v, want %v", gotCols, want)
+	}
+	if want := [][]string{{"work_mem", "4096"}}; !reflect.DeepEqual(gotRows, want) {
+		t.Errorf("rows = %v, want %v", gotRows, want)
+	}
+	if rows[0][2] != "kB" {
+		t.Errorf("hideColumns modified the input rows: %v", rows)
+	}
+
+	// Hiding everything would leave an empty table, so nothing is hidden
+	if gotCols, _ := hideColumns(columns, rows, columns); !reflect.DeepEqual(gotCols, columns) {
+		t.Errorf("hiding every column = %v, want all columns kept", gotCols)
+	}
+}
+
+func TestRowHealth(t *testing.T) {
+	columns := []string{"name", "Health"}
+	if got := rowHealth(columns, []string{"standby1", " CRITICAL "}); got != "critical" {
+		t.Errorf("rowHealth() = %q, want critical", got)
+	}
+	if got := rowHealth([]string{"name"}, []string{"standby1"}); got != "" {
+		t.Errorf("rowHealth() without a health column = %q, want empty", got)
+	}
+}
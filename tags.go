@@ -0,0 +1,149 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchTagPrefix filters search results by tag, e.g. "tag:replication"
+const searchTagPrefix = "tag:"
+
+// untaggedGroup heads queries without tags in grouped search results
+const untaggedGroup = "untagged"
+
+// parseTags splits a comma-separated tag list into lowercase tags, dropping blanks and duplicates
+func parseTags(s string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// normalizeTags rewrites a tag list in the canonical "a, b" form
+func normalizeTags(s string) string {
+	return strings.Join(parseTags(s), ", ")
+}
+
+// queryGroup is the tag a query is listed under in grouped search results: its first tag
+func queryGroup(q Query) string {
+	if tags := parseTags(q.Tags); len(tags) > 0 {
+		return tags[0]
+	}
+	return untaggedGroup
+}
+
+// matchesSearch reports whether q matches every term of a search. "tag:x" terms match
+// tags starting with x; other terms match the name, description or tags, and the SQL
+// itself when inSQL is set.
+func matchesSearch(q Query, search string, inSQL bool) bool {
+	tags := parseTags(q.Tags)
+	text := strings.ToLower(q.Name + "\n" + q.Description + "\n" + strings.Join(tags, "\n"))
+	if inSQL {
+		text += "\n" + strings.ToLower(q.SQL)
+	}
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		if want, ok := strings.CutPrefix(term, searchTagPrefix); ok {
+			found := false
+			for _, t := range tags {
+				if strings.HasPrefix(t, want) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		} else if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// sqlMatch finds the first search term that q matches only in its SQL and returns the
+// SQL line containing it, with the match's offsets in that line. ok is false when
+// every term matched the name, description or tags.
+func sqlMatch(q Query, search string) (line string, start, end int, ok bool) {
+	meta := strings.ToLower(q.Name + "\n" + q.Description + "\n" + q.Tags)
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		if strings.HasPrefix(term, searchTagPrefix) || strings.Contains(meta, term) {
+			continue
+		}
+		for _, l := range strings.Split(q.SQL, "\n") {
+			// ToLower can change byte lengths outside ASCII; only trust offsets when it doesn't
+			lower := strings.ToLower(l)
+			if i := strings.Index(lower, term); i >= 0 && len(lower) == len(l) {
+				return l, i, i + len(term), true
+			}
+		}
+	}
+	return "", 0, 0, false
+}
+
+// sqlSnippet renders the part of q's SQL that matched search, with the match
+// highlighted and the line cut down to about width characters around it
+func sqlSnippet(q Query, search string, width int) string {
+	line, start, end, ok := sqlMatch(q, search)
+	if !ok {
+		return ""
+	}
+	// Drop leading indentation, then trim context on either side to fit
+	trimmed := strings.TrimLeft(line, " \t")
+	offset := len(line) - len(trimmed)
+	line, start, end = trimmed, start-offset, end-offset
+
+	prefix, suffix := "", ""
+	if context := (width - (end - start)) / 2; context > 0 {
+		if start > context {
+			line, start, end, prefix = line[start-context:], context, end-(start-context), "…"
+		}
+		if len(line)-end > context {
+			line, suffix = line[:end+context], "…"
+		}
+	}
+
+	dim := lipgloss.NewStyle().Foreground(theme.Dim)
+	match := lipgloss.NewStyle().Bold(true).Foreground(theme.Accent)
+	return dim.Render(prefix+line[:start]) + match.Render(line[start:end]) + dim.Render(line[end:]+suffix)
+}
+
+// groupByTag orders queries by their group tag, untagged last, keeping the existing order within a group
+func groupByTag(queries []Query) []Query {
+	grouped := append([]Query(nil), queries...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		gi, gj := queryGroup(grouped[i]), queryGroup(grouped[j])
+		if (gi == untaggedGroup) != (gj == untaggedGroup) {
+			return gj == untaggedGroup
+		}
+		return gi < gj
+	})
+	return grouped
+}
+
+// sortByUpdated orders queries most recently edited first
+func sortByUpdated(queries []Query) []Query {
+	sorted := append([]Query(nil), queries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
+	})
+	return sorted
+}
+
+// hasTags reports whether any query is tagged
+func hasTags(queries []Query) bool {
+	for _, q := range queries {
+		if len(parseTags(q.Tags)) > 0 {
+			return true
+		}
+	}
+	return false
+}
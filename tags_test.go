@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	if got := normalizeTags(" Replication,wal,, replication "); got != "replication, wal" {
+		t.Errorf("normalizeTags() = %q, want %q", got, "replication, wal")
+	}
+}
+
+func TestMatchesSearch(t *testing.T) {
+	lag := Query{Name: "Replication Lag", Description: "Bytes behind", Tags: "replication, wal"}
+	locks := Query{Name: "Lock Information", Description: "Show current locks"}
+
+	tests := []struct {
+		search string
+		query  Query
+		want   bool
+	}{
+		{"", locks, true},
+		{"lock", locks, true},
+		{"tag:replication", lag, true},
+		{"tag:rep", lag, true},
+		{"tag:replication", locks, false},
+		{"tag:wal lag", lag, true},
+		{"tag:wal locks", lag, false},
+		{"wal", lag, true},
+	}
+	for _, tt := range tests {
+		if got := matchesSearch(tt.query, tt.search, false); got != tt.want {
+			t.Errorf("matchesSearch(%q, %q) = %v, want %v", tt.query.Name, tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestGroupByTag(t *testing.T) {
+	queries := []Query{
+		{Name: "Locks"},
+		{Name: "Lag", Tags: "replication"},
+		{Name: "Bloat", Tags: "maintenance, tables"},
+		{Name: "Slots", Tags: "replication"},
+	}
+
+	var got []string
+	for _, q := range groupByTag(queries) {
+		got = append(got, q.Name)
+	}
+	want := []string{"Bloat", "Lag", "Slots", "Locks"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByTag() = %v, want %v", got, want)
+	}
+}
+
+func TestSortByUpdated(t *testing.T) {
+	now := time.Now()
+	queries := []Query{
+		{Name: "Never"},
+		{Name: "Old", UpdatedAt: now.Add(-48 * time.Hour)},
+		{Name: "New", UpdatedAt: now},
+	}
+
+	var got []string
+	for _, q := range sortByUpdated(queries) {
+		got = append(got, q.Name)
+	}
+	want := []string{"New", "Old", "Never"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortByUpdated() = %v, want %v", got, want)
+	}
+}
+
+func TestSearchSQL(t *testing.T) {
+	bloat := Query{Name: "Table Bloat", Description: "Dead tuples", SQL: "SELECT relname,\n       n_dead_tup\nFROM pg_stat_user_tables"}
+
+	if matchesSearch(bloat, "pg_stat_user_tables", false) {
+		t.Errorf("names-only search matched SQL")
+	}
+	if !matchesSearch(bloat, "pg_stat_user_tables", true) {
+		t.Errorf("SQL search missed a table named in the SQL")
+	}
+	if !matchesSearch(bloat, "bloat N_DEAD_TUP", true) {
+		t.Errorf("SQL search should combine name and SQL terms case-insensitively")
+	}
+
+	tests := []struct {
+		search    string
+		wantLine  string
+		wantMatch string
+		wantOK    bool
+	}{
+		{"n_dead", "       n_dead_tup", "n_dead", true},
+		{"bloat user_tables", "FROM pg_stat_user_tables", "user_tables", true},
+		{"bloat", "", "", false}, // the name explains the hit; no snippet
+		{"tag:x relname", "SELECT relname,", "relname", true},
+	}
+	for _, tt := range tests {
+		line, start, end, ok := sqlMatch(bloat, tt.search)
+		if ok != tt.wantOK || line != tt.wantLine || (ok && line[start:end] != tt.wantMatch) {
+			t.Errorf("sqlMatch(%q) = %q [%d:%d], %v; want %q matching %q", tt.search, line, start, end, ok, tt.wantLine, tt.wantMatch)
+		}
+	}
+
+	long := Query{Name: "Long", SQL: strings.Repeat("a", 50) + "needle" + strings.Repeat("b", 50)}
+	got := sqlSnippet(long, "needle", 20)
+	if want := "…" + strings.Repeat("a", 7) + "needle" + strings.Repeat("b", 7) + "…"; !strings.Contains(got, "needle") || lipgloss.Width(got) != lipgloss.Width(want) {
+		t.Errorf("sqlSnippet() = %q, want about %q", got, want)
+	}
+}
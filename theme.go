@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme holds the colors used across the UI, by role rather than by value
+type Theme struct {
+	Primary    lipgloss.Color // titles, selected items
+	Accent     lipgloss.Color // service name in the header
+	Text       lipgloss.Color // regular table and list text
+	Dim        lipgloss.Color // hints, separators, footers
+	Muted      lipgloss.Color // empty states and N/A values
+	Subtle     lipgloss.Color // help descriptions and detail labels
+	Key        lipgloss.Color // key names in help
+	Border     lipgloss.Color // viewport and widget borders
+	SelectedBg lipgloss.Color // background of the selected tab or row
+	TabBg      lipgloss.Color // background of unselected tabs
+	HeaderFg   lipgloss.Color // results table header text
+	HeaderBg   lipgloss.Color // results table header background
+	Success    lipgloss.Color
+	Warning    lipgloss.Color
+	Error      lipgloss.Color
+	Info       lipgloss.Color // neutral chart bars
+	Caution    lipgloss.Color // between warning and error
+	ChartAxis  lipgloss.Color
+	ChartLabel lipgloss.Color
+}
+
+// DefaultThemeName is used when neither config nor --theme picks a theme
+const DefaultThemeName = "dark"
+
+// themes are the built-in palettes selectable by name
+var themes = map[string]Theme{
+	"dark": {
+		Primary: "86", Accent: "201", Text: "252", Dim: "240", Muted: "8", Subtle: "244",
+		Key: "39", Border: "62", SelectedBg: "235", TabBg: "238",
+		HeaderFg: "#FFFFFF", HeaderBg: "#7C3AED",
+		Success: "10", Warning: "11", Error: "9", Info: "12", Caution: "208",
+		ChartAxis: "3", ChartLabel: "63",
+	},
+	"light": {
+		Primary: "30", Accent: "127", Text: "235", Dim: "245", Muted: "246", Subtle: "240",
+		Key: "25", Border: "61", SelectedBg: "254", TabBg: "252",
+		HeaderFg: "#FFFFFF", HeaderBg: "#6D28D9",
+		Success: "28", Warning: "136", Error: "160", Info: "26", Caution: "166",
+		ChartAxis: "94", ChartLabel: "61",
+	},
+	"high-contrast": {
+		Primary: "14", Accent: "13", Text: "15", Dim: "250", Muted: "250", Subtle: "252",
+		Key: "14", Border: "15", SelectedBg: "19", TabBg: "236",
+		HeaderFg: "0", HeaderBg: "11",
+		Success: "10", Warning: "11", Error: "9", Info: "12", Caution: "208",
+		ChartAxis: "15", ChartLabel: "15",
+	},
+}
+
+// theme is the active palette; it is set once at startup before any rendering
+var theme = themes[DefaultThemeName]
+
+// themeNames lists the built-in theme names for help and error messages
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorSlots maps theme_colors config keys to the colors they override
+func (t *Theme) colorSlots() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"primary": &t.Primary, "accent": &t.Accent, "text": &t.Text, "dim": &t.Dim,
+		"muted": &t.Muted, "subtle": &t.Subtle, "key": &t.Key, "border": &t.Border,
+		"selected_bg": &t.SelectedBg, "tab_bg": &t.TabBg,
+		"header_fg": &t.HeaderFg, "header_bg": &t.HeaderBg,
+		"success": &t.Success, "warning": &t.Warning, "error": &t.Error,
+		"info": &t.Info, "caution": &t.Caution,
+		"chart_axis": &t.ChartAxis, "chart_label": &t.ChartLabel,
+	}
+}
+
+// buildTheme returns the named built-in theme with custom color overrides applied
+func buildTheme(name string, overrides map[string]string) (Theme, error) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	t, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return themes[DefaultThemeName], fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+
+	slots := t.colorSlots()
+	for key, color := range overrides {
+		slot, ok := slots[key]
+		if !ok {
+			return t, fmt.Errorf("unknown theme color %q", key)
+		}
+		*slot = lipgloss.Color(color)
+	}
+	return t, nil
+}
+
+// applyTheme makes the named theme, with overrides, the active palette
+func applyTheme(name string, overrides map[string]string) error {
+	t, err := buildTheme(name, overrides)
+	if err != nil {
+		return err
+	}
+	theme = t
+	return nil
+}
+
+// monochrome is set when colors are disabled, either by $NO_COLOR or a terminal without color
+// support. lipgloss then strips all styling, so selection has to be shown with text markers.
+var monochrome bool
+
+// detectColorSupport sets monochrome from the color profile lipgloss detected for the terminal,
+// which already honors NO_COLOR and CLICOLOR
+func detectColorSupport() {
+	monochrome = lipgloss.ColorProfile() == termenv.Ascii
+}
+
+// markSelected brackets the selected item in monochrome mode, where highlighting is invisible
+func markSelected(s string) string {
+	if monochrome {
+		return "[" + s + "]"
+	}
+	return s
+}
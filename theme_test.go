@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestBuildTheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		themeName   string
+		overrides   map[string]string
+		wantPrimary lipgloss.Color
+		wantErr     bool
+	}{
+		{name: "default is dark", themeName: "", wantPrimary: themes["dark"].Primary},
+		{name: "light", themeName: "light", wantPrimary: themes["light"].Primary},
+		{name: "case insensitive", themeName: "High-Contrast", wantPrimary: themes["high-contrast"].Primary},
+		{name: "custom override", themeName: "light", overrides: map[string]string{"primary": "#FF0000"}, wantPrimary: "#FF0000"},
+		{name: "unknown theme", themeName: "solarized", wantErr: true},
+		{name: "unknown color key", themeName: "dark", overrides: map[string]string{"sparkle": "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTheme(tt.themeName, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildTheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Primary != tt.wantPrimary {
+				t.Errorf("Primary = %v, want %v", got.Primary, tt.wantPrimary)
+			}
+		})
+	}
+
+	// Overrides must not leak into the built-in palette
+	if themes["light"].Primary == "#FF0000" {
+		t.Error("buildTheme() modified the built-in light theme")
+	}
+}
+
+func TestMonochromeRendering(t *testing.T) {
+	monochrome = true
+	defer func() { monochrome = false }()
+
+	av := &ActiveView{
+		Processes: []ActiveProcess{
+			{PID: 101, Username: "app", State: "active", Query: "SELECT 1"},
+			{PID: 202, Username: "etl", State: "active", Query: "SELECT 2"},
+		},
+		SelectedIndex: 1,
+	}
+	list := RenderActiveList(av, 120, 40)
+	if !strings.Contains(list, "> 202") || !strings.Contains(list, "  101") {
+		t.Errorf("RenderActiveList() should mark the selected row without color:\n%s", list)
+	}
+
+	table := renderTable([]string{"name"}, [][]string{{"x"}}, TableOptions{})
+	if lines := strings.Split(table, "\n"); !strings.HasPrefix(lines[1], "---") {
+		t.Errorf("renderTable() should rule off the header without color:\n%s", table)
+	}
+
+	if got := markSelected("Home"); got != "[Home]" {
+		t.Errorf("markSelected() = %q, want [Home]", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SessionTimeouts holds the server settings that stop forgotten sessions from holding locks
+type SessionTimeouts struct {
+	Database          string // current_database(), used to spot production databases
+	IdleInTransaction string // idle_in_transaction_session_timeout, e.g. "5min" or "0"
+	Statement         string // statement_timeout
+}
+
+// GetSessionTimeouts reads the timeout settings that apply to this session
+func GetSessionTimeouts(db *sql.DB) (*SessionTimeouts, error) {
+	var t SessionTimeouts
+	err := db.QueryRow(`SELECT current_database(),
+		current_setting('idle_in_transaction_session_timeout'),
+		current_setting('statement_timeout')`).Scan(&t.Database, &t.IdleInTransaction, &t.Statement)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// timeoutDisabled checks if a timeout setting is turned off
+func timeoutDisabled(setting string) bool {
+	return setting == "0" || setting == ""
+}
+
+// looksLikeProduction guesses from the service or database name whether a server is production
+func looksLikeProduction(names ...string) bool {
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), "prod") {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderSessionTimeouts renders the timeout settings for the header, warning when they are
+// disabled on a production-looking database
+func RenderSessionTimeouts(t *SessionTimeouts, service string) string {
+	if t == nil {
+		return ""
+	}
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Dim)
+
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Warning)
+
+	display := func(setting string) string {
+		if timeoutDisabled(setting) {
+			return "off"
+		}
+		return setting
+	}
+
+	text := "idle tx timeout: " + display(t.IdleInTransaction) + "  statement timeout: " + display(t.Statement)
+	if looksLikeProduction(service, t.Database) &&
+		(timeoutDisabled(t.IdleInTransaction) || timeoutDisabled(t.Statement)) {
+		return warnStyle.Render("⚠ " + text)
+	}
+	return dimStyle.Render(text)
+}
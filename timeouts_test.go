@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSessionTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts *SessionTimeouts
+		service  string
+		wantWarn bool
+		wantText string
+	}{
+		{name: "nil", timeouts: nil, service: "prod"},
+		{name: "prod service with disabled timeout", timeouts: &SessionTimeouts{Database: "app", IdleInTransaction: "0", Statement: "30s"}, service: "prod-primary", wantWarn: true, wantText: "idle tx timeout: off"},
+		{name: "prod database name", timeouts: &SessionTimeouts{Database: "app_production", IdleInTransaction: "5min", Statement: "0"}, service: "main", wantWarn: true, wantText: "statement timeout: off"},
+		{name: "dev with disabled timeouts", timeouts: &SessionTimeouts{Database: "app", IdleInTransaction: "0", Statement: "0"}, service: "dev", wantText: "idle tx timeout: off"},
+		{name: "prod with timeouts set", timeouts: &SessionTimeouts{Database: "app", IdleInTransaction: "5min", Statement: "30s"}, service: "prod", wantText: "idle tx timeout: 5min"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderSessionTimeouts(tt.timeouts, tt.service)
+			if tt.timeouts == nil {
+				if got != "" {
+					t.Errorf("RenderSessionTimeouts(nil) = %q, want empty", got)
+				}
+				return
+			}
+			if strings.Contains(got, "⚠") != tt.wantWarn {
+				t.Errorf("RenderSessionTimeouts() = %q, want warning %v", got, tt.wantWarn)
+			}
+			if !strings.Contains(got, tt.wantText) {
+				t.Errorf("RenderSessionTimeouts() = %q, want it to contain %q", got, tt.wantText)
+			}
+		})
+	}
+}
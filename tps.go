@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tpsTickMsg asks for the next transactions/sec sample
+type tpsTickMsg time.Time
+
+// tpsSampleMsg carries a commit total read for the transactions/sec sparkline
+type tpsSampleMsg struct {
+	Commits float64
+	At      time.Time // database clock when Commits was read
+	Err     error
+}
+
+// scheduleTPSSample waits a refresh interval before the next sample. Sampling runs on its
+// own tick, whatever tab is open, so the sparkline has no gaps when Home is shown again.
+func scheduleTPSSample() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return tpsTickMsg(t)
+	})
+}
+
+// handleTPSTick reads the commit total in the background, unless the Home layout has no
+// sparkline or there's no connection to read it from
+func (m *Model) handleTPSTick() (tea.Model, tea.Cmd) {
+	db := m.db
+	if db == nil || m.reconnecting || !m.showsHomeWidget(WidgetTPS) {
+		return m, scheduleTPSSample()
+	}
+	return m, func() tea.Msg {
+		commits, at, err := GetTransactionCommits(db)
+		return tpsSampleMsg{Commits: commits, At: at, Err: err}
+	}
+}
+
+// handleTPSSample records a sample and schedules the next one. Failed reads are skipped.
+func (m *Model) handleTPSSample(msg tpsSampleMsg) (tea.Model, tea.Cmd) {
+	if msg.Err == nil {
+		m.recordCommits(msg.Commits, msg.At)
+	}
+	return m, scheduleTPSSample()
+}
+
+// recordCommits adds the commit rate since the previous sample to the sparkline. Rates use
+// the database's clock so slow round trips don't skew them; the first sample only sets
+// the baseline.
+func (m *Model) recordCommits(commits float64, at time.Time) {
+	if m.lastCommits > 0 && !m.lastCommitTime.IsZero() {
+		if elapsed := at.Sub(m.lastCommitTime).Seconds(); elapsed > 0 {
+			m.sparklineData.AddPoint((commits-m.lastCommits)/elapsed, at)
+		}
+	}
+	m.lastCommits = commits
+	m.lastCommitTime = at
+}
+
+// homeWidgets returns the Home dashboard layout from config.json, or the default one
+func (m *Model) homeWidgets() []string {
+	if m.config != nil && len(m.config.HomeWidgets) > 0 {
+		return m.config.HomeWidgets
+	}
+	return defaultHomeWidgets
+}
+
+// showsHomeWidget reports whether the Home dashboard includes the named widget
+func (m *Model) showsHomeWidget(name string) bool {
+	for _, w := range m.homeWidgets() {
+		if w == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordCommits(t *testing.T) {
+	m := &Model{sparklineData: NewSparklineData(3)}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m.recordCommits(1000, start)
+	if len(m.sparklineData.Values) != 0 {
+		t.Fatalf("first sample should only set the baseline, got %v", m.sparklineData.Values)
+	}
+
+	m.recordCommits(1100, start.Add(2*time.Second))
+	m.recordCommits(1100, start.Add(2*time.Second)) // same timestamp: no rate
+	m.recordCommits(1400, start.Add(5*time.Second))
+	want := []float64{50, 100}
+	if len(m.sparklineData.Values) != len(want) {
+		t.Fatalf("got %v, want %v", m.sparklineData.Values, want)
+	}
+	for i, v := range want {
+		if m.sparklineData.Values[i] != v {
+			t.Errorf("point %d = %v, want %v", i, m.sparklineData.Values[i], v)
+		}
+	}
+}
+
+func TestHandleTPSTickWithoutSparkline(t *testing.T) {
+	m := &Model{config: &Config{HomeWidgets: []string{WidgetBlockingLocks}}, sparklineData: NewSparklineData(3)}
+	if m.showsHomeWidget(WidgetTPS) {
+		t.Fatal("tps should not be shown")
+	}
+	if _, cmd := m.handleTPSTick(); cmd == nil {
+		t.Error("sampling should stay scheduled while the sparkline is hidden")
+	}
+}
+
+func TestSparklineCloneIsIndependent(t *testing.T) {
+	// Home renders a copy on the query goroutine while samples keep arriving on the main one
+	s := NewSparklineData(2)
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.AddPoint(1, at)
+	s.AddPoint(2, at.Add(time.Second))
+
+	c := s.Clone()
+	s.AddPoint(3, at.Add(2*time.Second))
+	if len(c.Values) != 2 || c.Values[0] != 1 || c.Values[1] != 2 || len(c.Timestamps) != 2 {
+		t.Errorf("clone changed after AddPoint on the original: %v", c.Values)
+	}
+}
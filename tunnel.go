@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshKeepAliveInterval is how often an open tunnel checks the bastion is still there
+const sshKeepAliveInterval = 30 * time.Second
+
+// sshDialTimeout bounds connecting and authenticating to the bastion
+const sshDialTimeout = 15 * time.Second
+
+// sshConfig is the bastion a service is reached through, from the service's ssh_* settings
+type sshConfig struct {
+	Host    string
+	Port    string
+	User    string
+	KeyFile string // private key to offer besides ssh-agent's; "" tries the usual ~/.ssh keys
+}
+
+// sshConfigFromSettings reads ssh_host, ssh_port, ssh_user and ssh_key from a service stanza.
+// It returns nil when the service has no ssh_host and is connected to directly.
+func sshConfigFromSettings(settings map[string]string) (*sshConfig, error) {
+	host := settings["ssh_host"]
+	if host == "" {
+		return nil, nil
+	}
+	cfg := &sshConfig{Host: host, Port: settings["ssh_port"], User: settings["ssh_user"], KeyFile: settings["ssh_key"]}
+	if cfg.Port == "" {
+		cfg.Port = "22"
+	} else if n, err := strconv.Atoi(cfg.Port); err != nil || n < 1 || n > 65535 {
+		return nil, fmt.Errorf("invalid ssh_port %q: must be a port number", cfg.Port)
+	}
+	if cfg.User == "" {
+		if u, err := user.Current(); err == nil {
+			cfg.User = u.Username
+		}
+	}
+	if strings.HasPrefix(cfg.KeyFile, "~/") {
+		cfg.KeyFile = filepath.Join(os.ExpandEnv("$HOME"), cfg.KeyFile[2:])
+	}
+	return cfg, nil
+}
+
+// address is the bastion's host:port
+func (c *sshConfig) address() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// clientConfig authenticates with ssh-agent and private keys that need no passphrase, and
+// only trusts host keys already in ~/.ssh/known_hosts. The returned connection to ssh-agent,
+// nil without one, stays open for the config's signers and is the caller's to close.
+func (c *sshConfig) clientConfig() (*ssh.ClientConfig, net.Conn, error) {
+	home := os.ExpandEnv("$HOME")
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read ~/.ssh/known_hosts (connect to %s with ssh once to add its host key): %w", c.Host, err)
+	}
+
+	var auth []ssh.AuthMethod
+	var agentConn net.Conn
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	fail := func(err error) (*ssh.ClientConfig, net.Conn, error) {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, err
+	}
+	keyFiles := []string{c.KeyFile}
+	if c.KeyFile == "" {
+		keyFiles = []string{
+			filepath.Join(home, ".ssh", "id_ed25519"),
+			filepath.Join(home, ".ssh", "id_ecdsa"),
+			filepath.Join(home, ".ssh", "id_rsa"),
+		}
+	}
+	var signers []ssh.Signer
+	for _, path := range keyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if c.KeyFile != "" {
+				return fail(fmt.Errorf("failed to read ssh_key: %w", err))
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			// There's no terminal to ask on while the UI runs; ssh-agent holds unlocked keys
+			if c.KeyFile != "" {
+				return fail(fmt.Errorf("ssh_key %s needs a passphrase: add it to ssh-agent with ssh-add instead", path))
+			}
+			continue
+		}
+		if err != nil {
+			return fail(fmt.Errorf("failed to parse private key %s: %w", path, err))
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		return fail(fmt.Errorf("no SSH credentials for %s: start ssh-agent or set ssh_key", c.Host))
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         sshDialTimeout,
+	}, agentConn, nil
+}
+
+// sshTunnel forwards connections to a local port through an SSH connection to target
+type sshTunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+	target   string        // host:port as seen from the bastion
+	done     chan struct{} // closed once the SSH connection is gone
+	agent    net.Conn      // ssh-agent connection the client authenticated with, if any
+}
+
+// dialTunnel connects to the bastion at address and starts forwarding a random local port to target
+func dialTunnel(address, target string, clientConfig *ssh.ClientConfig) (*sshTunnel, error) {
+	client, err := ssh.Dial("tcp", address, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH host %s: %w", address, err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open local tunnel port: %w", err)
+	}
+
+	t := &sshTunnel{client: client, listener: listener, target: target, done: make(chan struct{})}
+	go func() {
+		client.Wait()
+		close(t.done)
+		listener.Close()
+	}()
+	go t.keepAlive()
+	go t.accept()
+	return t, nil
+}
+
+// localPort is the port on 127.0.0.1 that reaches the target
+func (t *sshTunnel) localPort() string {
+	return strconv.Itoa(t.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// alive reports whether the SSH connection is still up
+func (t *sshTunnel) alive() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// close tears down the local port, the SSH connection and the ssh-agent connection
+func (t *sshTunnel) close() {
+	t.listener.Close()
+	t.client.Close()
+	if t.agent != nil {
+		t.agent.Close()
+	}
+}
+
+// accept forwards each local connection until the listener closes
+func (t *sshTunnel) accept() {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+// forward copies one local connection to the target through the bastion, both ways
+func (t *sshTunnel) forward(local net.Conn) {
+	defer local.Close()
+	remote, err := t.client.Dial("tcp", t.target)
+	if err != nil {
+		debugLog.Warn("tunnel dial failed", "target", t.target, "error", err)
+		return
+	}
+	defer remote.Close()
+
+	copied := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		copied <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		copied <- struct{}{}
+	}()
+	// Either side closing ends the connection
+	<-copied
+}
+
+// keepAlive pings the bastion so a dead connection is noticed and the next connect redials
+func (t *sshTunnel) keepAlive() {
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			if _, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				t.client.Close()
+				return
+			}
+		}
+	}
+}
+
+// tunnels holds the open tunnel for each service, shared by every connection to it
+var tunnels = struct {
+	sync.Mutex
+	open map[string]*sshTunnel
+}{open: map[string]*sshTunnel{}}
+
+// throughTunnel returns config pointed at service's local tunnel port, opening the tunnel
+// or replacing a dead one as needed. Configs without ssh settings are returned unchanged.
+func throughTunnel(service string, config *DBConfig) (*DBConfig, error) {
+	if config.SSH == nil {
+		return config, nil
+	}
+	tunnels.Lock()
+	defer tunnels.Unlock()
+
+	t := tunnels.open[service]
+	if t == nil || !t.alive() {
+		if t != nil {
+			t.close()
+		}
+		clientConfig, agentConn, err := config.SSH.clientConfig()
+		if err != nil {
+			return nil, err
+		}
+		debugLog.Info("opening ssh tunnel", "service", service, "ssh_host", config.SSH.address(), "target", net.JoinHostPort(config.Host, config.Port))
+		t, err = dialTunnel(config.SSH.address(), net.JoinHostPort(config.Host, config.Port), clientConfig)
+		if err != nil {
+			if agentConn != nil {
+				agentConn.Close()
+			}
+			delete(tunnels.open, service)
+			return nil, err
+		}
+		t.agent = agentConn
+		tunnels.open[service] = t
+	}
+
+	local := *config
+	local.Host = "127.0.0.1"
+	local.Port = t.localPort()
+	return &local, nil
+}
+
+// closeTunnel tears down service's tunnel, if it has one
+func closeTunnel(service string) {
+	tunnels.Lock()
+	defer tunnels.Unlock()
+	if t := tunnels.open[service]; t != nil {
+		t.close()
+		delete(tunnels.open, service)
+		debugLog.Info("closed ssh tunnel", "service", service)
+	}
+}
+
+// getTunneledDBConfig is getDBConfig for opening connections: services behind a bastion get
+// the local end of their tunnel as host and port
+func getTunneledDBConfig(serviceName string) (*DBConfig, error) {
+	config, err := getDBConfig(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return throughTunnel(serviceName, config)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHConfigFromSettings(t *testing.T) {
+	setEnv(t, map[string]string{"HOME": "/home/tester"})
+
+	if cfg, err := sshConfigFromSettings(map[string]string{"host": "db"}); cfg != nil || err != nil {
+		t.Errorf("no ssh_host = %+v, %v; want a direct connection", cfg, err)
+	}
+
+	cfg, err := sshConfigFromSettings(map[string]string{"ssh_host": "bastion", "ssh_user": "ops", "ssh_key": "~/.ssh/prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sshConfig{Host: "bastion", Port: "22", User: "ops", KeyFile: filepath.Join("/home/tester", ".ssh", "prod")}
+	if *cfg != want {
+		t.Errorf("sshConfigFromSettings() = %+v, want %+v", *cfg, want)
+	}
+	if got := cfg.address(); got != "bastion:22" {
+		t.Errorf("address() = %q, want bastion:22", got)
+	}
+
+	if _, err := sshConfigFromSettings(map[string]string{"ssh_host": "bastion", "ssh_port": "ssh"}); err == nil {
+		t.Error("a non-numeric ssh_port should be rejected")
+	}
+}
+
+func TestGetDBConfigSSH(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "psq.conf")
+	content := "[behind-bastion]\nhost=10.0.0.5\ndbname=app\nuser=app\nssh_host=bastion.example.com\nssh_port=2222\nssh_user=ops\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, map[string]string{"PGSERVICEFILE": path, "HOME": tmpDir})
+
+	config, err := getDBConfig("behind-bastion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The database host stays as the bastion sees it; only the tunnel changes where psq connects
+	if config.Host != "10.0.0.5" || config.SSH == nil || config.SSH.address() != "bastion.example.com:2222" || config.SSH.User != "ops" {
+		t.Errorf("getDBConfig() = %+v (ssh %+v)", config, config.SSH)
+	}
+}
+
+// startSSHServer runs a password-authenticated SSH server that allows direct-tcpip forwards
+func startSSHServer(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				sconn, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sconn.Close()
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+						newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+						continue
+					}
+					remote, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						newChannel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, reqs, err := newChannel.Accept()
+					if err != nil {
+						remote.Close()
+						continue
+					}
+					go ssh.DiscardRequests(reqs)
+					go func() {
+						defer channel.Close()
+						defer remote.Close()
+						go io.Copy(remote, channel)
+						io.Copy(channel, remote)
+					}()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestDialTunnelForwards(t *testing.T) {
+	// An echo server stands in for Postgres behind the bastion
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	address := startSSHServer(t)
+	clientConfig := &ssh.ClientConfig{
+		User:            "tester",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	}
+	tunnel, err := dialTunnel(address, echo.Addr().String(), clientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentConn, agentEnd := net.Pipe()
+	defer agentEnd.Close()
+	tunnel.agent = agentConn
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", tunnel.localPort()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "ping" {
+		t.Fatalf("read through tunnel = %q, %v; want ping", reply, err)
+	}
+
+	if !tunnel.alive() {
+		t.Error("tunnel should be alive while connected")
+	}
+	tunnel.close()
+	select {
+	case <-tunnel.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the tunnel should end the SSH connection")
+	}
+	if tunnel.alive() {
+		t.Error("tunnel should be dead after close")
+	}
+	if _, err := agentConn.Write([]byte("x")); err == nil {
+		t.Error("closing the tunnel should close its ssh-agent connection")
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
 )
@@ -17,86 +19,269 @@ func (m *Model) View() string {
 		return "Getting ready..."
 	}
 
-	return zone.Scan(m.viewport.View())
+	// The header is drawn fresh every frame; only the body below it lives in the viewport
+	return zone.Scan(viewportStyle(m.tableOpts.Compact).Render(m.header+"\n"+m.viewport.View()) + "\n" + m.footer)
+}
+
+// viewportStyle frames the header and viewport in a rounded border, or leaves them bare for
+// compact tables
+func viewportStyle(compact bool) lipgloss.Style {
+	if compact {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border)
 }
 
 func (m *Model) updateContent() {
+	m.footer = renderFooter(m.footerHints(), m.width)
+
 	var content string
 
+	// Connection warning banner, on every tab
+	content += m.renderConnectionWarning()
+
 	// Header section
 	content += " " + lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		Render("psq@") +
 		lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("201")).
+			Foreground(theme.Accent).
 			Render(m.service)
+	if m.timeouts != nil {
+		content += "  " + RenderSessionTimeouts(m.timeouts, m.service)
+	}
+	m.shownRefreshAge = m.refreshAge()
+	m.shownDuration = m.detailDuration()
+	if m.shownRefreshAge != "" {
+		ageStyle := lipgloss.NewStyle().Foreground(theme.Dim)
+		if m.refreshOverdue() {
+			ageStyle = ageStyle.Foreground(theme.Caution)
+		}
+		content += "  " + ageStyle.Render(m.shownRefreshAge)
+	}
+	if m.busy() {
+		content += " " + m.spinner.View()
+	}
+	if m.reconnecting {
+		content += " " + lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Render(fmt.Sprintf("reconnecting… (attempt %d/%d)", m.reconnectTries, maxReconnectAttempts))
+	}
+	if m.connecting {
+		content += " " + lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Render(fmt.Sprintf("connecting to %s… (attempt %d/%d)", m.service, m.reconnectTries, maxStartupAttempts))
+	}
+	content += m.renderAlerts()
+
+	separator := "\n" + lipgloss.NewStyle().
+		Foreground(theme.Dim).
+		Render(strings.Repeat("─", m.width)) + "\n"
 
 	// Show help if requested
 	if m.showHelp {
-		content += "\n\n" + m.customHelpView()
-		m.viewport.SetContent(content)
+		m.setHeader(content)
+		m.setViewportContent("\n" + m.customHelpView())
 		return
 	}
 
-	if m.searchMode {
-		content += m.renderSearchMode()
-	} else if m.editMode {
-		content += m.renderEditMode()
-	} else {
-		content += m.renderNormalMode()
+	// The tabs stay put above the results; the search list and the editor scroll with them
+	if m.searchMode || m.editMode {
+		mode := m.renderSearchMode()
+		if m.editMode {
+			mode = m.renderEditMode()
+		}
+		first, rest, _ := strings.Cut(mode, "\n")
+		m.setHeader(content + first)
+		m.setViewportContent(rest + separator + m.renderStatus() + m.renderResults())
+		return
 	}
 
-	content += "\n" + lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240")).
-		Render(strings.Repeat("─", m.width)) + "\n"
+	m.setHeader(content + m.renderNormalMode() + separator + m.renderStatus())
+	m.setViewportContent(m.renderResults())
+}
+
+// renderedContent is what updateContent last gave the viewport
+type renderedContent struct {
+	body string
+	sets int // times the viewport's content was replaced
+
+	// the query results with their note above, reused while neither changes
+	note      string
+	noted     string
+	resultsOf string
+}
+
+// setHeader lays out the lines drawn above the viewport, wrapped to the frame, and gives the
+// viewport the height left below them
+func (m *Model) setHeader(header string) {
+	frame := viewportStyle(m.tableOpts.Compact)
+	width := max(m.width-frame.GetHorizontalFrameSize(), 1)
+	m.header = lipgloss.NewStyle().Width(width).Render(strings.TrimSuffix(header, "\n"))
+	if m.height > 0 {
+		m.viewport.Width = width
+		m.viewport.Height = max(m.height-footerHeight-frame.GetVerticalFrameSize()-lipgloss.Height(m.header), 1)
+	}
+}
+
+// setViewportContent hands body to the viewport unless it already holds exactly that. A
+// query result can be large, and handing it to the viewport splits it into lines again, so
+// redraws that only move the spinner, a tab or the status line, which are all in the header,
+// leave the viewport alone. Comparing a reused results string is cheap: it's the same
+// string, not a copy.
+func (m *Model) setViewportContent(body string) {
+	if body == m.rendered.body && m.viewport.TotalLineCount() > 0 {
+		return
+	}
+	m.rendered.body = body
+	m.rendered.sets++
+	m.viewport.SetContent(body)
+}
 
-	// Results section
-	if m.err != "" {
-		content += "Error: " + m.err
-	} else if m.activeView != nil && len(m.activeView.Processes) > 0 &&
-		m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name) {
+// renderResults renders the section below the status line: an overlay, an error, the
+// Active view or the current query's results
+func (m *Model) renderResults() string {
+	switch {
+	case m.importView != nil:
+		return RenderImportView(m.importView)
+	case m.archiveView != nil:
+		return RenderArchiveView(m.archiveView)
+	case m.aiAnswer != nil:
+		return RenderAIAnswer(m.aiAnswer, m.width, m.spinner.View())
+	case m.listenView != nil:
+		return RenderListenView(m.listenView)
+	case m.fleetRun != nil:
+		return RenderFleetRun(m.fleetRun, m.spinner.View())
+	case m.schemaBrowser != nil:
+		return RenderSchemaBrowser(m.schemaBrowser, m.viewport.Height)
+	case m.columnPicker != nil:
+		return RenderColumnPicker(m.columnPicker)
+	case m.confirmReset:
+		return RenderStatsResetConfirm()
+	case m.err != "":
+		return "Error: " + m.err
+	case m.activeView != nil && len(m.activeView.Processes) > 0 &&
+		m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name):
 		// Re-render active view from cached data so key presses take effect immediately
 		switch m.activeView.Mode {
 		case ActiveModeDetail:
-			content += RenderActiveDetail(m.activeView, m.width)
+			return RenderActiveDetail(m.activeView, m.width)
 		case ActiveModeConfirmTerminate:
-			content += RenderTerminateConfirm(m.activeView)
+			return RenderTerminateConfirm(m.activeView)
+		case ActiveModeKillPattern:
+			return RenderKillPattern(m.activeView)
+		case ActiveModeConfirmKill:
+			return RenderConfirmKill(m.activeView, m.width)
 		default:
-			content += RenderActiveList(m.activeView, m.width, m.height)
+			return RenderActiveList(m.activeView, m.width, m.height)
 		}
-	} else {
-		content += m.results
 	}
 
-	m.viewport.SetContent(content)
+	note := ""
+	if m.resultNote != "" {
+		note = lipgloss.NewStyle().Foreground(theme.Warning).Render(m.resultNote) + "\n\n"
+	} else if m.resultsStale && m.loading {
+		note = lipgloss.NewStyle().Foreground(theme.Dim).Render("Showing the result from "+timeAgo(m.lastRefreshAt, time.Now())+" while refreshing") + "\n\n"
+	} else if m.tableOpts.Diff && len(m.resultColumns) > 0 {
+		note = lipgloss.NewStyle().Foreground(theme.Dim).Render("Diff: new rows green, changed cells amber, gone rows struck through (v to turn off)") + "\n\n"
+	}
+	if note == "" {
+		return m.results
+	}
+	// Keep handing back the same string so setViewportContent can tell nothing changed
+	if note != m.rendered.note || m.results != m.rendered.resultsOf {
+		m.rendered.note, m.rendered.resultsOf, m.rendered.noted = note, m.results, note+m.results
+	}
+	return m.rendered.noted
 }
 
 func (m *Model) renderSearchMode() string {
-	content := "\n Search: " + m.searchQuery + "█\n\n"
+	order := "tab: sort by recently edited"
+	if m.searchByRecent {
+		order = "tab: group by tag"
+	}
+	scope := "ctrl+f: names only"
+	if m.searchNamesOnly {
+		scope = "ctrl+f: include SQL"
+	}
+	content := "\n Search: " + m.searchQuery + "█  " +
+		lipgloss.NewStyle().Foreground(theme.Dim).Render(order+"  "+scope) + "\n\n"
+
+	groupStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.Accent)
+	tagStyle := lipgloss.NewStyle().Foreground(theme.Subtle)
+	editedStyle := lipgloss.NewStyle().Foreground(theme.Dim)
+	now := time.Now()
 
-	// Display filtered queries
+	// Display filtered queries, under a heading per tag once any query is tagged
 	if len(m.filteredQueries) == 0 {
 		content += "No queries match your search"
 	} else {
+		grouped := !m.searchByRecent && hasTags(m.filteredQueries)
+		group := ""
 		for i, query := range m.filteredQueries {
+			if g := queryGroup(query); grouped && (i == 0 || g != group) {
+				if i > 0 {
+					content += "\n"
+				}
+				content += groupStyle.Render(g) + "\n"
+				group = g
+			}
+			line := query.Name + " - " + query.Description
 			if i == m.selected {
 				content += lipgloss.NewStyle().
 					Bold(true).
-					Foreground(lipgloss.Color("86")).
-					Render("▶ " + query.Name + " - " + query.Description)
+					Foreground(theme.Primary).
+					Render("▶ " + line)
 			} else {
-				content += "  " + query.Name + " - " + query.Description
+				content += "  " + line
+			}
+			if tags := normalizeTags(query.Tags); tags != "" {
+				content += " " + tagStyle.Render("["+tags+"]")
+			}
+			if !query.UpdatedAt.IsZero() {
+				content += " " + editedStyle.Render("edited "+timeAgo(query.UpdatedAt, now))
 			}
 			content += "\n"
+			// Show where the SQL matched when nothing else explains the hit
+			if !m.searchNamesOnly {
+				if snippet := sqlSnippet(query, m.searchQuery, m.width-8); snippet != "" {
+					content += "    " + snippet + "\n"
+				}
+			}
 		}
 	}
 	return content
 }
 
 func (m *Model) renderEditMode() string {
-	content := ": Tab to switch fields, Ctrl+S to save, Ctrl+D to delete, Esc to cancel\n\n"
+	content := ""
+	if m.confirmDiscard {
+		content = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Warning).
+			Render(": Discard changes? y/n") + "\n\n"
+	}
+	if m.confirmPurge {
+		content = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Warning).
+			Render(fmt.Sprintf(": Delete %s for good? It won't be in the archive. y/n", m.editQuery.Name)) + "\n\n"
+	}
+	if m.confirmAIWrite != "" {
+		content = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Warning).
+			Render(fmt.Sprintf(": The AI-generated SQL contains %s. Saving runs this exact statement on %s. Run it? y/n", m.confirmAIWrite, m.service)) + "\n" +
+			lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(theme.Warning).
+				Padding(0, 1).
+				Render(highlightSQL(m.sqlTextarea.Value())) + "\n\n"
+	}
 
 	// Query editor
 	editorTitle := "Edit Query"
@@ -105,14 +290,14 @@ func (m *Model) renderEditMode() string {
 	}
 	content += lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		Render(editorTitle) + "\n\n"
 
 	// Name input
 	nameStyle := lipgloss.NewStyle()
 	if m.editFocus == 0 {
 		nameStyle = nameStyle.BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("86"))
+			BorderForeground(theme.Primary)
 	}
 	content += "Name:\n" + nameStyle.Render(m.nameInput.View()) + "\n\n"
 
@@ -120,7 +305,7 @@ func (m *Model) renderEditMode() string {
 	descStyle := lipgloss.NewStyle()
 	if m.editFocus == 1 {
 		descStyle = descStyle.BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("86"))
+			BorderForeground(theme.Primary)
 	}
 	content += "Description:\n" + descStyle.Render(m.descInput.View()) + "\n\n"
 
@@ -128,7 +313,7 @@ func (m *Model) renderEditMode() string {
 	orderStyle := lipgloss.NewStyle()
 	if m.editFocus == 2 {
 		orderStyle = orderStyle.BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("86"))
+			BorderForeground(theme.Primary)
 	}
 	content += "Order Position (empty to hide from tabs):\n" + orderStyle.Render(m.orderInput.View()) + "\n\n"
 
@@ -136,112 +321,255 @@ func (m *Model) renderEditMode() string {
 	sqlStyle := lipgloss.NewStyle()
 	if m.editFocus == 3 {
 		sqlStyle = sqlStyle.BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("86"))
+			BorderForeground(theme.Primary)
+	}
+	sqlView := m.sqlTextarea.View()
+	if m.editFocus != 3 && m.sqlTextarea.Value() != "" {
+		// The textarea can't color tokens, so show a highlighted preview until the field is focused
+		sqlView = lipgloss.NewStyle().
+			Width(m.sqlTextarea.Width()).
+			Height(m.sqlTextarea.Height()).
+			MaxHeight(m.sqlTextarea.Height()).
+			Render(highlightSQL(m.sqlTextarea.Value()))
+	}
+	content += "SQL:\n" + sqlStyle.Render(sqlView) + "\n"
+
+	// Auto refresh toggle
+	refreshStyle := lipgloss.NewStyle()
+	if m.editFocus == 4 {
+		refreshStyle = refreshStyle.BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Primary)
+	}
+	refreshValue := "[ ] off - runs only when selected or refreshed with r"
+	if m.editAutoRefresh {
+		refreshValue = "[x] on - re-runs every second"
+	}
+	content += "Auto Refresh (space to toggle):\n" + refreshStyle.Render(refreshValue) + "\n\n"
+
+	// Tags input
+	tagsStyle := lipgloss.NewStyle()
+	if m.editFocus == 5 {
+		tagsStyle = tagsStyle.BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Primary)
+	}
+	content += "Tags (comma-separated, search with tag:name):\n" + tagsStyle.Render(m.tagsInput.View()) + "\n\n"
+
+	// Cache TTL input
+	cacheStyle := lipgloss.NewStyle()
+	if m.editFocus == 6 {
+		cacheStyle = cacheStyle.BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Primary)
+	}
+	content += "Cache TTL (auto refresh reuses the last result this long; r always runs):\n" + cacheStyle.Render(m.cacheInput.View()) + "\n"
+	if m.aiUndoArmed {
+		content += lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Render("AI replaced SQL — press u to undo") + "\n"
+	}
+
+	if m.aiState != AIStateNone {
+		content += m.renderChatGPTPanel()
 	}
-	content += "SQL:\n" + sqlStyle.Render(m.sqlTextarea.View()) + "\n"
 
 	return content
 }
 
 func (m *Model) renderNormalMode() string {
-	content := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(": Press ? for help\n")
+	content := lipgloss.NewStyle().Foreground(theme.Dim).Render(": Press ? for help\n")
+	if m.gotoMode {
+		content = lipgloss.NewStyle().Foreground(theme.Primary).Render(": Go to tab: "+m.gotoInput+"█ (enter to jump, esc to cancel)") + "\n"
+	}
+	if m.psqlMode {
+		sandbox := "tab: sandbox"
+		if m.psqlSandbox {
+			sandbox = lipgloss.NewStyle().Bold(true).Foreground(theme.Warning).Render("sandbox: starts with BEGIN, rolled back on quit") +
+				lipgloss.NewStyle().Foreground(theme.Primary).Render(", tab: off")
+		}
+		content = lipgloss.NewStyle().Foreground(theme.Primary).Render(": psql as [user@]dbname: "+m.psqlInput+"█ (enter for "+m.psqlDefault+", ") +
+			sandbox + lipgloss.NewStyle().Foreground(theme.Primary).Render(", esc to cancel)") + "\n"
+	}
+	if m.dumpMode {
+		content = lipgloss.NewStyle().Foreground(theme.Primary).Render(": Dump queries to: "+m.dumpInput+"█ (names go in "+configDir()+"; enter to save, esc to cancel)") + "\n"
+		if m.dumpOverwrite {
+			content = lipgloss.NewStyle().Bold(true).Foreground(theme.Warning).Render(": "+resolveDumpPath(m.dumpInput)+" exists; enter again to replace it, or edit the name") + "\n"
+		}
+	}
 
-	// Query list
-	content += "\n "
-	for i, query := range m.queries {
-		var queryText string
-		if i == m.selected {
-			style := lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("86")).
-				Background(lipgloss.Color("235"))
-
-			// Add italics for temporary queries
-			if m.isTemporaryQuery(query.Name) {
-				style = style.Italic(true)
-			}
+	// Render every tab up front so the strip can be sized to the terminal width
+	tabs := make([]string, len(m.queries))
+	widths := make([]int, len(m.queries))
+	for i := range m.queries {
+		tab := m.renderTab(i)
+		// Wrap in bubblezone mark for clickability
+		tabs[i] = zone.Mark(fmt.Sprintf("query_%d", i), tab)
+		widths[i] = lipgloss.Width(tab)
+	}
+	// The offset is kept in view by scrollTabsToSelected; rendering only reads it
+	start, end := visibleTabRange(widths, m.tabOffset, m.tabStripWidth())
 
-			queryText = style.Render(query.Name)
-		} else {
-			// Non-selected queries: subtle background and padding to show they're clickable
-			baseStyle := lipgloss.NewStyle().
-				Background(lipgloss.Color("238")).
-				Foreground(lipgloss.Color("252"))
+	arrowStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.Primary)
 
-			if m.isTemporaryQuery(query.Name) {
-				baseStyle = baseStyle.Italic(true)
-			}
+	content += "\n "
+	if start > 0 {
+		content += zone.Mark("tabs_prev", arrowStyle.Render("‹")) + " "
+	}
+	content += strings.Join(tabs[start:end], " ")
+	if end < len(tabs) {
+		content += " " + zone.Mark("tabs_next", arrowStyle.Render("›"))
+	}
+	return content
+}
+
+// renderTab renders the label of the tab at index i, highlighted when it's selected
+func (m *Model) renderTab(i int) string {
+	query := m.queries[i]
+	label := query.Name
+	if query.NoAutoRefresh {
+		label = "⏸ " + label
+	}
+	if i == m.selected {
+		style := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Primary).
+			Background(theme.SelectedBg)
 
-			queryText = baseStyle.Render(query.Name)
+		// Add italics for temporary queries
+		if m.isTemporaryQuery(query.Name) {
+			style = style.Italic(true)
 		}
 
-		// Wrap in bubblezone mark for clickability
-		content += zone.Mark(fmt.Sprintf("query_%d", i), queryText)
+		return style.Render(markSelected(label))
+	}
+
+	// Non-selected queries: subtle background and padding to show they're clickable
+	baseStyle := lipgloss.NewStyle().
+		Background(theme.TabBg).
+		Foreground(theme.Text)
+
+	if m.isTemporaryQuery(query.Name) {
+		baseStyle = baseStyle.Italic(true)
+	}
+
+	return baseStyle.Render(label)
+}
+
+// tabWidths returns the rendered width of every tab, so the strip can be sized to the terminal
+func (m *Model) tabWidths() []int {
+	widths := make([]int, len(m.queries))
+	for i := range m.queries {
+		widths[i] = lipgloss.Width(m.renderTab(i))
+	}
+	return widths
+}
 
-		if i < len(m.queries)-1 {
-			content += " "
+// tabStripWidth is the room for tabs: the viewport border (2) and leading space (1) aren't available
+func (m *Model) tabStripWidth() int {
+	return m.width - 3
+}
+
+// scrollTabsToSelected scrolls the tab strip just far enough to show the selected tab. It
+// runs when the selection or the terminal width changes, so ‹/› clicks can browse freely
+// in between.
+func (m *Model) scrollTabsToSelected() {
+	m.tabOffset = ensureTabVisible(m.tabWidths(), m.tabOffset, m.selected, m.tabStripWidth())
+}
+
+// tabArrowWidth is the space taken by a ‹ or › scroll affordance and its separator
+const tabArrowWidth = 2
+
+// visibleTabRange returns the [start, end) range of tabs that fit in width when the strip
+// begins at offset, reserving room for the ‹/› affordances when tabs are hidden on either side
+func visibleTabRange(widths []int, offset, width int) (int, int) {
+	if offset < 0 || len(widths) == 0 {
+		offset = 0
+	}
+	if offset >= len(widths) {
+		offset = len(widths) - 1
+	}
+	if offset < 0 {
+		return 0, 0
+	}
+
+	available := width
+	if offset > 0 {
+		available -= tabArrowWidth
+	}
+
+	used := 0
+	end := offset
+	for end < len(widths) {
+		w := widths[end]
+		if end > offset {
+			w++ // separator
 		}
+		// Keep room for › unless this is the last tab
+		reserve := 0
+		if end < len(widths)-1 {
+			reserve = tabArrowWidth
+		}
+		if used+w+reserve > available && end > offset {
+			break
+		}
+		used += w
+		end++
 	}
-	return content
+	return offset, end
 }
 
+// ensureTabVisible returns an offset at which the selected tab is inside the visible range
+func ensureTabVisible(widths []int, offset, selected, width int) int {
+	if selected < offset {
+		return selected
+	}
+	for offset < selected {
+		if _, end := visibleTabRange(widths, offset, width); selected < end {
+			break
+		}
+		offset++
+	}
+	return offset
+}
+
+// customHelpView renders the help screen from the key map, so it always matches the bindings the handlers use
 func (m *Model) customHelpView() string {
 	var helpText strings.Builder
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(theme.Primary).
 		MarginBottom(1)
 
-	keyStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("39"))
+	h := m.help
+	h.Styles.FullKey = lipgloss.NewStyle().Bold(true).Foreground(theme.Key)
+	h.Styles.FullDesc = lipgloss.NewStyle().Foreground(theme.Subtle)
 
-	descStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("244"))
+	section := func(title string, bindings []key.Binding) {
+		helpText.WriteString(titleStyle.Render(title+":") + "\n")
+		helpText.WriteString(h.FullHelpView([][]key.Binding{bindings}) + "\n\n")
+	}
 
 	helpText.WriteString(titleStyle.Render("Help") + "\n\n")
 
-	// Query Navigation
-	helpText.WriteString(titleStyle.Render("Query Navigation:") + "\n")
-	helpText.WriteString(keyStyle.Render("←/h") + " " + descStyle.Render("previous query") + "\n")
-	helpText.WriteString(keyStyle.Render("→/l") + " " + descStyle.Render("next query") + "\n")
-	helpText.WriteString(keyStyle.Render("click") + " " + descStyle.Render("select query") + "\n")
-	helpText.WriteString(keyStyle.Render("enter/space/r") + " " + descStyle.Render("execute query") + "\n\n")
-
-	// Viewport Navigation
-	helpText.WriteString(titleStyle.Render("Viewport Navigation:") + "\n")
-	helpText.WriteString(keyStyle.Render("↑/k") + " " + descStyle.Render("scroll up") + "\n")
-	helpText.WriteString(keyStyle.Render("↓/j") + " " + descStyle.Render("scroll down") + "\n")
-	helpText.WriteString(keyStyle.Render("pgup") + " " + descStyle.Render("page up") + "\n")
-	helpText.WriteString(keyStyle.Render("pgdn") + " " + descStyle.Render("page down") + "\n")
-	helpText.WriteString(keyStyle.Render("home") + " " + descStyle.Render("go to top") + "\n")
-	helpText.WriteString(keyStyle.Render("end") + " " + descStyle.Render("go to bottom") + "\n\n")
-
-	// Query Operations
-	helpText.WriteString(titleStyle.Render("Query Operations:") + "\n")
-	helpText.WriteString(keyStyle.Render("s") + " " + descStyle.Render("search queries (type to filter, ↑/↓ navigate, enter select, esc cancel)") + "\n")
-	helpText.WriteString(keyStyle.Render("e") + " " + descStyle.Render("edit query") + "\n")
-	helpText.WriteString(keyStyle.Render("n") + " " + descStyle.Render("new query") + "\n")
-	helpText.WriteString(keyStyle.Render("ctrl+d") + " " + descStyle.Render("delete query (in edit mode)") + "\n")
-	helpText.WriteString(keyStyle.Render("d") + " " + descStyle.Render("dump queries") + "\n")
-	helpText.WriteString(keyStyle.Render("x") + " " + descStyle.Render("psql prompt") + "\n\n")
-
-	// Active View
-	helpText.WriteString(titleStyle.Render("Active View:") + "\n")
-	helpText.WriteString(keyStyle.Render("↑/k") + " " + descStyle.Render("select previous process") + "\n")
-	helpText.WriteString(keyStyle.Render("↓/j") + " " + descStyle.Render("select next process") + "\n")
-	helpText.WriteString(keyStyle.Render("enter") + " " + descStyle.Render("view process details") + "\n")
-	helpText.WriteString(keyStyle.Render("t") + " " + descStyle.Render("terminate backend") + "\n")
-	helpText.WriteString(keyStyle.Render("c") + " " + descStyle.Render("cancel query") + "\n")
-	helpText.WriteString(keyStyle.Render("y") + " " + descStyle.Render("copy query to clipboard (detail view)") + "\n")
-	helpText.WriteString(keyStyle.Render("esc") + " " + descStyle.Render("back to list / quit") + "\n\n")
-
-	// System
-	helpText.WriteString(titleStyle.Render("System:") + "\n")
-	helpText.WriteString(keyStyle.Render("?") + " " + descStyle.Render("toggle help") + "\n")
-	helpText.WriteString(keyStyle.Render("c") + " " + descStyle.Render("return to connection picker") + "\n")
-	helpText.WriteString(keyStyle.Render("esc") + " " + descStyle.Render("quit") + "\n")
+	// On the Active tab, lead with the keys for each Active view mode, marking the current one
+	onActive := m.activeView != nil && m.selected < len(m.queries) && IsActiveTab(m.queries[m.selected].Name)
+	if onActive {
+		for _, s := range keys.activeSections() {
+			title := s.title
+			if m.activeView.Mode == s.mode {
+				title += " (current)"
+			}
+			section(title, s.bindings)
+		}
+	}
+
+	for _, s := range keys.sections() {
+		section(s.title, s.bindings)
+	}
+
+	if !onActive {
+		helpText.WriteString(titleStyle.Render("Active View:") + "\n")
+		helpText.WriteString(h.Styles.FullDesc.Render("open the Active tab and press ? for process list, detail and terminate keys") + "\n")
+	}
 
 	return helpText.String()
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestVisibleTabRange(t *testing.T) {
+	// Five tabs, 10 columns each; a separator adds 1 column between tabs
+	widths := []int{10, 10, 10, 10, 10}
+
+	tests := []struct {
+		name      string
+		offset    int
+		width     int
+		wantStart int
+		wantEnd   int
+	}{
+		{name: "all fit", offset: 0, width: 100, wantStart: 0, wantEnd: 5},
+		{name: "exact fit without arrows", offset: 0, width: 54, wantStart: 0, wantEnd: 5},
+		{name: "overflow reserves right arrow", offset: 0, width: 33, wantStart: 0, wantEnd: 2},
+		{name: "scrolled reserves left arrow", offset: 2, width: 35, wantStart: 2, wantEnd: 5},
+		{name: "tiny width still shows one tab", offset: 1, width: 5, wantStart: 1, wantEnd: 2},
+		{name: "offset past end is clamped", offset: 9, width: 100, wantStart: 4, wantEnd: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := visibleTabRange(widths, tt.offset, tt.width)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("visibleTabRange() = [%d, %d), want [%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestEnsureTabVisible(t *testing.T) {
+	widths := []int{10, 10, 10, 10, 10}
+
+	tests := []struct {
+		name       string
+		offset     int
+		selected   int
+		wantOffset int
+	}{
+		{name: "already visible", offset: 0, selected: 1, wantOffset: 0},
+		{name: "selection left of strip", offset: 3, selected: 1, wantOffset: 1},
+		{name: "selection right of strip", offset: 0, selected: 4, wantOffset: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ensureTabVisible(widths, tt.offset, tt.selected, 35)
+			if got != tt.wantOffset {
+				t.Errorf("ensureTabVisible() = %d, want %d", got, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestTabStripFollowsResize(t *testing.T) {
+	var queries []Query
+	for _, name := range []string{"Home", "Active", "Locks", "Replication", "Table Bloat", "Index Usage"} {
+		queries = append(queries, Query{Name: name})
+	}
+	m := &Model{queries: queries, selected: len(queries) - 1, ready: true, width: 200, height: 30,
+		viewport: viewport.New(200, 30), spinner: spinner.New()}
+	m.ensureValidSelection()
+	if m.tabOffset != 0 {
+		t.Fatalf("tabOffset = %d with every tab fitting, want 0", m.tabOffset)
+	}
+
+	// Shrinking the terminal must bring the selected tab back into view without a selection change
+	m.handleWindowSizeMsg(tea.WindowSizeMsg{Width: 40, Height: 30})
+	if _, end := visibleTabRange(m.tabWidths(), m.tabOffset, m.tabStripWidth()); m.tabOffset == 0 || m.selected >= end {
+		t.Errorf("after a resize the strip shows up to tab %d from %d; want the selected tab %d visible", end, m.tabOffset, m.selected)
+	}
+
+	// Rendering only reads the offset, so ‹ browsing survives a redraw
+	m.tabOffset = 0
+	m.updateContent()
+	if m.tabOffset != 0 {
+		t.Errorf("updateContent() moved tabOffset to %d; rendering shouldn't scroll the strip", m.tabOffset)
+	}
+}
+
+func TestUpdateContentReusesResults(t *testing.T) {
+	m := &Model{
+		queries:    []Query{{Name: "Big"}, {Name: "Other"}},
+		ready:      true,
+		width:      100,
+		height:     30,
+		viewport:   viewport.New(100, 30),
+		spinner:    spinner.New(),
+		loading:    true,
+		results:    strings.Repeat("row\n", 10000),
+		resultNote: "Query cancelled",
+	}
+	m.updateContent()
+	if m.rendered.sets != 1 {
+		t.Fatalf("viewport content set %d times, want 1", m.rendered.sets)
+	}
+
+	// Spinner frames and tab moves only redraw the header
+	tick := m.spinner.Tick()
+	for i := 0; i < 5; i++ {
+		_, cmd := m.handleSpinnerTick(tick.(spinner.TickMsg))
+		tick = cmd()
+	}
+	m.selected = 1
+	m.updateContent()
+	if m.rendered.sets != 1 {
+		t.Errorf("viewport content set %d times across spinner ticks and a tab move, want 1", m.rendered.sets)
+	}
+	view := m.View()
+	if !strings.Contains(view, "Query cancelled") || !strings.Contains(view, "psq@") {
+		t.Errorf("view lost the header or the results note:\n%s", view)
+	}
+	if got, want := lipgloss.Height(view), m.height; got != want {
+		t.Errorf("view is %d lines, want the terminal's %d", got, want)
+	}
+
+	m.results = "fresh result"
+	m.updateContent()
+	if m.rendered.sets != 2 || !strings.Contains(m.viewport.View(), "fresh result") {
+		t.Errorf("new results should replace the viewport content:\n%s", m.viewport.View())
+	}
+}